@@ -0,0 +1,183 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/db"
+	"github.com/kuthumipepple/numeris-book/util"
+)
+
+const headerActor = "X-Actor"
+
+// actorPattern limits actors to names and email addresses that are safe to
+// store and echo back.
+var actorPattern = regexp.MustCompile(`^[A-Za-z0-9._:@+-]{1,128}$`)
+
+// identifyActor adds who makes the request to its context, for the audit
+// log. The service does not authenticate users itself: the proxy in front of
+// it does and names the user in the X-Actor header, and the commands name the
+// operator that runs them. Requests without a valid actor are audited as
+// anonymous.
+func identifyActor() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		actor := c.GetHeader(headerActor)
+		if !actorPattern.MatchString(actor) {
+			actor = "anonymous"
+		}
+		c.Request = c.Request.WithContext(util.WithActor(c.Request.Context(), actor))
+		c.Next()
+	}
+}
+
+type auditEventResponse struct {
+	ID         int64           `json:"id"`
+	OccurredAt string          `json:"occurred_at"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   int64           `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestID  string          `json:"request_id"`
+}
+
+func generateAuditEventsResponse(events []db.AuditEvent) []auditEventResponse {
+	response := make([]auditEventResponse, len(events))
+	for i, v := range events {
+		response[i] = auditEventResponse{
+			ID:         v.ID,
+			OccurredAt: v.OccurredAt.Format(time.RFC3339),
+			Actor:      v.Actor,
+			Action:     v.Action,
+			EntityType: v.EntityType,
+			EntityID:   v.EntityID,
+			Before:     v.Before,
+			After:      v.After,
+			RequestID:  v.RequestID,
+		}
+	}
+	return response
+}
+
+type auditPageRequest struct {
+	PageID   int32 `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32 `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+func (req auditPageRequest) limitOffset() (limit, offset int32) {
+	if req.PageID == 0 {
+		req.PageID = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+	return req.PageSize, (req.PageID - 1) * req.PageSize
+}
+
+// invoiceHistory lists the changes to an invoice, oldest first.
+func (server *Server) invoiceHistory(c *gin.Context) {
+	var uri getInvoiceRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var page auditPageRequest
+	if err := c.ShouldBindQuery(&page); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	arg := db.ListAuditEventsParams{
+		AuditEventFilter: db.AuditEventFilter{
+			EntityType: pgtype.Text{String: db.EntityInvoice, Valid: true},
+			EntityID:   pgtype.Int8{Int64: uri.ID, Valid: true},
+		},
+	}
+	arg.Limit, arg.Offset = page.limitOffset()
+
+	events, err := server.store.ListAuditEvents(c, arg)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generateAuditEventsResponse(events))
+}
+
+type listAuditEventsRequest struct {
+	auditPageRequest
+	Actor      string `form:"actor" binding:"omitempty,max=128"`
+	Action     string `form:"action" binding:"omitempty,oneof=create import convert update update_status record_payment delete"`
+	EntityType string `form:"entity_type" binding:"omitempty,oneof=invoice quote product"`
+	EntityID   int64  `form:"entity_id" binding:"omitempty,min=1"`
+	RequestID  string `form:"request_id" binding:"omitempty,max=128"`
+	From       string `form:"from"`
+	To         string `form:"to"`
+}
+
+// toFilter converts the request into a db.AuditEventFilter. Both dates are
+// inclusive.
+func (req listAuditEventsRequest) toFilter() (db.AuditEventFilter, error) {
+	filter := db.AuditEventFilter{
+		Actor:      pgtype.Text{String: req.Actor, Valid: req.Actor != ""},
+		Action:     pgtype.Text{String: req.Action, Valid: req.Action != ""},
+		EntityType: pgtype.Text{String: req.EntityType, Valid: req.EntityType != ""},
+		EntityID:   pgtype.Int8{Int64: req.EntityID, Valid: req.EntityID != 0},
+		RequestID:  pgtype.Text{String: req.RequestID, Valid: req.RequestID != ""},
+	}
+
+	var fields validationError
+	if req.From != "" {
+		from, err := time.Parse(time.DateOnly, req.From)
+		if err != nil {
+			fields = append(fields, invalidDateError("from"))
+		}
+		filter.From = pgtype.Timestamptz{Time: from, Valid: err == nil}
+	}
+
+	if req.To != "" {
+		to, err := time.Parse(time.DateOnly, req.To)
+		if err != nil {
+			fields = append(fields, invalidDateError("to"))
+		}
+		filter.To = pgtype.Timestamptz{Time: to.AddDate(0, 0, 1), Valid: err == nil}
+	}
+
+	if filter.From.Valid && filter.To.Valid && !filter.To.Time.After(filter.From.Time) {
+		fields = append(fields, fieldError{Field: "to", Code: "too_early", Message: "must not come before from"})
+	}
+	if fields != nil {
+		return filter, fields
+	}
+	return filter, nil
+}
+
+// listAuditEvents searches the audit log of every entity, oldest event first.
+func (server *Server) listAuditEvents(c *gin.Context) {
+	var req listAuditEventsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	filter, err := req.toFilter()
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	arg := db.ListAuditEventsParams{AuditEventFilter: filter}
+	arg.Limit, arg.Offset = req.limitOffset()
+
+	events, err := server.store.ListAuditEvents(c, arg)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, generateAuditEventsResponse(events))
+}
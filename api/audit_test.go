@@ -0,0 +1,272 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func randomAuditEvent(invoiceNumber int64) db.AuditEvent {
+	return db.AuditEvent{
+		ID:         util.RandomInt(1, 1000),
+		OccurredAt: time.Date(2025, 2, 14, 9, 30, 0, 0, time.UTC),
+		Actor:      util.RandomEmail(),
+		Action:     db.ActionRecordPayment,
+		EntityType: db.EntityInvoice,
+		EntityID:   invoiceNumber,
+		Before:     json.RawMessage(`{"status":"pending_payment","version":1}`),
+		After:      json.RawMessage(`{"status":"paid","version":2,"payment":{"id":3}}`),
+		RequestID:  util.RandomString(16),
+	}
+}
+
+func TestInvoiceHistoryAPI(t *testing.T) {
+	event := randomAuditEvent(42)
+
+	testCases := []struct {
+		name          string
+		url           string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			url:  "/invoices/42/history?page_id=2&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAuditEventsParams{
+					AuditEventFilter: db.AuditEventFilter{
+						EntityType: pgtype.Text{String: db.EntityInvoice, Valid: true},
+						EntityID:   pgtype.Int8{Int64: 42, Valid: true},
+					},
+					Limit:  5,
+					Offset: 5,
+				}
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.AuditEvent{event}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got []auditEventResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Len(t, got, 1)
+				require.Equal(t, event.ID, got[0].ID)
+				require.Equal(t, "2025-02-14T09:30:00Z", got[0].OccurredAt)
+				require.Equal(t, event.Actor, got[0].Actor)
+				require.Equal(t, db.ActionRecordPayment, got[0].Action)
+				require.JSONEq(t, string(event.Before), string(got[0].Before))
+				require.JSONEq(t, string(event.After), string(got[0].After))
+				require.Equal(t, event.RequestID, got[0].RequestID)
+			},
+		},
+
+		{
+			name: "Creation",
+			url:  "/invoices/42/history",
+			buildStubs: func(store *mockdb.MockStore) {
+				created := event
+				created.Action = db.ActionCreate
+				created.Before = nil
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.AuditEvent{created}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Body.String(), `"before":null`)
+			},
+		},
+
+		{
+			name: "InvalidID",
+			url:  "/invoices/0/history",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name: "InternalError",
+			url:  "/invoices/42/history",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			request, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListAuditEventsAPI(t *testing.T) {
+	testCases := []struct {
+		name       string
+		query      string
+		buildStubs func(store *mockdb.MockStore)
+		status     int
+	}{
+		{
+			name:  "AllFilters",
+			query: "actor=ops@example.com&action=delete&entity_type=product&entity_id=9&request_id=abc&from=2025-01-01&to=2025-01-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListAuditEventsParams{
+					AuditEventFilter: db.AuditEventFilter{
+						Actor:      pgtype.Text{String: "ops@example.com", Valid: true},
+						Action:     pgtype.Text{String: db.ActionDelete, Valid: true},
+						EntityType: pgtype.Text{String: db.EntityProduct, Valid: true},
+						EntityID:   pgtype.Int8{Int64: 9, Valid: true},
+						RequestID:  pgtype.Text{String: "abc", Valid: true},
+						From:       pgtype.Timestamptz{Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+						To:         pgtype.Timestamptz{Time: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+					},
+					Limit:  20,
+					Offset: 0,
+				}
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.AuditEvent{}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:  "NoFilters",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListAuditEvents(gomock.Any(), gomock.Eq(db.ListAuditEventsParams{Limit: 20})).
+					Times(1).
+					Return([]db.AuditEvent{randomAuditEvent(7)}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:  "UnknownAction",
+			query: "action=approve",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:  "InvalidDate",
+			query: "from=01/01/2025",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:  "ToBeforeFrom",
+			query: "from=2025-02-01&to=2025-01-01",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Times(0)
+			},
+			status: http.StatusBadRequest,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			request, err := http.NewRequest(http.MethodGet, "/admin/audit-events?"+tc.query, nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.router.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.status, recorder.Code, recorder.Body.String())
+		})
+	}
+}
+
+func TestIdentifyActor(t *testing.T) {
+	testCases := []struct {
+		name   string
+		header string
+		actor  string
+	}{
+		{"Email", "ops@example.com", "ops@example.com"},
+		{"Missing", "", "anonymous"},
+		{"Invalid", "ops <ops@example.com>", "anonymous"},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				DeleteProductTx(gomock.Any(), gomock.Eq(int64(9))).
+				Times(1).
+				DoAndReturn(func(ctx context.Context, id int64) error {
+					require.Equal(t, tc.actor, util.Actor(ctx))
+					require.NotEmpty(t, util.RequestID(ctx))
+					return nil
+				})
+
+			request, err := http.NewRequest(http.MethodDelete, fmt.Sprintf("/products/%d", 9), nil)
+			require.NoError(t, err)
+			if tc.header != "" {
+				request.Header.Set(headerActor, tc.header)
+			}
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusNoContent, recorder.Code)
+		})
+	}
+}
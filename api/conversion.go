@@ -1,9 +1,19 @@
 package api
 
 import (
+	"errors"
+	"fmt"
 	"strconv"
+	"strings"
+
+	"github.com/Rhymond/go-money"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
+// errInvalidAmount is returned for an amount that is not a non-negative
+// decimal number or that has more decimal places than its currency.
+var errInvalidAmount = errors.New("invalid amount")
+
 func convertRateFromPercentToBasisPoints(rate string) int {
 	rateFloat, _ := strconv.ParseFloat(rate, 64)
 	basisPoints := int((rateFloat * 100))
@@ -15,7 +25,54 @@ func basisPointsToPercent(rate int64) string {
 	return strconv.FormatFloat(f, 'f', -1, 64)
 }
 
-func convertStringToFloat64(value string) float64 {
-	floatValue, _ := strconv.ParseFloat(value, 64)
-	return floatValue
+func nullableInt64(value pgtype.Int8) *int64 {
+	if !value.Valid {
+		return nil
+	}
+	return &value.Int64
+}
+
+// parseMinorUnits converts a decimal amount in major units into minor units of
+// currency, e.g. "1234.56" USD into 123456. The digits are converted as they
+// are rather than through a float, so that large amounts stay exact, and an
+// amount with more decimal places than the currency has is rejected rather
+// than rounded.
+func parseMinorUnits(value, currency string) (int64, error) {
+	c := money.GetCurrency(currency)
+	if c == nil {
+		return 0, fmt.Errorf("%w: unknown currency %q", errInvalidAmount, currency)
+	}
+	if !amountPattern.MatchString(value) {
+		return 0, fmt.Errorf("%w: %q is not a non-negative decimal number", errInvalidAmount, value)
+	}
+
+	whole, fraction, _ := strings.Cut(value, ".")
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > c.Fraction {
+		return 0, fmt.Errorf("%w: %s has more than %d decimal places for %s", errInvalidAmount, value, c.Fraction, currency)
+	}
+	amount, err := strconv.ParseInt(whole+fraction+strings.Repeat("0", c.Fraction-len(fraction)), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %s is too large", errInvalidAmount, value)
+	}
+	return amount, nil
+}
+
+// formatMajorUnits renders an amount in minor units as a plain decimal string,
+// e.g. 123456 USD as "1234.56", for exports that are read by spreadsheets and
+// for amounts that are parsed again with parseMinorUnits.
+func formatMajorUnits(amount int64, currency string) string {
+	fraction := money.New(amount, currency).Currency().Fraction
+	sign, magnitude := "", uint64(amount)
+	if amount < 0 {
+		sign, magnitude = "-", -magnitude
+	}
+	digits := strconv.FormatUint(magnitude, 10)
+	if fraction == 0 {
+		return sign + digits
+	}
+	if len(digits) <= fraction {
+		digits = strings.Repeat("0", fraction-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-fraction] + "." + digits[len(digits)-fraction:]
 }
@@ -0,0 +1,55 @@
+package api
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMinorUnits(t *testing.T) {
+	testCases := []struct {
+		value    string
+		currency string
+		amount   int64
+		ok       bool
+	}{
+		{"125.50", "USD", 12550, true},
+		{"125.5", "USD", 12550, true},
+		{"125", "USD", 12500, true},
+		{"0.07", "USD", 7, true},
+		{"1250", "JPY", 1250, true},
+		{"1250.00", "JPY", 1250, true},
+		{"1.234", "BHD", 1234, true},
+		{"92233720368547758.07", "USD", 9223372036854775807, true},
+		{"0.285", "USD", 0, false},
+		{"0.5", "JPY", 0, false},
+		{"92233720368547758.08", "USD", 0, false},
+		{"-1", "USD", 0, false},
+		{"1e3", "USD", 0, false},
+		{".5", "USD", 0, false},
+		{"", "USD", 0, false},
+		{"1", "XYZ", 0, false},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.value+" "+tc.currency, func(t *testing.T) {
+			amount, err := parseMinorUnits(tc.value, tc.currency)
+			if !tc.ok {
+				require.ErrorIs(t, err, errInvalidAmount)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.amount, amount)
+		})
+	}
+}
+
+func TestFormatMajorUnits(t *testing.T) {
+	require.Equal(t, "1234.56", formatMajorUnits(123456, "USD"))
+	require.Equal(t, "0.07", formatMajorUnits(7, "USD"))
+	require.Equal(t, "-0.07", formatMajorUnits(-7, "USD"))
+	require.Equal(t, "1250", formatMajorUnits(1250, "JPY"))
+	require.Equal(t, "1.234", formatMajorUnits(1234, "BHD"))
+	require.Equal(t, "92233720368547758.07", formatMajorUnits(9223372036854775807, "USD"))
+	require.Equal(t, "-92233720368547758.08", formatMajorUnits(-9223372036854775808, "USD"))
+}
@@ -0,0 +1,92 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuthumipepple/numeris-book/db"
+	"github.com/kuthumipepple/numeris-book/einvoice"
+)
+
+const mimeXML = "application/xml"
+
+// eInvoiceRequest holds the buyer details that structured invoices need but
+// that invoices do not record.
+type eInvoiceRequest struct {
+	BuyerCountry   string `form:"buyer_country" binding:"omitempty,iso3166_1_alpha2"`
+	BuyerReference string `form:"buyer_reference" binding:"omitempty,max=200"`
+}
+
+// invoiceUBL renders an invoice as a PEPPOL BIS Billing 3.0 UBL invoice,
+// signed if a signing certificate is configured.
+func (server *Server) invoiceUBL(c *gin.Context) {
+	var uri getInvoiceRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req eInvoiceRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := server.store.GetInvoice(c, uri.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	document, ok := server.eInvoiceDocument(c, result, req)
+	if !ok {
+		return
+	}
+
+	data, err := document.UBL()
+	if err == nil {
+		data, err = server.signDocument(data, (*einvoice.Signer).SignXML)
+	}
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="invoice-%s.xml"`, document.Number))
+	c.Data(http.StatusOK, mimeXML, data)
+}
+
+// eInvoiceDocument builds and validates the e-invoice model of an invoice.
+// Invoices do not record the buyer's country, so it defaults to the seller's.
+// Invoices that break the EN 16931 or PEPPOL business rules are rejected with
+// the list of violated rules, in which case ok is false and the response has
+// been written.
+func (server *Server) eInvoiceDocument(c *gin.Context, result db.InvoiceResult, req eInvoiceRequest) (document einvoice.Document, ok bool) {
+	buyerCountry := req.BuyerCountry
+	if buyerCountry == "" {
+		buyerCountry = server.config.SellerCountryCode
+	}
+
+	document, err := einvoice.NewDocument(result, einvoice.Options{
+		SellerCountryCode: server.config.SellerCountryCode,
+		SellerVATID:       server.config.SellerVATID,
+		BuyerCountryCode:  buyerCountry,
+		BuyerReference:    req.BuyerReference,
+		DefaultTaxCode:    server.config.DefaultTaxCode,
+	})
+	if err == nil {
+		err = document.Validate()
+	}
+	if err != nil {
+		var validationErr *einvoice.ValidationError
+		if errors.As(err, &validationErr) {
+			respondWithViolations(c, "invoice does not meet the e-invoicing rules", validationErr.Violations)
+			return einvoice.Document{}, false
+		}
+		respondWithError(c, http.StatusUnprocessableEntity, err)
+		return einvoice.Document{}, false
+	}
+	return document, true
+}
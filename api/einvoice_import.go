@@ -0,0 +1,158 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"mime"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/db"
+	"github.com/kuthumipepple/numeris-book/einvoice"
+	"github.com/kuthumipepple/numeris-book/util"
+)
+
+const maxEInvoiceSize = 10 << 20
+
+var (
+	eInvoiceMediaTypes = []string{mimeXML, "text/xml"}
+
+	unsafeFilenameChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+)
+
+type importEInvoiceResponse struct {
+	InvoiceNumber int64 `json:"invoice_number"`
+	// DocumentNumber is the invoice number given by the seller.
+	DocumentNumber string    `json:"document_number"`
+	AttachmentID   int64     `json:"attachment_id"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+// importUBL records a received UBL 2.1 invoice.
+func (server *Server) importUBL(c *gin.Context) {
+	server.importEInvoice(c, einvoice.ParseUBL)
+}
+
+// importCII records a received Cross Industry Invoice.
+func (server *Server) importCII(c *gin.Context) {
+	server.importEInvoice(c, einvoice.ParseCII)
+}
+
+// importEInvoice creates an invoice from the e-invoice in the request body.
+// The totals stated in the e-invoice must agree with the totals recomputed
+// from its lines, and it must meet the same business rules as the e-invoices
+// rendered here. The original XML is kept as an attachment of the invoice.
+func (server *Server) importEInvoice(c *gin.Context, parse func([]byte) (einvoice.Document, error)) {
+	if c.ContentType() != "" {
+		mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+		if !util.Contains(eInvoiceMediaTypes, mediaType) {
+			err := fmt.Errorf("unsupported content type %q", c.ContentType())
+			respondWithError(c, http.StatusUnsupportedMediaType, err)
+			return
+		}
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxEInvoiceSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(c, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	document, err := parse(data)
+	if err == nil {
+		err = document.Validate()
+	}
+	if err != nil {
+		var validationErr *einvoice.ValidationError
+		switch {
+		case errors.As(err, &validationErr):
+			respondWithViolations(c, "e-invoice does not meet the e-invoicing rules", validationErr.Violations)
+		case errors.Is(err, einvoice.ErrInvalidDocument):
+			respondWithError(c, http.StatusUnprocessableEntity, err)
+		default:
+			respondWithError(c, http.StatusBadRequest, err)
+		}
+		return
+	}
+
+	result, err := server.store.ImportInvoiceTx(c, db.ImportInvoiceTxParams{
+		Invoice: importedInvoiceParams(document),
+		Attachment: db.InsertInvoiceAttachmentParams{
+			Filename:    unsafeFilenameChars.ReplaceAllString(document.Number, "_") + ".xml",
+			ContentType: mimeXML,
+			Content:     data,
+		},
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	server.metrics.invoiceCreated(result.Invoice)
+
+	c.JSON(http.StatusCreated, importEInvoiceResponse{
+		InvoiceNumber:  result.InvoiceNumber,
+		DocumentNumber: document.Number,
+		AttachmentID:   result.Attachment.ID,
+		CreatedAt:      result.CreatedAt,
+	})
+}
+
+// importedInvoiceParams maps a validated e-invoice to a new invoice awaiting
+// payment. Line amounts are net of VAT and the VAT category of each line is
+// kept in its tax code, while the total is the amount payable, VAT included,
+// as it is for invoices created through the API. An e-invoice without a due
+// date is due on issue.
+func importedInvoiceParams(d einvoice.Document) db.CreateInvoiceTxParams {
+	items := make([]db.InsertLineItemParams, len(d.Lines))
+	for i, v := range d.Lines {
+		items[i] = db.InsertLineItemParams{
+			Description: v.Name,
+			Quantity:    v.Quantity,
+			UnitPrice:   v.UnitPrice,
+			TotalPrice:  v.NetAmount,
+			TaxCode:     v.Tax.TaxCode(),
+		}
+	}
+
+	dueDate := d.DueDate
+	if dueDate.IsZero() {
+		dueDate = d.IssueDate
+	}
+
+	var discountRate int64
+	if d.LineTotal != 0 {
+		discountRate = int64(math.Round(float64(d.AllowanceTotal) * 10000 / float64(d.LineTotal)))
+	}
+
+	return db.CreateInvoiceTxParams{
+		CustomerName:    d.Buyer.Name,
+		CustomerEmail:   d.Buyer.Email,
+		CustomerPhone:   d.Buyer.Phone,
+		CustomerAddress: d.Buyer.Address,
+		SenderName:      d.Seller.Name,
+		SenderEmail:     d.Seller.Email,
+		SenderPhone:     d.Seller.Phone,
+		SenderAddress:   d.Seller.Address,
+		IssueDate:       d.IssueDate,
+		DueDate:         dueDate,
+		Status:          util.PENDING_PAYMENT,
+		Subtotal:        d.LineTotal,
+		DiscountRate:    discountRate,
+		Discount:        d.AllowanceTotal,
+		TotalAmount:     d.PayableAmount,
+		PaymentInfo:     d.PaymentTerms,
+		BillingCurrency: pgtype.Text{String: d.Currency, Valid: true},
+		Note:            pgtype.Text{String: d.Note, Valid: d.Note != ""},
+		Items:           items,
+	}
+}
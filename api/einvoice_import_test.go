@@ -0,0 +1,234 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/einvoice"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestImportEInvoiceAPI(t *testing.T) {
+	invoice := randomEInvoice(1042)
+	invoice.LineItems[0].TaxCode = "S:7.5"
+	invoice.Discount = 3000
+	invoice.TotalAmount = 29025
+	document, err := einvoice.NewDocument(invoice, einvoice.Options{
+		SellerCountryCode: "NG",
+		SellerVATID:       "NG12345678",
+		BuyerCountryCode:  "NG",
+		DefaultTaxCode:    "O",
+	})
+	require.NoError(t, err)
+	ubl, err := document.UBL()
+	require.NoError(t, err)
+	cii, err := document.CII()
+	require.NoError(t, err)
+
+	importArg := func(content []byte) db.ImportInvoiceTxParams {
+		return db.ImportInvoiceTxParams{
+			Invoice: db.CreateInvoiceTxParams{
+				CustomerName:    invoice.CustomerName,
+				CustomerEmail:   invoice.CustomerEmail,
+				SenderName:      invoice.SenderName,
+				SenderEmail:     invoice.SenderEmail,
+				IssueDate:       invoice.IssueDate,
+				DueDate:         invoice.DueDate,
+				Status:          util.PENDING_PAYMENT,
+				Subtotal:        30000,
+				DiscountRate:    1000,
+				Discount:        3000,
+				TotalAmount:     29025, // 270.00 after the discount plus 7.5% VAT
+				PaymentInfo:     invoice.PaymentInfo,
+				BillingCurrency: pgtype.Text{String: "USD", Valid: true},
+				Items: []db.InsertLineItemParams{
+					{Description: "Consulting", Quantity: 3, UnitPrice: 10000, TotalPrice: 30000, TaxCode: "S:7.5"},
+				},
+			},
+			Attachment: db.InsertInvoiceAttachmentParams{
+				Filename:    "1042.xml",
+				ContentType: mimeXML,
+				Content:     content,
+			},
+		}
+	}
+	importResult := db.ImportInvoiceResult{
+		InvoiceResult: db.InvoiceResult{Invoice: db.Invoice{InvoiceNumber: 7}},
+		Attachment:    db.InvoiceAttachment{ID: 3},
+	}
+
+	testCases := []struct {
+		name          string
+		path          string
+		contentType   string
+		body          []byte
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "UBL",
+			path:        "/invoices/import/ubl",
+			contentType: mimeXML,
+			body:        ubl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ImportInvoiceTx(gomock.Any(), gomock.Eq(importArg(ubl))).
+					Times(1).
+					Return(importResult, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var got importEInvoiceResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, int64(7), got.InvoiceNumber)
+				require.Equal(t, "1042", got.DocumentNumber)
+				require.Equal(t, int64(3), got.AttachmentID)
+			},
+		},
+
+		{
+			name:        "CII",
+			path:        "/invoices/import/cii",
+			contentType: "text/xml; charset=utf-8",
+			body:        cii,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ImportInvoiceTx(gomock.Any(), gomock.Eq(importArg(cii))).
+					Times(1).
+					Return(importResult, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+
+		{
+			name:        "MisstatedTotals",
+			path:        "/invoices/import/ubl",
+			contentType: mimeXML,
+			body:        bytes.Replace(ubl, []byte(`<cbc:PayableAmount currencyID="USD">290.25`), []byte(`<cbc:PayableAmount currencyID="USD">300.00`), 1),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ImportInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+				var got struct {
+					Violations []einvoice.Violation `json:"violations"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got.Violations, 1)
+				require.Equal(t, "BR-CO-16", got.Violations[0].Rule)
+			},
+		},
+
+		{
+			name:        "UnsupportedDocument",
+			path:        "/invoices/import/cii",
+			contentType: mimeXML,
+			body:        ubl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ImportInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+
+		{
+			name:        "MalformedXML",
+			path:        "/invoices/import/ubl",
+			contentType: mimeXML,
+			body:        ubl[:len(ubl)/2],
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ImportInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:        "UnsupportedContentType",
+			path:        "/invoices/import/ubl",
+			contentType: "application/json",
+			body:        ubl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ImportInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+			},
+		},
+
+		{
+			name:        "TooLarge",
+			path:        "/invoices/import/ubl",
+			contentType: mimeXML,
+			body:        []byte(strings.Repeat(" ", maxEInvoiceSize+1)),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ImportInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+
+		{
+			name:        "InternalError",
+			path:        "/invoices/import/ubl",
+			contentType: mimeXML,
+			body:        ubl,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ImportInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ImportInvoiceResult{}, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			request, err := http.NewRequest(http.MethodPost, tc.path, bytes.NewReader(tc.body))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", tc.contentType)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,406 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/einvoice"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// randomEInvoice returns an invoice that meets the e-invoicing rules with the
+// test server's configuration.
+func randomEInvoice(invoiceNumber int64) db.InvoiceResult {
+	issueDate := time.Date(2025, 2, 3, 0, 0, 0, 0, time.UTC)
+	return db.InvoiceResult{
+		Invoice: db.Invoice{
+			InvoiceNumber:   invoiceNumber,
+			CustomerName:    "Globex",
+			CustomerEmail:   "ap@globex.example",
+			SenderName:      "Numeris Studio",
+			SenderEmail:     "billing@numeris.example",
+			IssueDate:       issueDate,
+			DueDate:         issueDate.AddDate(0, 0, 30),
+			Status:          "pending_payment",
+			Subtotal:        30000,
+			TotalAmount:     30000,
+			PaymentInfo:     "Bank transfer",
+			BillingCurrency: "USD",
+			Version:         1,
+		},
+		LineItems: []db.LineItem{
+			{ID: 1, InvoiceNumber: invoiceNumber, Description: "Consulting", Quantity: 3, UnitPrice: 10000, TotalPrice: 30000},
+		},
+	}
+}
+
+func TestInvoiceUBLAPI(t *testing.T) {
+	fakeID := int64(1042)
+	result := randomEInvoice(fakeID)
+
+	type ublInvoice struct {
+		ID             string `xml:"ID"`
+		BuyerReference string `xml:"BuyerReference"`
+		Customer       struct {
+			CountryCode string `xml:"Party>PostalAddress>Country>IdentificationCode"`
+		} `xml:"AccountingCustomerParty"`
+		PayableAmount string `xml:"LegalMonetaryTotal>PayableAmount"`
+	}
+
+	testCases := []struct {
+		name          string
+		invoiceNumber int64
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:          "OK",
+			invoiceNumber: fakeID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Eq(fakeID)).
+					Times(1).
+					Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, mimeXML, recorder.Header().Get("Content-Type"))
+				require.Equal(t, `attachment; filename="invoice-1042.xml"`, recorder.Header().Get("Content-Disposition"))
+
+				var got ublInvoice
+				require.NoError(t, xml.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, "1042", got.ID)
+				require.Equal(t, "1042", got.BuyerReference)
+				require.Equal(t, "NG", got.Customer.CountryCode)
+				require.Equal(t, "300.00", got.PayableAmount)
+			},
+		},
+
+		{
+			name:          "BuyerDetails",
+			invoiceNumber: fakeID,
+			query:         "?buyer_country=DE&buyer_reference=PO-77",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Eq(fakeID)).
+					Times(1).
+					Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got ublInvoice
+				require.NoError(t, xml.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Equal(t, "PO-77", got.BuyerReference)
+				require.Equal(t, "DE", got.Customer.CountryCode)
+			},
+		},
+
+		{
+			name:          "RuleViolations",
+			invoiceNumber: fakeID,
+			buildStubs: func(store *mockdb.MockStore) {
+				invalid := result
+				invalid.LineItems = []db.LineItem{
+					{ID: 1, InvoiceNumber: fakeID, Description: "Consulting", Quantity: 3, UnitPrice: 10000, TotalPrice: 30000, TaxCode: "S:7.5"},
+				}
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Eq(fakeID)).
+					Times(1).
+					Return(invalid, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+
+				var got struct {
+					Violations []struct {
+						Rule string `json:"rule"`
+					} `json:"violations"`
+				}
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &got))
+				require.Len(t, got.Violations, 1)
+				require.Equal(t, "BR-S-02", got.Violations[0].Rule)
+			},
+		},
+
+		{
+			name:          "InvalidTaxCode",
+			invoiceNumber: fakeID,
+			buildStubs: func(store *mockdb.MockStore) {
+				invalid := result
+				invalid.LineItems = []db.LineItem{
+					{ID: 1, InvoiceNumber: fakeID, Description: "Consulting", Quantity: 3, UnitPrice: 10000, TotalPrice: 30000, TaxCode: "S:x"},
+				}
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Eq(fakeID)).
+					Times(1).
+					Return(invalid, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+
+		{
+			name:          "InvalidBuyerCountry",
+			invoiceNumber: fakeID,
+			query:         "?buyer_country=XX",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:          "NotFound",
+			invoiceNumber: fakeID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Eq(fakeID)).
+					Times(1).
+					Return(db.InvoiceResult{}, db.ErrNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+
+		{
+			name:          "InternalError",
+			invoiceNumber: fakeID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Eq(fakeID)).
+					Times(1).
+					Return(db.InvoiceResult{}, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			url := fmt.Sprintf("/invoices/%d/ubl%s", tc.invoiceNumber, tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCreateInvoiceTotalMatchesEInvoice(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	var arg db.CreateInvoiceTxParams
+	store.EXPECT().
+		CreateInvoiceTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ context.Context, params db.CreateInvoiceTxParams) (db.InvoiceResult, error) {
+			arg = params
+			return db.InvoiceResult{Invoice: db.Invoice{InvoiceNumber: 1}}, nil
+		})
+
+	body := gin.H{
+		"customer_name":    "Globex",
+		"customer_email":   "ap@globex.example",
+		"customer_phone":   "+1234567890",
+		"customer_address": "123 A Street",
+		"sender_name":      "Numeris Studio",
+		"sender_email":     "billing@numeris.example",
+		"sender_phone":     "+9876543210",
+		"sender_address":   "456 X Street",
+		"issue_date":       "2025-02-03",
+		"due_date":         "2025-03-05",
+		"status":           "pending_payment",
+		"discount_rate":    "5.80",
+		"payment_info":     "Bank transfer",
+		"line_items": []gin.H{
+			{"description": "Consulting", "quantity": 2, "unit_price": "33.33", "tax_code": "S:7.5"},
+			{"description": "Printed report", "quantity": 1, "unit_price": "10.00", "tax_code": "Z"},
+			{"description": "Courier", "quantity": 1, "unit_price": "5.00"},
+		},
+	}
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+
+	request, err := http.NewRequest(http.MethodPost, "/invoices", bytes.NewReader(data))
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	server := newTestServer(t, store)
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusCreated, recorder.Code)
+
+	result := db.InvoiceResult{
+		Invoice: db.Invoice{
+			InvoiceNumber:   1,
+			Subtotal:        arg.Subtotal,
+			DiscountRate:    arg.DiscountRate,
+			Discount:        arg.Discount,
+			TotalAmount:     arg.TotalAmount,
+			BillingCurrency: arg.BillingCurrency.String,
+		},
+	}
+	for i, v := range arg.Items {
+		result.LineItems = append(result.LineItems, db.LineItem{
+			ID:          int64(i + 1),
+			Description: v.Description,
+			Quantity:    v.Quantity,
+			UnitPrice:   v.UnitPrice,
+			TotalPrice:  v.TotalPrice,
+			TaxCode:     v.TaxCode,
+		})
+	}
+
+	d, err := einvoice.NewDocument(result, einvoice.Options{DefaultTaxCode: server.config.DefaultTaxCode})
+	require.NoError(t, err)
+	require.NotZero(t, d.TaxTotal)
+	require.Equal(t, arg.Subtotal-arg.Discount+d.TaxTotal, arg.TotalAmount)
+	require.Equal(t, d.PayableAmount, arg.TotalAmount)
+}
+
+func TestInvoiceFacturXAPI(t *testing.T) {
+	fakeID := int64(1042)
+	result := randomEInvoice(fakeID)
+
+	testCases := []struct {
+		name          string
+		query         string
+		accept        string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "FormatParam",
+			query: "?format=pdf&buyer_country=FR",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Eq(fakeID)).
+					Times(1).
+					Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, mimePDF, recorder.Header().Get("Content-Type"))
+				require.Equal(t, `attachment; filename="invoice-1042.pdf"`, recorder.Header().Get("Content-Disposition"))
+				require.True(t, bytes.HasPrefix(recorder.Body.Bytes(), []byte("%PDF-")))
+				require.Contains(t, recorder.Body.String(), "<ram:CountryID>FR</ram:CountryID>")
+			},
+		},
+
+		{
+			name:   "AcceptHeader",
+			accept: mimePDF,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Eq(fakeID)).
+					Times(1).
+					Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, mimePDF, recorder.Header().Get("Content-Type"))
+				require.Contains(t, recorder.Body.String(), "/AFRelationship /Alternative")
+			},
+		},
+
+		{
+			name:   "JSONByDefault",
+			accept: "application/json, application/pdf",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Eq(fakeID)).
+					Times(1).
+					Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Contains(t, recorder.Header().Get("Content-Type"), gin.MIMEJSON)
+			},
+		},
+
+		{
+			name:  "RuleViolations",
+			query: "?format=pdf",
+			buildStubs: func(store *mockdb.MockStore) {
+				invalid := result
+				invalid.CustomerName = ""
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Eq(fakeID)).
+					Times(1).
+					Return(invalid, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+				require.Contains(t, recorder.Body.String(), "BR-07")
+			},
+		},
+
+		{
+			name:  "InvalidFormat",
+			query: "?format=xml",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			url := fmt.Sprintf("/invoices/%d%s", fakeID, tc.query)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			if tc.accept != "" {
+				request.Header.Set("Accept", tc.accept)
+			}
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.router.ServeHTTP(recorder, request)
+			tc.checkResponse(recorder)
+		})
+	}
+}
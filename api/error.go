@@ -2,20 +2,76 @@ package api
 
 import (
 	"errors"
+	"log/slog"
+	"net/http"
 
-	"github.com/jackc/pgx/v5"
-	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/gin-gonic/gin"
+	"github.com/kuthumipepple/numeris-book/db"
+	"github.com/kuthumipepple/numeris-book/util"
 )
 
-const ForeignKeyViolation = "23503"
+// errorStatuses maps the kinds of store errors to response statuses.
+var errorStatuses = []struct {
+	kind   error
+	status int
+}{
+	{db.ErrNotFound, http.StatusNotFound},
+	{db.ErrConflict, http.StatusConflict},
+	{db.ErrConstraintViolation, http.StatusConflict},
+	{db.ErrValidation, http.StatusUnprocessableEntity},
+	{db.ErrPreconditionFailed, http.StatusPreconditionFailed},
+	{db.ErrUnavailable, http.StatusServiceUnavailable},
+}
+
+// describeError returns the status and a client-safe description of an error
+// from the store. Errors of no known kind are internal failures, which are
+// not described.
+func describeError(err error) (status int, detail string) {
+	for _, v := range errorStatuses {
+		if !errors.Is(err, v.kind) {
+			continue
+		}
+		var dbErr *db.Error
+		if errors.As(err, &dbErr) {
+			return v.status, dbErr.Message
+		}
+		return v.status, v.kind.Error()
+	}
+	return http.StatusInternalServerError, "an internal error occurred"
+}
+
+// handleErrors responds to the errors that handlers record with c.Error,
+// which are those from the store and internal failures. The errors are logged
+// and the client is given the request ID as a correlation ID, so that a report
+// of a failed request can be matched with its cause.
+func handleErrors() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 {
+			return
+		}
+
+		for _, err := range c.Errors {
+			level := slog.LevelError
+			if status, _ := describeError(err.Err); status < http.StatusInternalServerError {
+				level = slog.LevelWarn
+			}
+			slog.Log(c, level, "request failed", slog.String("error", err.Err.Error()))
+		}
 
-var ErrRecordNotFound = pgx.ErrNoRows
-var ErrForeignKeyViolation = &pgconn.PgError{Code: ForeignKeyViolation}
+		// A handler that has started its response, such as a streamed
+		// export, can no longer change it.
+		if c.Writer.Written() {
+			return
+		}
 
-func ErrorCode(err error) string {
-	var pgErr *pgconn.PgError
-	if errors.As(err, &pgErr) {
-		return pgErr.Code
+		status, detail := describeError(c.Errors.Last().Err)
+		writeProblem(c, problemResponse{
+			Type:          "about:blank",
+			Title:         http.StatusText(status),
+			Status:        status,
+			Detail:        detail,
+			CorrelationID: util.RequestID(c),
+		})
 	}
-	return ""
 }
@@ -0,0 +1,97 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHandleErrors(t *testing.T) {
+	cause := &pgconn.PgError{
+		Code:    "23503",
+		Message: `insert or update on table "invoices" violates foreign key constraint "invoices_quote_number_fkey"`,
+	}
+
+	testCases := []struct {
+		name   string
+		err    error
+		status int
+		detail string
+	}{
+		{
+			name:   "NotFound",
+			err:    db.ErrNotFound,
+			status: http.StatusNotFound,
+			detail: "record not found",
+		},
+		{
+			name:   "ConstraintViolation",
+			err:    &db.Error{Kind: db.ErrConstraintViolation, Message: "record refers to a record that does not exist", Err: cause},
+			status: http.StatusConflict,
+			detail: "record refers to a record that does not exist",
+		},
+		{
+			name:   "Validation",
+			err:    db.ErrInvoiceNotPayable,
+			status: http.StatusUnprocessableEntity,
+			detail: "invoice is not awaiting payment",
+		},
+		{
+			name:   "PreconditionFailed",
+			err:    db.ErrStaleInvoice,
+			status: http.StatusPreconditionFailed,
+			detail: "invoice has changed since it was read",
+		},
+		{
+			name:   "Unavailable",
+			err:    &db.Error{Kind: db.ErrUnavailable, Message: "database is unavailable", Err: errors.New("dial tcp 10.0.0.5:5432: connect: connection refused")},
+			status: http.StatusServiceUnavailable,
+			detail: "database is unavailable",
+		},
+		{
+			name:   "Internal",
+			err:    cause,
+			status: http.StatusInternalServerError,
+			detail: "an internal error occurred",
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetInvoice(gomock.Any(), gomock.Eq(int64(7))).
+				Times(1).
+				Return(db.InvoiceResult{}, tc.err)
+
+			request, err := http.NewRequest(http.MethodGet, "/invoices/7", nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.router.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.status, recorder.Code)
+			require.Equal(t, mimeProblemJSON, recorder.Header().Get("Content-Type"))
+			require.NotContains(t, recorder.Body.String(), "invoices_quote_number_fkey")
+			require.NotContains(t, recorder.Body.String(), "5432")
+
+			var problem problemResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+			require.Equal(t, tc.status, problem.Status)
+			require.Equal(t, tc.detail, problem.Detail)
+			require.Len(t, problem.CorrelationID, 16)
+		})
+	}
+}
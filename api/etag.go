@@ -0,0 +1,78 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuthumipepple/numeris-book/db"
+)
+
+// invoiceETag is the entity tag of the JSON representation of an invoice,
+// which is its version. The PDF is another representation of the same
+// version and has a tag of its own.
+func invoiceETag(invoice db.Invoice, format string) string {
+	if format == "pdf" {
+		return `"` + strconv.FormatInt(invoice.Version, 10) + `-pdf"`
+	}
+	return `"` + strconv.FormatInt(invoice.Version, 10) + `"`
+}
+
+// notModified reports whether the If-None-Match header of the request lists
+// etag, in which case it has responded with 304 Not Modified. The comparison
+// is weak, as RFC 9110 requires for If-None-Match.
+func notModified(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return false
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimPrefix(strings.TrimSpace(tag), "W/")
+		if tag == "*" || tag == strings.TrimPrefix(etag, "W/") {
+			c.Header("ETag", etag)
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
+
+// requireInvoiceVersion returns the version of the invoice that the If-Match
+// header of a request that changes the invoice was made against. A request
+// without the header is rejected with 428 Precondition Required, and one with
+// a header that names no version of the JSON representation with 412
+// Precondition Failed; ok is false and the response has been written then.
+// If-Match: * returns version 0, which matches any version.
+func requireInvoiceVersion(c *gin.Context) (version int64, ok bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" {
+		writeProblem(c, problemResponse{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusPreconditionRequired),
+			Status: http.StatusPreconditionRequired,
+			Detail: "If-Match must give the ETag of the invoice",
+		})
+		return 0, false
+	}
+	if header == "*" {
+		return 0, true
+	}
+
+	// The header must be a single strong tag. A weak tag never matches, since
+	// If-Match compares tags strongly, and the store checks the invoice
+	// against one version.
+	unquoted, quoted := strings.CutPrefix(header, `"`)
+	unquoted, closed := strings.CutSuffix(unquoted, `"`)
+	version, err := strconv.ParseInt(unquoted, 10, 64)
+	if !quoted || !closed || err != nil || version < 1 {
+		writeProblem(c, problemResponse{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusPreconditionFailed),
+			Status: http.StatusPreconditionFailed,
+			Detail: db.ErrStaleInvoice.Message,
+		})
+		return 0, false
+	}
+	return version, true
+}
@@ -0,0 +1,275 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuthumipepple/numeris-book/db"
+	"github.com/xuri/excelize/v2"
+)
+
+const mimeXLSX = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+type exportInvoicesRequest struct {
+	invoiceFilterRequest
+	Format    string `form:"format" binding:"omitempty,oneof=csv xlsx"`
+	LineItems bool   `form:"line_items"`
+}
+
+// exportColumn describes a column of an export. Numeric columns are written
+// as numbers rather than text in XLSX files.
+type exportColumn struct {
+	Name    string
+	Numeric bool
+}
+
+var invoiceExportColumns = []exportColumn{
+	{"invoice_number", true},
+	{"issue_date", false},
+	{"due_date", false},
+	{"status", false},
+	{"customer_name", false},
+	{"customer_email", false},
+	{"sender_name", false},
+	{"sender_email", false},
+	{"billing_currency", false},
+	{"subtotal", true},
+	{"discount_rate", true},
+	{"discount", true},
+	{"total_amount", true},
+	{"quote_number", true},
+	{"created_at", false},
+}
+
+var lineItemExportColumns = []exportColumn{
+	{"line_item_id", true},
+	{"description", false},
+	{"quantity", true},
+	{"unit_price", true},
+	{"total_price", true},
+	{"product_id", true},
+	{"tax_code", false},
+}
+
+// exportInvoices streams the invoices matching the filters as CSV (the
+// default) or XLSX. With line_items=true there is one row per line item, with
+// the invoice columns repeated on each row. Amounts are plain decimals in
+// major units and the discount rate is a percentage.
+func (server *Server) exportInvoices(c *gin.Context) {
+	var req exportInvoicesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	filter, err := req.toFilter()
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		format = "csv"
+		if c.NegotiateFormat(mimeCSV, mimeXLSX) == mimeXLSX {
+			format = "xlsx"
+		}
+	}
+
+	columns := invoiceExportColumns
+	if req.LineItems {
+		columns = append(columns[:len(columns):len(columns)], lineItemExportColumns...)
+	}
+
+	var w exportWriter
+	if format == "xlsx" {
+		xw, err := newXLSXExportWriter(c, columns)
+		if err != nil {
+			c.Error(err)
+			return
+		}
+		defer xw.f.Close()
+		w = xw
+	} else {
+		w = newCSVExportWriter(c, columns)
+	}
+
+	if req.LineItems {
+		err = server.store.StreamInvoiceLineItems(c, filter, func(i db.Invoice, l db.LineItem) error {
+			return w.Write(append(invoiceExportRecord(i), lineItemExportRecord(l, i.BillingCurrency)...))
+		})
+	} else {
+		err = server.store.StreamInvoices(c, filter, func(i db.Invoice) error {
+			return w.Write(invoiceExportRecord(i))
+		})
+	}
+	if err == nil {
+		err = w.Close()
+	}
+	if err != nil {
+		// If the response has started, the status can no longer change and
+		// the client gets a truncated file.
+		c.Error(err)
+	}
+}
+
+func invoiceExportRecord(i db.Invoice) []string {
+	quoteNumber := ""
+	if i.QuoteNumber.Valid {
+		quoteNumber = strconv.FormatInt(i.QuoteNumber.Int64, 10)
+	}
+	return []string{
+		strconv.FormatInt(i.InvoiceNumber, 10),
+		i.IssueDate.Format(time.DateOnly),
+		i.DueDate.Format(time.DateOnly),
+		i.Status,
+		i.CustomerName,
+		i.CustomerEmail,
+		i.SenderName,
+		i.SenderEmail,
+		i.BillingCurrency,
+		formatMajorUnits(i.Subtotal, i.BillingCurrency),
+		basisPointsToPercent(i.DiscountRate),
+		formatMajorUnits(i.Discount, i.BillingCurrency),
+		formatMajorUnits(i.TotalAmount, i.BillingCurrency),
+		quoteNumber,
+		i.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func lineItemExportRecord(l db.LineItem, currency string) []string {
+	productID := ""
+	if l.ProductID.Valid {
+		productID = strconv.FormatInt(l.ProductID.Int64, 10)
+	}
+	return []string{
+		strconv.FormatInt(l.ID, 10),
+		l.Description,
+		strconv.FormatInt(l.Quantity, 10),
+		formatMajorUnits(l.UnitPrice, currency),
+		formatMajorUnits(l.TotalPrice, currency),
+		productID,
+		l.TaxCode,
+	}
+}
+
+// exportWriter writes the rows of an export to the response.
+type exportWriter interface {
+	Write(record []string) error
+	// Close finishes the file. It must be called after the last row.
+	Close() error
+}
+
+// csvExportWriter sends the headers and the header row with the first record,
+// so that an error before any row is read can still be reported as JSON.
+type csvExportWriter struct {
+	c       *gin.Context
+	w       *csv.Writer
+	columns []exportColumn
+	started bool
+}
+
+func newCSVExportWriter(c *gin.Context, columns []exportColumn) *csvExportWriter {
+	return &csvExportWriter{c: c, w: csv.NewWriter(c.Writer), columns: columns}
+}
+
+func (e *csvExportWriter) start() error {
+	if e.started {
+		return nil
+	}
+	e.started = true
+
+	e.c.Header("Content-Type", mimeCSV+"; charset=utf-8")
+	e.c.Header("Content-Disposition", `attachment; filename="invoices.csv"`)
+	e.c.Status(http.StatusOK)
+
+	header := make([]string, len(e.columns))
+	for i, v := range e.columns {
+		header[i] = v.Name
+	}
+	return e.w.Write(header)
+}
+
+func (e *csvExportWriter) Write(record []string) error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	return e.w.Write(record)
+}
+
+func (e *csvExportWriter) Close() error {
+	if err := e.start(); err != nil {
+		return err
+	}
+	e.w.Flush()
+	return e.w.Error()
+}
+
+// xlsxExportWriter streams rows into a single worksheet. excelize keeps the
+// rows in a temporary file, and the workbook is sent when it is closed. The
+// caller must close f to remove the temporary file.
+type xlsxExportWriter struct {
+	c       *gin.Context
+	f       *excelize.File
+	sw      *excelize.StreamWriter
+	columns []exportColumn
+	row     int
+}
+
+func newXLSXExportWriter(c *gin.Context, columns []exportColumn) (*xlsxExportWriter, error) {
+	f := excelize.NewFile()
+	sw, err := f.NewStreamWriter("Sheet1")
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	e := &xlsxExportWriter{c: c, f: f, sw: sw, columns: columns}
+
+	header := make([]string, len(columns))
+	for i, v := range columns {
+		header[i] = v.Name
+	}
+	if err := e.Write(header); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return e, nil
+}
+
+func (e *xlsxExportWriter) Write(record []string) error {
+	e.row++
+	cells := make([]interface{}, len(record))
+	for i, v := range record {
+		cells[i] = v
+		if e.row > 1 && e.columns[i].Numeric && v != "" {
+			if n, err := strconv.ParseFloat(v, 64); err == nil {
+				cells[i] = n
+			}
+		}
+	}
+
+	cell, err := excelize.CoordinatesToCellName(1, e.row)
+	if err != nil {
+		return err
+	}
+	return e.sw.SetRow(cell, cells)
+}
+
+func (e *xlsxExportWriter) Close() error {
+	if err := e.sw.Flush(); err != nil {
+		return err
+	}
+
+	e.c.Header("Content-Type", mimeXLSX)
+	e.c.Header("Content-Disposition", `attachment; filename="invoices.xlsx"`)
+	e.c.Status(http.StatusOK)
+	if _, err := e.f.WriteTo(e.c.Writer); err != nil {
+		return fmt.Errorf("write xlsx: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,219 @@
+package api
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+	"github.com/xuri/excelize/v2"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExportInvoicesAPI(t *testing.T) {
+	issueDate := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	invoice := db.Invoice{
+		InvoiceNumber:   42,
+		CustomerName:    "Acme, Inc.",
+		CustomerEmail:   "billing@acme.example",
+		SenderName:      "Numeris",
+		SenderEmail:     "hello@numeris.example",
+		IssueDate:       issueDate,
+		DueDate:         issueDate.AddDate(0, 0, 30),
+		Status:          util.PENDING_PAYMENT,
+		Subtotal:        20000,
+		DiscountRate:    1250,
+		Discount:        2500,
+		TotalAmount:     17500,
+		BillingCurrency: "USD",
+		CreatedAt:       issueDate,
+		QuoteNumber:     pgtype.Int8{Int64: 7, Valid: true},
+	}
+	lineItems := []db.LineItem{
+		{ID: 1, InvoiceNumber: 42, Description: "Design", Quantity: 2, UnitPrice: 5000, TotalPrice: 10000},
+		{ID: 2, InvoiceNumber: 42, Description: "Build", Quantity: 1, UnitPrice: 10000, TotalPrice: 10000, ProductID: pgtype.Int8{Int64: 3, Valid: true}, TaxCode: "S"},
+	}
+
+	const invoiceHeader = "invoice_number,issue_date,due_date,status,customer_name,customer_email,sender_name,sender_email,billing_currency,subtotal,discount_rate,discount,total_amount,quote_number,created_at"
+	const invoiceRow = `42,2025-01-10,2025-02-09,pending_payment,"Acme, Inc.",billing@acme.example,Numeris,hello@numeris.example,USD,200.00,12.5,25.00,175.00,7,2025-01-10T00:00:00Z`
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "CSV",
+			query: "?status=pending_payment&issued_from=2025-01-01&issued_to=2025-01-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				filter := db.InvoiceFilter{
+					Status:     pgtype.Text{String: util.PENDING_PAYMENT, Valid: true},
+					IssuedFrom: pgtype.Timestamptz{Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+					IssuedTo:   pgtype.Timestamptz{Time: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+				}
+				store.EXPECT().
+					StreamInvoices(gomock.Any(), gomock.Eq(filter), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, _ db.InvoiceFilter, fn func(db.Invoice) error) error {
+						return fn(invoice)
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+				require.Equal(t, `attachment; filename="invoices.csv"`, recorder.Header().Get("Content-Disposition"))
+				require.Equal(t, invoiceHeader+"\n"+invoiceRow+"\n", recorder.Body.String())
+			},
+		},
+
+		{
+			name:  "CSVWithLineItems",
+			query: "?line_items=true",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StreamInvoiceLineItems(gomock.Any(), gomock.Eq(db.InvoiceFilter{}), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, _ db.InvoiceFilter, fn func(db.Invoice, db.LineItem) error) error {
+						for _, l := range lineItems {
+							if err := fn(invoice, l); err != nil {
+								return err
+							}
+						}
+						return nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, ""+
+					invoiceHeader+",line_item_id,description,quantity,unit_price,total_price,product_id,tax_code\n"+
+					invoiceRow+",1,Design,2,50.00,100.00,,\n"+
+					invoiceRow+",2,Build,1,100.00,100.00,3,S\n",
+					recorder.Body.String())
+			},
+		},
+
+		{
+			name:  "EmptyCSV",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StreamInvoices(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, invoiceHeader+"\n", recorder.Body.String())
+			},
+		},
+
+		{
+			name:  "XLSX",
+			query: "?format=xlsx&line_items=true",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StreamInvoiceLineItems(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, _ db.InvoiceFilter, fn func(db.Invoice, db.LineItem) error) error {
+						for _, l := range lineItems {
+							if err := fn(invoice, l); err != nil {
+								return err
+							}
+						}
+						return nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, mimeXLSX, recorder.Header().Get("Content-Type"))
+
+				f, err := excelize.OpenReader(bytes.NewReader(recorder.Body.Bytes()))
+				require.NoError(t, err)
+				defer f.Close()
+
+				rows, err := f.GetRows("Sheet1")
+				require.NoError(t, err)
+				require.Len(t, rows, 3)
+				require.Equal(t, "invoice_number", rows[0][0])
+				require.Equal(t, "tax_code", rows[0][len(rows[0])-1])
+				require.Equal(t, "42", rows[1][0])
+				require.Equal(t, "Acme, Inc.", rows[1][4])
+				require.Equal(t, "Build", rows[2][16])
+
+				cellType, err := f.GetCellType("Sheet1", "M2")
+				require.NoError(t, err)
+				require.NotEqual(t, excelize.CellTypeSharedString, cellType)
+				require.NotEqual(t, excelize.CellTypeInlineString, cellType)
+			},
+		},
+
+		{
+			name:  "InvalidFormat",
+			query: "?format=pdf",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StreamInvoices(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:  "IssuedToBeforeIssuedFrom",
+			query: "?issued_from=2025-02-01&issued_to=2025-01-01",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StreamInvoices(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:  "InternalError",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					StreamInvoices(gomock.Any(), gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(&pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			request, err := http.NewRequest(http.MethodGet, "/exports/invoices"+tc.query, nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
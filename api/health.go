@@ -0,0 +1,56 @@
+package api
+
+import (
+	"log/slog"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+type healthResponse struct {
+	Status string `json:"status"`
+}
+
+type readinessResponse struct {
+	Status           string `json:"status"`
+	MigrationVersion int64  `json:"migration_version"`
+}
+
+// healthz reports that the process is up and serving requests. It does not
+// look at the database, so that an outage of the database does not get the
+// server restarted.
+func (server *Server) healthz(c *gin.Context) {
+	c.JSON(http.StatusOK, healthResponse{Status: "ok"})
+}
+
+// readyz reports whether the server can take traffic: the database must
+// answer and its schema must not be left dirty by a failed migration.
+func (server *Server) readyz(c *gin.Context) {
+	if err := server.store.Ping(c); err != nil {
+		slog.WarnContext(c, "database is not ready", slog.String("error", err.Error()))
+		respondNotReady(c, "database is unavailable")
+		return
+	}
+
+	version, err := server.store.GetMigrationVersion(c)
+	if err != nil {
+		slog.WarnContext(c, "cannot read migration version", slog.String("error", err.Error()))
+		respondNotReady(c, "migration version is unknown")
+		return
+	}
+	if version.Dirty {
+		respondNotReady(c, "migration is incomplete")
+		return
+	}
+
+	c.JSON(http.StatusOK, readinessResponse{Status: "ready", MigrationVersion: version.Version})
+}
+
+func respondNotReady(c *gin.Context, detail string) {
+	writeProblem(c, problemResponse{
+		Type:   "about:blank",
+		Title:  http.StatusText(http.StatusServiceUnavailable),
+		Status: http.StatusServiceUnavailable,
+		Detail: detail,
+	})
+}
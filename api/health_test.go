@@ -0,0 +1,170 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestHealthzAPI(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+
+	request, err := http.NewRequest(http.MethodGet, "/healthz", nil)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	server := newTestServer(t, store)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.JSONEq(t, `{"status":"ok"}`, recorder.Body.String())
+	// Probes are not logged.
+	require.Empty(t, recorder.Header().Get("X-Request-ID"))
+}
+
+func TestReadyzAPI(t *testing.T) {
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().
+					GetMigrationVersion(gomock.Any()).
+					Times(1).
+					Return(db.MigrationVersion{Version: 6}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, `{"status":"ready","migration_version":6}`, recorder.Body.String())
+			},
+		},
+		{
+			name: "DatabaseUnavailable",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(db.ErrUnavailable)
+				store.EXPECT().GetMigrationVersion(gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				requireNotReady(t, recorder, "database is unavailable")
+			},
+		},
+		{
+			name: "NotMigrated",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().
+					GetMigrationVersion(gomock.Any()).
+					Times(1).
+					Return(db.MigrationVersion{}, errors.New(`relation "schema_migrations" does not exist`))
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				requireNotReady(t, recorder, "migration version is unknown")
+			},
+		},
+		{
+			name: "DirtyMigration",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Times(1).Return(nil)
+				store.EXPECT().
+					GetMigrationVersion(gomock.Any()).
+					Times(1).
+					Return(db.MigrationVersion{Version: 7, Dirty: true}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				requireNotReady(t, recorder, "migration is incomplete")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			request, err := http.NewRequest(http.MethodGet, "/readyz", nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func requireNotReady(t *testing.T, recorder *httptest.ResponseRecorder, detail string) {
+	require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+	require.Equal(t, mimeProblemJSON, recorder.Header().Get("Content-Type"))
+
+	var problem problemResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &problem)
+	require.NoError(t, err)
+	require.Equal(t, detail, problem.Detail)
+}
+
+// TestShutdown checks that a request in flight when the server is shut down
+// is answered before Start returns.
+func TestShutdown(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	started := make(chan struct{})
+	store.EXPECT().
+		Ping(gomock.Any()).
+		Times(1).
+		DoAndReturn(func(ctx context.Context) error {
+			close(started)
+			time.Sleep(100 * time.Millisecond)
+			return nil
+		})
+	store.EXPECT().
+		GetMigrationVersion(gomock.Any()).
+		Times(1).
+		Return(db.MigrationVersion{Version: 6}, nil)
+
+	server := newTestServer(t, store)
+	listener := httptest.NewUnstartedServer(nil).Listener
+	address := listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	stopped := make(chan error, 1)
+	go func() { stopped <- server.Start(address) }()
+
+	response := make(chan int, 1)
+	go func() {
+		for {
+			res, err := http.Get("http://" + address + "/readyz")
+			if err != nil {
+				time.Sleep(10 * time.Millisecond)
+				continue
+			}
+			res.Body.Close()
+			response <- res.StatusCode
+			return
+		}
+	}()
+
+	<-started
+	err := server.Shutdown(context.Background())
+	require.NoError(t, err)
+	require.NoError(t, <-stopped)
+	require.Equal(t, http.StatusOK, <-response)
+}
@@ -0,0 +1,330 @@
+package api
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+	"github.com/kuthumipepple/numeris-book/db"
+)
+
+const (
+	// importBatchSize is the number of invoices created per transaction.
+	importBatchSize = 100
+	maxImportSize   = 32 << 20
+	maxImportLine   = 1 << 20
+)
+
+var importFormats = map[string]string{
+	"text/csv":             "csv",
+	"application/jsonl":    "jsonl",
+	"application/x-ndjson": "jsonl",
+}
+
+type importInvoicesRequest struct {
+	Format string `form:"format" binding:"omitempty,oneof=csv jsonl"`
+	DryRun bool   `form:"dry_run"`
+}
+
+type importInvoicesResponse struct {
+	DryRun   bool                    `json:"dry_run"`
+	Total    int                     `json:"total"`
+	Valid    int                     `json:"valid"`
+	Imported int                     `json:"imported"`
+	Invoices []importInvoiceResponse `json:"invoices"`
+	Errors   []importRowError        `json:"errors"`
+}
+
+type importInvoiceResponse struct {
+	Row           int   `json:"row"`
+	InvoiceNumber int64 `json:"invoice_number"`
+}
+
+type importRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+	// Errors lists the fields that failed validation, as in a problem
+	// document.
+	Errors []fieldError `json:"errors,omitempty"`
+}
+
+func newImportRowError(row int, err error) importRowError {
+	rowErr := importRowError{Row: row, Error: err.Error()}
+	if fields, ok := asValidationError(err); ok {
+		rowErr.Error = fields.Error()
+		rowErr.Errors = fields
+	}
+	return rowErr
+}
+
+// importRecord is one invoice read from an import file. Row is the line the
+// invoice starts on.
+type importRecord struct {
+	Row     int
+	Request createInvoiceRequest
+	Err     error
+}
+
+// importInvoices creates invoices from a CSV or JSON Lines upload. Every
+// record is validated like a POST /invoices body, and the report lists the
+// rows that could not be imported. With dry_run=true nothing is created.
+//
+// The format is taken from the format parameter or the Content-Type header.
+// Each JSON line is a create invoice request. CSV files have one row per line
+// item, and consecutive rows with the same invoice_ref make up one invoice.
+func (server *Server) importInvoices(c *gin.Context) {
+	var req importInvoicesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	format := req.Format
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+		format = importFormats[mediaType]
+	}
+	if format == "" {
+		err := fmt.Errorf("unsupported content type %q", c.ContentType())
+		respondWithError(c, http.StatusUnsupportedMediaType, err)
+		return
+	}
+
+	body := http.MaxBytesReader(c.Writer, c.Request.Body, maxImportSize)
+
+	var records []importRecord
+	var err error
+	if format == "csv" {
+		records, err = readCSVImport(body)
+	} else {
+		records, err = readJSONLinesImport(body)
+	}
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(c, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	rsp := importInvoicesResponse{
+		DryRun:   req.DryRun,
+		Total:    len(records),
+		Invoices: []importInvoiceResponse{},
+		Errors:   []importRowError{},
+	}
+
+	var rows []int
+	var args []db.CreateInvoiceTxParams
+	for _, record := range records {
+		err := record.Err
+		if err == nil {
+			err = binding.Validator.ValidateStruct(&record.Request)
+		}
+		if err != nil {
+			rsp.Errors = append(rsp.Errors, newImportRowError(record.Row, err))
+			continue
+		}
+
+		arg, err := server.createInvoiceParams(c, record.Request)
+		if err != nil {
+			var fields validationError
+			if errors.Is(err, errInvalidProduct) || errors.As(err, &fields) {
+				rsp.Errors = append(rsp.Errors, newImportRowError(record.Row, err))
+				continue
+			}
+			c.Error(err)
+			return
+		}
+
+		rows = append(rows, record.Row)
+		args = append(args, arg)
+	}
+	rsp.Valid = len(args)
+
+	if !req.DryRun {
+		for start := 0; start < len(args); start += importBatchSize {
+			end := min(start+importBatchSize, len(args))
+			server.importBatch(c, rows[start:end], args[start:end], &rsp)
+		}
+		rsp.Imported = len(rsp.Invoices)
+	}
+
+	sort.Slice(rsp.Errors, func(i, j int) bool {
+		return rsp.Errors[i].Row < rsp.Errors[j].Row
+	})
+	c.JSON(http.StatusOK, rsp)
+}
+
+// importBatch creates a batch of invoices in one transaction. If that fails,
+// the invoices are created one by one so that only the rows at fault are
+// reported.
+func (server *Server) importBatch(c *gin.Context, rows []int, args []db.CreateInvoiceTxParams, rsp *importInvoicesResponse) {
+	results, err := server.store.CreateInvoicesTx(c, args)
+	if err == nil {
+		for i, result := range results {
+			server.metrics.invoiceCreated(result.Invoice)
+			rsp.Invoices = append(rsp.Invoices, importInvoiceResponse{Row: rows[i], InvoiceNumber: result.InvoiceNumber})
+		}
+		return
+	}
+
+	for i, arg := range args {
+		result, err := server.store.CreateInvoiceTx(c, arg)
+		if err != nil {
+			// The row is reported like a request of its own: the cause is
+			// logged and the client only gets its description.
+			c.Error(err)
+			_, detail := describeError(err)
+			rsp.Errors = append(rsp.Errors, importRowError{Row: rows[i], Error: detail})
+			continue
+		}
+		server.metrics.invoiceCreated(result.Invoice)
+		rsp.Invoices = append(rsp.Invoices, importInvoiceResponse{Row: rows[i], InvoiceNumber: result.InvoiceNumber})
+	}
+}
+
+func readJSONLinesImport(r io.Reader) ([]importRecord, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLine)
+
+	var records []importRecord
+	for row := 1; scanner.Scan(); row++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		record := importRecord{Row: row}
+		record.Err = json.Unmarshal([]byte(line), &record.Request)
+		records = append(records, record)
+	}
+	return records, scanner.Err()
+}
+
+// importCSVInvoiceColumns are the invoice columns of a CSV import. They are
+// read from the first row of each invoice.
+var importCSVInvoiceColumns = []string{
+	"invoice_ref",
+	"customer_name", "customer_email", "customer_phone", "customer_address",
+	"sender_name", "sender_email", "sender_phone", "sender_address",
+	"issue_date", "due_date", "status", "discount_rate", "payment_info",
+}
+
+var importCSVLineItemColumns = []string{
+	"product_id", "description", "quantity", "unit_price", "tax_code",
+}
+
+func readCSVImport(r io.Reader) ([]importRecord, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return nil, errors.New("missing CSV header")
+		}
+		return nil, err
+	}
+
+	index := make(map[string]int, len(header))
+	for i, name := range header {
+		index[strings.TrimSpace(name)] = i
+	}
+	for _, name := range append(importCSVInvoiceColumns, importCSVLineItemColumns...) {
+		if _, ok := index[name]; !ok {
+			return nil, fmt.Errorf("missing CSV column %q", name)
+		}
+	}
+
+	var records []importRecord
+	ref := ""
+	for row := 2; ; row++ {
+		fields, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, err
+			}
+			records = append(records, importRecord{Row: row, Err: err})
+			ref = ""
+			continue
+		}
+
+		get := func(name string) string {
+			if i := index[name]; i < len(fields) {
+				return strings.TrimSpace(fields[i])
+			}
+			return ""
+		}
+
+		if len(records) == 0 || get("invoice_ref") == "" || get("invoice_ref") != ref {
+			ref = get("invoice_ref")
+			records = append(records, importRecord{
+				Row: row,
+				Request: createInvoiceRequest{
+					CustomerName:    get("customer_name"),
+					CustomerEmail:   get("customer_email"),
+					CustomerPhone:   get("customer_phone"),
+					CustomerAddress: get("customer_address"),
+					SenderName:      get("sender_name"),
+					SenderEmail:     get("sender_email"),
+					SenderPhone:     get("sender_phone"),
+					SenderAddress:   get("sender_address"),
+					IssueDate:       get("issue_date"),
+					DueDate:         get("due_date"),
+					Status:          get("status"),
+					DiscountRate:    get("discount_rate"),
+					PaymentInfo:     get("payment_info"),
+				},
+			})
+		}
+
+		record := &records[len(records)-1]
+		if record.Err != nil {
+			continue
+		}
+
+		item := createLineItemRequest{
+			Description: get("description"),
+			UnitPrice:   get("unit_price"),
+			TaxCode:     get("tax_code"),
+		}
+		invalidInteger := func(name string) error {
+			return validationError{{
+				Field:   fmt.Sprintf("line_items[%d].%s", len(record.Request.LineItems), name),
+				Code:    "invalid_type",
+				Message: fmt.Sprintf("must be an integer (row %d)", row),
+			}}
+		}
+		if v := get("product_id"); v != "" {
+			if item.ProductID, err = strconv.ParseInt(v, 10, 64); err != nil {
+				record.Err = invalidInteger("product_id")
+				continue
+			}
+		}
+		if v := get("quantity"); v != "" {
+			if item.Quantity, err = strconv.ParseInt(v, 10, 64); err != nil {
+				record.Err = invalidInteger("quantity")
+				continue
+			}
+		}
+		record.Request.LineItems = append(record.Request.LineItems, item)
+	}
+	return records, nil
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func randomImportInvoice() gin.H {
+	return gin.H{
+		"customer_name":    util.RandomName(),
+		"customer_email":   util.RandomEmail(),
+		"customer_phone":   util.RandomPhone(),
+		"customer_address": util.RandomAddress(),
+		"sender_name":      util.RandomName(),
+		"sender_email":     util.RandomEmail(),
+		"sender_phone":     util.RandomPhone(),
+		"sender_address":   util.RandomAddress(),
+		"issue_date":       "2025-01-21",
+		"due_date":         "2025-02-20",
+		"status":           util.PENDING_PAYMENT,
+		"discount_rate":    "10",
+		"payment_info":     "Bank transfer",
+		"line_items": []gin.H{
+			{"description": "Design", "quantity": 2, "unit_price": "50.00"},
+		},
+	}
+}
+
+func jsonLines(t *testing.T, lines ...any) string {
+	var sb strings.Builder
+	for _, line := range lines {
+		if s, ok := line.(string); ok {
+			sb.WriteString(s)
+		} else {
+			data, err := json.Marshal(line)
+			require.NoError(t, err)
+			sb.Write(data)
+		}
+		sb.WriteString("\n")
+	}
+	return sb.String()
+}
+
+const importCSVHeader = "invoice_ref,customer_name,customer_email,customer_phone,customer_address,sender_name,sender_email,sender_phone,sender_address,issue_date,due_date,status,discount_rate,payment_info,product_id,description,quantity,unit_price,tax_code\n"
+
+func TestImportInvoicesAPI(t *testing.T) {
+	invalidInvoice := randomImportInvoice()
+	invalidInvoice["due_date"] = "2025-01-01"
+
+	testCases := []struct {
+		name          string
+		query         string
+		contentType   string
+		body          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "JSONLines",
+			contentType: "application/x-ndjson",
+			body:        jsonLines(t, randomImportInvoice(), "", "{not json", invalidInvoice, randomImportInvoice()),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateInvoicesTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, args []db.CreateInvoiceTxParams) ([]db.InvoiceResult, error) {
+						require.Len(t, args, 2)
+						require.Equal(t, int64(10000), args[0].Subtotal)
+						require.Equal(t, int64(1000), args[0].Discount)
+						require.Equal(t, int64(9000), args[0].TotalAmount)
+						return []db.InvoiceResult{
+							{Invoice: db.Invoice{InvoiceNumber: 11}},
+							{Invoice: db.Invoice{InvoiceNumber: 12}},
+						}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := requireImportResponse(t, recorder)
+				require.False(t, rsp.DryRun)
+				require.Equal(t, 4, rsp.Total)
+				require.Equal(t, 2, rsp.Valid)
+				require.Equal(t, 2, rsp.Imported)
+				require.Equal(t, []importInvoiceResponse{{1, 11}, {5, 12}}, rsp.Invoices)
+				require.Len(t, rsp.Errors, 2)
+				require.Equal(t, 3, rsp.Errors[0].Row)
+				require.Equal(t, 4, rsp.Errors[1].Row)
+				require.Equal(t, []fieldError{{Field: "due_date", Code: "too_early", Message: "must come after issue_date"}}, rsp.Errors[1].Errors)
+			},
+		},
+
+		{
+			name:        "CSV",
+			contentType: "text/csv; charset=utf-8",
+			body: importCSVHeader +
+				"A-1,Acme,billing@acme.example,+1 555 0100,1 Main St,Numeris,hello@numeris.example,+1 555 0199,2 High St,2025-01-21,2025-02-20,pending_payment,0,Bank transfer,,Design,2,50.00,\n" +
+				"A-1,,,,,,,,,,,,,,,Build,1,100,S\n" +
+				"A-2,Globex,ap@globex.example,+1 555 0101,3 Side St,Numeris,hello@numeris.example,+1 555 0199,2 High St,2025-01-21,2025-02-20,draft,0,Bank transfer,,Support,many,10.00,\n",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateInvoicesTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, args []db.CreateInvoiceTxParams) ([]db.InvoiceResult, error) {
+						require.Len(t, args, 1)
+						require.Equal(t, "Acme", args[0].CustomerName)
+						require.Len(t, args[0].Items, 2)
+						require.Equal(t, "S", args[0].Items[1].TaxCode)
+						require.Equal(t, int64(20000), args[0].TotalAmount)
+						return []db.InvoiceResult{{Invoice: db.Invoice{InvoiceNumber: 21}}}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := requireImportResponse(t, recorder)
+				require.Equal(t, 2, rsp.Total)
+				require.Equal(t, 1, rsp.Valid)
+				require.Equal(t, []importInvoiceResponse{{2, 21}}, rsp.Invoices)
+				require.Len(t, rsp.Errors, 1)
+				require.Equal(t, 4, rsp.Errors[0].Row)
+				require.Contains(t, rsp.Errors[0].Error, "line_items[0].quantity")
+			},
+		},
+
+		{
+			name:        "DryRun",
+			query:       "?dry_run=true&format=jsonl",
+			contentType: "text/plain",
+			body:        jsonLines(t, randomImportInvoice(), invalidInvoice),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateInvoicesTx(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					CreateInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := requireImportResponse(t, recorder)
+				require.True(t, rsp.DryRun)
+				require.Equal(t, 2, rsp.Total)
+				require.Equal(t, 1, rsp.Valid)
+				require.Zero(t, rsp.Imported)
+				require.Empty(t, rsp.Invoices)
+				require.Len(t, rsp.Errors, 1)
+				require.Equal(t, 2, rsp.Errors[0].Row)
+			},
+		},
+
+		{
+			name:        "BatchFailure",
+			contentType: "application/jsonl",
+			body:        jsonLines(t, randomImportInvoice(), randomImportInvoice()),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateInvoicesTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, db.ErrConstraintViolation)
+				gomock.InOrder(
+					store.EXPECT().
+						CreateInvoiceTx(gomock.Any(), gomock.Any()).
+						Return(db.InvoiceResult{Invoice: db.Invoice{InvoiceNumber: 31}}, nil),
+					store.EXPECT().
+						CreateInvoiceTx(gomock.Any(), gomock.Any()).
+						Return(db.InvoiceResult{}, &db.Error{
+							Kind:    db.ErrConstraintViolation,
+							Message: "a required value is missing",
+							Err:     errors.New(`null value in column "customer_name" violates not-null constraint`),
+						}),
+				)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				rsp := requireImportResponse(t, recorder)
+				require.Equal(t, 2, rsp.Valid)
+				require.Equal(t, 1, rsp.Imported)
+				require.Equal(t, []importInvoiceResponse{{1, 31}}, rsp.Invoices)
+				require.Equal(t, []importRowError{{Row: 2, Error: "a required value is missing"}}, rsp.Errors)
+			},
+		},
+
+		{
+			name:        "MissingCSVColumn",
+			contentType: "text/csv",
+			body:        "customer_name,customer_email\nAcme,billing@acme.example\n",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateInvoicesTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:        "UnsupportedContentType",
+			contentType: "application/xml",
+			body:        "<invoices/>",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateInvoicesTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			request, err := http.NewRequest(http.MethodPost, "/imports"+tc.query, strings.NewReader(tc.body))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", tc.contentType)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func requireImportResponse(t *testing.T, recorder *httptest.ResponseRecorder) importInvoicesResponse {
+	var rsp importInvoicesResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &rsp)
+	require.NoError(t, err)
+	return rsp
+}
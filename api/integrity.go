@@ -0,0 +1,179 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/db"
+)
+
+type integrityRequest struct {
+	Series string `form:"series" binding:"omitempty,max=254"`
+}
+
+func (req integrityRequest) series() pgtype.Text {
+	return pgtype.Text{String: req.Series, Valid: req.Series != ""}
+}
+
+// chainHeadResponse is the last link of a series of the invoice chain. Hash
+// is hex encoded.
+type chainHeadResponse struct {
+	Series        string `json:"series"`
+	Position      int64  `json:"position"`
+	InvoiceNumber int64  `json:"invoice_number"`
+	Hash          string `json:"hash"`
+}
+
+func generateChainHeadsResponse(heads []db.InvoiceChainLink) []chainHeadResponse {
+	response := make([]chainHeadResponse, len(heads))
+	for i, v := range heads {
+		response[i] = chainHeadResponse{
+			Series:        v.Series,
+			Position:      v.Position,
+			InvoiceNumber: v.InvoiceNumber,
+			Hash:          hex.EncodeToString(v.Hash),
+		}
+	}
+	return response
+}
+
+type verifyIntegrityResponse struct {
+	Intact bool                `json:"intact"`
+	Links  int64               `json:"links"`
+	Heads  []chainHeadResponse `json:"heads"`
+	Breaks []db.ChainBreak     `json:"breaks"`
+}
+
+// verifyIntegrity walks the hash chain of the issued invoices of every
+// series, or of the one given, and reports the links that are broken. A
+// broken chain is a finding rather than a failure of the request, so it is
+// reported with 200.
+func (server *Server) verifyIntegrity(c *gin.Context) {
+	var req integrityRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := server.store.VerifyInvoiceChain(c, req.series())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	breaks := result.Breaks
+	if breaks == nil {
+		breaks = []db.ChainBreak{}
+	}
+	c.JSON(http.StatusOK, verifyIntegrityResponse{
+		Intact: len(breaks) == 0,
+		Links:  result.Links,
+		Heads:  generateChainHeadsResponse(result.Heads),
+		Breaks: breaks,
+	})
+}
+
+// chainHeadExport is what an export of the chain heads signs.
+type chainHeadExport struct {
+	ExportedAt string              `json:"exported_at"`
+	Heads      []chainHeadResponse `json:"heads"`
+}
+
+type exportChainHeadResponse struct {
+	chainHeadExport
+	// Signature is a JWS in compact serialization, signed with EdDSA, whose
+	// payload is the JSON of the other fields.
+	Signature string `json:"signature"`
+}
+
+// exportChainHead returns the heads of the invoice chain, signed with the
+// configured key. An auditor who keeps the export can later tell whether the
+// chain was cut short or rewritten, which a verification of the chain alone
+// cannot show.
+func (server *Server) exportChainHead(c *gin.Context) {
+	var req integrityRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if server.config.ChainSigningKeyFile == "" {
+		writeProblem(c, problemResponse{
+			Type:   "about:blank",
+			Title:  http.StatusText(http.StatusServiceUnavailable),
+			Status: http.StatusServiceUnavailable,
+			Detail: "no key is configured to sign exports of the invoice chain",
+		})
+		return
+	}
+	key, err := loadChainSigningKey(server.config.ChainSigningKeyFile)
+	if err != nil {
+		c.Error(fmt.Errorf("cannot load chain signing key: %w", err))
+		return
+	}
+
+	heads, err := server.store.ListInvoiceChainHeads(c, req.series())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	export := chainHeadExport{
+		ExportedAt: time.Now().UTC().Format(time.RFC3339),
+		Heads:      generateChainHeadsResponse(heads),
+	}
+	payload, err := json.Marshal(export)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, exportChainHeadResponse{
+		chainHeadExport: export,
+		Signature:       signJWS(key, payload),
+	})
+}
+
+// loadChainSigningKey reads an Ed25519 private key from a PEM file in PKCS #8
+// form. The file is read for each export, so that a rotated key is used
+// without a restart.
+func loadChainSigningKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("no PEM block found")
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	edKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("key is a %T, not an Ed25519 key", key)
+	}
+	return edKey, nil
+}
+
+// jwsHeader is the protected header of the signatures of exports.
+var jwsHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"EdDSA"}`))
+
+// signJWS signs payload as an RFC 7515 JWS in compact serialization, which
+// any JOSE library can verify with the public key.
+func signJWS(key ed25519.PrivateKey, payload []byte) string {
+	input := jwsHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	signature := ed25519.Sign(key, []byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
@@ -0,0 +1,288 @@
+package api
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func randomChainLink(series string, position int64) db.InvoiceChainLink {
+	return db.InvoiceChainLink{
+		InvoiceNumber: util.RandomInt(1, 1000),
+		Series:        series,
+		Position:      position,
+		PreviousHash:  []byte(util.RandomString(32)),
+		Hash:          []byte{0xca, 0xfe, 0xba, 0xbe},
+	}
+}
+
+// writeChainSigningKey writes a new Ed25519 key where the server can load it
+// and returns its public key.
+func writeChainSigningKey(t *testing.T) (string, ed25519.PublicKey) {
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.MarshalPKCS8PrivateKey(private)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "chain.pem")
+	data := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	return path, public
+}
+
+func TestVerifyIntegrityAPI(t *testing.T) {
+	head := randomChainLink("billing@example.com", 3)
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "Intact",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyInvoiceChain(gomock.Any(), gomock.Eq(pgtype.Text{})).
+					Times(1).
+					Return(db.ChainVerification{Links: 3, Heads: []db.InvoiceChainLink{head}}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got verifyIntegrityResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.True(t, got.Intact)
+				require.Equal(t, int64(3), got.Links)
+				require.Equal(t, []chainHeadResponse{{
+					Series:        head.Series,
+					Position:      3,
+					InvoiceNumber: head.InvoiceNumber,
+					Hash:          "cafebabe",
+				}}, got.Heads)
+				require.Contains(t, recorder.Body.String(), `"breaks":[]`)
+			},
+		},
+
+		{
+			name:  "Broken",
+			query: "series=billing@example.com",
+			buildStubs: func(store *mockdb.MockStore) {
+				result := db.ChainVerification{
+					Links: 3,
+					Heads: []db.InvoiceChainLink{head},
+					Breaks: []db.ChainBreak{{
+						Series:        head.Series,
+						Position:      2,
+						InvoiceNumber: 17,
+						Reason:        db.BreakHash,
+					}},
+				}
+				store.EXPECT().
+					VerifyInvoiceChain(gomock.Any(), gomock.Eq(pgtype.Text{String: head.Series, Valid: true})).
+					Times(1).
+					Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got verifyIntegrityResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.False(t, got.Intact)
+				require.Len(t, got.Breaks, 1)
+				require.Equal(t, db.BreakHash, got.Breaks[0].Reason)
+				require.Equal(t, int64(17), got.Breaks[0].InvoiceNumber)
+			},
+		},
+
+		{
+			name:  "SeriesTooLong",
+			query: "series=" + strings.Repeat("a", 255),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyInvoiceChain(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:  "InternalError",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					VerifyInvoiceChain(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ChainVerification{}, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			request, err := http.NewRequest(http.MethodGet, "/integrity/verify?"+tc.query, nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestExportChainHeadAPI(t *testing.T) {
+	head := randomChainLink("billing@example.com", 5)
+	keyFile, publicKey := writeChainSigningKey(t)
+
+	testCases := []struct {
+		name          string
+		keyFile       string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OK",
+			keyFile: keyFile,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListInvoiceChainHeads(gomock.Any(), gomock.Eq(pgtype.Text{})).
+					Times(1).
+					Return([]db.InvoiceChainLink{head}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var got exportChainHeadResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &got)
+				require.NoError(t, err)
+				require.Len(t, got.Heads, 1)
+				require.Equal(t, "cafebabe", got.Heads[0].Hash)
+				require.NotEmpty(t, got.ExportedAt)
+
+				parts := strings.Split(got.Signature, ".")
+				require.Len(t, parts, 3)
+				header, err := base64.RawURLEncoding.DecodeString(parts[0])
+				require.NoError(t, err)
+				require.JSONEq(t, `{"alg":"EdDSA"}`, string(header))
+				signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+				require.NoError(t, err)
+				require.True(t, ed25519.Verify(publicKey, []byte(parts[0]+"."+parts[1]), signature))
+
+				// The signed payload is the export itself.
+				payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+				require.NoError(t, err)
+				var signed chainHeadExport
+				require.NoError(t, json.Unmarshal(payload, &signed))
+				require.Equal(t, got.chainHeadExport, signed)
+			},
+		},
+
+		{
+			name:    "NotConfigured",
+			keyFile: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListInvoiceChainHeads(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusServiceUnavailable, recorder.Code)
+				require.Equal(t, mimeProblemJSON, recorder.Header().Get("Content-Type"))
+			},
+		},
+
+		{
+			name:    "UnreadableKey",
+			keyFile: filepath.Join(t.TempDir(), "missing.pem"),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListInvoiceChainHeads(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+
+		{
+			name:    "InternalError",
+			keyFile: keyFile,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListInvoiceChainHeads(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			request, err := http.NewRequest(http.MethodGet, "/integrity/head", nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.config.ChainSigningKeyFile = tc.keyFile
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestLoadChainSigningKey(t *testing.T) {
+	keyFile, publicKey := writeChainSigningKey(t)
+	key, err := loadChainSigningKey(keyFile)
+	require.NoError(t, err)
+	require.True(t, publicKey.Equal(key.Public()))
+
+	notPEM := filepath.Join(t.TempDir(), "key.txt")
+	require.NoError(t, os.WriteFile(notPEM, []byte("not a key"), 0o600))
+	_, err = loadChainSigningKey(notPEM)
+	require.Error(t, err)
+}
@@ -8,7 +8,9 @@ import (
 
 	"github.com/Rhymond/go-money"
 	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/kuthumipepple/numeris-book/db"
+	"github.com/kuthumipepple/numeris-book/einvoice"
 )
 
 type createInvoiceRequest struct {
@@ -29,9 +31,11 @@ type createInvoiceRequest struct {
 }
 
 type createLineItemRequest struct {
-	Description string `json:"description" binding:"required"`
+	ProductID   int64  `json:"product_id" binding:"omitempty,min=1"`
+	Description string `json:"description" binding:"required_without=ProductID"`
 	Quantity    int64  `json:"quantity" binding:"required,gt=0"`
-	UnitPrice   string `json:"unit_price" binding:"required"`
+	UnitPrice   string `json:"unit_price" binding:"required_without=ProductID"`
+	TaxCode     string `json:"tax_code"`
 }
 
 type createInvoiceResponse struct {
@@ -42,36 +46,59 @@ type createInvoiceResponse struct {
 func (server *Server) createInvoice(c *gin.Context) {
 	var req createInvoiceRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(err))
+		respondWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
-	issueDate, _ := time.Parse(time.DateOnly, req.IssueDate)
+	arg, err := server.createInvoiceParams(c, req)
+	if err != nil {
+		var fields validationError
+		switch {
+		case errors.Is(err, errInvalidProduct):
+			respondWithError(c, http.StatusUnprocessableEntity, err)
+		case errors.As(err, &fields):
+			respondWithError(c, http.StatusBadRequest, err)
+		default:
+			c.Error(err)
+		}
+		return
+	}
 
-	dueDate, _ := time.Parse(time.DateOnly, req.DueDate)
+	result, err := server.store.CreateInvoiceTx(c, arg)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	server.metrics.invoiceCreated(result.Invoice)
 
-	discountRate := convertRateFromPercentToBasisPoints(req.DiscountRate)
+	c.JSON(
+		http.StatusCreated,
+		createInvoiceResponse{
+			InvoiceNumber: result.InvoiceNumber,
+			CreatedAt:     result.CreatedAt,
+		},
+	)
+}
 
-	subtotal := money.New(0, money.USD)
+// createInvoiceParams prices a validated request in the default currency,
+// filling in line item defaults from the product catalog.
+func (server *Server) createInvoiceParams(c *gin.Context, req createInvoiceRequest) (db.CreateInvoiceTxParams, error) {
+	issueDate, _ := time.Parse(time.DateOnly, req.IssueDate)
 
-	items := make([]db.InsertLineItemParams, len(req.LineItems))
+	dueDate, _ := time.Parse(time.DateOnly, req.DueDate)
 
-	for i, v := range req.LineItems {
-		unitPrice := money.NewFromFloat(convertStringToFloat64(v.UnitPrice), money.USD)
-		totalPrice := unitPrice.Multiply(v.Quantity)
-		items[i] = db.InsertLineItemParams{
-			Description: v.Description,
-			Quantity:    v.Quantity,
-			UnitPrice:   unitPrice.Amount(),
-			TotalPrice:  totalPrice.Amount(),
-		}
-		subtotal, _ = subtotal.Add(totalPrice)
+	currency := server.config.DefaultCurrency
+	lineItems, err := server.applyProductDefaults(c, req.LineItems, currency)
+	if err != nil {
+		return db.CreateInvoiceTxParams{}, err
 	}
 
-	parts, _ := subtotal.Allocate(discountRate, 10000-discountRate)
-	discount, totalAmount := parts[0], parts[1]
+	amounts, err := calculateAmounts(lineItems, req.DiscountRate, currency, server.config.DefaultTaxCode)
+	if err != nil {
+		return db.CreateInvoiceTxParams{}, err
+	}
 
-	arg := db.CreateInvoiceTxParams{
+	return db.CreateInvoiceTxParams{
 		CustomerName:    req.CustomerName,
 		CustomerEmail:   req.CustomerEmail,
 		CustomerPhone:   req.CustomerPhone,
@@ -83,37 +110,98 @@ func (server *Server) createInvoice(c *gin.Context) {
 		IssueDate:       issueDate,
 		DueDate:         dueDate,
 		Status:          req.Status,
-		Subtotal:        subtotal.Amount(),
-		DiscountRate:    int64(discountRate),
-		Discount:        discount.Amount(),
-		TotalAmount:     totalAmount.Amount(),
+		Subtotal:        amounts.Subtotal,
+		DiscountRate:    amounts.DiscountRate,
+		Discount:        amounts.Discount,
+		TotalAmount:     amounts.TotalAmount,
 		PaymentInfo:     req.PaymentInfo,
-		Items:           items,
+		BillingCurrency: pgtype.Text{String: currency, Valid: true},
+		Items:           amounts.Items,
+	}, nil
+}
+
+// invoiceAmounts holds the priced line items of an invoice or quote together
+// with the totals derived from them.
+type invoiceAmounts struct {
+	Items        []db.InsertLineItemParams
+	Subtotal     int64
+	DiscountRate int64
+	Discount     int64
+	TotalAmount  int64
+}
+
+// calculateAmounts prices each line item in currency and splits the subtotal
+// into the discount and the rest according to the discount rate. The amount
+// due is the rest plus VAT, worked out the way e-invoices do so that they
+// agree with it; line items without a tax code are given defaultTaxCode. A
+// unit price with more decimal places than the currency has, or a tax code
+// that cannot be parsed, is a validation error.
+func calculateAmounts(lineItems []createLineItemRequest, rate string, currency string, defaultTaxCode string) (invoiceAmounts, error) {
+	discountRate := convertRateFromPercentToBasisPoints(rate)
+
+	subtotal := money.New(0, currency)
+
+	items := make([]db.InsertLineItemParams, len(lineItems))
+
+	for i, v := range lineItems {
+		amount, err := parseMinorUnits(v.UnitPrice, currency)
+		if err != nil {
+			return invoiceAmounts{}, validationError{{
+				Field:   fmt.Sprintf("line_items[%d].unit_price", i),
+				Code:    "invalid_amount",
+				Message: fmt.Sprintf("must be a non-negative amount with at most %d decimal places in %s", money.GetCurrency(currency).Fraction, currency),
+			}}
+		}
+		taxCode := v.TaxCode
+		if taxCode == "" {
+			taxCode = defaultTaxCode
+		}
+		if _, err := einvoice.ParseTaxCode(taxCode); err != nil {
+			return invoiceAmounts{}, validationError{{
+				Field:   fmt.Sprintf("line_items[%d].tax_code", i),
+				Code:    "invalid_tax_code",
+				Message: "must be a VAT category optionally followed by a colon and a non-negative rate in percent, e.g. S:7.5",
+			}}
+		}
+		unitPrice := money.New(amount, currency)
+		totalPrice := unitPrice.Multiply(v.Quantity)
+		items[i] = db.InsertLineItemParams{
+			Description: v.Description,
+			Quantity:    v.Quantity,
+			UnitPrice:   unitPrice.Amount(),
+			TotalPrice:  totalPrice.Amount(),
+			ProductID:   pgtype.Int8{Int64: v.ProductID, Valid: v.ProductID != 0},
+			TaxCode:     taxCode,
+		}
+		subtotal, _ = subtotal.Add(totalPrice)
 	}
 
-	result, err := server.store.CreateInvoiceTx(c, arg)
+	parts, _ := subtotal.Allocate(discountRate, 10000-discountRate)
+	discount, net := parts[0], parts[1]
+
+	tax, err := einvoice.TaxTotal(items, discount.Amount(), defaultTaxCode)
 	if err != nil {
-		if errorCode := ErrorCode(err); errorCode == ForeignKeyViolation {
-			c.JSON(http.StatusForbidden, errorResponse(err))
-			return
-		}
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
-		return
+		return invoiceAmounts{}, err
 	}
 
-	c.JSON(
-		http.StatusCreated,
-		createInvoiceResponse{
-			InvoiceNumber: result.InvoiceNumber,
-			CreatedAt:     result.CreatedAt,
-		},
-	)
+	return invoiceAmounts{
+		Items:        items,
+		Subtotal:     subtotal.Amount(),
+		DiscountRate: int64(discountRate),
+		Discount:     discount.Amount(),
+		TotalAmount:  net.Amount() + tax,
+	}, nil
 }
 
 type getInvoiceRequest struct {
 	ID int64 `uri:"id" binding:"required,min=1"`
 }
 
+type getInvoiceQuery struct {
+	eInvoiceRequest
+	Format string `form:"format" binding:"omitempty,oneof=json pdf"`
+}
+
 type getInvoiceResponse struct {
 	InvoiceNumber   int64                    `json:"invoice_number"`
 	CustomerName    string                   `json:"customer_name"`
@@ -135,6 +223,7 @@ type getInvoiceResponse struct {
 	BillingCurrency string                   `json:"billing_currency"`
 	Note            string                   `json:"note"`
 	CreatedAt       string                   `json:"created_at"`
+	QuoteNumber     *int64                   `json:"quote_number,omitempty"`
 	Items           []getInvoiceResponseItem `json:"items"`
 }
 
@@ -145,26 +234,71 @@ type getInvoiceResponseItem struct {
 	Quantity      int64  `json:"quantity"`
 	UnitPrice     string `json:"unit_price"`
 	TotalPrice    string `json:"total_price"`
+	ProductID     *int64 `json:"product_id,omitempty"`
+	TaxCode       string `json:"tax_code,omitempty"`
 }
 
+// getInvoice returns an invoice as JSON, or as a Factur-X PDF when format=pdf
+// is given or the client only accepts PDF. The PDF is signed if a signing
+// certificate is configured. The response has the ETag of the
+// invoice's version, and a request whose If-None-Match lists it is answered
+// with 304 Not Modified.
 func (s *Server) getInvoice(c *gin.Context) {
 	var req getInvoiceRequest
 	if err := c.ShouldBindUri(&req); err != nil {
-		c.JSON(http.StatusBadRequest, errorResponse(err))
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var query getInvoiceQuery
+	if err := c.ShouldBindQuery(&query); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
 		return
 	}
 
 	result, err := s.store.GetInvoice(c, req.ID)
 	if err != nil {
-		if errors.Is(err, ErrRecordNotFound) {
-			c.JSON(http.StatusNotFound, errorResponse(err))
+		c.Error(err)
+		return
+	}
+
+	format := query.Format
+	if format == "" && c.NegotiateFormat(gin.MIMEJSON, mimePDF) == mimePDF {
+		format = "pdf"
+	}
+
+	etag := invoiceETag(result.Invoice, format)
+	if format == "pdf" && s.config.SigningCertificateFile != "" {
+		// each signature has its own signing time, so signed PDFs of the
+		// same version are equivalent rather than identical
+		etag = "W/" + etag
+	}
+	c.Header("Vary", "Accept")
+	if notModified(c, etag) {
+		return
+	}
+
+	if format == "pdf" {
+		document, ok := s.eInvoiceDocument(c, result, query.eInvoiceRequest)
+		if !ok {
+			return
+		}
+		data, err := document.FacturX()
+		if err == nil {
+			data, err = s.signDocument(data, (*einvoice.Signer).SignPDF)
+		}
+		if err != nil {
+			c.Error(err)
 			return
 		}
-		c.JSON(http.StatusInternalServerError, errorResponse(err))
+		c.Header("ETag", etag)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="invoice-%s.pdf"`, document.Number))
+		c.Data(http.StatusOK, mimePDF, data)
 		return
 	}
 
 	response := generateGetInvoiceResponse(result)
+	c.Header("ETag", etag)
 	c.JSON(http.StatusOK, response)
 
 }
@@ -179,6 +313,8 @@ func generateGetInvoiceResponse(result db.InvoiceResult) getInvoiceResponse {
 			Quantity:      v.Quantity,
 			UnitPrice:     money.New(v.UnitPrice, result.BillingCurrency).Display(),
 			TotalPrice:    money.New(v.TotalPrice, result.BillingCurrency).Display(),
+			ProductID:     nullableInt64(v.ProductID),
+			TaxCode:       v.TaxCode,
 		}
 	}
 
@@ -203,6 +339,117 @@ func generateGetInvoiceResponse(result db.InvoiceResult) getInvoiceResponse {
 		BillingCurrency: result.BillingCurrency,
 		Note:            result.Note,
 		CreatedAt:       result.CreatedAt.Format(time.RFC3339),
+		QuoteNumber:     nullableInt64(result.QuoteNumber),
 		Items:           items,
 	}
 }
+
+// invoiceFilterRequest holds the query parameters shared by the invoice
+// listing and the invoice exports.
+type invoiceFilterRequest struct {
+	CustomerEmail string `form:"customer_email" binding:"omitempty,email"`
+	Status        string `form:"status" binding:"omitempty,oneof=draft pending_payment overdue paid"`
+	Currency      string `form:"currency" binding:"omitempty,iso4217"`
+	IssuedFrom    string `form:"issued_from"`
+	IssuedTo      string `form:"issued_to"`
+}
+
+// toFilter converts the request into a db.InvoiceFilter. Both issue dates are
+// inclusive.
+func (req invoiceFilterRequest) toFilter() (db.InvoiceFilter, error) {
+	filter := db.InvoiceFilter{
+		CustomerEmail: pgtype.Text{String: req.CustomerEmail, Valid: req.CustomerEmail != ""},
+		Status:        pgtype.Text{String: req.Status, Valid: req.Status != ""},
+		Currency:      pgtype.Text{String: req.Currency, Valid: req.Currency != ""},
+	}
+
+	var fields validationError
+	if req.IssuedFrom != "" {
+		from, err := time.Parse(time.DateOnly, req.IssuedFrom)
+		if err != nil {
+			fields = append(fields, invalidDateError("issued_from"))
+		}
+		filter.IssuedFrom = pgtype.Timestamptz{Time: from, Valid: err == nil}
+	}
+
+	if req.IssuedTo != "" {
+		to, err := time.Parse(time.DateOnly, req.IssuedTo)
+		if err != nil {
+			fields = append(fields, invalidDateError("issued_to"))
+		}
+		filter.IssuedTo = pgtype.Timestamptz{Time: to.AddDate(0, 0, 1), Valid: err == nil}
+	}
+
+	if filter.IssuedFrom.Valid && filter.IssuedTo.Valid && !filter.IssuedTo.Time.After(filter.IssuedFrom.Time) {
+		fields = append(fields, fieldError{Field: "issued_to", Code: "too_early", Message: "must not come before issued_from"})
+	}
+	if fields != nil {
+		return filter, fields
+	}
+	return filter, nil
+}
+
+type listInvoicesRequest struct {
+	invoiceFilterRequest
+	PageID   int32 `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32 `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+type listInvoicesResponseItem struct {
+	InvoiceNumber   int64  `json:"invoice_number"`
+	CustomerName    string `json:"customer_name"`
+	CustomerEmail   string `json:"customer_email"`
+	IssueDate       string `json:"issue_date"`
+	DueDate         string `json:"due_date"`
+	Status          string `json:"status"`
+	TotalAmount     string `json:"total_amount"`
+	BillingCurrency string `json:"billing_currency"`
+}
+
+func (server *Server) listInvoices(c *gin.Context) {
+	var req listInvoicesRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	filter, err := req.toFilter()
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.PageID == 0 {
+		req.PageID = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+
+	arg := db.ListInvoicesParams{
+		InvoiceFilter: filter,
+		Limit:         req.PageSize,
+		Offset:        (req.PageID - 1) * req.PageSize,
+	}
+
+	invoices, err := server.store.ListInvoices(c, arg)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := make([]listInvoicesResponseItem, len(invoices))
+	for i, v := range invoices {
+		response[i] = listInvoicesResponseItem{
+			InvoiceNumber:   v.InvoiceNumber,
+			CustomerName:    v.CustomerName,
+			CustomerEmail:   v.CustomerEmail,
+			IssueDate:       v.IssueDate.Format(time.DateOnly),
+			DueDate:         v.DueDate.Format(time.DateOnly),
+			Status:          v.Status,
+			TotalAmount:     money.New(v.TotalAmount, v.BillingCurrency).Display(),
+			BillingCurrency: v.BillingCurrency,
+		}
+	}
+	c.JSON(http.StatusOK, response)
+}
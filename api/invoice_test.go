@@ -12,6 +12,7 @@ import (
 
 	"github.com/gin-gonic/gin"
 	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/kuthumipepple/numeris-book/db"
 	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
 	"github.com/kuthumipepple/numeris-book/util"
@@ -75,6 +76,7 @@ func TestCreateInvoiceAPI(t *testing.T) {
 					DiscountRate:    int64(580),
 					Discount:        int64(1265),
 					TotalAmount:     int64(20533),
+					BillingCurrency: pgtype.Text{String: "USD", Valid: true},
 					PaymentInfo:     "Bank transfer",
 					Items: []db.InsertLineItemParams{
 						{
@@ -82,12 +84,14 @@ func TestCreateInvoiceAPI(t *testing.T) {
 							Quantity:    int64(1),
 							UnitPrice:   int64(10000),
 							TotalPrice:  int64(10000),
+							TaxCode:     "O",
 						},
 						{
 							Description: "item 2",
 							Quantity:    int64(2),
 							UnitPrice:   int64(5899),
 							TotalPrice:  int64(11798),
+							TaxCode:     "O",
 						},
 					},
 				}
@@ -378,6 +382,67 @@ func TestCreateInvoiceAPI(t *testing.T) {
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, mimeProblemJSON, recorder.Header().Get("Content-Type"))
+
+				var problem problemResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+				require.Equal(t, problemTypeValidation, problem.Type)
+				require.Equal(t, []fieldError{{
+					Field:   "line_items[1].unit_price",
+					Code:    "invalid_amount",
+					Message: "must be a non-negative amount with at most 2 decimal places in USD",
+				}}, problem.Errors)
+			},
+		},
+
+		{
+			name: "InvalidTaxCode",
+			body: gin.H{
+				"customer_name":    "john doe",
+				"customer_email":   "jdoe@fakemail.com",
+				"customer_phone":   "+1234567890",
+				"customer_address": "123 A Street",
+				"sender_name":      "acme inc",
+				"sender_email":     "xyz@acme.com",
+				"sender_phone":     "+9876543210",
+				"sender_address":   "456 X Street",
+				"issue_date":       fixedTime.Format(time.DateOnly),
+				"due_date":         fixedTime.AddDate(0, 0, 1).Format(time.DateOnly),
+				"status":           "pending_payment",
+				"discount_rate":    "5.80",
+				"payment_info":     "Bank transfer",
+				"line_items": []gin.H{
+					{
+						"description": "item 1",
+						"quantity":    1,
+						"unit_price":  "100.00",
+					},
+					{
+						"description": "item 2",
+						"quantity":    2,
+						"unit_price":  "58.99",
+						"tax_code":    "S:seven",
+					},
+				},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+
+				store.EXPECT().
+					CreateInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+				require.Equal(t, mimeProblemJSON, recorder.Header().Get("Content-Type"))
+
+				var problem problemResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+				require.Equal(t, problemTypeValidation, problem.Type)
+				require.Equal(t, []fieldError{{
+					Field:   "line_items[1].tax_code",
+					Code:    "invalid_tax_code",
+					Message: "must be a VAT category optionally followed by a colon and a non-negative rate in percent, e.g. S:7.5",
+				}}, problem.Errors)
 			},
 		},
 
@@ -455,10 +520,10 @@ func TestCreateInvoiceAPI(t *testing.T) {
 				store.EXPECT().
 					CreateInvoiceTx(gomock.Any(), gomock.Any()).
 					Times(1).
-					Return(db.InvoiceResult{}, ErrForeignKeyViolation)
+					Return(db.InvoiceResult{}, db.ErrConstraintViolation)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
-				require.Equal(t, http.StatusForbidden, recorder.Code)
+				require.Equal(t, http.StatusConflict, recorder.Code)
 			},
 		},
 
@@ -520,7 +585,7 @@ func TestCreateInvoiceAPI(t *testing.T) {
 			require.NoError(t, err)
 
 			recorder := httptest.NewRecorder()
-			server := NewServer(store)
+			server := newTestServer(t, store)
 
 			server.router.ServeHTTP(recorder, request)
 
@@ -608,8 +673,8 @@ func TestGetInvoiceAPI(t *testing.T) {
 						Note:            "Thank you for your patronage",
 						CreatedAt:       fixedTime.Add(2 * time.Hour).Format(time.RFC3339),
 						Items: []getInvoiceResponseItem{
-							{fakeID + 1, fakeID, "item 1", 1, "$123.45", "$12,345.67"},
-							{fakeID + 2, fakeID, "item 2", 12, "$1.23", "$123.45"},
+							{fakeID + 1, fakeID, "item 1", 1, "$123.45", "$12,345.67", nil, ""},
+							{fakeID + 2, fakeID, "item 2", 12, "$1.23", "$123.45", nil, ""},
 						},
 					},
 				)
@@ -638,7 +703,7 @@ func TestGetInvoiceAPI(t *testing.T) {
 				store.EXPECT().
 					GetInvoice(gomock.Any(), gomock.Eq(fakeID)).
 					Times(1).
-					Return(db.InvoiceResult{}, ErrRecordNotFound)
+					Return(db.InvoiceResult{}, db.ErrNotFound)
 			},
 			checkResponse: func(recorder *httptest.ResponseRecorder) {
 				require.Equal(t, http.StatusNotFound, recorder.Code)
@@ -675,7 +740,7 @@ func TestGetInvoiceAPI(t *testing.T) {
 			require.NoError(t, err)
 
 			recorder := httptest.NewRecorder()
-			server := NewServer(store)
+			server := newTestServer(t, store)
 
 			server.router.ServeHTTP(recorder, request)
 
@@ -684,6 +749,59 @@ func TestGetInvoiceAPI(t *testing.T) {
 	}
 }
 
+func TestGetInvoiceConditional(t *testing.T) {
+	result := db.InvoiceResult{
+		Invoice: db.Invoice{
+			InvoiceNumber:   7,
+			BillingCurrency: "USD",
+			Version:         4,
+		},
+	}
+
+	testCases := []struct {
+		name        string
+		ifNoneMatch string
+		status      int
+	}{
+		{"NoHeader", "", http.StatusOK},
+		{"Match", `"4"`, http.StatusNotModified},
+		{"WeakMatch", `W/"4"`, http.StatusNotModified},
+		{"ListMatch", `"3", "4"`, http.StatusNotModified},
+		{"Wildcard", "*", http.StatusNotModified},
+		{"OlderVersion", `"3"`, http.StatusOK},
+		{"PDFTag", `"4-pdf"`, http.StatusOK},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetInvoice(gomock.Any(), gomock.Eq(int64(7))).
+				Times(1).
+				Return(result, nil)
+
+			request, err := http.NewRequest(http.MethodGet, "/invoices/7", nil)
+			require.NoError(t, err)
+			if tc.ifNoneMatch != "" {
+				request.Header.Set("If-None-Match", tc.ifNoneMatch)
+			}
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.router.ServeHTTP(recorder, request)
+
+			require.Equal(t, tc.status, recorder.Code)
+			require.Equal(t, `"4"`, recorder.Header().Get("ETag"))
+			if tc.status == http.StatusNotModified {
+				require.Zero(t, recorder.Body.Len())
+			}
+		})
+	}
+}
+
 func requireBodyMatchGetResponse(t *testing.T, body *bytes.Buffer, response getInvoiceResponse) {
 	data, err := io.ReadAll(body)
 	require.NoError(t, err)
@@ -694,3 +812,148 @@ func requireBodyMatchGetResponse(t *testing.T, body *bytes.Buffer, response getI
 	require.NoError(t, err)
 	require.Equal(t, response, gotResponse)
 }
+
+func TestListInvoicesAPI(t *testing.T) {
+	issueDate := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	invoices := []db.Invoice{
+		{
+			InvoiceNumber:   4,
+			CustomerName:    "Globex",
+			CustomerEmail:   "ap@globex.example",
+			IssueDate:       issueDate,
+			DueDate:         issueDate.AddDate(0, 0, 30),
+			Status:          util.OVERDUE,
+			TotalAmount:     19550,
+			BillingCurrency: "USD",
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "?customer_email=ap@globex.example&status=overdue&currency=USD&issued_from=2025-01-01&issued_to=2025-01-31&page_id=2&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListInvoicesParams{
+					InvoiceFilter: db.InvoiceFilter{
+						CustomerEmail: pgtype.Text{String: "ap@globex.example", Valid: true},
+						Status:        pgtype.Text{String: util.OVERDUE, Valid: true},
+						Currency:      pgtype.Text{String: "USD", Valid: true},
+						IssuedFrom:    pgtype.Timestamptz{Time: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+						IssuedTo:      pgtype.Timestamptz{Time: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), Valid: true},
+					},
+					Limit:  5,
+					Offset: 5,
+				}
+				store.EXPECT().
+					ListInvoices(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(invoices, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotResponse []listInvoicesResponseItem
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotResponse)
+				require.NoError(t, err)
+				require.Equal(t, []listInvoicesResponseItem{
+					{4, "Globex", "ap@globex.example", "2025-01-10", "2025-02-09", util.OVERDUE, "$195.50", "USD"},
+				}, gotResponse)
+			},
+		},
+
+		{
+			name:  "DefaultPage",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListInvoicesParams{Limit: 20, Offset: 0}
+				store.EXPECT().
+					ListInvoices(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.Invoice{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.JSONEq(t, "[]", recorder.Body.String())
+			},
+		},
+
+		{
+			name:  "InvalidStatus",
+			query: "?status=sent",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListInvoices(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:  "InvalidIssuedFrom",
+			query: "?issued_from=01/01/2025",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListInvoices(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:  "PageSizeTooLarge",
+			query: "?page_size=101",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListInvoices(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:  "InternalError",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListInvoices(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			request, err := http.NewRequest(http.MethodGet, "/invoices"+tc.query, nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
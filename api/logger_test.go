@@ -0,0 +1,99 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRequestID(t *testing.T) {
+	testCases := []struct {
+		name      string
+		requestID string
+		check     func(t *testing.T, id string)
+	}{
+		{
+			name:      "FromClient",
+			requestID: "edge-7f3a:42",
+			check: func(t *testing.T, id string) {
+				require.Equal(t, "edge-7f3a:42", id)
+			},
+		},
+		{
+			name: "Generated",
+			check: func(t *testing.T, id string) {
+				require.Len(t, id, 16)
+			},
+		},
+		{
+			name:      "Invalid",
+			requestID: "abc\" injected=\"1",
+			check: func(t *testing.T, id string) {
+				require.Len(t, id, 16)
+				require.Regexp(t, requestIDPattern, id)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger, err := util.NewLogger(&logs, "debug")
+			require.NoError(t, err)
+			defaultLogger := slog.Default()
+			slog.SetDefault(logger)
+			defer slog.SetDefault(defaultLogger)
+
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetInvoice(gomock.Any(), gomock.Eq(int64(7))).
+				Times(1).
+				Return(db.InvoiceResult{}, db.ErrNotFound)
+
+			request, err := http.NewRequest(http.MethodGet, "/invoices/7", nil)
+			require.NoError(t, err)
+			if tc.requestID != "" {
+				request.Header.Set(headerRequestID, tc.requestID)
+			}
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.router.ServeHTTP(recorder, request)
+			require.Equal(t, http.StatusNotFound, recorder.Code)
+
+			id := recorder.Header().Get(headerRequestID)
+			tc.check(t, id)
+
+			var problem problemResponse
+			require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+			require.Equal(t, id, problem.CorrelationID)
+
+			// Every record made while serving the request carries its ID,
+			// and the last one describes the request.
+			var record map[string]any
+			decoder := json.NewDecoder(&logs)
+			for decoder.More() {
+				record = nil
+				require.NoError(t, decoder.Decode(&record))
+				require.Equal(t, id, record["request_id"])
+			}
+			require.Equal(t, "request", record["msg"])
+			require.Equal(t, "WARN", record["level"])
+			require.Equal(t, "/invoices/:id", record["route"])
+			require.EqualValues(t, http.StatusNotFound, record["status"])
+			require.Contains(t, record, "latency")
+		})
+	}
+}
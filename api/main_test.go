@@ -5,8 +5,21 @@ import (
 	"testing"
 
 	"github.com/gin-gonic/gin"
+	"github.com/kuthumipepple/numeris-book/db"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/prometheus/client_golang/prometheus"
 )
 
+func newTestServer(t *testing.T, store db.Store) *Server {
+	config := util.Config{
+		SellerCountryCode: "NG",
+		DefaultTaxCode:    "O",
+		DefaultCurrency:   "USD",
+	}
+
+	return NewServer(config, store, prometheus.NewRegistry())
+}
+
 func TestMain(m *testing.M) {
 	gin.SetMode(gin.TestMode)
 	os.Exit(m.Run())
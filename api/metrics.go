@@ -0,0 +1,81 @@
+package api
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/gin-gonic/gin"
+	"github.com/kuthumipepple/numeris-book/db"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "numeris"
+
+// metrics are the Prometheus metrics of the server: requests per route and
+// the invoices and payments recorded through it.
+type metrics struct {
+	requests         *prometheus.CounterVec
+	requestDuration  *prometheus.HistogramVec
+	invoicesCreated  *prometheus.CounterVec
+	invoicedAmount   *prometheus.CounterVec
+	paymentsReceived *prometheus.CounterVec
+}
+
+func newMetrics(registerer prometheus.Registerer) *metrics {
+	m := &metrics{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_requests_total",
+			Help:      "Number of HTTP requests served, by route and status.",
+		}, []string{"method", "route", "status"}),
+		requestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: metricsNamespace,
+			Name:      "http_request_duration_seconds",
+			Help:      "Time taken to serve HTTP requests, by route.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"method", "route"}),
+		invoicesCreated: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "invoices_created_total",
+			Help:      "Number of invoices created, by status and billing currency.",
+		}, []string{"status", "currency"}),
+		invoicedAmount: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "invoiced_amount_total",
+			Help:      "Total amount of the invoices created, in major units of the billing currency.",
+		}, []string{"currency"}),
+		paymentsReceived: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      "payments_received_amount_total",
+			Help:      "Total amount of the payments recorded, in major units of the billing currency.",
+		}, []string{"currency"}),
+	}
+	registerer.MustRegister(m.requests, m.requestDuration, m.invoicesCreated, m.invoicedAmount, m.paymentsReceived)
+	return m
+}
+
+// instrument counts and times requests. Requests that match no route are
+// counted together so that scanners cannot create a series per path.
+func (m *metrics) instrument() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		m.requests.WithLabelValues(c.Request.Method, route, strconv.Itoa(c.Writer.Status())).Inc()
+		m.requestDuration.WithLabelValues(c.Request.Method, route).Observe(time.Since(start).Seconds())
+	}
+}
+
+func (m *metrics) invoiceCreated(invoice db.Invoice) {
+	m.invoicesCreated.WithLabelValues(invoice.Status, invoice.BillingCurrency).Inc()
+	m.invoicedAmount.WithLabelValues(invoice.BillingCurrency).Add(money.New(invoice.TotalAmount, invoice.BillingCurrency).AsMajorUnits())
+}
+
+func (m *metrics) paymentRecorded(payment db.Payment, currency string) {
+	m.paymentsReceived.WithLabelValues(currency).Add(money.New(payment.Amount, currency).AsMajorUnits())
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		CreateInvoiceTx(gomock.Any(), gomock.Any()).
+		Times(2).
+		Return(db.InvoiceResult{Invoice: db.Invoice{
+			InvoiceNumber:   12,
+			Status:          util.PENDING_PAYMENT,
+			BillingCurrency: "EUR",
+			TotalAmount:     9000,
+		}}, nil)
+	store.EXPECT().
+		RecordPaymentTx(gomock.Any(), gomock.Any()).
+		Times(1).
+		Return(db.PaymentResult{
+			Payment: db.Payment{InvoiceNumber: 12, Amount: 2550},
+			Invoice: db.Invoice{InvoiceNumber: 12, Status: util.PENDING_PAYMENT, BillingCurrency: "EUR"},
+		}, nil)
+	gomock.InOrder(
+		store.EXPECT().
+			GetInvoice(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.InvoiceResult{Invoice: db.Invoice{InvoiceNumber: 12, BillingCurrency: "EUR"}}, nil),
+		store.EXPECT().
+			GetInvoice(gomock.Any(), gomock.Any()).
+			Times(1).
+			Return(db.InvoiceResult{}, db.ErrNotFound),
+	)
+
+	server := newTestServer(t, store)
+	serve := func(method, url string, body any, header http.Header) {
+		var data []byte
+		if body != nil {
+			var err error
+			data, err = json.Marshal(body)
+			require.NoError(t, err)
+		}
+		request, err := http.NewRequest(method, url, bytes.NewReader(data))
+		require.NoError(t, err)
+		for key, values := range header {
+			request.Header[key] = values
+		}
+		server.router.ServeHTTP(httptest.NewRecorder(), request)
+	}
+
+	serve(http.MethodPost, "/invoices", randomImportInvoice(), nil)
+	serve(http.MethodPost, "/invoices", randomImportInvoice(), nil)
+	serve(http.MethodPost, "/invoices/12/payments", map[string]string{"amount": "25.50", "paid_at": "2025-01-30"}, http.Header{"If-Match": {"*"}})
+	serve(http.MethodGet, "/invoices/12", nil, nil)
+	serve(http.MethodGet, "/no/such/path", nil, nil)
+
+	m := server.metrics
+	require.Equal(t, 2.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodPost, "/invoices", "201")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodPost, "/invoices/:id/payments", "201")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, "/invoices/:id", "404")))
+	require.Equal(t, 1.0, testutil.ToFloat64(m.requests.WithLabelValues(http.MethodGet, "unmatched", "404")))
+	require.Equal(t, 2.0, testutil.ToFloat64(m.invoicesCreated.WithLabelValues(util.PENDING_PAYMENT, "EUR")))
+	require.Equal(t, 180.0, testutil.ToFloat64(m.invoicedAmount.WithLabelValues("EUR")))
+	require.Equal(t, 25.5, testutil.ToFloat64(m.paymentsReceived.WithLabelValues("EUR")))
+
+	recorder := httptest.NewRecorder()
+	request, err := http.NewRequest(http.MethodGet, "/metrics", nil)
+	require.NoError(t, err)
+	server.router.ServeHTTP(recorder, request)
+
+	require.Equal(t, http.StatusOK, recorder.Code)
+	require.Contains(t, recorder.Body.String(), `numeris_invoices_created_total{currency="EUR",status="pending_payment"} 2`)
+	require.Contains(t, recorder.Body.String(), `numeris_http_request_duration_seconds_count{method="POST",route="/invoices"} 2`)
+}
@@ -0,0 +1,42 @@
+package api
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// openAPISpec describes every route of the server. The contract tests check
+// the handlers' responses against it, so it must be updated with them.
+//
+//go:embed openapi.json
+var openAPISpec []byte
+
+// swaggerUIPage loads Swagger UI from a CDN and points it at the spec.
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>numeris-book API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({url: "/openapi.json", dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`
+
+func (server *Server) getOpenAPISpec(c *gin.Context) {
+	c.Data(http.StatusOK, gin.MIMEJSON, openAPISpec)
+}
+
+func (server *Server) swaggerUI(c *gin.Context) {
+	c.Data(http.StatusOK, gin.MIMEHTML+"; charset=utf-8", []byte(swaggerUIPage))
+}
@@ -0,0 +1,851 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/einvoice"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// loadOpenAPISpec loads and validates the served OpenAPI document.
+func loadOpenAPISpec(t *testing.T) (*openapi3.T, routers.Router) {
+	doc, err := openapi3.NewLoader().LoadFromData(openAPISpec)
+	require.NoError(t, err)
+	require.NoError(t, doc.Validate(context.Background()))
+
+	// The spec is matched against requests without a host.
+	doc.Servers = nil
+	router, err := gorillamux.NewRouter(doc)
+	require.NoError(t, err)
+
+	// Files and uploads are only checked for their content type.
+	for _, contentType := range []string{mimePDF, mimeXML, mimeXLSX, "text/xml", "text/html", "application/jsonl", "application/x-ndjson"} {
+		openapi3filter.RegisterBodyDecoder(contentType, openapi3filter.FileBodyDecoder)
+	}
+	return doc, router
+}
+
+var ginPathParam = regexp.MustCompile(`:(\w+)`)
+
+func TestOpenAPIRoutes(t *testing.T) {
+	doc, _ := loadOpenAPISpec(t)
+	server := newTestServer(t, mockdb.NewMockStore(gomock.NewController(t)))
+
+	var routes, operations []string
+	for _, route := range server.router.Routes() {
+		routes = append(routes, route.Method+" "+ginPathParam.ReplaceAllString(route.Path, "{$1}"))
+	}
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			operations = append(operations, method+" "+path)
+		}
+	}
+	require.ElementsMatch(t, routes, operations)
+}
+
+// TestOpenAPIContract sends requests to the real handlers and checks each
+// response, including its status, against the OpenAPI document. Requests that
+// succeed must also conform to the document.
+func TestOpenAPIContract(t *testing.T) {
+	_, router := loadOpenAPISpec(t)
+	chainSigningKeyFile, _ := writeChainSigningKey(t)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	invoice := randomEInvoice(1042)
+	invoice.CreatedAt = now
+	invoice.LineItems[0].ProductID = pgtype.Int8{Int64: 9, Valid: true}
+	invoice.LineItems[0].TaxCode = "O"
+	convertedInvoice := randomEInvoice(1043)
+	convertedInvoice.QuoteNumber = pgtype.Int8{Int64: 12, Valid: true}
+	product := randomProduct()
+
+	invalidInvoice := randomEInvoice(1042)
+	invalidInvoice.LineItems[0].TaxCode = "S:7.5"
+
+	document, err := einvoice.NewDocument(invoice, einvoice.Options{
+		SellerCountryCode: "NG",
+		BuyerCountryCode:  "NG",
+		DefaultTaxCode:    "O",
+	})
+	require.NoError(t, err)
+	ubl, err := document.UBL()
+	require.NoError(t, err)
+	cii, err := document.CII()
+	require.NoError(t, err)
+
+	quote := db.QuoteResult{
+		Quote: db.Quote{
+			QuoteNumber:     12,
+			CustomerName:    "Globex",
+			CustomerEmail:   "ap@globex.example",
+			SenderName:      "Numeris Studio",
+			SenderEmail:     "billing@numeris.example",
+			IssueDate:       now,
+			ExpiryDate:      now.AddDate(0, 0, 30),
+			Status:          util.SENT,
+			Subtotal:        10000,
+			TotalAmount:     10000,
+			BillingCurrency: "USD",
+			CreatedAt:       now,
+		},
+		LineItems: []db.QuoteLineItem{
+			{ID: 1, QuoteNumber: 12, Description: "Design", Quantity: 1, UnitPrice: 10000, TotalPrice: 10000},
+		},
+	}
+
+	statement := db.CustomerStatement{
+		CustomerEmail:  "ap@globex.example",
+		CustomerName:   "Globex",
+		Currency:       "USD",
+		From:           time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:             time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+		OpeningBalance: 5000,
+		Entries: []db.CustomerStatementEntry{
+			{StatementEntry: db.StatementEntry{Kind: "invoice", Date: time.Date(2025, 1, 5, 0, 0, 0, 0, time.UTC), InvoiceNumber: 1042, Amount: 30000}, Balance: 35000},
+			{StatementEntry: db.StatementEntry{Kind: "payment", Date: time.Date(2025, 1, 20, 0, 0, 0, 0, time.UTC), InvoiceNumber: 1042, Reference: "TRF-1", Amount: 10000}, Balance: 25000},
+		},
+		ClosingBalance: 25000,
+	}
+
+	testCases := []struct {
+		name        string
+		method      string
+		url         string
+		contentType string
+		accept      string
+		header      http.Header
+		// body is sent as is if it is a string and as JSON otherwise.
+		body       any
+		buildStubs func(store *mockdb.MockStore)
+		status     int
+	}{
+		{
+			name:   "CreateInvoice",
+			method: http.MethodPost,
+			url:    "/invoices",
+			body:   randomImportInvoice(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateInvoiceTx(gomock.Any(), gomock.Any()).Return(invoice, nil)
+			},
+			status: http.StatusCreated,
+		},
+		{
+			name:   "CreateInvoiceInvalid",
+			method: http.MethodPost,
+			url:    "/invoices",
+			body:   gin.H{"customer_email": "not an email"},
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "CreateInvoiceForeignKeyViolation",
+			method: http.MethodPost,
+			url:    "/invoices",
+			body:   randomImportInvoice(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateInvoiceTx(gomock.Any(), gomock.Any()).Return(db.InvoiceResult{}, db.ErrConstraintViolation)
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name:   "CreateInvoiceUnknownProduct",
+			method: http.MethodPost,
+			url:    "/invoices",
+			body: func() gin.H {
+				body := randomImportInvoice()
+				body["line_items"] = []gin.H{{"product_id": 9, "quantity": 1}}
+				return body
+			}(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetProduct(gomock.Any(), int64(9)).Return(db.ProductResult{}, db.ErrNotFound)
+			},
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "ListInvoices",
+			method: http.MethodGet,
+			url:    "/invoices?status=pending_payment&currency=USD&page_id=2&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListInvoices(gomock.Any(), gomock.Any()).Return([]db.Invoice{invoice.Invoice, convertedInvoice.Invoice}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "ListInvoicesInvalidPageSize",
+			method: http.MethodGet,
+			url:    "/invoices?page_size=101",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:        "ImportUBL",
+			method:      http.MethodPost,
+			url:         "/invoices/import/ubl",
+			contentType: mimeXML,
+			body:        string(ubl),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ImportInvoiceTx(gomock.Any(), gomock.Any()).Return(db.ImportInvoiceResult{InvoiceResult: invoice, Attachment: db.InvoiceAttachment{ID: 3}}, nil)
+			},
+			status: http.StatusCreated,
+		},
+		{
+			name:        "ImportCII",
+			method:      http.MethodPost,
+			url:         "/invoices/import/cii",
+			contentType: "text/xml",
+			body:        string(cii),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ImportInvoiceTx(gomock.Any(), gomock.Any()).Return(db.ImportInvoiceResult{InvoiceResult: invoice, Attachment: db.InvoiceAttachment{ID: 3}}, nil)
+			},
+			status: http.StatusCreated,
+		},
+		{
+			name:        "ImportUBLRuleViolations",
+			method:      http.MethodPost,
+			url:         "/invoices/import/ubl",
+			contentType: mimeXML,
+			body:        strings.Replace(string(ubl), `<cbc:PayableAmount currencyID="USD">300.00`, `<cbc:PayableAmount currencyID="USD">310.00`, 1),
+			status:      http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "ImportUBLUnsupportedDocument",
+			method:      http.MethodPost,
+			url:         "/invoices/import/ubl",
+			contentType: mimeXML,
+			body:        string(cii),
+			status:      http.StatusUnprocessableEntity,
+		},
+		{
+			name:        "ImportCIIMalformed",
+			method:      http.MethodPost,
+			url:         "/invoices/import/cii",
+			contentType: mimeXML,
+			body:        "<rsm:CrossIndustryInvoice",
+			status:      http.StatusBadRequest,
+		},
+		{
+			name:        "ImportUBLTooLarge",
+			method:      http.MethodPost,
+			url:         "/invoices/import/ubl",
+			contentType: mimeXML,
+			body:        strings.Repeat(" ", maxEInvoiceSize+1),
+			status:      http.StatusRequestEntityTooLarge,
+		},
+		{
+			name:        "ImportUBLUnsupportedContentType",
+			method:      http.MethodPost,
+			url:         "/invoices/import/ubl",
+			contentType: "text/plain",
+			body:        string(ubl),
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			name:   "GetInvoice",
+			method: http.MethodGet,
+			url:    "/invoices/1043",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), int64(1043)).Return(convertedInvoice, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "GetInvoiceNotModified",
+			method: http.MethodGet,
+			url:    "/invoices/1043",
+			header: http.Header{"If-None-Match": {`"1"`}},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), int64(1043)).Return(convertedInvoice, nil)
+			},
+			status: http.StatusNotModified,
+		},
+		{
+			name:   "GetInvoicePDF",
+			method: http.MethodGet,
+			url:    "/invoices/1042",
+			accept: mimePDF,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), int64(1042)).Return(invoice, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "GetInvoicePDFRuleViolations",
+			method: http.MethodGet,
+			url:    "/invoices/1042?format=pdf",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), int64(1042)).Return(invalidInvoice, nil)
+			},
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "GetInvoiceNotFound",
+			method: http.MethodGet,
+			url:    "/invoices/1042",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), int64(1042)).Return(db.InvoiceResult{}, db.ErrNotFound)
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "GetInvoiceInternalError",
+			method: http.MethodGet,
+			url:    "/invoices/1042",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), int64(1042)).Return(db.InvoiceResult{}, &pgconn.PgError{})
+			},
+			status: http.StatusInternalServerError,
+		},
+		{
+			name:   "GetInvoiceUnavailable",
+			method: http.MethodGet,
+			url:    "/invoices/1042",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), int64(1042)).Return(db.InvoiceResult{}, db.ErrUnavailable)
+			},
+			status: http.StatusServiceUnavailable,
+		},
+		{
+			name:   "GetInvoiceInvalidID",
+			method: http.MethodGet,
+			url:    "/invoices/0",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "RecordPayment",
+			method: http.MethodPost,
+			url:    "/invoices/1042/payments",
+			header: http.Header{"If-Match": {`"1"`}},
+			body:   gin.H{"amount": "100.00", "paid_at": "2025-02-10", "reference": "TRF-1"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Eq(int64(1042))).Return(invoice, nil)
+				store.EXPECT().RecordPaymentTx(gomock.Any(), gomock.Any()).Return(db.PaymentResult{
+					Payment:    db.Payment{ID: 4, InvoiceNumber: 1042, Amount: 10000, PaidAt: time.Date(2025, 2, 10, 0, 0, 0, 0, time.UTC), Reference: "TRF-1"},
+					Invoice:    invoice.Invoice,
+					BalanceDue: 20000,
+				}, nil)
+			},
+			status: http.StatusCreated,
+		},
+		{
+			name:   "RecordPaymentOverpayment",
+			method: http.MethodPost,
+			url:    "/invoices/1042/payments",
+			header: http.Header{"If-Match": {`"1"`}},
+			body:   gin.H{"amount": "1000.00", "paid_at": "2025-02-10"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Eq(int64(1042))).Return(invoice, nil)
+				store.EXPECT().RecordPaymentTx(gomock.Any(), gomock.Any()).Return(db.PaymentResult{}, db.ErrOverpayment)
+			},
+			status: http.StatusUnprocessableEntity,
+		},
+		{
+			name:   "RecordPaymentStaleInvoice",
+			method: http.MethodPost,
+			url:    "/invoices/1042/payments",
+			header: http.Header{"If-Match": {`"1"`}},
+			body:   gin.H{"amount": "100.00", "paid_at": "2025-02-10"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Eq(int64(1042))).Return(invoice, nil)
+				store.EXPECT().RecordPaymentTx(gomock.Any(), gomock.Any()).Return(db.PaymentResult{}, db.ErrStaleInvoice)
+			},
+			status: http.StatusPreconditionFailed,
+		},
+		{
+			name:   "RecordPaymentWithoutIfMatch",
+			method: http.MethodPost,
+			url:    "/invoices/1042/payments",
+			body:   gin.H{"amount": "100.00", "paid_at": "2025-02-10"},
+			status: http.StatusPreconditionRequired,
+		},
+		{
+			name:   "InvoiceHistory",
+			method: http.MethodGet,
+			url:    "/invoices/1042/history",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Return([]db.AuditEvent{
+					{ID: 1, OccurredAt: time.Date(2025, 2, 10, 9, 0, 0, 0, time.UTC), Actor: "ops@example.com", Action: db.ActionCreate, EntityType: db.EntityInvoice, EntityID: 1042, After: json.RawMessage(`{"status":"pending_payment"}`), RequestID: "abc"},
+					{ID: 2, OccurredAt: time.Date(2025, 2, 11, 9, 0, 0, 0, time.UTC), Actor: "anonymous", Action: db.ActionRecordPayment, EntityType: db.EntityInvoice, EntityID: 1042, Before: json.RawMessage(`{"version":1}`), After: json.RawMessage(`{"version":2}`)},
+				}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "ListAuditEvents",
+			method: http.MethodGet,
+			url:    "/admin/audit-events?entity_type=product&action=delete&from=2025-01-01&to=2025-01-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListAuditEvents(gomock.Any(), gomock.Any()).Return([]db.AuditEvent{
+					{ID: 3, Actor: "cli:ops", Action: db.ActionDelete, EntityType: db.EntityProduct, EntityID: 9, Before: json.RawMessage(`{"id":9}`)},
+				}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "ListAuditEventsInvalidAction",
+			method: http.MethodGet,
+			url:    "/admin/audit-events?action=approve",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "VerifyIntegrity",
+			method: http.MethodGet,
+			url:    "/integrity/verify",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyInvoiceChain(gomock.Any(), gomock.Any()).Return(db.ChainVerification{
+					Links: 2,
+					Heads: []db.InvoiceChainLink{{InvoiceNumber: 1042, Series: "billing@numeris.example", Position: 2, Hash: []byte{0xab}}},
+					Breaks: []db.ChainBreak{
+						{Series: "billing@numeris.example", Position: 1, InvoiceNumber: 1041, Reason: db.BreakHash},
+					},
+				}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "VerifyIntegrityEmpty",
+			method: http.MethodGet,
+			url:    "/integrity/verify?series=billing@numeris.example",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().VerifyInvoiceChain(gomock.Any(), gomock.Any()).Return(db.ChainVerification{}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "ExportChainHead",
+			method: http.MethodGet,
+			url:    "/integrity/head",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListInvoiceChainHeads(gomock.Any(), gomock.Any()).Return([]db.InvoiceChainLink{
+					{InvoiceNumber: 1042, Series: "billing@numeris.example", Position: 2, Hash: []byte{0xab}},
+				}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "InvoiceUBL",
+			method: http.MethodGet,
+			url:    "/invoices/1042/ubl?buyer_country=DE&buyer_reference=PO-77",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), int64(1042)).Return(invoice, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "CreateQuote",
+			method: http.MethodPost,
+			url:    "/quotes",
+			body: gin.H{
+				"customer_name":    "Globex",
+				"customer_email":   "ap@globex.example",
+				"customer_phone":   "+2348012345678",
+				"customer_address": "12 Marina Road, Lagos",
+				"sender_name":      "Numeris Studio",
+				"sender_email":     "billing@numeris.example",
+				"sender_phone":     "+2348087654321",
+				"sender_address":   "4 Broad Street, Lagos",
+				"issue_date":       "2025-01-21",
+				"expiry_date":      "2025-02-20",
+				"discount_rate":    "0",
+				"line_items":       []gin.H{{"description": "Design", "quantity": 1, "unit_price": "100"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateQuoteTx(gomock.Any(), gomock.Any()).Return(quote, nil)
+			},
+			status: http.StatusCreated,
+		},
+		{
+			name:   "GetQuote",
+			method: http.MethodGet,
+			url:    "/quotes/12",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetQuote(gomock.Any(), int64(12)).Return(quote, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "UpdateQuoteStatus",
+			method: http.MethodPatch,
+			url:    "/quotes/12/status",
+			body:   gin.H{"status": util.ACCEPTED},
+			buildStubs: func(store *mockdb.MockStore) {
+				accepted := quote.Quote
+				accepted.Status = util.ACCEPTED
+				store.EXPECT().UpdateQuoteStatusTx(gomock.Any(), gomock.Any()).Return(accepted, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "UpdateQuoteStatusNotSent",
+			method: http.MethodPatch,
+			url:    "/quotes/12/status",
+			body:   gin.H{"status": util.DECLINED},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateQuoteStatusTx(gomock.Any(), gomock.Any()).Return(db.Quote{}, db.ErrQuoteDecided)
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name:   "ConvertQuote",
+			method: http.MethodPost,
+			url:    "/quotes/12/convert",
+			body:   gin.H{"issue_date": "2025-02-01", "due_date": "2025-03-01", "status": util.PENDING_PAYMENT, "payment_info": "Bank transfer"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ConvertQuoteTx(gomock.Any(), gomock.Any()).Return(convertedInvoice, nil)
+			},
+			status: http.StatusCreated,
+		},
+		{
+			name:   "ConvertQuoteTwice",
+			method: http.MethodPost,
+			url:    "/quotes/12/convert",
+			body:   gin.H{"issue_date": "2025-02-01", "due_date": "2025-03-01", "status": util.DRAFT, "payment_info": "Bank transfer"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ConvertQuoteTx(gomock.Any(), gomock.Any()).Return(db.InvoiceResult{}, db.ErrConflict)
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name:   "CreateProduct",
+			method: http.MethodPost,
+			url:    "/products",
+			body: gin.H{
+				"sku":    product.SKU,
+				"name":   product.Name,
+				"unit":   product.Unit,
+				"prices": []gin.H{{"currency": "EUR", "unit_price": "90"}, {"currency": "USD", "unit_price": "125.50"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateProductTx(gomock.Any(), gomock.Any()).Return(product, nil)
+			},
+			status: http.StatusCreated,
+		},
+		{
+			name:   "CreateProductDuplicateSKU",
+			method: http.MethodPost,
+			url:    "/products",
+			body: gin.H{
+				"sku":    product.SKU,
+				"name":   product.Name,
+				"unit":   product.Unit,
+				"prices": []gin.H{{"currency": "USD", "unit_price": "125.50"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().CreateProductTx(gomock.Any(), gomock.Any()).Return(db.ProductResult{}, db.ErrConflict)
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name:   "ListProducts",
+			method: http.MethodGet,
+			url:    "/products?active=true",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ListProducts(gomock.Any(), gomock.Any()).Return([]db.ProductResult{product}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "GetProduct",
+			method: http.MethodGet,
+			url:    "/products/9",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetProduct(gomock.Any(), int64(9)).Return(product, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "UpdateProduct",
+			method: http.MethodPut,
+			url:    "/products/9",
+			body: gin.H{
+				"sku":              product.SKU,
+				"name":             product.Name,
+				"unit":             product.Unit,
+				"default_tax_code": "S:7.5",
+				"active":           false,
+				"prices":           []gin.H{{"currency": "USD", "unit_price": "125.50"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().UpdateProductTx(gomock.Any(), gomock.Any()).Return(product, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "DeleteProduct",
+			method: http.MethodDelete,
+			url:    "/products/9",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteProductTx(gomock.Any(), int64(9)).Return(nil)
+			},
+			status: http.StatusNoContent,
+		},
+		{
+			name:   "DeleteProductInUse",
+			method: http.MethodDelete,
+			url:    "/products/9",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().DeleteProductTx(gomock.Any(), int64(9)).Return(db.ErrConstraintViolation)
+			},
+			status: http.StatusConflict,
+		},
+		{
+			name:   "RevenueByProduct",
+			method: http.MethodGet,
+			url:    "/reports/revenue-by-product?from=2025-01-01&to=2025-01-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevenueByProduct(gomock.Any(), gomock.Any()).Return([]db.RevenueByProductRow{
+					{ProductID: pgtype.Int8{Int64: 9, Valid: true}, SKU: "SKU-9", Name: "Design", BillingCurrency: "USD", InvoiceCount: 2, Quantity: 3, GrossAmount: 30000, NetAmount: 27000},
+					{BillingCurrency: "USD", InvoiceCount: 1, Quantity: 1, GrossAmount: 5000, NetAmount: 5000},
+				}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "RevenueByProductMissingRange",
+			method: http.MethodGet,
+			url:    "/reports/revenue-by-product",
+			status: http.StatusBadRequest,
+		},
+		{
+			name:   "ARAging",
+			method: http.MethodGet,
+			url:    "/reports/ar-aging?as_of=2025-03-01",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ARAging(gomock.Any(), gomock.Any()).Return([]db.ARAgingRow{
+					{BillingCurrency: "USD", CustomerEmail: "ap@globex.example", CustomerName: "Globex", InvoiceCount: 2, Current: 10000, Days1To30: 5000, Total: 15000},
+				}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "ARAgingCSV",
+			method: http.MethodGet,
+			url:    "/reports/ar-aging",
+			accept: mimeCSV,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().ARAging(gomock.Any(), gomock.Any()).Return([]db.ARAgingRow{
+					{BillingCurrency: "USD", CustomerEmail: "ap@globex.example", CustomerName: "Globex", InvoiceCount: 1, Current: 10000, Total: 10000},
+				}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "RevenueSummary",
+			method: http.MethodGet,
+			url:    "/reports/revenue?from=2025-01-01&to=2025-02-28&interval=month",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().RevenueSummary(gomock.Any(), gomock.Any()).Return([]db.RevenueSummaryRow{
+					{PeriodStart: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC), BillingCurrency: "USD", InvoiceCount: 2, Invoiced: 30000, Billed: 30000, Collected: 10000, Outstanding: 20000},
+					{PeriodStart: time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC), BillingCurrency: "USD"},
+				}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "CustomerStatement",
+			method: http.MethodGet,
+			url:    "/customers/ap@globex.example/statement?from=2025-01-01&to=2025-01-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetCustomerStatement(gomock.Any(), gomock.Any()).Return(statement, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "CustomerStatementPDF",
+			method: http.MethodGet,
+			url:    "/customers/ap@globex.example/statement?from=2025-01-01&to=2025-01-31&format=pdf",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetCustomerStatement(gomock.Any(), gomock.Any()).Return(statement, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "CustomerStatementNotFound",
+			method: http.MethodGet,
+			url:    "/customers/ap@globex.example/statement?from=2025-01-01&to=2025-01-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetCustomerStatement(gomock.Any(), gomock.Any()).Return(db.CustomerStatement{}, db.ErrNotFound)
+			},
+			status: http.StatusNotFound,
+		},
+		{
+			name:   "ExportInvoicesCSV",
+			method: http.MethodGet,
+			url:    "/exports/invoices?line_items=true",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().StreamInvoiceLineItems(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ db.InvoiceFilter, fn func(db.Invoice, db.LineItem) error) error {
+						return fn(invoice.Invoice, invoice.LineItems[0])
+					})
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "ExportInvoicesXLSX",
+			method: http.MethodGet,
+			url:    "/exports/invoices?format=xlsx",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().StreamInvoices(gomock.Any(), gomock.Any(), gomock.Any()).
+					DoAndReturn(func(_ context.Context, _ db.InvoiceFilter, fn func(db.Invoice) error) error {
+						return fn(invoice.Invoice)
+					})
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "ExportInvoicesInternalError",
+			method: http.MethodGet,
+			url:    "/exports/invoices",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().StreamInvoices(gomock.Any(), gomock.Any(), gomock.Any()).Return(&pgconn.PgError{})
+			},
+			status: http.StatusInternalServerError,
+		},
+		{
+			name:        "ImportInvoices",
+			method:      http.MethodPost,
+			url:         "/imports?dry_run=true",
+			contentType: "application/x-ndjson",
+			body:        jsonLines(t, randomImportInvoice(), gin.H{"customer_name": "Globex"}),
+			status:      http.StatusOK,
+		},
+		{
+			name:        "ImportInvoicesUnsupportedContentType",
+			method:      http.MethodPost,
+			url:         "/imports",
+			contentType: "text/plain",
+			body:        "invoice_ref\n",
+			status:      http.StatusUnsupportedMediaType,
+		},
+		{
+			name:   "OpenAPISpec",
+			method: http.MethodGet,
+			url:    "/openapi.json",
+			status: http.StatusOK,
+		},
+		{
+			name:   "SwaggerUI",
+			method: http.MethodGet,
+			url:    "/docs",
+			status: http.StatusOK,
+		},
+		{
+			name:   "Metrics",
+			method: http.MethodGet,
+			url:    "/metrics",
+			status: http.StatusOK,
+		},
+		{
+			name:   "Healthz",
+			method: http.MethodGet,
+			url:    "/healthz",
+			status: http.StatusOK,
+		},
+		{
+			name:   "Readyz",
+			method: http.MethodGet,
+			url:    "/readyz",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Return(nil)
+				store.EXPECT().GetMigrationVersion(gomock.Any()).Return(db.MigrationVersion{Version: 6}, nil)
+			},
+			status: http.StatusOK,
+		},
+		{
+			name:   "ReadyzUnavailable",
+			method: http.MethodGet,
+			url:    "/readyz",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().Ping(gomock.Any()).Return(db.ErrUnavailable)
+			},
+			status: http.StatusServiceUnavailable,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			if tc.buildStubs != nil {
+				tc.buildStubs(store)
+			}
+
+			var body []byte
+			switch v := tc.body.(type) {
+			case nil:
+			case string:
+				body = []byte(v)
+			default:
+				var err error
+				body, err = json.Marshal(v)
+				require.NoError(t, err)
+			}
+
+			newRequest := func() *http.Request {
+				request, err := http.NewRequest(tc.method, tc.url, bytes.NewReader(body))
+				require.NoError(t, err)
+				if tc.body != nil {
+					contentType := tc.contentType
+					if contentType == "" {
+						contentType = gin.MIMEJSON
+					}
+					request.Header.Set("Content-Type", contentType)
+				}
+				if tc.accept != "" {
+					request.Header.Set("Accept", tc.accept)
+				}
+				for key, values := range tc.header {
+					request.Header[key] = values
+				}
+				return request
+			}
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.config.ChainSigningKeyFile = chainSigningKeyFile
+			server.router.ServeHTTP(recorder, newRequest())
+			require.Equal(t, tc.status, recorder.Code, recorder.Body.String())
+
+			request := newRequest()
+			route, pathParams, err := router.FindRoute(request)
+			require.NoError(t, err)
+
+			requestInput := &openapi3filter.RequestValidationInput{
+				Request:    request,
+				PathParams: pathParams,
+				Route:      route,
+			}
+			if tc.status < http.StatusBadRequest {
+				require.NoError(t, openapi3filter.ValidateRequest(context.Background(), requestInput))
+			}
+
+			err = openapi3filter.ValidateResponse(context.Background(), &openapi3filter.ResponseValidationInput{
+				RequestValidationInput: requestInput,
+				Status:                 recorder.Code,
+				Header:                 recorder.Header(),
+				Body:                   io.NopCloser(recorder.Body),
+				Options:                &openapi3filter.Options{IncludeResponseStatus: true},
+			})
+			require.NoError(t, err)
+		})
+	}
+}
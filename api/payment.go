@@ -0,0 +1,102 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/gin-gonic/gin"
+	"github.com/kuthumipepple/numeris-book/db"
+)
+
+type recordPaymentRequest struct {
+	Amount    string `json:"amount" binding:"required"`
+	PaidAt    string `json:"paid_at" binding:"required"`
+	Reference string `json:"reference"`
+}
+
+type recordPaymentResponse struct {
+	PaymentID     int64  `json:"payment_id"`
+	InvoiceNumber int64  `json:"invoice_number"`
+	Amount        string `json:"amount"`
+	PaidAt        string `json:"paid_at"`
+	Reference     string `json:"reference"`
+	InvoiceStatus string `json:"invoice_status"`
+	BalanceDue    string `json:"balance_due"`
+}
+
+// recordPayment records a payment received against a pending or overdue
+// invoice. The amount is in the billing currency of the invoice, so the
+// invoice is read first to parse it. The invoice is marked as paid once the
+// payments cover its total.
+// The request must give the ETag of the invoice in If-Match, so that a
+// payment is not recorded against an invoice that changed after the client
+// read it; the response has the ETag of the invoice after the payment.
+func (server *Server) recordPayment(c *gin.Context) {
+	var uri getInvoiceRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req recordPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	version, ok := requireInvoiceVersion(c)
+	if !ok {
+		return
+	}
+
+	invoice, err := server.store.GetInvoice(c, uri.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	currency := invoice.BillingCurrency
+
+	amount, err := parseMinorUnits(req.Amount, currency)
+	if err != nil {
+		fraction := 0
+		if known := money.GetCurrency(currency); known != nil {
+			fraction = known.Fraction
+		}
+		respondWithError(c, http.StatusBadRequest, validationError{{
+			Field:   "amount",
+			Code:    "invalid_amount",
+			Message: fmt.Sprintf("must be a positive amount with at most %d decimal places in %s", fraction, currency),
+		}})
+		return
+	}
+
+	paidAt, _ := time.Parse(time.DateOnly, req.PaidAt)
+
+	arg := db.RecordPaymentTxParams{
+		InvoiceNumber: uri.ID,
+		Amount:        amount,
+		PaidAt:        paidAt,
+		Reference:     req.Reference,
+		Version:       version,
+	}
+
+	result, err := server.store.RecordPaymentTx(c, arg)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	server.metrics.paymentRecorded(result.Payment, currency)
+	c.Header("ETag", invoiceETag(result.Invoice, ""))
+	c.JSON(http.StatusCreated, recordPaymentResponse{
+		PaymentID:     result.ID,
+		InvoiceNumber: result.InvoiceNumber,
+		Amount:        money.New(result.Amount, currency).Display(),
+		PaidAt:        result.PaidAt.Format(time.DateOnly),
+		Reference:     result.Reference,
+		InvoiceStatus: result.Invoice.Status,
+		BalanceDue:    money.New(result.BalanceDue, currency).Display(),
+	})
+}
@@ -0,0 +1,391 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRecordPaymentAPI(t *testing.T) {
+	fakeID := util.RandomInt(1, 1000)
+	paidAt := time.Date(2025, 2, 14, 0, 0, 0, 0, time.UTC)
+
+	validBody := gin.H{
+		"amount":    "125.50",
+		"paid_at":   paidAt.Format(time.DateOnly),
+		"reference": "TRX-0042",
+	}
+
+	expectInvoice := func(store *mockdb.MockStore, currency string) {
+		store.EXPECT().
+			GetInvoice(gomock.Any(), gomock.Eq(fakeID)).
+			Times(1).
+			Return(db.InvoiceResult{Invoice: db.Invoice{InvoiceNumber: fakeID, BillingCurrency: currency}}, nil)
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		ifMatch       string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "OK",
+			body:    validBody,
+			ifMatch: `"2"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectInvoice(store, "USD")
+				arg := db.RecordPaymentTxParams{
+					InvoiceNumber: fakeID,
+					Amount:        12550,
+					PaidAt:        paidAt,
+					Reference:     "TRX-0042",
+					Version:       2,
+				}
+				result := db.PaymentResult{
+					Payment: db.Payment{
+						ID:            3,
+						InvoiceNumber: fakeID,
+						Amount:        12550,
+						PaidAt:        paidAt,
+						Reference:     "TRX-0042",
+					},
+					Invoice: db.Invoice{
+						InvoiceNumber:   fakeID,
+						Status:          util.PENDING_PAYMENT,
+						BillingCurrency: "USD",
+						Version:         3,
+					},
+					BalanceDue: 4450,
+				}
+
+				store.EXPECT().
+					RecordPaymentTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				require.Equal(t, `"3"`, recorder.Header().Get("ETag"))
+
+				var gotResponse recordPaymentResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotResponse)
+				require.NoError(t, err)
+				require.Equal(t, recordPaymentResponse{
+					PaymentID:     3,
+					InvoiceNumber: fakeID,
+					Amount:        "$125.50",
+					PaidAt:        "2025-02-14",
+					Reference:     "TRX-0042",
+					InvoiceStatus: util.PENDING_PAYMENT,
+					BalanceDue:    "$44.50",
+				}, gotResponse)
+			},
+		},
+
+		{
+			name:    "AnyVersion",
+			body:    validBody,
+			ifMatch: "*",
+			buildStubs: func(store *mockdb.MockStore) {
+				expectInvoice(store, "USD")
+				store.EXPECT().
+					RecordPaymentTx(gomock.Any(), gomock.Eq(db.RecordPaymentTxParams{
+						InvoiceNumber: fakeID,
+						Amount:        12550,
+						PaidAt:        paidAt,
+						Reference:     "TRX-0042",
+					})).
+					Times(1).
+					Return(db.PaymentResult{Invoice: db.Invoice{BillingCurrency: "USD", Version: 3}}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+
+		{
+			name:    "StaleInvoice",
+			body:    validBody,
+			ifMatch: `"2"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectInvoice(store, "USD")
+				store.EXPECT().
+					RecordPaymentTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentResult{}, db.ErrStaleInvoice)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+
+		{
+			name: "MissingIfMatch",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					RecordPaymentTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionRequired, recorder.Code)
+				require.Equal(t, mimeProblemJSON, recorder.Header().Get("Content-Type"))
+			},
+		},
+
+		{
+			name:    "WeakIfMatch",
+			body:    validBody,
+			ifMatch: `W/"2"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					RecordPaymentTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+
+		{
+			name:    "PDFIfMatch",
+			body:    validBody,
+			ifMatch: `"2-pdf"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					RecordPaymentTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusPreconditionFailed, recorder.Code)
+			},
+		},
+
+		{
+			name:    "InvoiceNotFound",
+			body:    validBody,
+			ifMatch: `"2"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Eq(fakeID)).
+					Times(1).
+					Return(db.InvoiceResult{}, db.ErrNotFound)
+				store.EXPECT().
+					RecordPaymentTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+
+		{
+			name:    "InvoiceNotPayable",
+			body:    validBody,
+			ifMatch: `"2"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectInvoice(store, "USD")
+				store.EXPECT().
+					RecordPaymentTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentResult{}, db.ErrInvoiceNotPayable)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+
+		{
+			name:    "Overpayment",
+			body:    validBody,
+			ifMatch: `"2"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectInvoice(store, "USD")
+				store.EXPECT().
+					RecordPaymentTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentResult{}, db.ErrOverpayment)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+
+		{
+			name: "ZeroAmount",
+			body: gin.H{
+				"amount":  "0.00",
+				"paid_at": paidAt.Format(time.DateOnly),
+			},
+			ifMatch: `"2"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					RecordPaymentTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name: "InvalidAmount",
+			body: gin.H{
+				"amount":  "12.345",
+				"paid_at": paidAt.Format(time.DateOnly),
+			},
+			ifMatch: `"2"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectInvoice(store, "USD")
+				store.EXPECT().
+					RecordPaymentTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name: "ZeroDecimalCurrency",
+			body: gin.H{
+				"amount":  "1250",
+				"paid_at": paidAt.Format(time.DateOnly),
+			},
+			ifMatch: "*",
+			buildStubs: func(store *mockdb.MockStore) {
+				expectInvoice(store, "JPY")
+				store.EXPECT().
+					RecordPaymentTx(gomock.Any(), gomock.Eq(db.RecordPaymentTxParams{
+						InvoiceNumber: fakeID,
+						Amount:        1250,
+						PaidAt:        paidAt,
+					})).
+					Times(1).
+					Return(db.PaymentResult{Payment: db.Payment{Amount: 1250}, Invoice: db.Invoice{BillingCurrency: "JPY", Version: 3}}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var gotResponse recordPaymentResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &gotResponse))
+				require.Equal(t, "¥1,250", gotResponse.Amount)
+			},
+		},
+
+		{
+			name: "MoreDecimalsThanCurrency",
+			body: gin.H{
+				"amount":  "1250.50",
+				"paid_at": paidAt.Format(time.DateOnly),
+			},
+			ifMatch: `"2"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectInvoice(store, "JPY")
+				store.EXPECT().
+					RecordPaymentTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+				var problem problemResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+				require.Equal(t, []fieldError{{
+					Field:   "amount",
+					Code:    "invalid_amount",
+					Message: "must be a positive amount with at most 0 decimal places in JPY",
+				}}, problem.Errors)
+			},
+		},
+
+		{
+			name: "InvalidPaidAt",
+			body: gin.H{
+				"amount":  "10",
+				"paid_at": "14/02/2025",
+			},
+			ifMatch: `"2"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetInvoice(gomock.Any(), gomock.Any()).
+					Times(0)
+				store.EXPECT().
+					RecordPaymentTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:    "InternalError",
+			body:    validBody,
+			ifMatch: `"2"`,
+			buildStubs: func(store *mockdb.MockStore) {
+				expectInvoice(store, "USD")
+				store.EXPECT().
+					RecordPaymentTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.PaymentResult{}, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			url := fmt.Sprintf("/invoices/%d/payments", fakeID)
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+			if tc.ifMatch != "" {
+				request.Header.Set("If-Match", tc.ifMatch)
+			}
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"github.com/kuthumipepple/numeris-book/einvoice"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const mimeProblemJSON = "application/problem+json"
+
+// Problem types. Other problems are about:blank and identified by their
+// status alone.
+const (
+	problemTypeValidation    = "/problems/validation-error"
+	problemTypeEInvoiceRules = "/problems/e-invoice-rules"
+)
+
+// problemResponse is an RFC 9457 problem details document.
+type problemResponse struct {
+	Type   string `json:"type"`
+	Title  string `json:"title"`
+	Status int    `json:"status"`
+	Detail string `json:"detail,omitempty"`
+	// Errors lists the fields of the request that failed validation.
+	Errors []fieldError `json:"errors,omitempty"`
+	// Violations lists the e-invoicing business rules that were broken.
+	Violations []einvoice.Violation `json:"violations,omitempty"`
+	// CorrelationID identifies the log entry of an error from the store or
+	// an internal failure.
+	CorrelationID string `json:"correlation_id,omitempty"`
+	// TraceID identifies the trace of the request when tracing is on.
+	TraceID string `json:"trace_id,omitempty"`
+}
+
+// fieldError describes a request field that failed validation. Field is the
+// path of the field in the request body, such as line_items[2].unit_price,
+// or the name of a query or path parameter. Code is one of a fixed set of
+// identifiers that clients can match on.
+type fieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// validationError is returned by the checks that handlers make after binding
+// a request, so that they are reported like binding errors.
+type validationError []fieldError
+
+func (e validationError) Error() string {
+	messages := make([]string, len(e))
+	for i, v := range e {
+		messages[i] = v.Field + " " + v.Message
+	}
+	return strings.Join(messages, "; ")
+}
+
+func invalidDateError(field string) fieldError {
+	return fieldError{Field: field, Code: "invalid_date", Message: "must be a date formatted as YYYY-MM-DD"}
+}
+
+// respondWithError writes err as a problem document with the given status.
+// Validation errors list every field that failed.
+func respondWithError(c *gin.Context, status int, err error) {
+	problem := problemResponse{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: err.Error(),
+	}
+	if fields, ok := asValidationError(err); ok {
+		problem.Type = problemTypeValidation
+		problem.Title = "Request validation failed"
+		problem.Detail = fields.Error()
+		problem.Errors = fields
+	}
+	writeProblem(c, problem)
+}
+
+// respondWithViolations rejects an e-invoice that breaks the e-invoicing
+// rules, listing the rules it breaks.
+func respondWithViolations(c *gin.Context, detail string, violations []einvoice.Violation) {
+	writeProblem(c, problemResponse{
+		Type:       problemTypeEInvoiceRules,
+		Title:      "E-invoicing rules violated",
+		Status:     http.StatusUnprocessableEntity,
+		Detail:     detail,
+		Violations: violations,
+	})
+}
+
+func writeProblem(c *gin.Context, problem problemResponse) {
+	if span := trace.SpanContextFromContext(c); span.HasTraceID() {
+		problem.TraceID = span.TraceID().String()
+	}
+	c.Header("Content-Type", mimeProblemJSON)
+	c.JSON(problem.Status, problem)
+}
+
+// asValidationError lists the fields that failed validation if err is a
+// validation error or a JSON value of the wrong type.
+func asValidationError(err error) (validationError, bool) {
+	var fields validationError
+	if errors.As(err, &fields) {
+		return fields, true
+	}
+
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields := make(validationError, len(validationErrs))
+		for i, fe := range validationErrs {
+			fields[i] = fieldError{
+				Field:   fieldPath(fe.Namespace()),
+				Code:    validationCode(fe.Tag()),
+				Message: validationMessage(fe),
+			}
+		}
+		return fields, true
+	}
+
+	var typeErr *json.UnmarshalTypeError
+	if errors.As(err, &typeErr) && typeErr.Field != "" {
+		return validationError{{
+			Field:   typeErr.Field,
+			Code:    "invalid_type",
+			Message: "must be " + jsonTypeName(typeErr.Type),
+		}}, true
+	}
+	return nil, false
+}
+
+// fieldPath turns a validator namespace, such as
+// createInvoiceRequest.line_items[2].unit_price, into the path of the field in
+// the request. Request bodies only nest objects in arrays, so the other
+// segments are the request type and the structs embedded in it.
+func fieldPath(namespace string) string {
+	segments := strings.Split(namespace, ".")[1:]
+	path := segments[:0]
+	for i, v := range segments {
+		if i == len(segments)-1 || strings.HasSuffix(v, "]") {
+			path = append(path, v)
+		}
+	}
+	return strings.Join(path, ".")
+}
+
+// validationCodes maps validation tags to the codes reported to clients. Tags
+// that are not listed are reported as is.
+var validationCodes = map[string]string{
+	"required":         "required",
+	"required_without": "required",
+	"email":            "invalid_email",
+	"min":              "too_small",
+	"gt":               "too_small",
+	"max":              "too_large",
+	"oneof":            "invalid_choice",
+	"iso4217":          "invalid_currency",
+	"iso3166_1_alpha2": "invalid_country",
+	"unique":           "duplicate",
+	"percentage":       "invalid_percentage",
+	"date":             "invalid_date",
+	"after":            "too_early",
+	"price":            "invalid_amount",
+	"positive_price":   "invalid_amount",
+}
+
+func validationCode(tag string) string {
+	if code, ok := validationCodes[tag]; ok {
+		return code
+	}
+	return tag
+}
+
+func validationMessage(fe validator.FieldError) string {
+	switch fe.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return "is required unless " + snakeCase(fe.Param()) + " is given"
+	case "email":
+		return "must be a valid email address"
+	case "min":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at least %s characters long", fe.Param())
+		}
+		return "must be at least " + fe.Param()
+	case "gt":
+		return "must be greater than " + fe.Param()
+	case "max":
+		if fe.Kind() == reflect.String {
+			return fmt.Sprintf("must be at most %s characters long", fe.Param())
+		}
+		return "must be at most " + fe.Param()
+	case "oneof":
+		return "must be one of " + strings.Join(strings.Fields(fe.Param()), ", ")
+	case "iso4217":
+		return "must be an ISO 4217 currency code"
+	case "iso3166_1_alpha2":
+		return "must be an ISO 3166-1 alpha-2 country code"
+	case "unique":
+		return "must not have two entries with the same " + snakeCase(fe.Param())
+	case "percentage":
+		return "must be a percentage from 0 up to but not including 100"
+	case "date":
+		return "must be a date formatted as YYYY-MM-DD"
+	case "after":
+		return "must come after " + fe.Param()
+	case "price":
+		return "must be a non-negative amount with no more decimal places than its currency has"
+	case "positive_price":
+		return "must be a positive amount"
+	}
+	return "is invalid"
+}
+
+// snakeCase turns the name of a struct field given as a validation parameter,
+// such as ProductID, into its JSON key.
+func snakeCase(name string) string {
+	var sb strings.Builder
+	for i, r := range name {
+		if i > 0 && unicode.IsUpper(r) && !unicode.IsUpper(rune(name[i-1])) {
+			sb.WriteByte('_')
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
+
+func jsonTypeName(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Slice, reflect.Array:
+		return "an array"
+	}
+	return "an object"
+}
+
+// jsonFieldName names struct fields in validation errors after the JSON key,
+// query parameter or path parameter they are read from.
+func jsonFieldName(fld reflect.StructField) string {
+	for _, key := range []string{"json", "form", "uri"} {
+		if name, _, _ := strings.Cut(fld.Tag.Get(key), ","); name != "" {
+			return name
+		}
+	}
+	return ""
+}
@@ -0,0 +1,271 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/db"
+)
+
+var errInvalidProduct = errors.New("invalid product")
+
+type productPriceRequest struct {
+	Currency  string `json:"currency" binding:"required,iso4217"`
+	UnitPrice string `json:"unit_price" binding:"required"`
+}
+
+type createProductRequest struct {
+	SKU            string                `json:"sku" binding:"required"`
+	Name           string                `json:"name" binding:"required"`
+	Description    string                `json:"description"`
+	Unit           string                `json:"unit" binding:"required"`
+	DefaultTaxCode string                `json:"default_tax_code"`
+	Active         *bool                 `json:"active"`
+	Prices         []productPriceRequest `json:"prices" binding:"required,unique=Currency,dive"`
+}
+
+type productResponse struct {
+	ID             int64                  `json:"id"`
+	SKU            string                 `json:"sku"`
+	Name           string                 `json:"name"`
+	Description    string                 `json:"description"`
+	Unit           string                 `json:"unit"`
+	DefaultTaxCode string                 `json:"default_tax_code"`
+	Active         bool                   `json:"active"`
+	CreatedAt      string                 `json:"created_at"`
+	Prices         []productPriceResponse `json:"prices"`
+}
+
+type productPriceResponse struct {
+	Currency  string `json:"currency"`
+	UnitPrice string `json:"unit_price"`
+}
+
+// toParams converts a validated request into store parameters.
+func (req createProductRequest) toParams() db.CreateProductTxParams {
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+
+	prices := make([]db.ProductPriceParams, len(req.Prices))
+	for i, v := range req.Prices {
+		unitPrice, _ := parseMinorUnits(v.UnitPrice, v.Currency)
+		prices[i] = db.ProductPriceParams{
+			Currency:  v.Currency,
+			UnitPrice: unitPrice,
+		}
+	}
+
+	return db.CreateProductTxParams{
+		SKU:            req.SKU,
+		Name:           req.Name,
+		Description:    req.Description,
+		Unit:           req.Unit,
+		DefaultTaxCode: req.DefaultTaxCode,
+		Active:         active,
+		Prices:         prices,
+	}
+}
+
+func (server *Server) createProduct(c *gin.Context) {
+	var req createProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := server.store.CreateProductTx(c, req.toParams())
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, generateProductResponse(result))
+}
+
+type getProductRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+func (server *Server) getProduct(c *gin.Context) {
+	var req getProductRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := server.store.GetProduct(c, req.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, generateProductResponse(result))
+}
+
+type listProductsRequest struct {
+	Active   *bool `form:"active"`
+	PageID   int32 `form:"page_id" binding:"omitempty,min=1"`
+	PageSize int32 `form:"page_size" binding:"omitempty,min=1,max=100"`
+}
+
+func (server *Server) listProducts(c *gin.Context) {
+	var req listProductsRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.PageID == 0 {
+		req.PageID = 1
+	}
+	if req.PageSize == 0 {
+		req.PageSize = 20
+	}
+
+	arg := db.ListProductRecordsParams{
+		Limit:  req.PageSize,
+		Offset: (req.PageID - 1) * req.PageSize,
+	}
+	if req.Active != nil {
+		arg.Active = pgtype.Bool{Bool: *req.Active, Valid: true}
+	}
+
+	results, err := server.store.ListProducts(c, arg)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	response := make([]productResponse, len(results))
+	for i, v := range results {
+		response[i] = generateProductResponse(v)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+func (server *Server) updateProduct(c *gin.Context) {
+	var uri getProductRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req createProductRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	arg := db.UpdateProductTxParams{
+		ID:                    uri.ID,
+		CreateProductTxParams: req.toParams(),
+	}
+
+	result, err := server.store.UpdateProductTx(c, arg)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, generateProductResponse(result))
+}
+
+// deleteProduct removes a product from the catalog. Products that are already
+// referenced by line items cannot be deleted; deactivate them instead.
+func (server *Server) deleteProduct(c *gin.Context) {
+	var req getProductRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	err := server.store.DeleteProductTx(c, req.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func generateProductResponse(result db.ProductResult) productResponse {
+	prices := make([]productPriceResponse, len(result.Prices))
+	for i, v := range result.Prices {
+		prices[i] = productPriceResponse{
+			Currency:  v.Currency,
+			UnitPrice: money.New(v.UnitPrice, v.Currency).Display(),
+		}
+	}
+
+	return productResponse{
+		ID:             result.ID,
+		SKU:            result.SKU,
+		Name:           result.Name,
+		Description:    result.Description,
+		Unit:           result.Unit,
+		DefaultTaxCode: result.DefaultTaxCode,
+		Active:         result.Active,
+		CreatedAt:      result.CreatedAt.Format(time.RFC3339),
+		Prices:         prices,
+	}
+}
+
+// applyProductDefaults fills in the description, unit price and tax code of
+// every line item that references a catalog product but leaves them blank.
+// Values supplied on the line item take precedence over the product defaults.
+func (server *Server) applyProductDefaults(c *gin.Context, lineItems []createLineItemRequest, currency string) ([]createLineItemRequest, error) {
+	products := make(map[int64]db.ProductResult)
+	items := make([]createLineItemRequest, len(lineItems))
+
+	for i, item := range lineItems {
+		items[i] = item
+		if item.ProductID == 0 {
+			continue
+		}
+
+		product, ok := products[item.ProductID]
+		if !ok {
+			var err error
+			product, err = server.store.GetProduct(c, item.ProductID)
+			if err != nil {
+				if errors.Is(err, db.ErrNotFound) {
+					return nil, fmt.Errorf("%w: product %d does not exist", errInvalidProduct, item.ProductID)
+				}
+				return nil, err
+			}
+			products[item.ProductID] = product
+		}
+
+		if !product.Active {
+			return nil, fmt.Errorf("%w: product %d is inactive", errInvalidProduct, item.ProductID)
+		}
+
+		if items[i].Description == "" {
+			items[i].Description = product.Description
+			if items[i].Description == "" {
+				items[i].Description = product.Name
+			}
+		}
+
+		if items[i].UnitPrice == "" {
+			unitPrice, ok := product.Price(currency)
+			if !ok {
+				return nil, fmt.Errorf("%w: product %d has no %s price", errInvalidProduct, item.ProductID, currency)
+			}
+			items[i].UnitPrice = formatMajorUnits(unitPrice, currency)
+		}
+
+		if items[i].TaxCode == "" {
+			items[i].TaxCode = product.DefaultTaxCode
+		}
+	}
+
+	return items, nil
+}
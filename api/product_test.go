@@ -0,0 +1,812 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func randomProduct() db.ProductResult {
+	id := util.RandomInt(1, 1000)
+	return db.ProductResult{
+		Product: db.Product{
+			ID:             id,
+			SKU:            util.RandomString(8),
+			Name:           util.RandomString(10),
+			Description:    util.RandomString(20),
+			Unit:           "hour",
+			DefaultTaxCode: "S",
+			Active:         true,
+			CreatedAt:      time.Now().UTC().Truncate(time.Second),
+		},
+		Prices: []db.ProductPrice{
+			{ProductID: id, Currency: "EUR", UnitPrice: 9000},
+			{ProductID: id, Currency: "USD", UnitPrice: 12550},
+		},
+	}
+}
+
+func TestCreateProductAPI(t *testing.T) {
+	product := randomProduct()
+
+	validBody := gin.H{
+		"sku":              product.SKU,
+		"name":             product.Name,
+		"description":      product.Description,
+		"unit":             product.Unit,
+		"default_tax_code": product.DefaultTaxCode,
+		"prices": []gin.H{
+			{"currency": "EUR", "unit_price": "90"},
+			{"currency": "USD", "unit_price": "125.50"},
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateProductTxParams{
+					SKU:            product.SKU,
+					Name:           product.Name,
+					Description:    product.Description,
+					Unit:           product.Unit,
+					DefaultTaxCode: product.DefaultTaxCode,
+					Active:         true,
+					Prices: []db.ProductPriceParams{
+						{Currency: "EUR", UnitPrice: 9000},
+						{Currency: "USD", UnitPrice: 12550},
+					},
+				}
+				store.EXPECT().
+					CreateProductTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(product, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				requireBodyMatchProduct(t, recorder.Body, product)
+			},
+		},
+
+		{
+			name: "DuplicateCurrency",
+			body: gin.H{
+				"sku":  product.SKU,
+				"name": product.Name,
+				"unit": product.Unit,
+				"prices": []gin.H{
+					{"currency": "USD", "unit_price": "1"},
+					{"currency": "USD", "unit_price": "2"},
+				},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateProductTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name: "InvalidCurrency",
+			body: gin.H{
+				"sku":    product.SKU,
+				"name":   product.Name,
+				"unit":   product.Unit,
+				"prices": []gin.H{{"currency": "XYZ", "unit_price": "1"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateProductTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name: "InvalidUnitPrice",
+			body: gin.H{
+				"sku":    product.SKU,
+				"name":   product.Name,
+				"unit":   product.Unit,
+				"prices": []gin.H{{"currency": "USD", "unit_price": "1.999"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateProductTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name: "ZeroDecimalCurrency",
+			body: gin.H{
+				"sku":  product.SKU,
+				"name": product.Name,
+				"unit": product.Unit,
+				"prices": []gin.H{
+					{"currency": "JPY", "unit_price": "1250"},
+					{"currency": "USD", "unit_price": "92233720368547758.07"},
+				},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateProductTxParams{
+					SKU:    product.SKU,
+					Name:   product.Name,
+					Unit:   product.Unit,
+					Active: true,
+					Prices: []db.ProductPriceParams{
+						{Currency: "JPY", UnitPrice: 1250},
+						{Currency: "USD", UnitPrice: 9223372036854775807},
+					},
+				}
+				store.EXPECT().
+					CreateProductTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(product, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+
+		{
+			name: "UnitPriceHasMoreDecimalsThanCurrency",
+			body: gin.H{
+				"sku":    product.SKU,
+				"name":   product.Name,
+				"unit":   product.Unit,
+				"prices": []gin.H{{"currency": "JPY", "unit_price": "0.5"}},
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateProductTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+				var problem problemResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+				require.Len(t, problem.Errors, 1)
+				require.Equal(t, "prices[0].unit_price", problem.Errors[0].Field)
+			},
+		},
+
+		{
+			name: "DuplicateSKU",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateProductTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ProductResult{}, db.ErrConflict)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/products", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetProductAPI(t *testing.T) {
+	product := randomProduct()
+
+	testCases := []struct {
+		name          string
+		productID     int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:      "OK",
+			productID: product.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetProduct(gomock.Any(), gomock.Eq(product.ID)).
+					Times(1).
+					Return(product, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchProduct(t, recorder.Body, product)
+			},
+		},
+
+		{
+			name:      "NotFound",
+			productID: product.ID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetProduct(gomock.Any(), gomock.Eq(product.ID)).
+					Times(1).
+					Return(db.ProductResult{}, db.ErrNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+
+		{
+			name:      "InvalidID",
+			productID: 0,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetProduct(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			url := fmt.Sprintf("/products/%d", tc.productID)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestListProductsAPI(t *testing.T) {
+	products := []db.ProductResult{randomProduct(), randomProduct()}
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "?active=true&page_id=2&page_size=5",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListProductRecordsParams{
+					Active: pgtype.Bool{Bool: true, Valid: true},
+					Limit:  5,
+					Offset: 5,
+				}
+				store.EXPECT().
+					ListProducts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(products, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotResponse []productResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotResponse)
+				require.NoError(t, err)
+				require.Len(t, gotResponse, len(products))
+				require.Equal(t, generateProductResponse(products[0]), gotResponse[0])
+			},
+		},
+
+		{
+			name:  "DefaultPagination",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ListProductRecordsParams{Limit: 20, Offset: 0}
+				store.EXPECT().
+					ListProducts(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.ProductResult{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+
+		{
+			name:  "PageSizeTooLarge",
+			query: "?page_size=1000",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ListProducts(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			request, err := http.NewRequest(http.MethodGet, "/products"+tc.query, nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateProductAPI(t *testing.T) {
+	product := randomProduct()
+
+	body := gin.H{
+		"sku":    product.SKU,
+		"name":   product.Name,
+		"unit":   product.Unit,
+		"active": false,
+		"prices": []gin.H{{"currency": "USD", "unit_price": "125.50"}},
+	}
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateProductTxParams{
+					ID: product.ID,
+					CreateProductTxParams: db.CreateProductTxParams{
+						SKU:    product.SKU,
+						Name:   product.Name,
+						Unit:   product.Unit,
+						Active: false,
+						Prices: []db.ProductPriceParams{{Currency: "USD", UnitPrice: 12550}},
+					},
+				}
+				store.EXPECT().
+					UpdateProductTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(product, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+			},
+		},
+
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateProductTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ProductResult{}, db.ErrNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+
+		{
+			name: "InternalError",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateProductTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.ProductResult{}, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			data, err := json.Marshal(body)
+			require.NoError(t, err)
+			url := fmt.Sprintf("/products/%d", product.ID)
+			request, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestDeleteProductAPI(t *testing.T) {
+	productID := util.RandomInt(1, 1000)
+
+	testCases := []struct {
+		name          string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteProductTx(gomock.Any(), gomock.Eq(productID)).
+					Times(1).
+					Return(nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNoContent, recorder.Code)
+			},
+		},
+
+		{
+			name: "NotFound",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteProductTx(gomock.Any(), gomock.Eq(productID)).
+					Times(1).
+					Return(db.ErrNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+
+		{
+			name: "ReferencedByLineItems",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					DeleteProductTx(gomock.Any(), gomock.Eq(productID)).
+					Times(1).
+					Return(db.ErrConstraintViolation)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			url := fmt.Sprintf("/products/%d", productID)
+			request, err := http.NewRequest(http.MethodDelete, url, nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestCreateInvoiceWithProductAPI(t *testing.T) {
+	fixedTime := time.Date(2025, 1, 21, 0, 0, 0, 0, time.UTC)
+	product := randomProduct()
+
+	body := func(lineItem gin.H) gin.H {
+		return gin.H{
+			"customer_name":    "john doe",
+			"customer_email":   "jdoe@fakemail.com",
+			"customer_phone":   "+1234567890",
+			"customer_address": "123 A Street",
+			"sender_name":      "acme inc",
+			"sender_email":     "xyz@acme.com",
+			"sender_phone":     "+9876543210",
+			"sender_address":   "456 X Street",
+			"issue_date":       fixedTime.Format(time.DateOnly),
+			"due_date":         fixedTime.AddDate(0, 0, 1).Format(time.DateOnly),
+			"status":           "pending_payment",
+			"discount_rate":    "0",
+			"payment_info":     "Bank transfer",
+			"line_items":       []gin.H{lineItem},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		currency      string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "InheritsDefaults",
+			body: body(gin.H{"product_id": product.ID, "quantity": 2}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetProduct(gomock.Any(), gomock.Eq(product.ID)).
+					Times(1).
+					Return(product, nil)
+
+				store.EXPECT().
+					CreateInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateInvoiceTxParams) (db.InvoiceResult, error) {
+						require.Equal(t, []db.InsertLineItemParams{
+							{
+								Description: product.Description,
+								Quantity:    2,
+								UnitPrice:   12550,
+								TotalPrice:  25100,
+								ProductID:   pgtype.Int8{Int64: product.ID, Valid: true},
+								TaxCode:     product.DefaultTaxCode,
+							},
+						}, arg.Items)
+						require.Equal(t, int64(25100), arg.TotalAmount)
+						return db.InvoiceResult{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+
+		{
+			name: "OverridesDefaults",
+			body: body(gin.H{
+				"product_id":  product.ID,
+				"description": "discounted consulting",
+				"quantity":    1,
+				"unit_price":  "99.99",
+				"tax_code":    "Z",
+			}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetProduct(gomock.Any(), gomock.Eq(product.ID)).
+					Times(1).
+					Return(product, nil)
+
+				store.EXPECT().
+					CreateInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateInvoiceTxParams) (db.InvoiceResult, error) {
+						require.Equal(t, []db.InsertLineItemParams{
+							{
+								Description: "discounted consulting",
+								Quantity:    1,
+								UnitPrice:   9999,
+								TotalPrice:  9999,
+								ProductID:   pgtype.Int8{Int64: product.ID, Valid: true},
+								TaxCode:     "Z",
+							},
+						}, arg.Items)
+						return db.InvoiceResult{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+
+		{
+			name:     "DefaultCurrency",
+			currency: "EUR",
+			body:     body(gin.H{"product_id": product.ID, "quantity": 2}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetProduct(gomock.Any(), gomock.Eq(product.ID)).
+					Times(1).
+					Return(product, nil)
+
+				store.EXPECT().
+					CreateInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					DoAndReturn(func(_ any, arg db.CreateInvoiceTxParams) (db.InvoiceResult, error) {
+						require.Equal(t, int64(9000), arg.Items[0].UnitPrice)
+						require.Equal(t, int64(18000), arg.TotalAmount)
+						require.Equal(t, pgtype.Text{String: "EUR", Valid: true}, arg.BillingCurrency)
+						return db.InvoiceResult{}, nil
+					})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+			},
+		},
+
+		{
+			name:     "NoPriceInDefaultCurrency",
+			currency: "JPY",
+			body:     body(gin.H{"product_id": product.ID, "quantity": 1}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetProduct(gomock.Any(), gomock.Eq(product.ID)).
+					Times(1).
+					Return(product, nil)
+
+				store.EXPECT().
+					CreateInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+
+		{
+			name:     "MoreDecimalsThanDefaultCurrency",
+			currency: "JPY",
+			body:     body(gin.H{"description": "consulting", "quantity": 1, "unit_price": "10.5"}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+
+				var problem problemResponse
+				require.NoError(t, json.Unmarshal(recorder.Body.Bytes(), &problem))
+				require.Equal(t, []fieldError{{
+					Field:   "line_items[0].unit_price",
+					Code:    "invalid_amount",
+					Message: "must be a non-negative amount with at most 0 decimal places in JPY",
+				}}, problem.Errors)
+			},
+		},
+
+		{
+			name: "ProductNotFound",
+			body: body(gin.H{"product_id": product.ID, "quantity": 1}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetProduct(gomock.Any(), gomock.Eq(product.ID)).
+					Times(1).
+					Return(db.ProductResult{}, db.ErrNotFound)
+
+				store.EXPECT().
+					CreateInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+
+		{
+			name: "InactiveProduct",
+			body: body(gin.H{"product_id": product.ID, "quantity": 1}),
+			buildStubs: func(store *mockdb.MockStore) {
+				inactive := product
+				inactive.Active = false
+				store.EXPECT().
+					GetProduct(gomock.Any(), gomock.Eq(product.ID)).
+					Times(1).
+					Return(inactive, nil)
+
+				store.EXPECT().
+					CreateInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+
+		{
+			name: "MissingDescriptionWithoutProduct",
+			body: body(gin.H{"quantity": 1, "unit_price": "10.00"}),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateInvoiceTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/invoices", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			if tc.currency != "" {
+				server.config.DefaultCurrency = tc.currency
+			}
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func requireBodyMatchProduct(t *testing.T, body *bytes.Buffer, product db.ProductResult) {
+	var gotResponse productResponse
+	err := json.Unmarshal(body.Bytes(), &gotResponse)
+	require.NoError(t, err)
+	require.Equal(t, generateProductResponse(product), gotResponse)
+}
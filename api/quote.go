@@ -0,0 +1,292 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/db"
+	"github.com/kuthumipepple/numeris-book/util"
+)
+
+type createQuoteRequest struct {
+	CustomerName    string                  `json:"customer_name" binding:"required"`
+	CustomerEmail   string                  `json:"customer_email" binding:"required,email"`
+	CustomerPhone   string                  `json:"customer_phone" binding:"required"`
+	CustomerAddress string                  `json:"customer_address" binding:"required"`
+	SenderName      string                  `json:"sender_name" binding:"required"`
+	SenderEmail     string                  `json:"sender_email" binding:"required,email"`
+	SenderPhone     string                  `json:"sender_phone" binding:"required"`
+	SenderAddress   string                  `json:"sender_address" binding:"required"`
+	IssueDate       string                  `json:"issue_date" binding:"required"`
+	ExpiryDate      string                  `json:"expiry_date" binding:"required"`
+	DiscountRate    string                  `json:"discount_rate" binding:"required"`
+	LineItems       []createLineItemRequest `json:"line_items" binding:"required,dive"`
+}
+
+type createQuoteResponse struct {
+	QuoteNumber int64     `json:"quote_number"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (server *Server) createQuote(c *gin.Context) {
+	var req createQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	issueDate, _ := time.Parse(time.DateOnly, req.IssueDate)
+
+	expiryDate, _ := time.Parse(time.DateOnly, req.ExpiryDate)
+
+	currency := server.config.DefaultCurrency
+	lineItems, err := server.applyProductDefaults(c, req.LineItems, currency)
+	if err != nil {
+		if errors.Is(err, errInvalidProduct) {
+			respondWithError(c, http.StatusUnprocessableEntity, err)
+			return
+		}
+		c.Error(err)
+		return
+	}
+
+	amounts, err := calculateAmounts(lineItems, req.DiscountRate, currency, server.config.DefaultTaxCode)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	items := make([]db.InsertQuoteLineItemParams, len(amounts.Items))
+	for i, v := range amounts.Items {
+		items[i] = db.InsertQuoteLineItemParams{
+			Description: v.Description,
+			Quantity:    v.Quantity,
+			UnitPrice:   v.UnitPrice,
+			TotalPrice:  v.TotalPrice,
+			ProductID:   v.ProductID,
+			TaxCode:     v.TaxCode,
+		}
+	}
+
+	arg := db.CreateQuoteTxParams{
+		CustomerName:    req.CustomerName,
+		CustomerEmail:   req.CustomerEmail,
+		CustomerPhone:   req.CustomerPhone,
+		CustomerAddress: req.CustomerAddress,
+		SenderName:      req.SenderName,
+		SenderEmail:     req.SenderEmail,
+		SenderPhone:     req.SenderPhone,
+		SenderAddress:   req.SenderAddress,
+		IssueDate:       issueDate,
+		ExpiryDate:      expiryDate,
+		Status:          util.SENT,
+		Subtotal:        amounts.Subtotal,
+		DiscountRate:    amounts.DiscountRate,
+		Discount:        amounts.Discount,
+		TotalAmount:     amounts.TotalAmount,
+		BillingCurrency: pgtype.Text{String: currency, Valid: true},
+		Items:           items,
+	}
+
+	result, err := server.store.CreateQuoteTx(c, arg)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(
+		http.StatusCreated,
+		createQuoteResponse{
+			QuoteNumber: result.QuoteNumber,
+			CreatedAt:   result.CreatedAt,
+		},
+	)
+}
+
+type getQuoteRequest struct {
+	ID int64 `uri:"id" binding:"required,min=1"`
+}
+
+type getQuoteResponse struct {
+	QuoteNumber     int64                  `json:"quote_number"`
+	CustomerName    string                 `json:"customer_name"`
+	CustomerEmail   string                 `json:"customer_email"`
+	CustomerPhone   string                 `json:"customer_phone"`
+	CustomerAddress string                 `json:"customer_address"`
+	SenderName      string                 `json:"sender_name"`
+	SenderEmail     string                 `json:"sender_email"`
+	SenderPhone     string                 `json:"sender_phone"`
+	SenderAddress   string                 `json:"sender_address"`
+	IssueDate       string                 `json:"issue_date"`
+	ExpiryDate      string                 `json:"expiry_date"`
+	Status          string                 `json:"status"`
+	Subtotal        string                 `json:"subtotal"`
+	DiscountRate    string                 `json:"discount_rate"`
+	Discount        string                 `json:"discount"`
+	TotalAmount     string                 `json:"total_amount"`
+	BillingCurrency string                 `json:"billing_currency"`
+	Note            string                 `json:"note"`
+	CreatedAt       string                 `json:"created_at"`
+	Items           []getQuoteResponseItem `json:"items"`
+}
+
+type getQuoteResponseItem struct {
+	ID          int64  `json:"id"`
+	QuoteNumber int64  `json:"quote_number"`
+	Description string `json:"description"`
+	Quantity    int64  `json:"quantity"`
+	UnitPrice   string `json:"unit_price"`
+	TotalPrice  string `json:"total_price"`
+	ProductID   *int64 `json:"product_id,omitempty"`
+	TaxCode     string `json:"tax_code,omitempty"`
+}
+
+func (server *Server) getQuote(c *gin.Context) {
+	var req getQuoteRequest
+	if err := c.ShouldBindUri(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := server.store.GetQuote(c, req.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, generateGetQuoteResponse(result))
+}
+
+func generateGetQuoteResponse(result db.QuoteResult) getQuoteResponse {
+	items := make([]getQuoteResponseItem, len(result.LineItems))
+	for i, v := range result.LineItems {
+		items[i] = getQuoteResponseItem{
+			ID:          v.ID,
+			QuoteNumber: v.QuoteNumber,
+			Description: v.Description,
+			Quantity:    v.Quantity,
+			UnitPrice:   money.New(v.UnitPrice, result.BillingCurrency).Display(),
+			TotalPrice:  money.New(v.TotalPrice, result.BillingCurrency).Display(),
+			ProductID:   nullableInt64(v.ProductID),
+			TaxCode:     v.TaxCode,
+		}
+	}
+
+	return getQuoteResponse{
+		QuoteNumber:     result.QuoteNumber,
+		CustomerName:    result.CustomerName,
+		CustomerEmail:   result.CustomerEmail,
+		CustomerPhone:   result.CustomerPhone,
+		CustomerAddress: result.CustomerAddress,
+		SenderName:      result.SenderName,
+		SenderEmail:     result.SenderEmail,
+		SenderPhone:     result.SenderPhone,
+		SenderAddress:   result.SenderAddress,
+		IssueDate:       result.IssueDate.Format(time.DateOnly),
+		ExpiryDate:      result.ExpiryDate.Format(time.DateOnly),
+		Status:          result.Status,
+		Subtotal:        money.New(result.Subtotal, result.BillingCurrency).Display(),
+		DiscountRate:    fmt.Sprintf("%s%%", basisPointsToPercent(result.DiscountRate)),
+		Discount:        money.New(result.Discount, result.BillingCurrency).Display(),
+		TotalAmount:     money.New(result.TotalAmount, result.BillingCurrency).Display(),
+		BillingCurrency: result.BillingCurrency,
+		Note:            result.Note,
+		CreatedAt:       result.CreatedAt.Format(time.RFC3339),
+		Items:           items,
+	}
+}
+
+type updateQuoteStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=accepted declined expired"`
+}
+
+type updateQuoteStatusResponse struct {
+	QuoteNumber int64  `json:"quote_number"`
+	Status      string `json:"status"`
+}
+
+// updateQuoteStatus records the customer's decision on a quote. Only quotes
+// that are still sent can be accepted, declined or expired; the store rejects
+// any other with a conflict.
+func (server *Server) updateQuoteStatus(c *gin.Context) {
+	var uri getQuoteRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req updateQuoteStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	quote, err := server.store.UpdateQuoteStatusTx(c, db.UpdateQuoteStatusParams{
+		QuoteNumber: uri.ID,
+		Status:      req.Status,
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	c.JSON(http.StatusOK, updateQuoteStatusResponse{
+		QuoteNumber: quote.QuoteNumber,
+		Status:      quote.Status,
+	})
+}
+
+type convertQuoteRequest struct {
+	IssueDate   string `json:"issue_date" binding:"required"`
+	DueDate     string `json:"due_date" binding:"required"`
+	Status      string `json:"status" binding:"required"`
+	PaymentInfo string `json:"payment_info" binding:"required"`
+}
+
+// convertQuote turns a sent or accepted quote into an invoice that refers back
+// to it.
+func (server *Server) convertQuote(c *gin.Context) {
+	var uri getQuoteRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req convertQuoteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	issueDate, _ := time.Parse(time.DateOnly, req.IssueDate)
+
+	dueDate, _ := time.Parse(time.DateOnly, req.DueDate)
+
+	arg := db.ConvertQuoteTxParams{
+		QuoteNumber: uri.ID,
+		IssueDate:   issueDate,
+		DueDate:     dueDate,
+		Status:      req.Status,
+		PaymentInfo: req.PaymentInfo,
+	}
+
+	result, err := server.store.ConvertQuoteTx(c, arg)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	server.metrics.invoiceCreated(result.Invoice)
+
+	c.JSON(
+		http.StatusCreated,
+		createInvoiceResponse{
+			InvoiceNumber: result.InvoiceNumber,
+			CreatedAt:     result.CreatedAt,
+		},
+	)
+}
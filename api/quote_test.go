@@ -0,0 +1,584 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCreateQuoteAPI(t *testing.T) {
+	fixedTime := time.Date(2025, 1, 21, 0, 0, 0, 0, time.UTC)
+
+	validBody := func() gin.H {
+		return gin.H{
+			"customer_name":    "john doe",
+			"customer_email":   "jdoe@fakemail.com",
+			"customer_phone":   "+1234567890",
+			"customer_address": "123 A Street",
+			"sender_name":      "acme inc",
+			"sender_email":     "xyz@acme.com",
+			"sender_phone":     "+9876543210",
+			"sender_address":   "456 X Street",
+			"issue_date":       fixedTime.Format(time.DateOnly),
+			"expiry_date":      fixedTime.AddDate(0, 0, 14).Format(time.DateOnly),
+			"discount_rate":    "5.80",
+			"line_items": []gin.H{
+				{
+					"description": "item 1",
+					"quantity":    1,
+					"unit_price":  "100.00",
+				},
+				{
+					"description": "item 2",
+					"quantity":    2,
+					"unit_price":  "58.99",
+				},
+			},
+		}
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: validBody(),
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CreateQuoteTxParams{
+					CustomerName:    "john doe",
+					CustomerEmail:   "jdoe@fakemail.com",
+					CustomerPhone:   "+1234567890",
+					CustomerAddress: "123 A Street",
+					SenderName:      "acme inc",
+					SenderEmail:     "xyz@acme.com",
+					SenderPhone:     "+9876543210",
+					SenderAddress:   "456 X Street",
+					IssueDate:       fixedTime,
+					ExpiryDate:      fixedTime.AddDate(0, 0, 14),
+					Status:          util.SENT,
+					Subtotal:        int64(21798),
+					DiscountRate:    int64(580),
+					Discount:        int64(1265),
+					TotalAmount:     int64(20533),
+					BillingCurrency: pgtype.Text{String: "USD", Valid: true},
+					Items: []db.InsertQuoteLineItemParams{
+						{
+							Description: "item 1",
+							Quantity:    int64(1),
+							UnitPrice:   int64(10000),
+							TotalPrice:  int64(10000),
+							TaxCode:     "O",
+						},
+						{
+							Description: "item 2",
+							Quantity:    int64(2),
+							UnitPrice:   int64(5899),
+							TotalPrice:  int64(11798),
+							TaxCode:     "O",
+						},
+					},
+				}
+				result := db.QuoteResult{
+					Quote: db.Quote{
+						QuoteNumber: int64(1),
+						CreatedAt:   fixedTime,
+					},
+				}
+
+				store.EXPECT().
+					CreateQuoteTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+
+				var gotResponse createQuoteResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotResponse)
+				require.NoError(t, err)
+				require.Equal(t, createQuoteResponse{1, fixedTime}, gotResponse)
+			},
+		},
+
+		{
+			name: "ExpiryDateNotLaterThanIssueDate",
+			body: func() gin.H {
+				body := validBody()
+				body["expiry_date"] = fixedTime.Format(time.DateOnly)
+				return body
+			}(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateQuoteTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name: "UnitPriceIsNegative",
+			body: func() gin.H {
+				body := validBody()
+				body["line_items"] = []gin.H{
+					{
+						"description": "item 1",
+						"quantity":    1,
+						"unit_price":  "-100.00",
+					},
+				}
+				return body
+			}(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateQuoteTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name: "InternalError",
+			body: validBody(),
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					CreateQuoteTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.QuoteResult{}, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, "/quotes", bytes.NewReader(data))
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestGetQuoteAPI(t *testing.T) {
+	fakeID := util.RandomInt(1, 1000)
+	fixedTime := time.Now().UTC()
+
+	testCases := []struct {
+		name          string
+		quoteNumber   int64
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "OK",
+			quoteNumber: fakeID,
+			buildStubs: func(store *mockdb.MockStore) {
+				result := db.QuoteResult{
+					Quote: db.Quote{
+						QuoteNumber:     fakeID,
+						IssueDate:       fixedTime,
+						ExpiryDate:      fixedTime.AddDate(0, 0, 14),
+						Status:          util.SENT,
+						Subtotal:        int64(1234567890),
+						DiscountRate:    int64(1234),
+						Discount:        int64(123456),
+						TotalAmount:     int64(123456789),
+						BillingCurrency: "USD",
+						Note:            "Thank you for your interest",
+						CreatedAt:       fixedTime.Add(2 * time.Hour),
+					},
+					LineItems: []db.QuoteLineItem{
+						{
+							ID:          fakeID + 1,
+							QuoteNumber: fakeID,
+							Description: "item 1",
+							Quantity:    int64(1),
+							UnitPrice:   int64(12345),
+							TotalPrice:  int64(12345),
+						},
+					},
+				}
+				store.EXPECT().
+					GetQuote(gomock.Any(), gomock.Eq(fakeID)).
+					Times(1).
+					Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				requireBodyMatchGetQuoteResponse(
+					t,
+					recorder.Body,
+					getQuoteResponse{
+						QuoteNumber:     fakeID,
+						IssueDate:       fixedTime.Format(time.DateOnly),
+						ExpiryDate:      fixedTime.AddDate(0, 0, 14).Format(time.DateOnly),
+						Status:          util.SENT,
+						Subtotal:        "$12,345,678.90",
+						DiscountRate:    "12.34%",
+						Discount:        "$1,234.56",
+						TotalAmount:     "$1,234,567.89",
+						BillingCurrency: "USD",
+						Note:            "Thank you for your interest",
+						CreatedAt:       fixedTime.Add(2 * time.Hour).Format(time.RFC3339),
+						Items: []getQuoteResponseItem{
+							{fakeID + 1, fakeID, "item 1", 1, "$123.45", "$123.45", nil, ""},
+						},
+					},
+				)
+			},
+		},
+
+		{
+			name:        "InvalidID",
+			quoteNumber: -1,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetQuote(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:        "NotFound",
+			quoteNumber: fakeID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetQuote(gomock.Any(), gomock.Eq(fakeID)).
+					Times(1).
+					Return(db.QuoteResult{}, db.ErrNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+
+		{
+			name:        "InternalError",
+			quoteNumber: fakeID,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetQuote(gomock.Any(), gomock.Eq(fakeID)).
+					Times(1).
+					Return(db.QuoteResult{}, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			url := fmt.Sprintf("/quotes/%d", tc.quoteNumber)
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestUpdateQuoteStatusAPI(t *testing.T) {
+	fakeID := util.RandomInt(1, 1000)
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: gin.H{"status": util.DECLINED},
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.UpdateQuoteStatusParams{QuoteNumber: fakeID, Status: util.DECLINED}
+				store.EXPECT().
+					UpdateQuoteStatusTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(db.Quote{QuoteNumber: fakeID, Status: util.DECLINED}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotResponse updateQuoteStatusResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotResponse)
+				require.NoError(t, err)
+				require.Equal(t, updateQuoteStatusResponse{fakeID, util.DECLINED}, gotResponse)
+			},
+		},
+
+		{
+			name: "InvalidStatus",
+			body: gin.H{"status": util.SENT},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateQuoteStatusTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name: "QuoteNoLongerSent",
+			body: gin.H{"status": util.EXPIRED},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateQuoteStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Quote{}, db.ErrQuoteDecided)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+
+		{
+			name: "NotFound",
+			body: gin.H{"status": util.ACCEPTED},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					UpdateQuoteStatusTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.Quote{}, db.ErrNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			url := fmt.Sprintf("/quotes/%d/status", fakeID)
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPatch, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestConvertQuoteAPI(t *testing.T) {
+	fakeID := util.RandomInt(1, 1000)
+	fixedTime := time.Date(2025, 1, 21, 0, 0, 0, 0, time.UTC)
+
+	validBody := gin.H{
+		"issue_date":   fixedTime.Format(time.DateOnly),
+		"due_date":     fixedTime.AddDate(0, 0, 30).Format(time.DateOnly),
+		"status":       util.PENDING_PAYMENT,
+		"payment_info": "Bank transfer",
+	}
+
+	testCases := []struct {
+		name          string
+		body          gin.H
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name: "OK",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ConvertQuoteTxParams{
+					QuoteNumber: fakeID,
+					IssueDate:   fixedTime,
+					DueDate:     fixedTime.AddDate(0, 0, 30),
+					Status:      util.PENDING_PAYMENT,
+					PaymentInfo: "Bank transfer",
+				}
+				result := db.InvoiceResult{
+					Invoice: db.Invoice{
+						InvoiceNumber: int64(7),
+						CreatedAt:     fixedTime,
+					},
+				}
+
+				store.EXPECT().
+					ConvertQuoteTx(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusCreated, recorder.Code)
+				requireBodyMatchResponse(t, recorder.Body, createInvoiceResponse{7, fixedTime})
+			},
+		},
+
+		{
+			name: "PaidStatusNotAllowed",
+			body: gin.H{
+				"issue_date":   fixedTime.Format(time.DateOnly),
+				"due_date":     fixedTime.AddDate(0, 0, 30).Format(time.DateOnly),
+				"status":       util.PAID,
+				"payment_info": "Bank transfer",
+			},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ConvertQuoteTx(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name: "NotFound",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ConvertQuoteTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.InvoiceResult{}, db.ErrNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+
+		{
+			name: "QuoteNotConvertible",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ConvertQuoteTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.InvoiceResult{}, db.ErrQuoteNotConvertible)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+
+		{
+			name: "AlreadyConverted",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ConvertQuoteTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.InvoiceResult{}, db.ErrConflict)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusConflict, recorder.Code)
+			},
+		},
+
+		{
+			name: "InternalError",
+			body: validBody,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ConvertQuoteTx(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.InvoiceResult{}, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			url := fmt.Sprintf("/quotes/%d/convert", fakeID)
+			data, err := json.Marshal(tc.body)
+			require.NoError(t, err)
+			request, err := http.NewRequest(http.MethodPost, url, bytes.NewReader(data))
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func requireBodyMatchGetQuoteResponse(t *testing.T, body *bytes.Buffer, response getQuoteResponse) {
+	data, err := io.ReadAll(body)
+	require.NoError(t, err)
+
+	var gotResponse getQuoteResponse
+	err = json.Unmarshal(data, &gotResponse)
+
+	require.NoError(t, err)
+	require.Equal(t, response, gotResponse)
+}
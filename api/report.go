@@ -0,0 +1,471 @@
+package api
+
+import (
+	"encoding/csv"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/gin-gonic/gin"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/db"
+)
+
+type revenueByProductRequest struct {
+	From     string `form:"from" binding:"required"`
+	To       string `form:"to" binding:"required"`
+	Currency string `form:"currency" binding:"omitempty,iso4217"`
+}
+
+type revenueByProductResponse struct {
+	From     string                        `json:"from"`
+	To       string                        `json:"to"`
+	Products []revenueByProductResponseRow `json:"products"`
+}
+
+type revenueByProductResponseRow struct {
+	ProductID       *int64 `json:"product_id"`
+	SKU             string `json:"sku"`
+	Name            string `json:"name"`
+	BillingCurrency string `json:"billing_currency"`
+	InvoiceCount    int64  `json:"invoice_count"`
+	Quantity        int64  `json:"quantity"`
+	GrossAmount     string `json:"gross_amount"`
+	NetAmount       string `json:"net_amount"`
+}
+
+// revenueByProduct groups the line items of issued invoices by catalog
+// product. Both ends of the date range are inclusive.
+func (server *Server) revenueByProduct(c *gin.Context) {
+	var req revenueByProductRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	from, to, err := parseDateRange(req.From, req.To)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	arg := db.RevenueByProductParams{
+		From:     from,
+		To:       to.AddDate(0, 0, 1),
+		Currency: pgtype.Text{String: req.Currency, Valid: req.Currency != ""},
+	}
+
+	rows, err := server.store.RevenueByProduct(c, arg)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	products := make([]revenueByProductResponseRow, len(rows))
+	for i, v := range rows {
+		products[i] = revenueByProductResponseRow{
+			ProductID:       nullableInt64(v.ProductID),
+			SKU:             v.SKU,
+			Name:            v.Name,
+			BillingCurrency: v.BillingCurrency,
+			InvoiceCount:    v.InvoiceCount,
+			Quantity:        v.Quantity,
+			GrossAmount:     money.New(v.GrossAmount, v.BillingCurrency).Display(),
+			NetAmount:       money.New(v.NetAmount, v.BillingCurrency).Display(),
+		}
+	}
+
+	c.JSON(http.StatusOK, revenueByProductResponse{
+		From:     from.Format(time.DateOnly),
+		To:       to.Format(time.DateOnly),
+		Products: products,
+	})
+}
+
+// parseDateRange parses two YYYY-MM-DD dates and checks that to does not come
+// before from.
+func parseDateRange(fromDate, toDate string) (from, to time.Time, err error) {
+	var fields validationError
+	from, err = time.Parse(time.DateOnly, fromDate)
+	if err != nil {
+		fields = append(fields, invalidDateError("from"))
+	}
+
+	to, err = time.Parse(time.DateOnly, toDate)
+	if err != nil {
+		fields = append(fields, invalidDateError("to"))
+	}
+
+	if fields == nil && to.Before(from) {
+		fields = append(fields, fieldError{Field: "to", Code: "too_early", Message: "must not come before from"})
+	}
+	if fields != nil {
+		return from, to, fields
+	}
+	return from, to, nil
+}
+
+const mimeCSV = "text/csv"
+
+type arAgingRequest struct {
+	AsOf     string `form:"as_of"`
+	Currency string `form:"currency" binding:"omitempty,iso4217"`
+	Format   string `form:"format" binding:"omitempty,oneof=json csv"`
+}
+
+type arAgingResponse struct {
+	AsOf       string                    `json:"as_of"`
+	Currencies []arAgingCurrencyResponse `json:"currencies"`
+}
+
+type arAgingCurrencyResponse struct {
+	Currency  string                    `json:"currency"`
+	Customers []arAgingCustomerResponse `json:"customers"`
+	Total     arAgingBuckets            `json:"total"`
+}
+
+type arAgingCustomerResponse struct {
+	CustomerEmail string `json:"customer_email"`
+	CustomerName  string `json:"customer_name"`
+	arAgingBuckets
+}
+
+type arAgingBuckets struct {
+	InvoiceCount int64  `json:"invoice_count"`
+	Current      string `json:"current"`
+	Days1To30    string `json:"days_1_30"`
+	Days31To60   string `json:"days_31_60"`
+	Days61To90   string `json:"days_61_90"`
+	DaysOver90   string `json:"days_over_90"`
+	Total        string `json:"total"`
+}
+
+var arAgingCSVHeader = []string{
+	"currency", "customer_email", "customer_name", "invoice_count",
+	"current", "days_1_30", "days_31_60", "days_61_90", "days_over_90", "total",
+}
+
+// arAging reports the balances of unpaid invoices, bucketed by how many days
+// past due they are on the as_of date (today by default). The report is
+// returned as CSV when format=csv is given or the client only accepts CSV.
+func (server *Server) arAging(c *gin.Context) {
+	var req arAgingRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	asOf := time.Now().UTC().Truncate(24 * time.Hour)
+	if req.AsOf != "" {
+		var err error
+		asOf, err = time.Parse(time.DateOnly, req.AsOf)
+		if err != nil {
+			respondWithError(c, http.StatusBadRequest, validationError{invalidDateError("as_of")})
+			return
+		}
+	}
+
+	arg := db.ARAgingParams{
+		AsOf:     asOf,
+		Currency: pgtype.Text{String: req.Currency, Valid: req.Currency != ""},
+	}
+
+	rows, err := server.store.ARAging(c, arg)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	format := req.Format
+	if format == "" && c.NegotiateFormat(gin.MIMEJSON, mimeCSV) == mimeCSV {
+		format = "csv"
+	}
+
+	if format == "csv" {
+		writeARAgingCSV(c, asOf, rows)
+		return
+	}
+
+	c.JSON(http.StatusOK, generateARAgingResponse(asOf, rows))
+}
+
+// agingTotals accumulates the buckets of the customers in one currency.
+type agingTotals struct {
+	invoiceCount int64
+	amounts      [6]int64
+}
+
+func (t *agingTotals) add(row db.ARAgingRow) {
+	t.invoiceCount += row.InvoiceCount
+	for i, v := range agingAmounts(row) {
+		t.amounts[i] += v
+	}
+}
+
+func agingAmounts(row db.ARAgingRow) [6]int64 {
+	return [6]int64{row.Current, row.Days1To30, row.Days31To60, row.Days61To90, row.DaysOver90, row.Total}
+}
+
+func generateARAgingBuckets(invoiceCount int64, amounts [6]int64, currency string) arAgingBuckets {
+	display := func(amount int64) string {
+		return money.New(amount, currency).Display()
+	}
+	return arAgingBuckets{
+		InvoiceCount: invoiceCount,
+		Current:      display(amounts[0]),
+		Days1To30:    display(amounts[1]),
+		Days31To60:   display(amounts[2]),
+		Days61To90:   display(amounts[3]),
+		DaysOver90:   display(amounts[4]),
+		Total:        display(amounts[5]),
+	}
+}
+
+// groupARAging splits rows, which are ordered by currency, into one slice per
+// currency.
+func groupARAging(rows []db.ARAgingRow) [][]db.ARAgingRow {
+	var groups [][]db.ARAgingRow
+	for i, row := range rows {
+		if i == 0 || row.BillingCurrency != rows[i-1].BillingCurrency {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], row)
+	}
+	return groups
+}
+
+func generateARAgingResponse(asOf time.Time, rows []db.ARAgingRow) arAgingResponse {
+	rsp := arAgingResponse{
+		AsOf:       asOf.Format(time.DateOnly),
+		Currencies: []arAgingCurrencyResponse{},
+	}
+
+	for _, group := range groupARAging(rows) {
+		currency := group[0].BillingCurrency
+		customers := make([]arAgingCustomerResponse, len(group))
+
+		var totals agingTotals
+		for i, row := range group {
+			totals.add(row)
+			customers[i] = arAgingCustomerResponse{
+				CustomerEmail:  row.CustomerEmail,
+				CustomerName:   row.CustomerName,
+				arAgingBuckets: generateARAgingBuckets(row.InvoiceCount, agingAmounts(row), currency),
+			}
+		}
+
+		rsp.Currencies = append(rsp.Currencies, arAgingCurrencyResponse{
+			Currency:  currency,
+			Customers: customers,
+			Total:     generateARAgingBuckets(totals.invoiceCount, totals.amounts, currency),
+		})
+	}
+	return rsp
+}
+
+// writeARAgingCSV writes one line per customer and a TOTAL line after the
+// customers of each currency. Amounts are plain decimals in major units.
+func writeARAgingCSV(c *gin.Context, asOf time.Time, rows []db.ARAgingRow) {
+	c.Header("Content-Type", mimeCSV+"; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="ar-aging-%s.csv"`, asOf.Format(time.DateOnly)))
+	c.Status(http.StatusOK)
+
+	record := func(currency, email, name string, invoiceCount int64, amounts [6]int64) []string {
+		line := []string{currency, email, name, strconv.FormatInt(invoiceCount, 10)}
+		for _, v := range amounts {
+			line = append(line, formatMajorUnits(v, currency))
+		}
+		return line
+	}
+
+	w := csv.NewWriter(c.Writer)
+	w.Write(arAgingCSVHeader)
+	for _, group := range groupARAging(rows) {
+		currency := group[0].BillingCurrency
+
+		var totals agingTotals
+		for _, row := range group {
+			totals.add(row)
+			w.Write(record(currency, row.CustomerEmail, row.CustomerName, row.InvoiceCount, agingAmounts(row)))
+		}
+		w.Write(record(currency, "", "TOTAL", totals.invoiceCount, totals.amounts))
+	}
+	w.Flush()
+}
+
+type revenueSummaryRequest struct {
+	From          string `form:"from" binding:"required"`
+	To            string `form:"to" binding:"required"`
+	Interval      string `form:"interval" binding:"omitempty,oneof=day week month quarter"`
+	CustomerEmail string `form:"customer_email" binding:"omitempty,email"`
+	Status        string `form:"status" binding:"omitempty,oneof=draft pending_payment overdue paid"`
+	Currency      string `form:"currency" binding:"omitempty,iso4217"`
+}
+
+type revenueSummaryResponse struct {
+	From     string                      `json:"from"`
+	To       string                      `json:"to"`
+	Interval string                      `json:"interval"`
+	Periods  []revenueSummaryResponseRow `json:"periods"`
+	Totals   []revenueSummaryResponseRow `json:"totals"`
+}
+
+type revenueSummaryResponseRow struct {
+	PeriodStart     string   `json:"period_start"`
+	PeriodEnd       string   `json:"period_end"`
+	BillingCurrency string   `json:"billing_currency"`
+	InvoiceCount    int64    `json:"invoice_count"`
+	Invoiced        string   `json:"invoiced"`
+	Discounted      string   `json:"discounted"`
+	Billed          string   `json:"billed"`
+	Collected       string   `json:"collected"`
+	Outstanding     string   `json:"outstanding"`
+	DSO             *float64 `json:"dso"`
+	// DSOMethod is how DSO was computed: dsoDaysToPay or dsoOutstandingRatio.
+	DSOMethod string `json:"dso_method,omitempty"`
+}
+
+// The ways in which DSO is computed.
+const (
+	dsoDaysToPay        = "days_to_pay"
+	dsoOutstandingRatio = "outstanding_ratio"
+)
+
+// revenueSummary totals the invoices issued in each interval of the date
+// range. Both ends of the range are inclusive, and the first and last periods
+// are cut to the range.
+func (server *Server) revenueSummary(c *gin.Context) {
+	var req revenueSummaryRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	from, to, err := parseDateRange(req.From, req.To)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Interval == "" {
+		req.Interval = "month"
+	}
+	end := to.AddDate(0, 0, 1)
+
+	arg := db.RevenueSummaryParams{
+		Interval:      req.Interval,
+		From:          from,
+		To:            end,
+		CustomerEmail: pgtype.Text{String: req.CustomerEmail, Valid: req.CustomerEmail != ""},
+		Status:        pgtype.Text{String: req.Status, Valid: req.Status != ""},
+		Currency:      pgtype.Text{String: req.Currency, Valid: req.Currency != ""},
+	}
+
+	rows, err := server.store.RevenueSummary(c, arg)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	rsp := revenueSummaryResponse{
+		From:     from.Format(time.DateOnly),
+		To:       to.Format(time.DateOnly),
+		Interval: req.Interval,
+		Periods:  make([]revenueSummaryResponseRow, len(rows)),
+		Totals:   []revenueSummaryResponseRow{},
+	}
+
+	totals := map[string]*db.RevenueSummaryRow{}
+	var currencies []string
+	for i, v := range rows {
+		periodStart := maxTime(v.PeriodStart, from)
+		periodEnd := minTime(addInterval(v.PeriodStart, req.Interval), end)
+		rsp.Periods[i] = generateRevenueSummaryResponseRow(v, periodStart, periodEnd)
+
+		total, ok := totals[v.BillingCurrency]
+		if !ok {
+			total = &db.RevenueSummaryRow{BillingCurrency: v.BillingCurrency}
+			totals[v.BillingCurrency] = total
+			currencies = append(currencies, v.BillingCurrency)
+		}
+		total.InvoiceCount += v.InvoiceCount
+		total.Invoiced += v.Invoiced
+		total.Discounted += v.Discounted
+		total.Billed += v.Billed
+		total.Collected += v.Collected
+		total.Outstanding += v.Outstanding
+		total.Paid += v.Paid
+		total.PaidDays += v.PaidDays
+	}
+
+	sort.Strings(currencies)
+	for _, currency := range currencies {
+		rsp.Totals = append(rsp.Totals, generateRevenueSummaryResponseRow(*totals[currency], from, end))
+	}
+
+	c.JSON(http.StatusOK, rsp)
+}
+
+// generateRevenueSummaryResponseRow formats a row covering [start, end).
+//
+// DSO (days sales outstanding) is the average number of days from issue to
+// payment, weighted by the amount paid, when payments have been recorded on
+// the invoices. Otherwise it is estimated as the share of the billed amount
+// that is still outstanding, scaled to the number of days covered. It is left
+// empty when nothing was billed.
+func generateRevenueSummaryResponseRow(v db.RevenueSummaryRow, start, end time.Time) revenueSummaryResponseRow {
+	row := revenueSummaryResponseRow{
+		PeriodStart:     start.Format(time.DateOnly),
+		PeriodEnd:       end.AddDate(0, 0, -1).Format(time.DateOnly),
+		BillingCurrency: v.BillingCurrency,
+		InvoiceCount:    v.InvoiceCount,
+		Invoiced:        money.New(v.Invoiced, v.BillingCurrency).Display(),
+		Discounted:      money.New(v.Discounted, v.BillingCurrency).Display(),
+		Billed:          money.New(v.Billed, v.BillingCurrency).Display(),
+		Collected:       money.New(v.Collected, v.BillingCurrency).Display(),
+		Outstanding:     money.New(v.Outstanding, v.BillingCurrency).Display(),
+	}
+
+	switch {
+	case v.Paid > 0:
+		dso := math.Round(float64(v.PaidDays)/float64(v.Paid)*10) / 10
+		row.DSO, row.DSOMethod = &dso, dsoDaysToPay
+	case v.Billed > 0:
+		days := end.Sub(start).Hours() / 24
+		dso := math.Round(float64(v.Outstanding)/float64(v.Billed)*days*10) / 10
+		row.DSO, row.DSOMethod = &dso, dsoOutstandingRatio
+	}
+	return row
+}
+
+// addInterval returns the start of the period after the one starting at t.
+func addInterval(t time.Time, interval string) time.Time {
+	switch interval {
+	case "day":
+		return t.AddDate(0, 0, 1)
+	case "week":
+		return t.AddDate(0, 0, 7)
+	case "quarter":
+		return t.AddDate(0, 3, 0)
+	default:
+		return t.AddDate(0, 1, 0)
+	}
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
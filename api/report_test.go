@@ -0,0 +1,518 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestRevenueByProductAPI(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "?from=2025-01-01&to=2025-01-31&currency=USD",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.RevenueByProductParams{
+					From:     from,
+					To:       to.AddDate(0, 0, 1),
+					Currency: pgtype.Text{String: "USD", Valid: true},
+				}
+				rows := []db.RevenueByProductRow{
+					{
+						ProductID:       pgtype.Int8{Int64: 3, Valid: true},
+						SKU:             "CONSULT-1H",
+						Name:            "Consulting hour",
+						BillingCurrency: "USD",
+						InvoiceCount:    2,
+						Quantity:        10,
+						GrossAmount:     125000,
+						NetAmount:       118750,
+					},
+					{
+						BillingCurrency: "USD",
+						InvoiceCount:    1,
+						Quantity:        1,
+						GrossAmount:     999,
+						NetAmount:       999,
+					},
+				}
+				store.EXPECT().
+					RevenueByProduct(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(rows, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotResponse revenueByProductResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotResponse)
+				require.NoError(t, err)
+
+				productID := int64(3)
+				require.Equal(t, revenueByProductResponse{
+					From: "2025-01-01",
+					To:   "2025-01-31",
+					Products: []revenueByProductResponseRow{
+						{&productID, "CONSULT-1H", "Consulting hour", "USD", 2, 10, "$1,250.00", "$1,187.50"},
+						{nil, "", "", "USD", 1, 1, "$9.99", "$9.99"},
+					},
+				}, gotResponse)
+			},
+		},
+
+		{
+			name:  "ToBeforeFrom",
+			query: "?from=2025-01-31&to=2025-01-01",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevenueByProduct(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:  "MissingRange",
+			query: "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevenueByProduct(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:  "InternalError",
+			query: "?from=2025-01-01&to=2025-01-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevenueByProduct(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			request, err := http.NewRequest(http.MethodGet, "/reports/revenue-by-product"+tc.query, nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestARAgingAPI(t *testing.T) {
+	asOf := time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC)
+
+	rows := []db.ARAgingRow{
+		{
+			BillingCurrency: "EUR",
+			CustomerEmail:   "billing@acme.example",
+			CustomerName:    "Acme, Inc.",
+			InvoiceCount:    1,
+			DaysOver90:      50000,
+			Total:           50000,
+		},
+		{
+			BillingCurrency: "USD",
+			CustomerEmail:   "ap@globex.example",
+			CustomerName:    "Globex",
+			InvoiceCount:    3,
+			Current:         10000,
+			Days1To30:       2550,
+			Days61To90:      7000,
+			Total:           19550,
+		},
+		{
+			BillingCurrency: "USD",
+			CustomerEmail:   "billing@acme.example",
+			CustomerName:    "Acme, Inc.",
+			InvoiceCount:    1,
+			Days31To60:      999,
+			Total:           999,
+		},
+	}
+
+	testCases := []struct {
+		name          string
+		query         string
+		accept        string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "?as_of=2025-03-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ARAgingParams{AsOf: asOf}
+				store.EXPECT().
+					ARAging(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(rows, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotResponse arAgingResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotResponse)
+				require.NoError(t, err)
+
+				require.Equal(t, arAgingResponse{
+					AsOf: "2025-03-31",
+					Currencies: []arAgingCurrencyResponse{
+						{
+							Currency: "EUR",
+							Customers: []arAgingCustomerResponse{
+								{"billing@acme.example", "Acme, Inc.", arAgingBuckets{1, "€0.00", "€0.00", "€0.00", "€0.00", "€500.00", "€500.00"}},
+							},
+							Total: arAgingBuckets{1, "€0.00", "€0.00", "€0.00", "€0.00", "€500.00", "€500.00"},
+						},
+						{
+							Currency: "USD",
+							Customers: []arAgingCustomerResponse{
+								{"ap@globex.example", "Globex", arAgingBuckets{3, "$100.00", "$25.50", "$0.00", "$70.00", "$0.00", "$195.50"}},
+								{"billing@acme.example", "Acme, Inc.", arAgingBuckets{1, "$0.00", "$0.00", "$9.99", "$0.00", "$0.00", "$9.99"}},
+							},
+							Total: arAgingBuckets{4, "$100.00", "$25.50", "$9.99", "$70.00", "$0.00", "$205.49"},
+						},
+					},
+				}, gotResponse)
+			},
+		},
+
+		{
+			name:  "CSVFormat",
+			query: "?as_of=2025-03-31&currency=USD&format=csv",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.ARAgingParams{
+					AsOf:     asOf,
+					Currency: pgtype.Text{String: "USD", Valid: true},
+				}
+				store.EXPECT().
+					ARAging(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(rows[1:], nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+				require.Equal(t, `attachment; filename="ar-aging-2025-03-31.csv"`, recorder.Header().Get("Content-Disposition"))
+
+				require.Equal(t, ""+
+					"currency,customer_email,customer_name,invoice_count,current,days_1_30,days_31_60,days_61_90,days_over_90,total\n"+
+					"USD,ap@globex.example,Globex,3,100.00,25.50,0.00,70.00,0.00,195.50\n"+
+					"USD,billing@acme.example,\"Acme, Inc.\",1,0.00,0.00,9.99,0.00,0.00,9.99\n"+
+					"USD,,TOTAL,4,100.00,25.50,9.99,70.00,0.00,205.49\n",
+					recorder.Body.String())
+			},
+		},
+
+		{
+			name:   "CSVAcceptHeader",
+			query:  "?as_of=2025-03-31",
+			accept: "text/csv",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ARAging(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return([]db.ARAgingRow{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, "text/csv; charset=utf-8", recorder.Header().Get("Content-Type"))
+				require.Equal(t, "currency,customer_email,customer_name,invoice_count,current,days_1_30,days_31_60,days_61_90,days_over_90,total\n", recorder.Body.String())
+			},
+		},
+
+		{
+			name:  "InvalidAsOf",
+			query: "?as_of=31-03-2025",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ARAging(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:  "InvalidFormat",
+			query: "?format=xml",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ARAging(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:  "InternalError",
+			query: "?as_of=2025-03-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					ARAging(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			request, err := http.NewRequest(http.MethodGet, "/reports/ar-aging"+tc.query, nil)
+			require.NoError(t, err)
+			if tc.accept != "" {
+				request.Header.Set("Accept", tc.accept)
+			}
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestRevenueSummaryAPI(t *testing.T) {
+	from := time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 3, 10, 0, 0, 0, 0, time.UTC)
+
+	testCases := []struct {
+		name          string
+		query         string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:  "OK",
+			query: "?from=2025-01-15&to=2025-03-10",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.RevenueSummaryParams{
+					Interval: "month",
+					From:     from,
+					To:       to.AddDate(0, 0, 1),
+				}
+				rows := []db.RevenueSummaryRow{
+					{
+						PeriodStart:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+						BillingCurrency: "USD",
+						InvoiceCount:    2,
+						Invoiced:        11000,
+						Discounted:      1000,
+						Billed:          10000,
+						Collected:       5000,
+						Outstanding:     5000,
+						Paid:            5000,
+						PaidDays:        5000 * 12,
+					},
+					{
+						PeriodStart:     time.Date(2025, 2, 1, 0, 0, 0, 0, time.UTC),
+						BillingCurrency: "USD",
+						InvoiceCount:    1,
+						Invoiced:        20000,
+						Billed:          20000,
+						Collected:       20000,
+						Paid:            20000,
+						PaidDays:        20000 * 20,
+					},
+					{
+						PeriodStart:     time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+						BillingCurrency: "EUR",
+						InvoiceCount:    1,
+						Invoiced:        3000,
+						Billed:          3000,
+						Outstanding:     3000,
+					},
+				}
+				store.EXPECT().
+					RevenueSummary(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(rows, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotResponse revenueSummaryResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotResponse)
+				require.NoError(t, err)
+
+				// DSO is the average days to pay where payments were
+				// recorded, and estimated from the outstanding share where
+				// they were not
+				dso := func(v float64) *float64 { return &v }
+				require.Equal(t, revenueSummaryResponse{
+					From:     "2025-01-15",
+					To:       "2025-03-10",
+					Interval: "month",
+					Periods: []revenueSummaryResponseRow{
+						{"2025-01-15", "2025-01-31", "USD", 2, "$110.00", "$10.00", "$100.00", "$50.00", "$50.00", dso(12), dsoDaysToPay},
+						{"2025-02-01", "2025-02-28", "USD", 1, "$200.00", "$0.00", "$200.00", "$200.00", "$0.00", dso(20), dsoDaysToPay},
+						{"2025-03-01", "2025-03-10", "EUR", 1, "€30.00", "€0.00", "€30.00", "€0.00", "€30.00", dso(10), dsoOutstandingRatio},
+					},
+					Totals: []revenueSummaryResponseRow{
+						{"2025-01-15", "2025-03-10", "EUR", 1, "€30.00", "€0.00", "€30.00", "€0.00", "€30.00", dso(55), dsoOutstandingRatio},
+						{"2025-01-15", "2025-03-10", "USD", 3, "$310.00", "$10.00", "$300.00", "$250.00", "$50.00", dso(18.4), dsoDaysToPay},
+					},
+				}, gotResponse)
+			},
+		},
+
+		{
+			name:  "Filters",
+			query: "?from=2025-01-15&to=2025-03-10&interval=week&customer_email=ap@globex.example&status=paid&currency=USD",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.RevenueSummaryParams{
+					Interval:      "week",
+					From:          from,
+					To:            to.AddDate(0, 0, 1),
+					CustomerEmail: pgtype.Text{String: "ap@globex.example", Valid: true},
+					Status:        pgtype.Text{String: "paid", Valid: true},
+					Currency:      pgtype.Text{String: "USD", Valid: true},
+				}
+				store.EXPECT().
+					RevenueSummary(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return([]db.RevenueSummaryRow{}, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotResponse revenueSummaryResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotResponse)
+				require.NoError(t, err)
+				require.Empty(t, gotResponse.Periods)
+				require.Empty(t, gotResponse.Totals)
+			},
+		},
+
+		{
+			name:  "InvalidInterval",
+			query: "?from=2025-01-15&to=2025-03-10&interval=year",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevenueSummary(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:  "InvalidStatus",
+			query: "?from=2025-01-15&to=2025-03-10&status=sent",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevenueSummary(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:  "ToBeforeFrom",
+			query: "?from=2025-03-10&to=2025-01-15",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevenueSummary(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:  "InternalError",
+			query: "?from=2025-01-15&to=2025-03-10",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					RevenueSummary(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(nil, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			request, err := http.NewRequest(http.MethodGet, "/reports/revenue"+tc.query, nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -1,39 +1,125 @@
 package api
 
 import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+
 	"github.com/gin-gonic/gin"
 	"github.com/gin-gonic/gin/binding"
 	"github.com/go-playground/validator/v10"
 	"github.com/kuthumipepple/numeris-book/db"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 )
 
 type Server struct {
-	store  db.Store
-	router *gin.Engine
+	config   util.Config
+	store    db.Store
+	registry *prometheus.Registry
+	metrics  *metrics
+	router   *gin.Engine
+	http     *http.Server
 }
 
-func NewServer(store db.Store) *Server {
-	server := &Server{store: store}
+// NewServer returns a server that registers its metrics with registry and
+// serves everything registered there at /metrics.
+func NewServer(config util.Config, store db.Store, registry *prometheus.Registry) *Server {
+	server := &Server{
+		config:   config,
+		store:    store,
+		registry: registry,
+		metrics:  newMetrics(registry),
+	}
 
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
+		v.RegisterTagNameFunc(jsonFieldName)
 		v.RegisterStructValidation(createInvoiceRequestValidation, createInvoiceRequest{})
+		v.RegisterStructValidation(createLineItemRequestValidation, createLineItemRequest{})
+		v.RegisterStructValidation(createQuoteRequestValidation, createQuoteRequest{})
+		v.RegisterStructValidation(convertQuoteRequestValidation, convertQuoteRequest{})
+		v.RegisterStructValidation(productPriceRequestValidation, productPriceRequest{})
+		v.RegisterStructValidation(recordPaymentRequestValidation, recordPaymentRequest{})
 	}
 
 	server.setupRouter()
+	server.http = &http.Server{
+		Handler:      server.router,
+		ReadTimeout:  config.HTTPReadTimeout,
+		WriteTimeout: config.HTTPWriteTimeout,
+		IdleTimeout:  config.HTTPIdleTimeout,
+	}
 	return server
 }
 
 func (server *Server) setupRouter() {
-	router := gin.Default()
+	router := gin.New()
+	// Let the store see the request context, which carries the request ID and
+	// is cancelled when the client goes away.
+	router.ContextWithFallback = true
+	// The probes are routed ahead of the middleware, so that the requests of
+	// orchestrators every few seconds are not logged, traced or counted.
+	router.GET("/healthz", server.healthz)
+	router.GET("/readyz", server.readyz)
+	router.Use(otelgin.Middleware(util.ServiceName), requestID(), identifyActor(), logRequests(), server.metrics.instrument(), handleErrors(), recoverPanics())
 	router.POST("/invoices", server.createInvoice)
+	router.POST("/invoices/import/ubl", server.importUBL)
+	router.POST("/invoices/import/cii", server.importCII)
+	router.GET("/invoices", server.listInvoices)
 	router.GET("/invoices/:id", server.getInvoice)
+	router.POST("/invoices/:id/payments", server.recordPayment)
+	router.GET("/invoices/:id/ubl", server.invoiceUBL)
+	router.GET("/invoices/:id/history", server.invoiceHistory)
+	router.POST("/invoices/:id/verify", server.verifyInvoiceDocument)
+	router.POST("/quotes", server.createQuote)
+	router.GET("/quotes/:id", server.getQuote)
+	router.PATCH("/quotes/:id/status", server.updateQuoteStatus)
+	router.POST("/quotes/:id/convert", server.convertQuote)
+	router.POST("/products", server.createProduct)
+	router.GET("/products", server.listProducts)
+	router.GET("/products/:id", server.getProduct)
+	router.PUT("/products/:id", server.updateProduct)
+	router.DELETE("/products/:id", server.deleteProduct)
+	router.GET("/reports/revenue-by-product", server.revenueByProduct)
+	router.GET("/reports/ar-aging", server.arAging)
+	router.GET("/reports/revenue", server.revenueSummary)
+	router.GET("/customers/:customer/statement", server.customerStatement)
+	router.GET("/exports/invoices", server.exportInvoices)
+	router.POST("/imports", server.importInvoices)
+	router.GET("/admin/audit-events", server.listAuditEvents)
+	router.GET("/integrity/verify", server.verifyIntegrity)
+	router.GET("/integrity/head", server.exportChainHead)
+	router.GET("/openapi.json", server.getOpenAPISpec)
+	router.GET("/docs", server.swaggerUI)
+	router.GET("/metrics", gin.WrapH(promhttp.HandlerFor(server.registry, promhttp.HandlerOpts{})))
 	server.router = router
 }
 
+// ServeHTTP serves a request without a listener. Commands use it to go
+// through the same validation and formatting as clients of the API.
+func (server *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	server.router.ServeHTTP(w, r)
+}
+
+// Start serves requests on address until Shutdown is called, after which it
+// returns nil.
 func (server *Server) Start(address string) error {
-	return server.router.Run(address)
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return err
+	}
+	err = server.http.Serve(listener)
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
 }
 
-func errorResponse(err error) gin.H {
-	return gin.H{"error": err.Error()}
+// Shutdown stops the server from accepting requests and waits for those in
+// flight to finish, or for ctx to be done.
+func (server *Server) Shutdown(ctx context.Context) error {
+	return server.http.Shutdown(ctx)
 }
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuthumipepple/numeris-book/einvoice"
+	"github.com/kuthumipepple/numeris-book/util"
+)
+
+var signedDocumentMediaTypes = []string{mimePDF, mimeXML, "text/xml"}
+
+// documentSigner loads the certificate and key that rendered documents are
+// signed with, or returns nil if none are configured. The files are read for
+// each document, so that a renewed certificate is used without a restart.
+func (server *Server) documentSigner() (*einvoice.Signer, error) {
+	certFile, keyFile := server.config.SigningCertificateFile, server.config.SigningKeyFile
+	if certFile == "" && keyFile == "" {
+		return nil, nil
+	}
+	if certFile == "" || keyFile == "" {
+		return nil, errors.New("both a signing certificate and a signing key must be configured")
+	}
+	signer, err := einvoice.LoadSigner(certFile, keyFile)
+	if err != nil {
+		return nil, fmt.Errorf("cannot load document signer: %w", err)
+	}
+	return signer, nil
+}
+
+// signDocument signs a rendered document with sign if a signer is
+// configured, and returns it unchanged otherwise.
+func (server *Server) signDocument(data []byte, sign func(*einvoice.Signer, []byte, time.Time) ([]byte, error)) ([]byte, error) {
+	signer, err := server.documentSigner()
+	if err != nil || signer == nil {
+		return data, err
+	}
+	return sign(signer, data, time.Now())
+}
+
+type verifyInvoiceDocumentResponse struct {
+	// Verified is true when the signature is valid, was made with the
+	// service's key and covers a document that matches the invoice.
+	Verified        bool       `json:"verified"`
+	Format          string     `json:"format"`
+	SignatureValid  bool       `json:"signature_valid"`
+	Signer          string     `json:"signer,omitempty"`
+	SignedAt        *time.Time `json:"signed_at,omitempty"`
+	SignedByService bool       `json:"signed_by_service"`
+	MatchesInvoice  bool       `json:"matches_invoice"`
+	Problems        []string   `json:"problems"`
+}
+
+// verifyInvoiceDocument checks the signature of a Factur-X PDF or XML
+// e-invoice in the request body and compares the invoice it carries with the
+// stored invoice, rendered with the same buyer details. A document that fails
+// the checks is a finding rather than a failure of the request, so it is
+// reported with 200 and the list of problems; only a document that cannot be
+// read at all is rejected.
+func (server *Server) verifyInvoiceDocument(c *gin.Context) {
+	var uri getInvoiceRequest
+	if err := c.ShouldBindUri(&uri); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req eInvoiceRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if c.ContentType() != "" {
+		mediaType, _, _ := mime.ParseMediaType(c.ContentType())
+		if !util.Contains(signedDocumentMediaTypes, mediaType) {
+			err := fmt.Errorf("unsupported content type %q", c.ContentType())
+			respondWithError(c, http.StatusUnsupportedMediaType, err)
+			return
+		}
+	}
+
+	data, err := io.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxEInvoiceSize))
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			respondWithError(c, http.StatusRequestEntityTooLarge, err)
+			return
+		}
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := server.store.GetInvoice(c, uri.ID)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	expected, ok := server.eInvoiceDocument(c, result, req)
+	if !ok {
+		return
+	}
+	signer, err := server.documentSigner()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	signed, err := einvoice.Verify(data)
+	response := verifyInvoiceDocumentResponse{
+		Format:         signed.Format,
+		SignatureValid: err == nil,
+		Problems:       []string{},
+	}
+	switch {
+	case err == nil:
+	case errors.Is(err, einvoice.ErrNoSignature), errors.Is(err, einvoice.ErrInvalidSignature):
+		response.Problems = append(response.Problems, err.Error())
+	case errors.Is(err, einvoice.ErrInvalidDocument):
+		respondWithError(c, http.StatusUnprocessableEntity, err)
+		return
+	default:
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if signed.Certificate != nil {
+		response.Signer = signed.Certificate.Subject.String()
+	}
+	if !signed.SigningTime.IsZero() {
+		response.SignedAt = &signed.SigningTime
+	}
+	switch {
+	case signer == nil:
+		response.Problems = append(response.Problems, "no signing certificate is configured to compare the signer with")
+	case response.SignatureValid && signed.SignedBy(signer):
+		response.SignedByService = true
+	case response.SignatureValid:
+		response.Problems = append(response.Problems, "document was not signed by this service")
+	}
+
+	if diffs := signed.Document.Differences(expected); len(diffs) > 0 {
+		response.Problems = append(response.Problems, "document does not match the invoice in: "+strings.Join(diffs, ", "))
+	} else {
+		response.MatchesInvoice = true
+	}
+
+	response.Verified = response.SignatureValid && response.SignedByService && response.MatchesInvoice
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,385 @@
+package api
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/einvoice"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+// writeDocumentSigner writes a new ECDSA key and a self-signed certificate
+// for it where the server can load them, and returns their paths with the
+// signer they make.
+func writeDocumentSigner(t *testing.T) (certFile, keyFile string, signer *einvoice.Signer) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "Numeris Studio"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	certDER, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(key)
+	require.NoError(t, err)
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "signing.crt"), filepath.Join(dir, "signing.key")
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER})
+	require.NoError(t, os.WriteFile(certFile, certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, keyPEM, 0o600))
+
+	signer, err = einvoice.NewSigner(certPEM, keyPEM)
+	require.NoError(t, err)
+	return certFile, keyFile, signer
+}
+
+func TestSignedDocumentsAPI(t *testing.T) {
+	result := randomEInvoice(1042)
+	certFile, keyFile, signer := writeDocumentSigner(t)
+
+	testCases := []struct {
+		name          string
+		url           string
+		keyFile       string
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:    "PDF",
+			url:     "/invoices/1042?format=pdf",
+			keyFile: keyFile,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, `W/"1-pdf"`, recorder.Header().Get("ETag"))
+
+				signed, err := einvoice.VerifyPDF(recorder.Body.Bytes())
+				require.NoError(t, err)
+				require.True(t, signed.SignedBy(signer))
+				require.Equal(t, "1042", signed.Document.Number)
+			},
+		},
+		{
+			name:    "UBL",
+			url:     "/invoices/1042/ubl",
+			keyFile: keyFile,
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				signed, err := einvoice.VerifyXML(recorder.Body.Bytes())
+				require.NoError(t, err)
+				require.True(t, signed.SignedBy(signer))
+				require.Equal(t, "1042", signed.Document.Number)
+			},
+		},
+		{
+			name:    "UnreadableKey",
+			url:     "/invoices/1042/ubl",
+			keyFile: filepath.Join(t.TempDir(), "missing.key"),
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			store.EXPECT().
+				GetInvoice(gomock.Any(), gomock.Eq(int64(1042))).
+				Times(1).
+				Return(result, nil)
+
+			request, err := http.NewRequest(http.MethodGet, tc.url, nil)
+			require.NoError(t, err)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.config.SigningCertificateFile = certFile
+			server.config.SigningKeyFile = tc.keyFile
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func TestVerifyInvoiceDocumentAPI(t *testing.T) {
+	result := randomEInvoice(1042)
+	certFile, keyFile, signer := writeDocumentSigner(t)
+	_, _, otherSigner := writeDocumentSigner(t)
+
+	document, err := einvoice.NewDocument(result, einvoice.Options{
+		SellerCountryCode: "NG",
+		BuyerCountryCode:  "NG",
+		DefaultTaxCode:    "O",
+	})
+	require.NoError(t, err)
+	ubl, err := document.UBL()
+	require.NoError(t, err)
+	signedUBL, err := signer.SignXML(ubl, time.Now())
+	require.NoError(t, err)
+	pdf, err := document.FacturX()
+	require.NoError(t, err)
+	signedPDF, err := signer.SignPDF(pdf, time.Now())
+	require.NoError(t, err)
+	otherUBL, err := otherSigner.SignXML(ubl, time.Now())
+	require.NoError(t, err)
+
+	changed := randomEInvoice(1042)
+	changed.LineItems[0].Quantity = 2
+	changed.LineItems[0].TotalPrice = 20000
+	changed.Subtotal, changed.TotalAmount = 20000, 20000
+
+	testCases := []struct {
+		name          string
+		body          []byte
+		contentType   string
+		keyFile       string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:        "SignedPDF",
+			body:        signedPDF,
+			contentType: mimePDF,
+			keyFile:     keyFile,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Eq(int64(1042))).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				got := unmarshalDocumentVerification(t, recorder)
+				require.True(t, got.Verified, got.Problems)
+				require.Equal(t, einvoice.FormatPAdES, got.Format)
+				require.Equal(t, "CN=Numeris Studio", got.Signer)
+				require.NotNil(t, got.SignedAt)
+				require.Empty(t, got.Problems)
+			},
+		},
+		{
+			name:        "SignedUBL",
+			body:        signedUBL,
+			contentType: mimeXML,
+			keyFile:     keyFile,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Eq(int64(1042))).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				got := unmarshalDocumentVerification(t, recorder)
+				require.True(t, got.Verified, got.Problems)
+				require.Equal(t, einvoice.FormatXAdES, got.Format)
+			},
+		},
+		{
+			name:        "Unsigned",
+			body:        ubl,
+			contentType: mimeXML,
+			keyFile:     keyFile,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Any()).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				got := unmarshalDocumentVerification(t, recorder)
+				require.False(t, got.Verified)
+				require.False(t, got.SignatureValid)
+				require.True(t, got.MatchesInvoice)
+				require.Equal(t, []string{einvoice.ErrNoSignature.Error()}, got.Problems)
+			},
+		},
+		{
+			name:        "Tampered",
+			body:        bytes.Replace(signedUBL, []byte(">300.00<"), []byte(">310.00<"), 1),
+			contentType: mimeXML,
+			keyFile:     keyFile,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Any()).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				got := unmarshalDocumentVerification(t, recorder)
+				require.False(t, got.Verified)
+				require.False(t, got.SignatureValid)
+				require.False(t, got.SignedByService)
+				require.False(t, got.MatchesInvoice)
+				require.Len(t, got.Problems, 2)
+			},
+		},
+		{
+			name:        "InvoiceChanged",
+			body:        signedPDF,
+			contentType: mimePDF,
+			keyFile:     keyFile,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Any()).Times(1).Return(changed, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				got := unmarshalDocumentVerification(t, recorder)
+				require.False(t, got.Verified)
+				require.True(t, got.SignatureValid)
+				require.True(t, got.SignedByService)
+				require.False(t, got.MatchesInvoice)
+				require.Len(t, got.Problems, 1)
+				require.Contains(t, got.Problems[0], "lines")
+			},
+		},
+		{
+			name:        "SignedByOtherKey",
+			body:        otherUBL,
+			contentType: mimeXML,
+			keyFile:     keyFile,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Any()).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				got := unmarshalDocumentVerification(t, recorder)
+				require.False(t, got.Verified)
+				require.True(t, got.SignatureValid)
+				require.False(t, got.SignedByService)
+				require.True(t, got.MatchesInvoice)
+			},
+		},
+		{
+			name:        "SigningKeyMissing",
+			body:        signedUBL,
+			contentType: mimeXML,
+			keyFile:     "",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Any()).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+		{
+			name:        "NotAnInvoice",
+			body:        []byte(`<Order xmlns="urn:example"/>`),
+			contentType: "text/xml",
+			keyFile:     keyFile,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Any()).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnprocessableEntity, recorder.Code)
+			},
+		},
+		{
+			name:        "MalformedXML",
+			body:        []byte(`<Invoice>`),
+			contentType: mimeXML,
+			keyFile:     keyFile,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Any()).Times(1).Return(result, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+		{
+			name:        "UnsupportedContentType",
+			body:        signedUBL,
+			contentType: "application/json",
+			keyFile:     keyFile,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusUnsupportedMediaType, recorder.Code)
+			},
+		},
+		{
+			name:        "TooLarge",
+			body:        []byte(strings.Repeat(" ", maxEInvoiceSize+1)),
+			contentType: mimeXML,
+			keyFile:     keyFile,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Any()).Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusRequestEntityTooLarge, recorder.Code)
+			},
+		},
+		{
+			name:        "NotFound",
+			body:        signedUBL,
+			contentType: mimeXML,
+			keyFile:     keyFile,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Any()).Times(1).Return(db.InvoiceResult{}, db.ErrNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+		{
+			name:        "InternalError",
+			body:        signedUBL,
+			contentType: mimeXML,
+			keyFile:     keyFile,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Any()).Times(1).Return(db.InvoiceResult{}, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			request, err := http.NewRequest(http.MethodPost, "/invoices/1042/verify", bytes.NewReader(tc.body))
+			require.NoError(t, err)
+			request.Header.Set("Content-Type", tc.contentType)
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+			server.config.SigningCertificateFile = certFile
+			server.config.SigningKeyFile = tc.keyFile
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
+
+func unmarshalDocumentVerification(t *testing.T, recorder *httptest.ResponseRecorder) verifyInvoiceDocumentResponse {
+	var got verifyInvoiceDocumentResponse
+	err := json.Unmarshal(recorder.Body.Bytes(), &got)
+	require.NoError(t, err)
+	return got
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/gin-gonic/gin"
+	"github.com/go-pdf/fpdf"
+	"github.com/kuthumipepple/numeris-book/db"
+)
+
+const mimePDF = "application/pdf"
+
+// Invoices only record the customer's details and there is no customer table,
+// so customers have no id and are identified by their email address.
+type customerStatementUri struct {
+	Customer string `uri:"customer" binding:"required,email"`
+}
+
+type customerStatementRequest struct {
+	From     string `form:"from" binding:"required"`
+	To       string `form:"to" binding:"required"`
+	Currency string `form:"currency" binding:"omitempty,iso4217"`
+	Format   string `form:"format" binding:"omitempty,oneof=json pdf"`
+}
+
+type customerStatementResponse struct {
+	CustomerEmail  string                           `json:"customer_email"`
+	CustomerName   string                           `json:"customer_name"`
+	Currency       string                           `json:"currency"`
+	From           string                           `json:"from"`
+	To             string                           `json:"to"`
+	OpeningBalance string                           `json:"opening_balance"`
+	Entries        []customerStatementResponseEntry `json:"entries"`
+	ClosingBalance string                           `json:"closing_balance"`
+}
+
+type customerStatementResponseEntry struct {
+	Date          string `json:"date"`
+	Kind          string `json:"kind"`
+	InvoiceNumber int64  `json:"invoice_number"`
+	Reference     string `json:"reference"`
+	Amount        string `json:"amount"`
+	Balance       string `json:"balance"`
+}
+
+// customerStatement lists a customer's invoices and payments in one currency
+// (the default currency unless asked otherwise) with a running balance. There
+// are no credit entries because credit notes are not recorded. Both ends of the date range are
+// inclusive. The statement is rendered as a PDF when format=pdf is given or
+// the client only accepts PDF.
+func (server *Server) customerStatement(c *gin.Context) {
+	var uri customerStatementUri
+	if err := c.ShouldBindUri(&uri); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	var req customerStatementRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	from, to, err := parseDateRange(req.From, req.To)
+	if err != nil {
+		respondWithError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	if req.Currency == "" {
+		req.Currency = server.config.DefaultCurrency
+	}
+
+	statement, err := server.store.GetCustomerStatement(c, db.CustomerStatementParams{
+		CustomerEmail: uri.Customer,
+		Currency:      req.Currency,
+		From:          from,
+		To:            to.AddDate(0, 0, 1),
+	})
+	if err != nil {
+		c.Error(err)
+		return
+	}
+
+	format := req.Format
+	if format == "" && c.NegotiateFormat(gin.MIMEJSON, mimePDF) == mimePDF {
+		format = "pdf"
+	}
+
+	if format == "pdf" {
+		c.Header("Content-Type", mimePDF)
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="statement-%s-%s.pdf"`, from.Format(time.DateOnly), to.Format(time.DateOnly)))
+		c.Status(http.StatusOK)
+		if err := writeStatementPDF(c.Writer, statement, to); err != nil {
+			c.Error(err)
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, generateCustomerStatementResponse(statement, to))
+}
+
+// generateCustomerStatementResponse formats a statement. to is the last day
+// covered, since the statement itself records the exclusive end of the range.
+func generateCustomerStatementResponse(statement db.CustomerStatement, to time.Time) customerStatementResponse {
+	entries := make([]customerStatementResponseEntry, len(statement.Entries))
+	for i, v := range statement.Entries {
+		entries[i] = customerStatementResponseEntry{
+			Date:          v.Date.Format(time.DateOnly),
+			Kind:          v.Kind,
+			InvoiceNumber: v.InvoiceNumber,
+			Reference:     v.Reference,
+			Amount:        money.New(v.Amount, statement.Currency).Display(),
+			Balance:       money.New(v.Balance, statement.Currency).Display(),
+		}
+	}
+
+	return customerStatementResponse{
+		CustomerEmail:  statement.CustomerEmail,
+		CustomerName:   statement.CustomerName,
+		Currency:       statement.Currency,
+		From:           statement.From.Format(time.DateOnly),
+		To:             to.Format(time.DateOnly),
+		OpeningBalance: money.New(statement.OpeningBalance, statement.Currency).Display(),
+		Entries:        entries,
+		ClosingBalance: money.New(statement.ClosingBalance, statement.Currency).Display(),
+	}
+}
+
+// writeStatementPDF renders a statement as a single table with charges and
+// payments in separate columns. Amounts are plain decimals because the core
+// PDF fonts cannot draw every currency symbol.
+func writeStatementPDF(w io.Writer, statement db.CustomerStatement, to time.Time) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	tr := pdf.UnicodeTranslatorFromDescriptor("")
+	amount := func(v int64) string {
+		return formatMajorUnits(v, statement.Currency)
+	}
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, "Statement of Account", "", 1, "L", false, 0, "")
+
+	pdf.SetFont("Helvetica", "", 10)
+	pdf.CellFormat(0, 6, tr(statement.CustomerName), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, tr(statement.CustomerEmail), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, fmt.Sprintf("Period: %s to %s", statement.From.Format(time.DateOnly), to.Format(time.DateOnly)), "", 1, "L", false, 0, "")
+	pdf.CellFormat(0, 6, "Currency: "+statement.Currency, "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	widths := []float64{25, 65, 20, 25, 25, 30}
+	row := func(cells ...string) {
+		for i, v := range cells {
+			align := "R"
+			if i < 2 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[i], 7, v, "B", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	row("Date", "Description", "Invoice", "Charges", "Payments", "Balance")
+
+	pdf.SetFont("Helvetica", "", 10)
+	row(statement.From.Format(time.DateOnly), "Opening balance", "", "", "", amount(statement.OpeningBalance))
+	for _, v := range statement.Entries {
+		description, charge, payment := "Invoice", amount(v.Amount), ""
+		if v.Kind == "payment" {
+			description, charge, payment = "Payment", "", amount(-v.Amount)
+			if v.Reference != "" {
+				description += " " + tr(v.Reference)
+			}
+		}
+		row(v.Date.Format(time.DateOnly), description, strconv.FormatInt(v.InvoiceNumber, 10), charge, payment, amount(v.Balance))
+	}
+
+	pdf.SetFont("Helvetica", "B", 10)
+	row(to.Format(time.DateOnly), "Closing balance", "", "", "", amount(statement.ClosingBalance))
+
+	return pdf.Output(w)
+}
@@ -0,0 +1,207 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestCustomerStatementAPI(t *testing.T) {
+	from := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2025, 1, 31, 0, 0, 0, 0, time.UTC)
+
+	statement := db.CustomerStatement{
+		CustomerEmail:  "ap@globex.example",
+		CustomerName:   "Globex",
+		Currency:       "USD",
+		From:           from,
+		To:             to.AddDate(0, 0, 1),
+		OpeningBalance: 5000,
+		Entries: []db.CustomerStatementEntry{
+			{
+				StatementEntry: db.StatementEntry{Kind: "invoice", Date: from.AddDate(0, 0, 4), InvoiceNumber: 12, Amount: 20000},
+				Balance:        25000,
+			},
+			{
+				StatementEntry: db.StatementEntry{Kind: "payment", Date: from.AddDate(0, 0, 19), InvoiceNumber: 9, Reference: "TRX-7", Amount: -5000},
+				Balance:        20000,
+			},
+		},
+		ClosingBalance: 20000,
+	}
+
+	testCases := []struct {
+		name          string
+		customer      string
+		query         string
+		accept        string
+		buildStubs    func(store *mockdb.MockStore)
+		checkResponse func(recorder *httptest.ResponseRecorder)
+	}{
+		{
+			name:     "OK",
+			customer: "ap@globex.example",
+			query:    "?from=2025-01-01&to=2025-01-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				arg := db.CustomerStatementParams{
+					CustomerEmail: "ap@globex.example",
+					Currency:      "USD",
+					From:          from,
+					To:            to.AddDate(0, 0, 1),
+				}
+				store.EXPECT().
+					GetCustomerStatement(gomock.Any(), gomock.Eq(arg)).
+					Times(1).
+					Return(statement, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+
+				var gotResponse customerStatementResponse
+				err := json.Unmarshal(recorder.Body.Bytes(), &gotResponse)
+				require.NoError(t, err)
+				require.Equal(t, customerStatementResponse{
+					CustomerEmail:  "ap@globex.example",
+					CustomerName:   "Globex",
+					Currency:       "USD",
+					From:           "2025-01-01",
+					To:             "2025-01-31",
+					OpeningBalance: "$50.00",
+					Entries: []customerStatementResponseEntry{
+						{"2025-01-05", "invoice", 12, "", "$200.00", "$250.00"},
+						{"2025-01-20", "payment", 9, "TRX-7", "-$50.00", "$200.00"},
+					},
+					ClosingBalance: "$200.00",
+				}, gotResponse)
+			},
+		},
+
+		{
+			name:     "PDFFormat",
+			customer: "ap@globex.example",
+			query:    "?from=2025-01-01&to=2025-01-31&currency=USD&format=pdf",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCustomerStatement(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(statement, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, mimePDF, recorder.Header().Get("Content-Type"))
+				require.Equal(t, `attachment; filename="statement-2025-01-01-2025-01-31.pdf"`, recorder.Header().Get("Content-Disposition"))
+				require.True(t, bytes.HasPrefix(recorder.Body.Bytes(), []byte("%PDF-")))
+			},
+		},
+
+		{
+			name:     "PDFAcceptHeader",
+			customer: "ap@globex.example",
+			query:    "?from=2025-01-01&to=2025-01-31",
+			accept:   mimePDF,
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCustomerStatement(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(statement, nil)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusOK, recorder.Code)
+				require.Equal(t, mimePDF, recorder.Header().Get("Content-Type"))
+				require.True(t, bytes.HasPrefix(recorder.Body.Bytes(), []byte("%PDF-")))
+			},
+		},
+
+		{
+			name:     "NotFound",
+			customer: "nobody@example.com",
+			query:    "?from=2025-01-01&to=2025-01-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCustomerStatement(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CustomerStatement{}, db.ErrNotFound)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusNotFound, recorder.Code)
+			},
+		},
+
+		{
+			name:     "InvalidCustomer",
+			customer: "42",
+			query:    "?from=2025-01-01&to=2025-01-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCustomerStatement(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:     "ToBeforeFrom",
+			customer: "ap@globex.example",
+			query:    "?from=2025-01-31&to=2025-01-01",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCustomerStatement(gomock.Any(), gomock.Any()).
+					Times(0)
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusBadRequest, recorder.Code)
+			},
+		},
+
+		{
+			name:     "InternalError",
+			customer: "ap@globex.example",
+			query:    "?from=2025-01-01&to=2025-01-31",
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().
+					GetCustomerStatement(gomock.Any(), gomock.Any()).
+					Times(1).
+					Return(db.CustomerStatement{}, &pgconn.PgError{})
+			},
+			checkResponse: func(recorder *httptest.ResponseRecorder) {
+				require.Equal(t, http.StatusInternalServerError, recorder.Code)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+
+			tc.buildStubs(store)
+
+			url := "/customers/" + tc.customer + "/statement" + tc.query
+			request, err := http.NewRequest(http.MethodGet, url, nil)
+			require.NoError(t, err)
+			if tc.accept != "" {
+				request.Header.Set("Accept", tc.accept)
+			}
+
+			recorder := httptest.NewRecorder()
+			server := newTestServer(t, store)
+
+			server.router.ServeHTTP(recorder, request)
+
+			tc.checkResponse(recorder)
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package api
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"go.uber.org/mock/gomock"
+	"google.golang.org/protobuf/proto"
+)
+
+// collector is an in-process OTLP/HTTP collector that keeps the names of the
+// spans it receives by trace ID.
+type collector struct {
+	mu    sync.Mutex
+	spans map[string][]string
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, err := io.ReadAll(r.Body)
+	if err != nil || r.URL.Path != "/v1/traces" {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	var request coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(body, &request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, resourceSpans := range request.ResourceSpans {
+		for _, scopeSpans := range resourceSpans.ScopeSpans {
+			for _, span := range scopeSpans.Spans {
+				traceID := hex.EncodeToString(span.TraceId)
+				c.spans[traceID] = append(c.spans[traceID], span.Name)
+			}
+		}
+	}
+	w.Header().Set("Content-Type", "application/x-protobuf")
+	w.WriteHeader(http.StatusOK)
+}
+
+func TestTracing(t *testing.T) {
+	collector := &collector{spans: make(map[string][]string)}
+	endpoint := httptest.NewServer(collector)
+	defer endpoint.Close()
+
+	tracerProvider, err := util.NewTracerProvider(context.Background(), endpoint.URL)
+	require.NoError(t, err)
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(tracerProvider)
+	defer otel.SetTracerProvider(previous)
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetInvoice(gomock.Any(), gomock.Eq(int64(7))).
+		Times(1).
+		Return(db.InvoiceResult{}, db.ErrNotFound)
+
+	request, err := http.NewRequest(http.MethodGet, "/invoices/7", nil)
+	require.NoError(t, err)
+
+	recorder := httptest.NewRecorder()
+	server := newTestServer(t, db.NewTracedStore(store))
+	server.router.ServeHTTP(recorder, request)
+	require.Equal(t, http.StatusNotFound, recorder.Code)
+
+	var problem problemResponse
+	err = json.Unmarshal(recorder.Body.Bytes(), &problem)
+	require.NoError(t, err)
+	require.Len(t, problem.TraceID, 32)
+
+	// Shutting down flushes the batch of spans to the collector.
+	err = tracerProvider.Shutdown(context.Background())
+	require.NoError(t, err)
+
+	collector.mu.Lock()
+	defer collector.mu.Unlock()
+	require.ElementsMatch(t, []string{"/invoices/:id", "Store.GetInvoice"}, collector.spans[problem.TraceID])
+}
@@ -2,50 +2,123 @@ package api
 
 import (
 	"regexp"
+	"strings"
 	"time"
 
+	"github.com/Rhymond/go-money"
 	"github.com/go-playground/validator/v10"
 	"github.com/kuthumipepple/numeris-book/util"
 )
 
 var ratePattern = regexp.MustCompile(`^(?:[0-9]|[1-9][0-9])(?:\.[0-9]{1,})?$`)
-var pricePattern = regexp.MustCompile(`^\d+(?:\.\d{1,2})?$`)
+
+// amountPattern matches a non-negative decimal amount. How many decimal places
+// it may have depends on its currency, which parseMinorUnits checks.
+var amountPattern = regexp.MustCompile(`^\d+(?:\.\d+)?$`)
 
 var createInvoiceRequestValidation validator.StructLevelFunc = func(sl validator.StructLevel) {
 	req := sl.Current().Interface().(createInvoiceRequest)
 
-	// Validate Status
-	validStatuses := []string{util.DRAFT, util.PENDING_PAYMENT, util.OVERDUE}
-	if !util.Contains(validStatuses, req.Status) {
-		sl.ReportError(req.Status, "Status", "status", "valid_status", "")
+	validateStatus(sl, req.Status, util.DRAFT, util.PENDING_PAYMENT, util.OVERDUE)
+
+	validateDiscountRate(sl, req.DiscountRate)
+
+	// Validate DueDate comes after IssueDate
+	validateDateRange(sl, req.IssueDate, req.DueDate, "due_date", "DueDate")
+}
+
+var createQuoteRequestValidation validator.StructLevelFunc = func(sl validator.StructLevel) {
+	req := sl.Current().Interface().(createQuoteRequest)
+
+	validateDiscountRate(sl, req.DiscountRate)
+
+	// Validate ExpiryDate comes after IssueDate
+	validateDateRange(sl, req.IssueDate, req.ExpiryDate, "expiry_date", "ExpiryDate")
+}
+
+var convertQuoteRequestValidation validator.StructLevelFunc = func(sl validator.StructLevel) {
+	req := sl.Current().Interface().(convertQuoteRequest)
+
+	validateStatus(sl, req.Status, util.DRAFT, util.PENDING_PAYMENT)
+
+	// Validate DueDate comes after IssueDate
+	validateDateRange(sl, req.IssueDate, req.DueDate, "due_date", "DueDate")
+}
+
+// validateStatus reports an error unless status is one of validStatuses.
+func validateStatus(sl validator.StructLevel, status string, validStatuses ...string) {
+	if !util.Contains(validStatuses, status) {
+		sl.ReportError(status, "status", "Status", "oneof", strings.Join(validStatuses, " "))
 	}
+}
 
-	// Validate DiscountRate
-	if !ratePattern.MatchString(req.DiscountRate) {
-		sl.ReportError(req.DiscountRate, "DiscountRate", "discount_rate", "rate_>=_0_AND_rate_<_100", "")
+// validateDiscountRate reports an error unless rate is a percentage in [0, 100).
+func validateDiscountRate(sl validator.StructLevel, rate string) {
+	if !ratePattern.MatchString(rate) {
+		sl.ReportError(rate, "discount_rate", "DiscountRate", "percentage", "")
 	}
+}
 
-	// Validate DueDate comes after IssueDate
-	issueDate, err := time.Parse("2006-01-02", req.IssueDate)
+// validateDateRange reports an error unless both dates are formatted as
+// YYYY-MM-DD and endDate comes after issueDate. endField is the JSON key of
+// endDate and endStructField the name of its struct field.
+func validateDateRange(sl validator.StructLevel, issueDate, endDate, endField, endStructField string) {
+	start, err := time.Parse("2006-01-02", issueDate)
 	if err != nil {
-		sl.ReportError(req.IssueDate, "IssueDate", "issue_date", "date_format_is_YYYY-MM-DD", "2006-01-02")
+		sl.ReportError(issueDate, "issue_date", "IssueDate", "date", "2006-01-02")
 		return
 	}
 
-	dueDate, err := time.Parse("2006-01-02", req.DueDate)
+	end, err := time.Parse("2006-01-02", endDate)
 	if err != nil {
-		sl.ReportError(req.DueDate, "DueDate", "due_date", "date_format_is_YYYY-MM-DD", "2006-01-02")
+		sl.ReportError(endDate, endField, endStructField, "date", "2006-01-02")
 		return
 	}
 
-	if !dueDate.After(issueDate) {
-		sl.ReportError(req.DueDate, "DueDate", "due_date", "duedate_is_later_than_issuedate", "IssueDate")
+	if !end.After(start) {
+		sl.ReportError(endDate, endField, endStructField, "after", "issue_date")
+	}
+}
+
+// productPriceRequestValidation reports an error if the unit price is
+// negative or has more decimal places than its currency. A currency that is
+// not valid is reported by its own field.
+var productPriceRequestValidation validator.StructLevelFunc = func(sl validator.StructLevel) {
+	req := sl.Current().Interface().(productPriceRequest)
+
+	if money.GetCurrency(req.Currency) == nil {
+		return
+	}
+	if _, err := parseMinorUnits(req.UnitPrice, req.Currency); err != nil {
+		sl.ReportError(req.UnitPrice, "unit_price", "UnitPrice", "price", "")
+	}
+}
+
+// createLineItemRequestValidation reports an error if the unit price is not a
+// non-negative amount. Whether it has too many decimal places depends on the
+// currency of the invoice, so that is checked when the line item is priced.
+// Line items that reference a product may leave the unit price blank to use
+// the product's default price.
+var createLineItemRequestValidation validator.StructLevelFunc = func(sl validator.StructLevel) {
+	req := sl.Current().Interface().(createLineItemRequest)
+
+	if req.UnitPrice == "" && req.ProductID != 0 {
+		return
+	}
+	if !amountPattern.MatchString(req.UnitPrice) {
+		sl.ReportError(req.UnitPrice, "unit_price", "UnitPrice", "price", "")
+	}
+}
+
+var recordPaymentRequestValidation validator.StructLevelFunc = func(sl validator.StructLevel) {
+	req := sl.Current().Interface().(recordPaymentRequest)
+
+	// the decimal places are checked against the currency of the invoice
+	if !amountPattern.MatchString(req.Amount) || strings.Trim(req.Amount, "0.") == "" {
+		sl.ReportError(req.Amount, "amount", "Amount", "positive_price", "")
 	}
 
-	// Validate UnitPrice is not a negative number
-	for _, item := range req.LineItems {
-		if !pricePattern.MatchString(item.UnitPrice) {
-			sl.ReportError(item.UnitPrice, "UnitPrice", "unit_price", "unitprice_is_positive_AND_unitprice_has_not_more_than_two_decimal_places", "")
-		}
+	if _, err := time.Parse("2006-01-02", req.PaidAt); err != nil {
+		sl.ReportError(req.PaidAt, "paid_at", "PaidAt", "date", "2006-01-02")
 	}
 }
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/kuthumipepple/numeris-book/api"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// client sends requests to the handlers of an api.Server in process, so that
+// commands validate and format data exactly like the API.
+type client struct {
+	server *api.Server
+	actor  string
+}
+
+// newClient connects to the database and returns a client of a server on
+// it. The returned function closes the connections.
+func (c *cli) newClient(ctx context.Context) (*client, func(), error) {
+	store, closeStore, err := c.openStore(ctx, c.config)
+	if err != nil {
+		return nil, nil, fmt.Errorf("cannot connect to db: %w", err)
+	}
+	return &client{server: api.NewServer(c.config, store, prometheus.NewRegistry()), actor: c.actor}, closeStore, nil
+}
+
+// do sends a request and writes the body of a successful response to out.
+// An error response is returned as an error that describes the problem.
+func (cl *client) do(ctx context.Context, method, target, contentType string, body io.Reader, out io.Writer) error {
+	request, err := http.NewRequestWithContext(ctx, method, target, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		request.Header.Set("Content-Type", contentType)
+	}
+	request.Header.Set("X-Actor", cl.actor)
+
+	w := &responseWriter{header: make(http.Header), out: out}
+	cl.server.ServeHTTP(w, request)
+	if w.status >= http.StatusBadRequest {
+		return problemError(w.status, w.problem.Bytes())
+	}
+	return nil
+}
+
+// doJSON sends a request and writes the JSON of a successful response to out,
+// indented.
+func (cl *client) doJSON(ctx context.Context, method, target, contentType string, body io.Reader, out io.Writer) error {
+	var buf bytes.Buffer
+	if err := cl.do(ctx, method, target, contentType, body, &buf); err != nil {
+		return err
+	}
+	var indented bytes.Buffer
+	if err := json.Indent(&indented, buf.Bytes(), "", "  "); err != nil {
+		return err
+	}
+	indented.WriteByte('\n')
+	_, err := indented.WriteTo(out)
+	return err
+}
+
+// problemError describes a problem document, with the fields that failed
+// validation or the broken rules on lines of their own.
+func problemError(status int, body []byte) error {
+	var problem struct {
+		Title  string `json:"title"`
+		Detail string `json:"detail"`
+		Errors []struct {
+			Field   string `json:"field"`
+			Message string `json:"message"`
+		} `json:"errors"`
+		Violations []struct {
+			Rule    string `json:"rule"`
+			Message string `json:"message"`
+		} `json:"violations"`
+	}
+	if err := json.Unmarshal(body, &problem); err != nil || problem.Title == "" {
+		return fmt.Errorf("request failed with status %d", status)
+	}
+
+	// The detail of a validation problem repeats its list of errors.
+	var sb strings.Builder
+	sb.WriteString(problem.Title)
+	if problem.Detail != "" && len(problem.Errors) == 0 && len(problem.Violations) == 0 {
+		sb.WriteString(": " + problem.Detail)
+	}
+	for _, v := range problem.Errors {
+		fmt.Fprintf(&sb, "\n  %s: %s", v.Field, v.Message)
+	}
+	for _, v := range problem.Violations {
+		fmt.Fprintf(&sb, "\n  %s: %s", v.Rule, v.Message)
+	}
+	return fmt.Errorf("%s", sb.String())
+}
+
+// responseWriter writes the body of a successful response to out and keeps
+// the body of an error response, which is a problem document.
+type responseWriter struct {
+	header  http.Header
+	status  int
+	out     io.Writer
+	problem bytes.Buffer
+}
+
+func (w *responseWriter) Header() http.Header {
+	return w.header
+}
+
+func (w *responseWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+}
+
+func (w *responseWriter) Write(p []byte) (int, error) {
+	w.WriteHeader(http.StatusOK)
+	if w.status >= http.StatusBadRequest {
+		return w.problem.Write(p)
+	}
+	return w.out.Write(p)
+}
+
+// Flush is called by streaming handlers. Writes go straight to out, so there
+// is nothing to flush.
+func (w *responseWriter) Flush() {}
@@ -0,0 +1,172 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+func (c *cli) invoiceCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "invoice",
+		Short: "Inspect and create invoices",
+	}
+	cmd.AddCommand(
+		c.invoiceGetCommand(),
+		c.invoiceListCommand(),
+		c.invoiceCreateFromFileCommand(),
+	)
+	return cmd
+}
+
+func (c *cli) invoiceGetCommand() *cobra.Command {
+	var format, output string
+	cmd := &cobra.Command{
+		Use:   "get <invoice-number>",
+		Short: "Print an invoice as JSON, or write it as a Factur-X PDF or UBL XML",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			id, err := strconv.ParseInt(args[0], 10, 64)
+			if err != nil {
+				return fmt.Errorf("invoice number must be an integer: %q", args[0])
+			}
+
+			cl, closeStore, err := c.newClient(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			out, closeOutput, err := openOutput(cmd, output)
+			if err != nil {
+				return err
+			}
+			defer closeOutput()
+
+			switch format {
+			case "json":
+				return cl.doJSON(cmd.Context(), http.MethodGet, fmt.Sprintf("/invoices/%d", id), "", nil, out)
+			case "pdf":
+				return cl.do(cmd.Context(), http.MethodGet, fmt.Sprintf("/invoices/%d?format=pdf", id), "", nil, out)
+			case "ubl":
+				return cl.do(cmd.Context(), http.MethodGet, fmt.Sprintf("/invoices/%d/ubl", id), "", nil, out)
+			default:
+				return fmt.Errorf("format must be json, pdf or ubl: %q", format)
+			}
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "json", "json, pdf or ubl")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "file to write to instead of stdout")
+	return cmd
+}
+
+func (c *cli) invoiceListCommand() *cobra.Command {
+	var filter invoiceFilterFlags
+	var page, pageSize int
+	cmd := &cobra.Command{
+		Use:   "list",
+		Short: "Print a page of invoices, newest first",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cl, closeStore, err := c.newClient(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			query := filter.query()
+			query.Set("page_id", strconv.Itoa(page))
+			query.Set("page_size", strconv.Itoa(pageSize))
+			return cl.doJSON(cmd.Context(), http.MethodGet, "/invoices?"+query.Encode(), "", nil, cmd.OutOrStdout())
+		},
+	}
+	filter.register(cmd)
+	cmd.Flags().IntVar(&page, "page", 1, "page to print, from 1")
+	cmd.Flags().IntVar(&pageSize, "page-size", 20, "invoices per page, at most 100")
+	return cmd
+}
+
+func (c *cli) invoiceCreateFromFileCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "create-from-file <file>",
+		Short: "Create an invoice from a JSON file in the format of POST /invoices, or - for stdin",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in, closeInput, err := openInput(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			defer closeInput()
+
+			cl, closeStore, err := c.newClient(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			return cl.doJSON(cmd.Context(), http.MethodPost, "/invoices", "application/json", in, cmd.OutOrStdout())
+		},
+	}
+}
+
+// invoiceFilterFlags are the flags of the commands that select invoices, named
+// after the query parameters of the API.
+type invoiceFilterFlags struct {
+	customerEmail string
+	status        string
+	currency      string
+	issuedFrom    string
+	issuedTo      string
+}
+
+func (f *invoiceFilterFlags) register(cmd *cobra.Command) {
+	cmd.Flags().StringVar(&f.customerEmail, "customer-email", "", "only invoices to this customer")
+	cmd.Flags().StringVar(&f.status, "status", "", "only invoices with this status")
+	cmd.Flags().StringVar(&f.currency, "currency", "", "only invoices billed in this currency")
+	cmd.Flags().StringVar(&f.issuedFrom, "issued-from", "", "only invoices issued on or after this date (YYYY-MM-DD)")
+	cmd.Flags().StringVar(&f.issuedTo, "issued-to", "", "only invoices issued on or before this date (YYYY-MM-DD)")
+}
+
+func (f *invoiceFilterFlags) query() url.Values {
+	query := url.Values{}
+	set := func(key, value string) {
+		if value != "" {
+			query.Set(key, value)
+		}
+	}
+	set("customer_email", f.customerEmail)
+	set("status", f.status)
+	set("currency", f.currency)
+	set("issued_from", f.issuedFrom)
+	set("issued_to", f.issuedTo)
+	return query
+}
+
+// openInput opens the file at path, or stdin if path is -.
+func openInput(cmd *cobra.Command, path string) (io.Reader, func(), error) {
+	if path == "-" {
+		return cmd.InOrStdin(), func() {}, nil
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func() { f.Close() }, nil
+}
+
+// openOutput creates the file at path, or returns stdout if path is empty.
+func openOutput(cmd *cobra.Command, path string) (io.Writer, func(), error) {
+	if path == "" {
+		return cmd.OutOrStdout(), func() {}, nil
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return f, func() { f.Close() }, nil
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestInvoiceGetCommand(t *testing.T) {
+	invoice := db.InvoiceResult{
+		Invoice: db.Invoice{
+			InvoiceNumber:   42,
+			CustomerName:    util.RandomName(),
+			IssueDate:       time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			DueDate:         time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+			Status:          util.PENDING_PAYMENT,
+			BillingCurrency: "USD",
+			TotalAmount:     12550,
+		},
+		LineItems: []db.LineItem{},
+	}
+
+	testCases := []struct {
+		name       string
+		args       []string
+		buildStubs func(store *mockdb.MockStore)
+		check      func(t *testing.T, stdout string, err error)
+	}{
+		{
+			name: "OK",
+			args: []string{"invoice", "get", "42"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Eq(int64(42))).Times(1).Return(invoice, nil)
+			},
+			check: func(t *testing.T, stdout string, err error) {
+				require.NoError(t, err)
+				var rsp map[string]any
+				require.NoError(t, json.Unmarshal([]byte(stdout), &rsp))
+				require.Equal(t, float64(42), rsp["invoice_number"])
+				require.Equal(t, "$125.50", rsp["total_amount"])
+				require.Equal(t, "2025-03-31", rsp["due_date"])
+			},
+		},
+		{
+			name: "NotFound",
+			args: []string{"invoice", "get", "42"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Eq(int64(42))).Times(1).Return(db.InvoiceResult{}, db.ErrNotFound)
+			},
+			check: func(t *testing.T, stdout string, err error) {
+				require.EqualError(t, err, "Not Found: record not found")
+				require.Empty(t, stdout)
+			},
+		},
+		{
+			name: "InvalidNumber",
+			args: []string{"invoice", "get", "forty-two"},
+			buildStubs: func(store *mockdb.MockStore) {
+				store.EXPECT().GetInvoice(gomock.Any(), gomock.Any()).Times(0)
+			},
+			check: func(t *testing.T, stdout string, err error) {
+				require.EqualError(t, err, `invoice number must be an integer: "forty-two"`)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			store := mockdb.NewMockStore(ctrl)
+			tc.buildStubs(store)
+
+			stdout, err := runCommand(t, store, "", tc.args...)
+			tc.check(t, stdout, err)
+		})
+	}
+}
+
+func TestInvoiceCreateFromFileCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateInvoiceTx(gomock.Any(), gomock.Any()).Times(0)
+
+	// The invoice is validated like a POST /invoices body.
+	body := `{"customer_name": "Globex", "customer_email": "not an email", "line_items": []}`
+	_, err := runCommand(t, store, body, "invoice", "create-from-file", "-")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "Request validation failed\n")
+	require.Contains(t, err.Error(), "\n  customer_email: ")
+}
+
+func TestActorFlag(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		GetInvoice(gomock.Any(), gomock.Eq(int64(42))).
+		Times(1).
+		DoAndReturn(func(ctx context.Context, id int64) (db.InvoiceResult, error) {
+			require.Equal(t, "ops@example.com", util.Actor(ctx))
+			return db.InvoiceResult{}, db.ErrNotFound
+		})
+
+	_, err := runCommand(t, store, "", "--actor", "ops@example.com", "invoice", "get", "42")
+	require.Error(t, err)
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/spf13/cobra"
+)
+
+// Callers of the API are authenticated by the proxy in front of it, so the
+// only key that the API itself holds is the one that signs exports of the
+// invoice chain.
+func (c *cli) rotateAPIKeyCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate-api-key",
+		Short: "Replace the key that the API signs exports of the invoice chain with",
+		Long: "Generates a new Ed25519 key in the file named by CHAIN_SIGNING_KEY_FILE, replacing the old key, " +
+			"and prints the public key that exports signed from now on can be verified with. " +
+			"The server reads the key for each export, so it does not need a restart. " +
+			"Keep the old public key to verify exports signed before the rotation.",
+		Args: cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			path := c.config.ChainSigningKeyFile
+			if path == "" {
+				return errors.New("CHAIN_SIGNING_KEY_FILE is not set")
+			}
+
+			public, private, err := ed25519.GenerateKey(rand.Reader)
+			if err != nil {
+				return err
+			}
+			if err := writeSigningKey(path, private); err != nil {
+				return err
+			}
+
+			der, err := x509.MarshalPKIXPublicKey(public)
+			if err != nil {
+				return err
+			}
+			return pem.Encode(cmd.OutOrStdout(), &pem.Block{Type: "PUBLIC KEY", Bytes: der})
+		},
+	}
+}
+
+// writeSigningKey writes key to path as a PKCS #8 PEM file that only its
+// owner can read. The key is written to a temporary file that is renamed over
+// the old one, so that the server never reads a partly written key.
+func writeSigningKey(path string, key ed25519.PrivateKey) error {
+	der, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.CreateTemp(filepath.Dir(path), ".signing-key-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if err := f.Chmod(0o600); err != nil {
+		f.Close()
+		return err
+	}
+	if err := pem.Encode(f, &pem.Block{Type: "PRIVATE KEY", Bytes: der}); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	return os.Rename(f.Name(), path)
+}
@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotateAPIKeyCommand(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "chain.pem")
+	t.Setenv("CHAIN_SIGNING_KEY_FILE", path)
+
+	rotate := func() ed25519.PublicKey {
+		stdout, err := runCommand(t, nil, "", "rotate-api-key")
+		require.NoError(t, err)
+
+		block, _ := pem.Decode([]byte(stdout))
+		require.NotNil(t, block)
+		require.Equal(t, "PUBLIC KEY", block.Type)
+		public, err := x509.ParsePKIXPublicKey(block.Bytes)
+		require.NoError(t, err)
+
+		info, err := os.Stat(path)
+		require.NoError(t, err)
+		require.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		block, _ = pem.Decode(data)
+		require.NotNil(t, block)
+		private, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		require.NoError(t, err)
+
+		require.IsType(t, ed25519.PrivateKey{}, private)
+		require.Equal(t, public, private.(ed25519.PrivateKey).Public())
+		return public.(ed25519.PublicKey)
+	}
+
+	// the first rotation creates the key
+	first := rotate()
+	second := rotate()
+	require.NotEqual(t, first, second)
+
+	entries, err := os.ReadDir(filepath.Dir(path))
+	require.NoError(t, err)
+	require.Len(t, entries, 1)
+}
+
+func TestRotateAPIKeyCommandWithoutKeyFile(t *testing.T) {
+	t.Setenv("CHAIN_SIGNING_KEY_FILE", "")
+
+	_, err := runCommand(t, nil, "", "rotate-api-key")
+	require.EqualError(t, err, "CHAIN_SIGNING_KEY_FILE is not set")
+}
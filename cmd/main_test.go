@@ -0,0 +1,36 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/kuthumipepple/numeris-book/db"
+	"github.com/kuthumipepple/numeris-book/util"
+)
+
+// runCommand runs the command line args on store, with the app.env of the
+// repository, and returns what it wrote to stdout.
+func runCommand(t *testing.T, store db.Store, stdin string, args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+	c := &cli{
+		stdin:  strings.NewReader(stdin),
+		stdout: &stdout,
+		stderr: &stderr,
+		openStore: func(ctx context.Context, config util.Config) (db.Store, func(), error) {
+			return store, func() {}, nil
+		},
+	}
+	root := c.rootCommand()
+	root.SetArgs(append([]string{"--config", ".."}, args...))
+	err := root.Execute()
+	return stdout.String(), err
+}
+
+func TestMain(m *testing.M) {
+	gin.SetMode(gin.TestMode)
+	os.Exit(m.Run())
+}
@@ -0,0 +1,122 @@
+package cmd
+
+import (
+	"fmt"
+	"log/slog"
+	"strconv"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kuthumipepple/numeris-book/db"
+	"github.com/spf13/cobra"
+)
+
+func (c *cli) migrateCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "migrate",
+		Short: "Migrate the database schema with the migrations built into the binary",
+	}
+
+	cmd.AddCommand(
+		&cobra.Command{
+			Use:   "up",
+			Short: "Apply every pending migration",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.migrate(cmd, func(m *db.Migrator) error {
+					return m.Up()
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "down [n]",
+			Short: "Revert the last n migrations, one by default",
+			Args:  cobra.MaximumNArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				steps := 1
+				if len(args) == 1 {
+					var err error
+					steps, err = strconv.Atoi(args[0])
+					if err != nil || steps < 1 {
+						return fmt.Errorf("number of migrations to revert must be a positive integer: %q", args[0])
+					}
+				}
+				return c.migrate(cmd, func(m *db.Migrator) error {
+					return m.Down(steps)
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "status",
+			Short: "Print the version of the schema and the latest version available",
+			Args:  cobra.NoArgs,
+			RunE: func(cmd *cobra.Command, args []string) error {
+				return c.migrate(cmd, func(m *db.Migrator) error {
+					return nil
+				})
+			},
+		},
+		&cobra.Command{
+			Use:   "force <version>",
+			Short: "Set the version of a dirty schema after fixing it by hand",
+			Args:  cobra.ExactArgs(1),
+			RunE: func(cmd *cobra.Command, args []string) error {
+				version, err := strconv.Atoi(args[0])
+				if err != nil || version < 0 {
+					return fmt.Errorf("version must be a non-negative integer: %q", args[0])
+				}
+				return c.migrate(cmd, func(m *db.Migrator) error {
+					return m.Force(version)
+				})
+			},
+		},
+	)
+	return cmd
+}
+
+// migrate runs fn with a Migrator on the configured database and prints the
+// status of the schema afterwards.
+func (c *cli) migrate(cmd *cobra.Command, fn func(*db.Migrator) error) error {
+	connPool, err := pgxpool.New(cmd.Context(), c.config.DBSource)
+	if err != nil {
+		return fmt.Errorf("cannot connect to db: %w", err)
+	}
+	defer connPool.Close()
+
+	migrator, err := db.NewMigrator(connPool)
+	if err != nil {
+		return fmt.Errorf("cannot create migrator: %w", err)
+	}
+	defer migrator.Close()
+
+	if err := fn(migrator); err != nil {
+		return err
+	}
+
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "version: %d\nlatest: %d\ndirty: %t\n", status.Version, status.Latest, status.Dirty)
+	return nil
+}
+
+// migrateOnStart applies the pending migrations before the server starts.
+// Replicas that start together take turns through the advisory lock of the
+// Migrator.
+func migrateOnStart(connPool *pgxpool.Pool) error {
+	migrator, err := db.NewMigrator(connPool)
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	if err := migrator.Up(); err != nil {
+		return err
+	}
+	status, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	slog.Info("database is migrated", slog.Int64("version", status.Version))
+	return nil
+}
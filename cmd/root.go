@@ -0,0 +1,114 @@
+// Package cmd is the command line of the service: the server itself and the
+// commands operators use to migrate the database and to inspect and fix its
+// data without writing SQL.
+package cmd
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"os"
+	"os/user"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kuthumipepple/numeris-book/db"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/spf13/cobra"
+)
+
+// cli holds what the commands share. The config is loaded before any command
+// runs.
+type cli struct {
+	configPath string
+	config     util.Config
+	stdin      io.Reader
+	stdout     io.Writer
+	stderr     io.Writer
+	// actor names the operator in the audit log of the changes that
+	// commands make.
+	actor string
+	// openStore connects to the database. The returned function closes the
+	// connections.
+	openStore func(ctx context.Context, config util.Config) (db.Store, func(), error)
+}
+
+// Execute runs the command named by the arguments of the process and exits
+// with status 1 if it fails.
+func Execute() {
+	c := &cli{
+		stdin:     os.Stdin,
+		stdout:    os.Stdout,
+		stderr:    os.Stderr,
+		openStore: openStore,
+	}
+	if err := c.rootCommand().Execute(); err != nil {
+		os.Exit(1)
+	}
+}
+
+func (c *cli) rootCommand() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "numeris-book",
+		Short: "Invoicing service and its administrative commands",
+		// Usage is only printed for mistakes on the command line, not for
+		// commands that fail.
+		SilenceUsage: true,
+		PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
+			return c.setup()
+		},
+	}
+	root.SetIn(c.stdin)
+	root.SetOut(c.stdout)
+	root.SetErr(c.stderr)
+	root.PersistentFlags().StringVar(&c.configPath, "config", ".", "directory of the app.env file")
+	root.PersistentFlags().StringVar(&c.actor, "actor", defaultActor(), "who to record in the audit log as making the changes")
+
+	root.AddCommand(
+		c.serveCommand(),
+		c.migrateCommand(),
+		c.invoiceCommand(),
+		c.exportCommand(),
+		c.importCommand(),
+		c.seedCommand(),
+		c.rotateAPIKeyCommand(),
+	)
+	return root
+}
+
+// setup loads the config and logs warnings and errors to stderr, so that the
+// output of a command can be piped and is not mixed with a log line for each
+// request it makes. The server logs to stdout at the configured level.
+func (c *cli) setup() error {
+	config, err := util.LoadConfig(c.configPath)
+	if err != nil {
+		return err
+	}
+	c.config = config
+
+	return c.setupLogger(c.stderr, "warn")
+}
+
+func (c *cli) setupLogger(w io.Writer, level string) error {
+	logger, err := util.NewLogger(w, level)
+	if err != nil {
+		return err
+	}
+	slog.SetDefault(logger)
+	return nil
+}
+
+// defaultActor names the user running the command.
+func defaultActor() string {
+	if u, err := user.Current(); err == nil {
+		return "cli:" + u.Username
+	}
+	return "cli"
+}
+
+func openStore(ctx context.Context, config util.Config) (db.Store, func(), error) {
+	connPool, err := pgxpool.New(ctx, config.DBSource)
+	if err != nil {
+		return nil, nil, err
+	}
+	return db.NewStore(connPool), connPool.Close, nil
+}
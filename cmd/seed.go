@@ -0,0 +1,121 @@
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/spf13/cobra"
+)
+
+func (c *cli) seedCommand() *cobra.Command {
+	var products, invoices int
+	cmd := &cobra.Command{
+		Use:   "seed",
+		Short: "Fill the database with random products and invoices for development",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if products < 1 {
+				return errors.New("at least one product is needed to seed invoices")
+			}
+			if invoices < 0 {
+				return errors.New("number of invoices must not be negative")
+			}
+
+			cl, closeStore, err := c.newClient(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			productIDs := make([]int64, products)
+			for i := range productIDs {
+				var rsp struct {
+					ID int64 `json:"id"`
+				}
+				if err := cl.post(cmd.Context(), "/products", randomProductRequest(), &rsp); err != nil {
+					return fmt.Errorf("cannot create product: %w", err)
+				}
+				productIDs[i] = rsp.ID
+			}
+
+			for i := 0; i < invoices; i++ {
+				if err := cl.post(cmd.Context(), "/invoices", randomInvoiceRequest(productIDs), nil); err != nil {
+					return fmt.Errorf("cannot create invoice: %w", err)
+				}
+			}
+
+			fmt.Fprintf(cmd.OutOrStdout(), "created %d products and %d invoices\n", products, invoices)
+			return nil
+		},
+	}
+	cmd.Flags().IntVar(&products, "products", 5, "number of products to create")
+	cmd.Flags().IntVar(&invoices, "invoices", 20, "number of invoices to create")
+	return cmd
+}
+
+// post sends body as JSON and decodes the response into rsp unless it is
+// nil.
+func (cl *client) post(ctx context.Context, target string, body any, rsp any) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := cl.do(ctx, http.MethodPost, target, "application/json", bytes.NewReader(data), &buf); err != nil {
+		return err
+	}
+	if rsp == nil {
+		return nil
+	}
+	return json.Unmarshal(buf.Bytes(), rsp)
+}
+
+func randomProductRequest() map[string]any {
+	return map[string]any{
+		"sku":         util.RandomString(8),
+		"name":        util.RandomString(10),
+		"description": util.RandomString(20),
+		"unit":        "hour",
+		"prices": []map[string]any{
+			{"currency": "USD", "unit_price": fmt.Sprintf("%d.%02d", util.RandomInt(10, 500), util.RandomInt(0, 99))},
+		},
+	}
+}
+
+// seedStatuses are the statuses an invoice can be created with.
+var seedStatuses = []string{util.DRAFT, util.PENDING_PAYMENT, util.OVERDUE}
+
+// randomInvoiceRequest returns an invoice issued in the last year with up to
+// five line items priced from the products.
+func randomInvoiceRequest(productIDs []int64) map[string]any {
+	issueDate := time.Now().AddDate(0, 0, -int(util.RandomInt(0, 365)))
+	lineItems := make([]map[string]any, util.RandomInt(1, 5))
+	for i := range lineItems {
+		lineItems[i] = map[string]any{
+			"product_id": productIDs[util.RandomInt(0, int64(len(productIDs)-1))],
+			"quantity":   util.RandomInt(1, 10),
+		}
+	}
+	return map[string]any{
+		"customer_name":    util.RandomName(),
+		"customer_email":   util.RandomEmail(),
+		"customer_phone":   util.RandomPhone(),
+		"customer_address": util.RandomAddress(),
+		"sender_name":      util.RandomName(),
+		"sender_email":     util.RandomEmail(),
+		"sender_phone":     util.RandomPhone(),
+		"sender_address":   util.RandomAddress(),
+		"issue_date":       issueDate.Format(time.DateOnly),
+		"due_date":         issueDate.AddDate(0, 0, 30).Format(time.DateOnly),
+		"status":           seedStatuses[util.RandomInt(0, int64(len(seedStatuses)-1))],
+		"discount_rate":    fmt.Sprint(util.RandomInt(0, 10)),
+		"payment_info":     "Bank transfer",
+		"line_items":       lineItems,
+	}
+}
@@ -0,0 +1,120 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/Rhymond/go-money"
+	"github.com/jackc/pgx/v5/multitracer"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/kuthumipepple/numeris-book/api"
+	"github.com/kuthumipepple/numeris-book/db"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/spf13/cobra"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+)
+
+func (c *cli) serveCommand() *cobra.Command {
+	return &cobra.Command{
+		Use:   "serve",
+		Short: "Serve the API until SIGINT or SIGTERM",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return c.serve()
+		},
+	}
+}
+
+func (c *cli) serve() error {
+	config := c.config
+	if err := c.setupLogger(c.stdout, config.LogLevel); err != nil {
+		return err
+	}
+	if money.GetCurrency(config.DefaultCurrency) == nil {
+		return fmt.Errorf("DEFAULT_CURRENCY %q is not an ISO 4217 currency code", config.DefaultCurrency)
+	}
+
+	if config.OTLPEndpoint != "" {
+		tracerProvider, err := util.NewTracerProvider(context.Background(), config.OTLPEndpoint)
+		if err != nil {
+			return fmt.Errorf("cannot create tracer provider: %w", err)
+		}
+		defer func() {
+			if err := tracerProvider.Shutdown(context.Background()); err != nil {
+				slog.Error("cannot flush traces", slog.String("error", err.Error()))
+			}
+		}()
+		otel.SetTracerProvider(tracerProvider)
+		otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+			propagation.TraceContext{},
+			propagation.Baggage{},
+		))
+	}
+
+	poolConfig, err := pgxpool.ParseConfig(config.DBSource)
+	if err != nil {
+		return fmt.Errorf("cannot parse db source: %w", err)
+	}
+	poolConfig.ConnConfig.Tracer = multitracer.New(
+		db.NewQueryTracer(slog.Default(), config.SlowQueryThreshold),
+		db.NewSpanTracer(),
+	)
+
+	connPool, err := pgxpool.NewWithConfig(context.Background(), poolConfig)
+	if err != nil {
+		return fmt.Errorf("cannot connect to db: %w", err)
+	}
+	defer connPool.Close()
+
+	if config.MigrateOnStart {
+		if err := migrateOnStart(connPool); err != nil {
+			return fmt.Errorf("cannot migrate db: %w", err)
+		}
+	}
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		db.NewPoolCollector(connPool),
+	)
+
+	store := db.NewTracedStore(db.NewStore(connPool))
+	apiServer := api.NewServer(config, store, registry)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	serverErr := make(chan error, 1)
+	go func() {
+		slog.Info("starting server", slog.String("address", config.HTTPServerAddress))
+		serverErr <- apiServer.Start(config.HTTPServerAddress)
+	}()
+
+	select {
+	case err = <-serverErr:
+		return fmt.Errorf("cannot start server: %w", err)
+	case <-ctx.Done():
+		// A second signal kills the process without waiting.
+		stop()
+	}
+
+	// Drain the requests in flight before the pool they use is closed. The
+	// tracer provider is shut down last, so that it exports the spans of the
+	// drained requests.
+	slog.Info("shutting down server", slog.Duration("timeout", config.ShutdownTimeout))
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), config.ShutdownTimeout)
+	defer cancel()
+	if err := apiServer.Shutdown(shutdownCtx); err != nil {
+		slog.Error("cannot drain requests", slog.String("error", err.Error()))
+	}
+	slog.Info("server stopped")
+	return nil
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+
+	"github.com/spf13/cobra"
+)
+
+func (c *cli) exportCommand() *cobra.Command {
+	var filter invoiceFilterFlags
+	var format, output string
+	var lineItems bool
+	cmd := &cobra.Command{
+		Use:   "export",
+		Short: "Export invoices as CSV or XLSX, like GET /exports/invoices",
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format != "csv" && format != "xlsx" {
+				return fmt.Errorf("format must be csv or xlsx: %q", format)
+			}
+
+			cl, closeStore, err := c.newClient(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			out, closeOutput, err := openOutput(cmd, output)
+			if err != nil {
+				return err
+			}
+			defer closeOutput()
+
+			query := filter.query()
+			query.Set("format", format)
+			query.Set("line_items", strconv.FormatBool(lineItems))
+			return cl.do(cmd.Context(), http.MethodGet, "/exports/invoices?"+query.Encode(), "", nil, out)
+		},
+	}
+	filter.register(cmd)
+	cmd.Flags().StringVar(&format, "format", "csv", "csv or xlsx")
+	cmd.Flags().BoolVar(&lineItems, "line-items", false, "write one row per line item")
+	cmd.Flags().StringVarP(&output, "output", "o", "", "file to write to instead of stdout")
+	return cmd
+}
+
+func (c *cli) importCommand() *cobra.Command {
+	var format string
+	var dryRun bool
+	cmd := &cobra.Command{
+		Use:   "import <file>",
+		Short: "Import invoices from a CSV or JSON Lines file, like POST /imports, and print the report",
+		Long: "Import invoices from a CSV or JSON Lines file, like POST /imports, and print the report.\n" +
+			"The format is taken from the extension of the file unless --format is given.",
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if format == "" {
+				switch filepath.Ext(args[0]) {
+				case ".csv":
+					format = "csv"
+				case ".jsonl", ".ndjson":
+					format = "jsonl"
+				default:
+					return fmt.Errorf("cannot tell the format of %q; use --format", args[0])
+				}
+			}
+
+			in, closeInput, err := openInput(cmd, args[0])
+			if err != nil {
+				return err
+			}
+			defer closeInput()
+
+			cl, closeStore, err := c.newClient(cmd.Context())
+			if err != nil {
+				return err
+			}
+			defer closeStore()
+
+			query := url.Values{}
+			query.Set("format", format)
+			query.Set("dry_run", strconv.FormatBool(dryRun))
+			return cl.doJSON(cmd.Context(), http.MethodPost, "/imports?"+query.Encode(), "", in, cmd.OutOrStdout())
+		},
+	}
+	cmd.Flags().StringVar(&format, "format", "", "csv or jsonl")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "validate the file without creating invoices")
+	return cmd
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/kuthumipepple/numeris-book/db"
+	mockdb "github.com/kuthumipepple/numeris-book/db/mock"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+)
+
+func TestExportCommand(t *testing.T) {
+	invoice := db.Invoice{
+		InvoiceNumber:   7,
+		IssueDate:       time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+		DueDate:         time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+		Status:          util.PAID,
+		CustomerEmail:   "ops@globex.com",
+		BillingCurrency: "USD",
+		TotalAmount:     12550,
+	}
+
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().
+		StreamInvoices(gomock.Any(), gomock.Any(), gomock.Any()).
+		Times(1).
+		DoAndReturn(func(_ any, filter db.InvoiceFilter, fn func(db.Invoice) error) error {
+			require.Equal(t, "paid", filter.Status.String)
+			return fn(invoice)
+		})
+
+	output := filepath.Join(t.TempDir(), "invoices.csv")
+	_, err := runCommand(t, store, "", "export", "--status", "paid", "-o", output)
+	require.NoError(t, err)
+
+	f, err := os.Open(output)
+	require.NoError(t, err)
+	defer f.Close()
+	records, err := csv.NewReader(f).ReadAll()
+	require.NoError(t, err)
+	require.Len(t, records, 2)
+	require.Equal(t, "invoice_number", records[0][0])
+	require.Equal(t, "7", records[1][0])
+}
+
+func TestImportCommand(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	store := mockdb.NewMockStore(ctrl)
+	store.EXPECT().CreateInvoicesTx(gomock.Any(), gomock.Any()).Times(0)
+
+	input := filepath.Join(t.TempDir(), "invoices.jsonl")
+	err := os.WriteFile(input, []byte("{\"customer_name\": \"Globex\"}\nnot json\n"), 0o600)
+	require.NoError(t, err)
+
+	stdout, err := runCommand(t, store, "", "import", "--dry-run", input)
+	require.NoError(t, err)
+
+	var report struct {
+		DryRun bool `json:"dry_run"`
+		Total  int  `json:"total"`
+		Valid  int  `json:"valid"`
+		Errors []struct {
+			Row int `json:"row"`
+		} `json:"errors"`
+	}
+	require.NoError(t, json.Unmarshal([]byte(stdout), &report))
+	require.True(t, report.DryRun)
+	require.Equal(t, 2, report.Total)
+	require.Zero(t, report.Valid)
+	require.Len(t, report.Errors, 2)
+
+	_, err = runCommand(t, store, "", "import", "invoices.txt")
+	require.EqualError(t, err, `cannot tell the format of "invoices.txt"; use --format`)
+}
@@ -0,0 +1,29 @@
+package db
+
+import "context"
+
+const InsertInvoiceAttachmentQuery = `
+	INSERT INTO invoice_attachments (
+		invoice_number, filename, content_type, content
+	) VALUES (
+	 $1, $2, $3, $4
+	) RETURNING *;
+`
+
+type InsertInvoiceAttachmentParams struct {
+	InvoiceNumber int64  `json:"invoice_number"`
+	Filename      string `json:"filename"`
+	ContentType   string `json:"content_type"`
+	Content       []byte `json:"content"`
+}
+
+func (q *Queries) InsertInvoiceAttachment(ctx context.Context, arg InsertInvoiceAttachmentParams) (InvoiceAttachment, error) {
+	row := q.db.QueryRow(ctx, InsertInvoiceAttachmentQuery,
+		arg.InvoiceNumber, arg.Filename, arg.ContentType, arg.Content,
+	)
+	var a InvoiceAttachment
+	err := row.Scan(
+		&a.ID, &a.InvoiceNumber, &a.Filename, &a.ContentType, &a.Content, &a.CreatedAt,
+	)
+	return a, err
+}
@@ -0,0 +1,199 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/util"
+)
+
+// Types of the entities that audit events are about.
+const (
+	EntityInvoice = "invoice"
+	EntityQuote   = "quote"
+	EntityProduct = "product"
+)
+
+// Actions that audit events record.
+const (
+	ActionCreate        = "create"
+	ActionImport        = "import"
+	ActionConvert       = "convert"
+	ActionUpdate        = "update"
+	ActionUpdateStatus  = "update_status"
+	ActionRecordPayment = "record_payment"
+	ActionDelete        = "delete"
+)
+
+// AuditEvent records a change to an entity. Before and After hold the fields
+// of the entity that the change set, as they were and as they became; Before
+// is null for a creation and After for a deletion.
+type AuditEvent struct {
+	ID         int64           `json:"id"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   int64           `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestID  string          `json:"request_id"`
+}
+
+const InsertAuditEventQuery = `
+	INSERT INTO audit_events (
+		actor, action, entity_type, entity_id, before, after, request_id
+	) VALUES (
+	 $1, $2, $3, $4, $5, $6, $7
+	) RETURNING *;
+`
+
+type InsertAuditEventParams struct {
+	Actor      string          `json:"actor"`
+	Action     string          `json:"action"`
+	EntityType string          `json:"entity_type"`
+	EntityID   int64           `json:"entity_id"`
+	Before     json.RawMessage `json:"before"`
+	After      json.RawMessage `json:"after"`
+	RequestID  string          `json:"request_id"`
+}
+
+func (q *Queries) InsertAuditEvent(ctx context.Context, arg InsertAuditEventParams) (AuditEvent, error) {
+	row := q.db.QueryRow(ctx, InsertAuditEventQuery,
+		arg.Actor, arg.Action, arg.EntityType, arg.EntityID,
+		[]byte(arg.Before), []byte(arg.After), arg.RequestID,
+	)
+	return scanAuditEvent(row)
+}
+
+// AuditEventFilter selects audit events. Unset fields match every event. To
+// is exclusive.
+type AuditEventFilter struct {
+	Actor      pgtype.Text        `json:"actor"`
+	Action     pgtype.Text        `json:"action"`
+	EntityType pgtype.Text        `json:"entity_type"`
+	EntityID   pgtype.Int8        `json:"entity_id"`
+	RequestID  pgtype.Text        `json:"request_id"`
+	From       pgtype.Timestamptz `json:"from"`
+	To         pgtype.Timestamptz `json:"to"`
+}
+
+const ListAuditEventsQuery = `
+	SELECT * FROM audit_events
+	WHERE ($1::varchar IS NULL OR actor = $1)
+		AND ($2::varchar IS NULL OR action = $2)
+		AND ($3::varchar IS NULL OR entity_type = $3)
+		AND ($4::bigint IS NULL OR entity_id = $4)
+		AND ($5::varchar IS NULL OR request_id = $5)
+		AND ($6::timestamptz IS NULL OR occurred_at >= $6)
+		AND ($7::timestamptz IS NULL OR occurred_at < $7)
+	ORDER BY id
+	LIMIT $8
+	OFFSET $9;
+`
+
+type ListAuditEventsParams struct {
+	AuditEventFilter
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+// ListAuditEvents returns audit events oldest first.
+func (q *Queries) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	rows, err := q.db.Query(ctx, ListAuditEventsQuery,
+		arg.Actor, arg.Action, arg.EntityType, arg.EntityID, arg.RequestID, arg.From, arg.To,
+		arg.Limit, arg.Offset,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []AuditEvent{}
+	for rows.Next() {
+		e, err := scanAuditEvent(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, e)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+func scanAuditEvent(row pgx.Row) (AuditEvent, error) {
+	var e AuditEvent
+	err := row.Scan(
+		&e.ID, &e.OccurredAt, &e.Actor, &e.Action, &e.EntityType, &e.EntityID,
+		&e.Before, &e.After, &e.RequestID,
+	)
+	return e, err
+}
+
+// audit records a change to an entity using q, which is expected to be bound
+// to the transaction that made the change, so that the change and its event
+// are committed together. before is nil for a creation and after for a
+// deletion. The actor and request ID are taken from ctx.
+func audit(ctx context.Context, q *Queries, action, entityType string, entityID int64, before, after any) error {
+	b, a, err := auditDiff(before, after)
+	if err != nil {
+		return err
+	}
+	_, err = q.InsertAuditEvent(ctx, InsertAuditEventParams{
+		Actor:      util.Actor(ctx),
+		Action:     action,
+		EntityType: entityType,
+		EntityID:   entityID,
+		Before:     b,
+		After:      a,
+		RequestID:  util.RequestID(ctx),
+	})
+	return err
+}
+
+// auditDiff returns the JSON fields of before and after that differ. A field
+// that only one of them has is kept in that one. A nil value is returned as
+// is, with every field of the other one.
+func auditDiff(before, after any) (json.RawMessage, json.RawMessage, error) {
+	b, err := marshalAuditValue(before)
+	if err != nil {
+		return nil, nil, err
+	}
+	a, err := marshalAuditValue(after)
+	if err != nil || b == nil || a == nil {
+		return b, a, err
+	}
+
+	var beforeFields, afterFields map[string]json.RawMessage
+	if err := json.Unmarshal(b, &beforeFields); err != nil {
+		return nil, nil, err
+	}
+	if err := json.Unmarshal(a, &afterFields); err != nil {
+		return nil, nil, err
+	}
+	for k, v := range beforeFields {
+		if w, ok := afterFields[k]; ok && bytes.Equal(v, w) {
+			delete(beforeFields, k)
+			delete(afterFields, k)
+		}
+	}
+
+	if b, err = json.Marshal(beforeFields); err != nil {
+		return nil, nil, err
+	}
+	a, err = json.Marshal(afterFields)
+	return b, a, err
+}
+
+func marshalAuditValue(v any) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
@@ -0,0 +1,208 @@
+package db
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAuditDiff(t *testing.T) {
+	type record struct {
+		Status  string `json:"status"`
+		Version int64  `json:"version"`
+		Note    string `json:"note"`
+	}
+
+	testCases := []struct {
+		name   string
+		before any
+		after  any
+		wantB  string
+		wantA  string
+	}{
+		{
+			name:   "Update",
+			before: record{Status: util.PENDING_PAYMENT, Version: 1, Note: "n"},
+			after:  record{Status: util.PAID, Version: 2, Note: "n"},
+			wantB:  `{"status":"pending_payment","version":1}`,
+			wantA:  `{"status":"paid","version":2}`,
+		},
+		{
+			name:   "AddedField",
+			before: record{Version: 1},
+			after: struct {
+				record
+				Payment int64 `json:"payment"`
+			}{record{Version: 2}, 7},
+			wantB: `{"version":1}`,
+			wantA: `{"version":2,"payment":7}`,
+		},
+		{
+			name:   "Creation",
+			before: nil,
+			after:  record{Status: util.DRAFT, Version: 1},
+			wantA:  `{"status":"draft","version":1,"note":""}`,
+		},
+		{
+			name:   "Deletion",
+			before: record{Status: util.DRAFT, Version: 1},
+			after:  nil,
+			wantB:  `{"status":"draft","version":1,"note":""}`,
+		},
+		{
+			name:   "NoChange",
+			before: record{Version: 1},
+			after:  record{Version: 1},
+			wantB:  `{}`,
+			wantA:  `{}`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			before, after, err := auditDiff(tc.before, tc.after)
+			require.NoError(t, err)
+			if tc.wantB == "" {
+				require.Nil(t, before)
+			} else {
+				require.JSONEq(t, tc.wantB, string(before))
+			}
+			if tc.wantA == "" {
+				require.Nil(t, after)
+			} else {
+				require.JSONEq(t, tc.wantA, string(after))
+			}
+		})
+	}
+}
+
+func listInvoiceAuditEvents(t *testing.T, invoiceNumber int64) []AuditEvent {
+	events, err := testStore.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		AuditEventFilter: AuditEventFilter{
+			EntityType: pgtype.Text{String: EntityInvoice, Valid: true},
+			EntityID:   pgtype.Int8{Int64: invoiceNumber, Valid: true},
+		},
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	return events
+}
+
+func TestAuditInvoiceChanges(t *testing.T) {
+	ctx := util.WithActor(context.Background(), "ops@example.com")
+	ctx = util.WithRequestID(ctx, util.RandomString(16))
+
+	result, err := testStore.CreateInvoiceTx(ctx, CreateInvoiceTxParams{
+		CustomerName:    util.RandomName(),
+		CustomerEmail:   util.RandomEmail(),
+		CustomerPhone:   util.RandomPhone(),
+		CustomerAddress: util.RandomAddress(),
+		SenderName:      util.RandomName(),
+		SenderEmail:     util.RandomEmail(),
+		SenderPhone:     util.RandomPhone(),
+		SenderAddress:   util.RandomAddress(),
+		IssueDate:       time.Now(),
+		DueDate:         time.Now().AddDate(0, 0, 30),
+		Status:          util.PENDING_PAYMENT,
+		Subtotal:        10000,
+		TotalAmount:     10000,
+		PaymentInfo:     util.RandomString(10),
+		Items:           randomLineItemParams(0, 1),
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.RecordPaymentTx(context.Background(), RecordPaymentTxParams{
+		InvoiceNumber: result.InvoiceNumber,
+		Amount:        10000,
+		PaidAt:        time.Now(),
+		Reference:     "TRX-1",
+	})
+	require.NoError(t, err)
+
+	events := listInvoiceAuditEvents(t, result.InvoiceNumber)
+	require.Len(t, events, 2)
+
+	created := events[0]
+	require.Equal(t, ActionCreate, created.Action)
+	require.Equal(t, "ops@example.com", created.Actor)
+	require.Equal(t, util.RequestID(ctx), created.RequestID)
+	require.Nil(t, created.Before)
+	var snapshot InvoiceResult
+	require.NoError(t, json.Unmarshal(created.After, &snapshot))
+	require.Equal(t, result.InvoiceNumber, snapshot.InvoiceNumber)
+	require.Len(t, snapshot.LineItems, 1)
+
+	paid := events[1]
+	require.Equal(t, ActionRecordPayment, paid.Action)
+	require.Equal(t, util.SystemActor, paid.Actor)
+	require.JSONEq(t, `{"status":"pending_payment","version":1}`, string(paid.Before))
+	var after struct {
+		Status  string  `json:"status"`
+		Version int64   `json:"version"`
+		Payment Payment `json:"payment"`
+	}
+	require.NoError(t, json.Unmarshal(paid.After, &after))
+	require.Equal(t, util.PAID, after.Status)
+	require.Equal(t, int64(2), after.Version)
+	require.Equal(t, "TRX-1", after.Payment.Reference)
+}
+
+func TestAuditRolledBackWithChange(t *testing.T) {
+	invoice := insertPayableInvoice(t, util.RandomEmail(), util.DRAFT, time.Now(), 10000)
+
+	_, err := testStore.RecordPaymentTx(context.Background(), RecordPaymentTxParams{
+		InvoiceNumber: invoice.InvoiceNumber,
+		Amount:        100,
+		PaidAt:        time.Now(),
+	})
+	require.ErrorIs(t, err, ErrInvoiceNotPayable)
+	require.Empty(t, listInvoiceAuditEvents(t, invoice.InvoiceNumber))
+}
+
+func TestAuditProductDeletion(t *testing.T) {
+	product := createRandomProductTx(t)
+
+	err := testStore.DeleteProductTx(context.Background(), product.ID)
+	require.NoError(t, err)
+
+	events, err := testStore.ListAuditEvents(context.Background(), ListAuditEventsParams{
+		AuditEventFilter: AuditEventFilter{
+			EntityType: pgtype.Text{String: EntityProduct, Valid: true},
+			EntityID:   pgtype.Int8{Int64: product.ID, Valid: true},
+		},
+		Limit: 10,
+	})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+	require.Equal(t, ActionDelete, events[1].Action)
+	require.Nil(t, events[1].After)
+
+	var before ProductResult
+	require.NoError(t, json.Unmarshal(events[1].Before, &before))
+	require.Equal(t, product.SKU, before.SKU)
+	require.Len(t, before.Prices, len(product.Prices))
+}
+
+func TestAuditEventsImmutable(t *testing.T) {
+	event, err := testQueries.InsertAuditEvent(context.Background(), InsertAuditEventParams{
+		Actor:      util.RandomEmail(),
+		Action:     ActionUpdate,
+		EntityType: EntityProduct,
+		EntityID:   util.RandomInt(1, 1000),
+		Before:     json.RawMessage(`{"name":"a"}`),
+		After:      json.RawMessage(`{"name":"b"}`),
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.(*SQLStore).connPool.Exec(context.Background(), `UPDATE audit_events SET actor = 'someone else' WHERE id = $1`, event.ID)
+	require.Error(t, err)
+	_, err = testStore.(*SQLStore).connPool.Exec(context.Background(), `DELETE FROM audit_events WHERE id = $1`, event.ID)
+	require.Error(t, err)
+}
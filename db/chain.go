@@ -0,0 +1,465 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/util"
+)
+
+// Reasons that a link of the invoice chain is reported as broken.
+const (
+	// BreakMissingLink means that links before this one are missing or,
+	// for the head of a series, that the walk did not reach it.
+	BreakMissingLink = "missing_link"
+	// BreakPreviousHash means that the link does not point to the hash of
+	// the link before it.
+	BreakPreviousHash = "previous_hash_mismatch"
+	// BreakHash means that the invoice, or the hash of its link, has
+	// changed since the invoice was issued.
+	BreakHash = "hash_mismatch"
+)
+
+// genesisHash is the previous hash of the first link of every series.
+var genesisHash = make([]byte, sha256.Size)
+
+// InvoiceChainLink chains an issued invoice to the invoice issued before it in
+// its series. Hash is the SHA-256 of PreviousHash followed by the canonical
+// serialization of the invoice, so that changing an issued invoice, or
+// removing or reordering links, breaks the chain from that point on.
+type InvoiceChainLink struct {
+	InvoiceNumber int64     `json:"invoice_number"`
+	Series        string    `json:"series"`
+	Position      int64     `json:"position"`
+	PreviousHash  []byte    `json:"previous_hash"`
+	Hash          []byte    `json:"hash"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// invoiceSeries returns the series of an invoice. Each sender numbers its
+// issued invoices in a series of its own.
+func invoiceSeries(i Invoice) string {
+	return strings.ToLower(i.SenderEmail)
+}
+
+// LockInvoiceSeriesQuery takes a lock on a series until the end of the
+// transaction. The first key keeps the lock apart from other advisory locks.
+const LockInvoiceSeriesQuery = `
+	SELECT pg_advisory_xact_lock(hashtext('invoice_chain'), hashtext($1));
+`
+
+// LockInvoiceSeries waits for the transactions that are chaining invoices to
+// series to finish, so that two invoices are not chained to the same head.
+func (q *Queries) LockInvoiceSeries(ctx context.Context, series string) error {
+	_, err := q.db.Exec(ctx, LockInvoiceSeriesQuery, series)
+	return err
+}
+
+const GetInvoiceChainHeadQuery = `
+	SELECT * FROM invoice_chain
+	WHERE series = $1
+	ORDER BY position DESC
+	LIMIT 1;
+`
+
+// GetInvoiceChainHead returns the last link of a series.
+func (q *Queries) GetInvoiceChainHead(ctx context.Context, series string) (InvoiceChainLink, error) {
+	row := q.db.QueryRow(ctx, GetInvoiceChainHeadQuery, series)
+	return scanInvoiceChainLink(row)
+}
+
+const ListInvoiceChainHeadsQuery = `
+	SELECT DISTINCT ON (series) * FROM invoice_chain
+	WHERE ($1::varchar IS NULL OR series = $1)
+	ORDER BY series, position DESC;
+`
+
+// ListInvoiceChainHeads returns the last link of every series, or of the
+// given one, ordered by series.
+func (q *Queries) ListInvoiceChainHeads(ctx context.Context, series pgtype.Text) ([]InvoiceChainLink, error) {
+	rows, err := q.db.Query(ctx, ListInvoiceChainHeadsQuery, series)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []InvoiceChainLink{}
+	for rows.Next() {
+		l, err := scanInvoiceChainLink(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const InsertInvoiceChainLinkQuery = `
+	INSERT INTO invoice_chain (
+		invoice_number, series, position, previous_hash, hash
+	) VALUES (
+	 $1, $2, $3, $4, $5
+	) RETURNING *;
+`
+
+type InsertInvoiceChainLinkParams struct {
+	InvoiceNumber int64  `json:"invoice_number"`
+	Series        string `json:"series"`
+	Position      int64  `json:"position"`
+	PreviousHash  []byte `json:"previous_hash"`
+	Hash          []byte `json:"hash"`
+}
+
+func (q *Queries) InsertInvoiceChainLink(ctx context.Context, arg InsertInvoiceChainLinkParams) (InvoiceChainLink, error) {
+	row := q.db.QueryRow(ctx, InsertInvoiceChainLinkQuery,
+		arg.InvoiceNumber, arg.Series, arg.Position, arg.PreviousHash, arg.Hash,
+	)
+	return scanInvoiceChainLink(row)
+}
+
+// InvoiceChainEntry is a row of StreamInvoiceChain: a link with its invoice
+// and one of the invoice's line items. LineItem is nil for an invoice without
+// line items, which has a single entry.
+type InvoiceChainEntry struct {
+	Link     InvoiceChainLink
+	Invoice  Invoice
+	LineItem *LineItem
+}
+
+// The line items are left joined so that invoices without any are not left
+// out of the chain. Their columns are coalesced to scan into a LineItem, and
+// has_line_item tells an invoice without line items apart.
+const StreamInvoiceChainQuery = `
+	SELECT
+		c.*, i.*,
+		li.id IS NOT NULL AS has_line_item,
+		COALESCE(li.id, 0), COALESCE(li.invoice_number, 0), COALESCE(li.description, ''),
+		COALESCE(li.quantity, 0), COALESCE(li.unit_price, 0), COALESCE(li.total_price, 0),
+		li.product_id, COALESCE(li.tax_code, '')
+	FROM invoice_chain c
+	JOIN invoices i ON i.invoice_number = c.invoice_number
+	LEFT JOIN line_items li ON li.invoice_number = i.invoice_number
+	WHERE ($1::varchar IS NULL OR c.series = $1)
+	ORDER BY c.series, c.position, li.id;
+`
+
+// StreamInvoiceChain calls fn for every line item of the chained invoices of
+// every series, or of the given one, in the order of the chain, and once for
+// each chained invoice without line items. It stops at the first error
+// returned by fn.
+func (q *Queries) StreamInvoiceChain(ctx context.Context, series pgtype.Text, fn func(InvoiceChainEntry) error) error {
+	rows, err := q.db.Query(ctx, StreamInvoiceChainQuery, series)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var e InvoiceChainEntry
+		var item LineItem
+		var hasItem bool
+		fields := invoiceChainLinkFields(&e.Link)
+		fields = append(fields, invoiceFields(&e.Invoice)...)
+		fields = append(fields, &hasItem)
+		fields = append(fields, lineItemFields(&item)...)
+		if err := rows.Scan(fields...); err != nil {
+			return err
+		}
+		if hasItem {
+			e.LineItem = &item
+		}
+		if err := fn(e); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+func scanInvoiceChainLink(row pgx.Row) (InvoiceChainLink, error) {
+	var l InvoiceChainLink
+	err := row.Scan(invoiceChainLinkFields(&l)...)
+	return l, err
+}
+
+// invoiceChainLinkFields returns the scan destinations for the columns of
+// invoice_chain in table order.
+func invoiceChainLinkFields(l *InvoiceChainLink) []any {
+	return []any{&l.InvoiceNumber, &l.Series, &l.Position, &l.PreviousHash, &l.Hash, &l.CreatedAt}
+}
+
+// chainInvoice appends an invoice to the chain of its series using q, which
+// is expected to be bound to the transaction that created the invoice. Drafts
+// are not issued, so they are not chained.
+func chainInvoice(ctx context.Context, q *Queries, result InvoiceResult) error {
+	if result.Status == util.DRAFT {
+		return nil
+	}
+
+	series := invoiceSeries(result.Invoice)
+	if err := q.LockInvoiceSeries(ctx, series); err != nil {
+		return err
+	}
+
+	previous := InvoiceChainLink{PreviousHash: genesisHash, Hash: genesisHash}
+	head, err := q.GetInvoiceChainHead(ctx, series)
+	switch {
+	case err == nil:
+		previous = head
+	case !errors.Is(err, pgx.ErrNoRows):
+		return err
+	}
+
+	hash, err := hashInvoice(previous.Hash, result)
+	if err != nil {
+		return err
+	}
+	_, err = q.InsertInvoiceChainLink(ctx, InsertInvoiceChainLinkParams{
+		InvoiceNumber: result.InvoiceNumber,
+		Series:        series,
+		Position:      previous.Position + 1,
+		PreviousHash:  previous.Hash,
+		Hash:          hash,
+	})
+	return err
+}
+
+// canonicalInvoice is the serialization of an issued invoice that is hashed.
+// It holds what was issued and leaves out what changes afterwards, such as the
+// status and the version. Its fields must never be changed or reordered, or
+// every chain would break; a new field needs a new version of the
+// serialization.
+type canonicalInvoice struct {
+	InvoiceNumber   int64                  `json:"invoice_number"`
+	IssueDate       string                 `json:"issue_date"`
+	DueDate         string                 `json:"due_date"`
+	CustomerName    string                 `json:"customer_name"`
+	CustomerEmail   string                 `json:"customer_email"`
+	CustomerPhone   string                 `json:"customer_phone"`
+	CustomerAddress string                 `json:"customer_address"`
+	SenderName      string                 `json:"sender_name"`
+	SenderEmail     string                 `json:"sender_email"`
+	SenderPhone     string                 `json:"sender_phone"`
+	SenderAddress   string                 `json:"sender_address"`
+	BillingCurrency string                 `json:"billing_currency"`
+	Subtotal        int64                  `json:"subtotal"`
+	DiscountRate    int64                  `json:"discount_rate"`
+	Discount        int64                  `json:"discount"`
+	TotalAmount     int64                  `json:"total_amount"`
+	PaymentInfo     string                 `json:"payment_info"`
+	Note            string                 `json:"note"`
+	QuoteNumber     *int64                 `json:"quote_number"`
+	LineItems       []canonicalInvoiceItem `json:"line_items"`
+}
+
+type canonicalInvoiceItem struct {
+	ID          int64  `json:"id"`
+	Description string `json:"description"`
+	Quantity    int64  `json:"quantity"`
+	UnitPrice   int64  `json:"unit_price"`
+	TotalPrice  int64  `json:"total_price"`
+	ProductID   *int64 `json:"product_id"`
+	TaxCode     string `json:"tax_code"`
+}
+
+// canonicalizeInvoice returns the canonical serialization of an invoice: JSON
+// with its fields in a fixed order, times in UTC and the line items in the
+// order of their IDs.
+func canonicalizeInvoice(result InvoiceResult) ([]byte, error) {
+	i := result.Invoice
+	v := canonicalInvoice{
+		InvoiceNumber:   i.InvoiceNumber,
+		IssueDate:       i.IssueDate.UTC().Format(time.RFC3339Nano),
+		DueDate:         i.DueDate.UTC().Format(time.RFC3339Nano),
+		CustomerName:    i.CustomerName,
+		CustomerEmail:   i.CustomerEmail,
+		CustomerPhone:   i.CustomerPhone,
+		CustomerAddress: i.CustomerAddress,
+		SenderName:      i.SenderName,
+		SenderEmail:     i.SenderEmail,
+		SenderPhone:     i.SenderPhone,
+		SenderAddress:   i.SenderAddress,
+		BillingCurrency: i.BillingCurrency,
+		Subtotal:        i.Subtotal,
+		DiscountRate:    i.DiscountRate,
+		Discount:        i.Discount,
+		TotalAmount:     i.TotalAmount,
+		PaymentInfo:     i.PaymentInfo,
+		Note:            i.Note,
+		QuoteNumber:     nullableInt8(i.QuoteNumber),
+		LineItems:       make([]canonicalInvoiceItem, len(result.LineItems)),
+	}
+	for k, l := range result.LineItems {
+		v.LineItems[k] = canonicalInvoiceItem{
+			ID:          l.ID,
+			Description: l.Description,
+			Quantity:    l.Quantity,
+			UnitPrice:   l.UnitPrice,
+			TotalPrice:  l.TotalPrice,
+			ProductID:   nullableInt8(l.ProductID),
+			TaxCode:     l.TaxCode,
+		}
+	}
+	return json.Marshal(v)
+}
+
+func nullableInt8(v pgtype.Int8) *int64 {
+	if !v.Valid {
+		return nil
+	}
+	return &v.Int64
+}
+
+// hashInvoice returns the hash of the link of an invoice that follows the
+// link with the hash previous.
+func hashInvoice(previous []byte, result InvoiceResult) ([]byte, error) {
+	canonical, err := canonicalizeInvoice(result)
+	if err != nil {
+		return nil, err
+	}
+	h := sha256.New()
+	h.Write(previous)
+	h.Write(canonical)
+	return h.Sum(nil), nil
+}
+
+// ChainBreak is a link of the invoice chain that failed verification.
+type ChainBreak struct {
+	Series        string `json:"series"`
+	Position      int64  `json:"position"`
+	InvoiceNumber int64  `json:"invoice_number"`
+	Reason        string `json:"reason"`
+}
+
+// ChainVerification is the result of walking the invoice chain. Heads are
+// the last links of the series that were walked; the chain is intact up to
+// them when there are no breaks.
+type ChainVerification struct {
+	Links  int64              `json:"links"`
+	Heads  []InvoiceChainLink `json:"heads"`
+	Breaks []ChainBreak       `json:"breaks"`
+}
+
+// VerifyInvoiceChain walks the chain of every series, or of the given one,
+// recomputing the hash of every link from its invoice. The walk reads a
+// single snapshot of the database, so that invoices issued meanwhile are
+// left out rather than reported as missing.
+func (store *SQLStore) VerifyInvoiceChain(ctx context.Context, series pgtype.Text) (ChainVerification, error) {
+	var result ChainVerification
+	opts := pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly}
+	err := store.execTxWithOptions(
+		ctx,
+		opts,
+		func(ctx context.Context, q *Queries) error {
+			heads, err := q.ListInvoiceChainHeads(ctx, series)
+			if err != nil {
+				return err
+			}
+
+			v := &chainVerifier{}
+			if err := q.StreamInvoiceChain(ctx, series, v.add); err != nil {
+				return err
+			}
+			result, err = v.finish(heads)
+			return err
+		},
+	)
+	return result, err
+}
+
+// chainVerifier checks the links of the invoice chain in the order of
+// StreamInvoiceChain. Each link is checked once all the line items of its
+// invoice have been added.
+type chainVerifier struct {
+	current  *InvoiceChainEntry
+	items    []LineItem
+	previous *InvoiceChainLink
+	// reached is the position of the last link checked in each series.
+	reached map[string]int64
+	result  ChainVerification
+}
+
+func (v *chainVerifier) add(e InvoiceChainEntry) error {
+	if v.current != nil && v.current.Link.InvoiceNumber != e.Link.InvoiceNumber {
+		if err := v.check(); err != nil {
+			return err
+		}
+	}
+	if v.current == nil {
+		v.current = &e
+	}
+	if e.LineItem != nil {
+		v.items = append(v.items, *e.LineItem)
+	}
+	return nil
+}
+
+// check verifies the current link against the link before it in its series.
+func (v *chainVerifier) check() error {
+	link := v.current.Link
+	result := InvoiceResult{Invoice: v.current.Invoice, LineItems: v.items}
+	v.current, v.items = nil, nil
+	v.result.Links++
+
+	expected := InvoiceChainLink{Series: link.Series, Hash: genesisHash}
+	if v.previous != nil && v.previous.Series == link.Series {
+		expected = *v.previous
+	}
+	v.previous = &link
+	if v.reached == nil {
+		v.reached = make(map[string]int64)
+	}
+	v.reached[link.Series] = link.Position
+
+	switch {
+	case link.Position != expected.Position+1:
+		v.report(link, BreakMissingLink)
+	case !bytes.Equal(link.PreviousHash, expected.Hash):
+		v.report(link, BreakPreviousHash)
+	}
+
+	hash, err := hashInvoice(link.PreviousHash, result)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(hash, link.Hash) {
+		v.report(link, BreakHash)
+	}
+	return nil
+}
+
+func (v *chainVerifier) report(link InvoiceChainLink, reason string) {
+	v.result.Breaks = append(v.result.Breaks, ChainBreak{
+		Series:        link.Series,
+		Position:      link.Position,
+		InvoiceNumber: link.InvoiceNumber,
+		Reason:        reason,
+	})
+}
+
+// finish checks the last link and returns the result of the walk. A head that
+// the walk did not reach, because its invoice has lost its line items, is
+// reported as missing.
+func (v *chainVerifier) finish(heads []InvoiceChainLink) (ChainVerification, error) {
+	if v.current != nil {
+		if err := v.check(); err != nil {
+			return ChainVerification{}, err
+		}
+	}
+	for _, head := range heads {
+		if v.reached[head.Series] != head.Position {
+			v.report(head, BreakMissingLink)
+		}
+	}
+	v.result.Heads = heads
+	return v.result, nil
+}
@@ -0,0 +1,281 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+)
+
+func randomChainedInvoice(invoiceNumber int64) InvoiceResult {
+	return InvoiceResult{
+		Invoice: Invoice{
+			InvoiceNumber:   invoiceNumber,
+			CustomerName:    util.RandomName(),
+			CustomerEmail:   util.RandomEmail(),
+			SenderEmail:     "billing@example.com",
+			IssueDate:       time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC),
+			DueDate:         time.Date(2025, 3, 31, 0, 0, 0, 0, time.UTC),
+			Status:          util.PENDING_PAYMENT,
+			Subtotal:        1000,
+			TotalAmount:     1000,
+			BillingCurrency: "USD",
+			Version:         1,
+		},
+		LineItems: []LineItem{
+			{ID: invoiceNumber * 10, InvoiceNumber: invoiceNumber, Description: "Design", Quantity: 1, UnitPrice: 600, TotalPrice: 600},
+			{ID: invoiceNumber*10 + 1, InvoiceNumber: invoiceNumber, Description: "Build", Quantity: 1, UnitPrice: 400, TotalPrice: 400},
+		},
+	}
+}
+
+// chainEntries links the invoices into a chain of one series, in order, and
+// returns the rows that StreamInvoiceChain would return for it.
+func chainEntries(t *testing.T, invoices []InvoiceResult) ([]InvoiceChainEntry, InvoiceChainLink) {
+	var entries []InvoiceChainEntry
+	previous := InvoiceChainLink{Hash: genesisHash}
+	for i, v := range invoices {
+		hash, err := hashInvoice(previous.Hash, v)
+		require.NoError(t, err)
+		link := InvoiceChainLink{
+			InvoiceNumber: v.InvoiceNumber,
+			Series:        invoiceSeries(v.Invoice),
+			Position:      int64(i + 1),
+			PreviousHash:  previous.Hash,
+			Hash:          hash,
+		}
+		if len(v.LineItems) == 0 {
+			entries = append(entries, InvoiceChainEntry{Link: link, Invoice: v.Invoice})
+		}
+		for k := range v.LineItems {
+			entries = append(entries, InvoiceChainEntry{Link: link, Invoice: v.Invoice, LineItem: &v.LineItems[k]})
+		}
+		previous = link
+	}
+	return entries, previous
+}
+
+func verifyEntries(t *testing.T, entries []InvoiceChainEntry, heads []InvoiceChainLink) ChainVerification {
+	v := &chainVerifier{}
+	for _, e := range entries {
+		require.NoError(t, v.add(e))
+	}
+	result, err := v.finish(heads)
+	require.NoError(t, err)
+	return result
+}
+
+func TestHashInvoice(t *testing.T) {
+	invoice := randomChainedInvoice(7)
+	hash, err := hashInvoice(genesisHash, invoice)
+	require.NoError(t, err)
+	require.Len(t, hash, 32)
+
+	// What changes after issuance is not hashed.
+	paid := invoice
+	paid.Status = util.PAID
+	paid.Version = 2
+	paid.CreatedAt = time.Now()
+	paid.IssueDate = invoice.IssueDate.In(time.FixedZone("WAT", 3600))
+	got, err := hashInvoice(genesisHash, paid)
+	require.NoError(t, err)
+	require.Equal(t, hash, got)
+
+	altered := invoice
+	altered.LineItems = append([]LineItem{}, invoice.LineItems...)
+	altered.LineItems[1].TotalPrice = 40
+	got, err = hashInvoice(genesisHash, altered)
+	require.NoError(t, err)
+	require.NotEqual(t, hash, got)
+
+	got, err = hashInvoice(hash, invoice)
+	require.NoError(t, err)
+	require.NotEqual(t, hash, got)
+}
+
+func TestChainVerifier(t *testing.T) {
+	invoices := []InvoiceResult{randomChainedInvoice(1), randomChainedInvoice(2), randomChainedInvoice(3)}
+
+	t.Run("Intact", func(t *testing.T) {
+		entries, head := chainEntries(t, invoices)
+		result := verifyEntries(t, entries, []InvoiceChainLink{head})
+		require.Equal(t, int64(3), result.Links)
+		require.Empty(t, result.Breaks)
+		require.Equal(t, []InvoiceChainLink{head}, result.Heads)
+	})
+
+	t.Run("AlteredInvoice", func(t *testing.T) {
+		entries, head := chainEntries(t, invoices)
+		for i := range entries {
+			if entries[i].Invoice.InvoiceNumber == 2 {
+				entries[i].Invoice.TotalAmount = 1
+			}
+		}
+		result := verifyEntries(t, entries, []InvoiceChainLink{head})
+		require.Equal(t, []ChainBreak{
+			{Series: "billing@example.com", Position: 2, InvoiceNumber: 2, Reason: BreakHash},
+		}, result.Breaks)
+	})
+
+	t.Run("RemovedLink", func(t *testing.T) {
+		entries, head := chainEntries(t, invoices)
+		var kept []InvoiceChainEntry
+		for _, e := range entries {
+			if e.Link.Position != 2 {
+				kept = append(kept, e)
+			}
+		}
+		result := verifyEntries(t, kept, []InvoiceChainLink{head})
+		require.Equal(t, []ChainBreak{
+			{Series: "billing@example.com", Position: 3, InvoiceNumber: 3, Reason: BreakMissingLink},
+		}, result.Breaks)
+	})
+
+	t.Run("RelinkedLink", func(t *testing.T) {
+		entries, head := chainEntries(t, invoices)
+		for i := range entries {
+			if entries[i].Link.Position == 3 {
+				entries[i].Link.PreviousHash = genesisHash
+			}
+		}
+		result := verifyEntries(t, entries, []InvoiceChainLink{head})
+		require.Equal(t, []ChainBreak{
+			{Series: "billing@example.com", Position: 3, InvoiceNumber: 3, Reason: BreakPreviousHash},
+			{Series: "billing@example.com", Position: 3, InvoiceNumber: 3, Reason: BreakHash},
+		}, result.Breaks)
+	})
+
+	t.Run("UnreachedHead", func(t *testing.T) {
+		entries, head := chainEntries(t, invoices)
+		var kept []InvoiceChainEntry
+		for _, e := range entries {
+			if e.Link.Position != 3 {
+				kept = append(kept, e)
+			}
+		}
+		result := verifyEntries(t, kept, []InvoiceChainLink{head})
+		require.Equal(t, int64(2), result.Links)
+		require.Equal(t, []ChainBreak{
+			{Series: "billing@example.com", Position: 3, InvoiceNumber: 3, Reason: BreakMissingLink},
+		}, result.Breaks)
+	})
+
+	t.Run("InvoiceWithoutLineItems", func(t *testing.T) {
+		empty := randomChainedInvoice(2)
+		empty.LineItems = nil
+		entries, head := chainEntries(t, []InvoiceResult{invoices[0], empty, invoices[2]})
+		result := verifyEntries(t, entries, []InvoiceChainLink{head})
+		require.Equal(t, int64(3), result.Links)
+		require.Empty(t, result.Breaks)
+	})
+
+	t.Run("SeveralSeries", func(t *testing.T) {
+		other := []InvoiceResult{randomChainedInvoice(4)}
+		other[0].SenderEmail = "Sales@Example.com"
+		first, firstHead := chainEntries(t, invoices)
+		second, secondHead := chainEntries(t, other)
+		result := verifyEntries(t, append(first, second...), []InvoiceChainLink{firstHead, secondHead})
+		require.Equal(t, int64(4), result.Links)
+		require.Empty(t, result.Breaks)
+		require.Equal(t, "sales@example.com", secondHead.Series)
+	})
+}
+
+func createChainedInvoice(t *testing.T, senderEmail, status string) InvoiceResult {
+	arg := randomCreateInvoiceTxParams(util.RandomEmail())
+	arg.SenderEmail = senderEmail
+	arg.Status = status
+	result, err := testStore.CreateInvoiceTx(context.Background(), arg)
+	require.NoError(t, err)
+	return result
+}
+
+func randomSeries() string {
+	return strings.ToLower(util.RandomString(12)) + "@example.com"
+}
+
+func TestChainInvoice(t *testing.T) {
+	series := randomSeries()
+	first := createChainedInvoice(t, series, util.PENDING_PAYMENT)
+	createChainedInvoice(t, series, util.DRAFT)
+	second := createChainedInvoice(t, series, util.PENDING_PAYMENT)
+
+	heads, err := testStore.ListInvoiceChainHeads(context.Background(), pgtype.Text{String: series, Valid: true})
+	require.NoError(t, err)
+	require.Len(t, heads, 1)
+	head := heads[0]
+	require.Equal(t, second.InvoiceNumber, head.InvoiceNumber)
+	require.Equal(t, int64(2), head.Position)
+
+	hash, err := hashInvoice(head.PreviousHash, second)
+	require.NoError(t, err)
+	require.Equal(t, hash, head.Hash)
+
+	previous, err := hashInvoice(genesisHash, first)
+	require.NoError(t, err)
+	require.Equal(t, previous, head.PreviousHash)
+
+	result, err := testStore.VerifyInvoiceChain(context.Background(), pgtype.Text{String: series, Valid: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Links)
+	require.Empty(t, result.Breaks)
+}
+
+func TestVerifyInvoiceChainWithoutLineItems(t *testing.T) {
+	series := randomSeries()
+	createChainedInvoice(t, series, util.PENDING_PAYMENT)
+
+	arg := randomCreateInvoiceTxParams(util.RandomEmail())
+	arg.SenderEmail = series
+	arg.Items = nil
+	_, err := testStore.CreateInvoiceTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	createChainedInvoice(t, series, util.PENDING_PAYMENT)
+
+	result, err := testStore.VerifyInvoiceChain(context.Background(), pgtype.Text{String: series, Valid: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), result.Links)
+	require.Empty(t, result.Breaks)
+}
+
+func TestVerifyInvoiceChainDetectsChange(t *testing.T) {
+	series := randomSeries()
+	createChainedInvoice(t, series, util.PENDING_PAYMENT)
+	altered := createChainedInvoice(t, series, util.PENDING_PAYMENT)
+	createChainedInvoice(t, series, util.PENDING_PAYMENT)
+
+	// Paying an invoice changes its status and version, which are not
+	// hashed.
+	_, err := testStore.RecordPaymentTx(context.Background(), RecordPaymentTxParams{
+		InvoiceNumber: altered.InvoiceNumber,
+		Amount:        altered.TotalAmount,
+		PaidAt:        time.Now(),
+	})
+	require.NoError(t, err)
+
+	_, err = testStore.(*SQLStore).connPool.Exec(context.Background(), `UPDATE invoices SET total_amount = 1 WHERE invoice_number = $1`, altered.InvoiceNumber)
+	require.NoError(t, err)
+
+	result, err := testStore.VerifyInvoiceChain(context.Background(), pgtype.Text{String: series, Valid: true})
+	require.NoError(t, err)
+	require.Equal(t, int64(3), result.Links)
+	require.Equal(t, []ChainBreak{
+		{Series: series, Position: 2, InvoiceNumber: altered.InvoiceNumber, Reason: BreakHash},
+	}, result.Breaks)
+}
+
+func TestInvoiceChainImmutable(t *testing.T) {
+	series := randomSeries()
+	result := createChainedInvoice(t, series, util.PENDING_PAYMENT)
+
+	pool := testStore.(*SQLStore).connPool
+	_, err := pool.Exec(context.Background(), `UPDATE invoice_chain SET hash = previous_hash WHERE invoice_number = $1`, result.InvoiceNumber)
+	require.Error(t, err)
+	_, err = pool.Exec(context.Background(), `DELETE FROM invoice_chain WHERE invoice_number = $1`, result.InvoiceNumber)
+	require.Error(t, err)
+}
@@ -13,8 +13,10 @@ type DBTX interface {
 	QueryRow(context.Context, string, ...interface{}) pgx.Row
 }
 
+// New returns Queries that run on db. Errors from db are translated into the
+// error kinds of this package.
 func New(db DBTX) *Queries {
-	return &Queries{db: db}
+	return &Queries{db: translatingDBTX{db}}
 }
 
 type Queries struct {
@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"net"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+// Error kinds. Every error that the store returns for a reason other than an
+// internal failure wraps one of them, so that callers can tell what went wrong
+// without looking at driver errors.
+var (
+	// ErrNotFound means that a record the operation needs does not exist.
+	ErrNotFound = errors.New("record not found")
+	// ErrConflict means that the operation clashes with an existing record
+	// or with a concurrent transaction.
+	ErrConflict = errors.New("record conflicts with an existing one")
+	// ErrValidation means that the records are not in a state that allows
+	// the operation, or that a value is not valid for the database.
+	ErrValidation = errors.New("operation is not valid for the record")
+	// ErrConstraintViolation means that a write would break a reference
+	// between records or another database constraint.
+	ErrConstraintViolation = errors.New("operation violates a database constraint")
+	// ErrPreconditionFailed means that the record has changed since the
+	// version that the operation was made against.
+	ErrPreconditionFailed = errors.New("record has changed since it was read")
+	// ErrUnavailable means that the database could not be reached or is not
+	// accepting work. The operation may succeed if retried later.
+	ErrUnavailable = errors.New("database is unavailable")
+)
+
+// Error is an error of one of the kinds above. Message describes it without
+// any detail of the database and is safe to show to clients; Err, the error
+// that caused it, is not.
+type Error struct {
+	Kind    error
+	Message string
+	Err     error
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return e.Message
+	}
+	return e.Message + ": " + e.Err.Error()
+}
+
+// Unwrap makes both the kind and the cause match with errors.Is and
+// errors.As.
+func (e *Error) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+// PostgreSQL error codes, from
+// https://www.postgresql.org/docs/current/errcodes-appendix.html.
+const (
+	notNullViolation     = "23502"
+	foreignKeyViolation  = "23503"
+	uniqueViolation      = "23505"
+	checkViolation       = "23514"
+	exclusionViolation   = "23P01"
+	serializationFailure = "40001"
+	deadlockDetected     = "40P01"
+	adminShutdown        = "57P01"
+	crashShutdown        = "57P02"
+	cannotConnectNow     = "57P03"
+)
+
+// translateError turns an error from pgx into an *Error of the matching kind.
+// Errors that the store cannot attribute to the request, such as a malformed
+// query, are internal failures and are returned unchanged.
+func translateError(err error) error {
+	var dbErr *Error
+	if err == nil || errors.As(err, &dbErr) {
+		return err
+	}
+
+	if errors.Is(err, pgx.ErrNoRows) {
+		return &Error{Kind: ErrNotFound, Message: "record not found", Err: err}
+	}
+
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		switch {
+		case pgErr.Code == uniqueViolation || pgErr.Code == exclusionViolation:
+			return &Error{Kind: ErrConflict, Message: "a record with the same key already exists", Err: err}
+		case pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected:
+			return &Error{Kind: ErrConflict, Message: "record was changed by a concurrent request", Err: err}
+		case pgErr.Code == foreignKeyViolation:
+			return &Error{Kind: ErrConstraintViolation, Message: "record refers to a record that does not exist or is still referred to", Err: err}
+		case pgErr.Code == notNullViolation:
+			return &Error{Kind: ErrConstraintViolation, Message: "a required value is missing", Err: err}
+		case pgErr.Code == checkViolation:
+			return &Error{Kind: ErrConstraintViolation, Message: "a value is outside the range allowed for it", Err: err}
+		case pgErr.Code == adminShutdown || pgErr.Code == crashShutdown || pgErr.Code == cannotConnectNow:
+			return &Error{Kind: ErrUnavailable, Message: "database is unavailable", Err: err}
+		// Classes 22 (data exception), 08 (connection exception) and 53
+		// (insufficient resources).
+		case strings.HasPrefix(pgErr.Code, "22"):
+			return &Error{Kind: ErrValidation, Message: "a value is not valid for the database", Err: err}
+		case strings.HasPrefix(pgErr.Code, "08"), strings.HasPrefix(pgErr.Code, "53"):
+			return &Error{Kind: ErrUnavailable, Message: "database is unavailable", Err: err}
+		}
+		return err
+	}
+
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	if errors.As(err, &connectErr) || errors.As(err, &netErr) || errors.Is(err, context.DeadlineExceeded) {
+		return &Error{Kind: ErrUnavailable, Message: "database is unavailable", Err: err}
+	}
+	return err
+}
+
+// translatingDBTX is a DBTX that translates the errors of the queries it runs.
+type translatingDBTX struct {
+	db DBTX
+}
+
+func (t translatingDBTX) Exec(ctx context.Context, sql string, args ...interface{}) (pgconn.CommandTag, error) {
+	tag, err := t.db.Exec(ctx, sql, args...)
+	return tag, translateError(err)
+}
+
+func (t translatingDBTX) Query(ctx context.Context, sql string, args ...interface{}) (pgx.Rows, error) {
+	rows, err := t.db.Query(ctx, sql, args...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	return translatingRows{rows}, nil
+}
+
+func (t translatingDBTX) QueryRow(ctx context.Context, sql string, args ...interface{}) pgx.Row {
+	return translatingRow{t.db.QueryRow(ctx, sql, args...)}
+}
+
+type translatingRow struct {
+	pgx.Row
+}
+
+func (r translatingRow) Scan(dest ...any) error {
+	return translateError(r.Row.Scan(dest...))
+}
+
+type translatingRows struct {
+	pgx.Rows
+}
+
+func (r translatingRows) Err() error {
+	return translateError(r.Rows.Err())
+}
+
+func (r translatingRows) Scan(dest ...any) error {
+	return translateError(r.Rows.Scan(dest...))
+}
@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTranslateError(t *testing.T) {
+	testCases := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{"NoRows", pgx.ErrNoRows, ErrNotFound},
+		{"WrappedNoRows", fmt.Errorf("get invoice: %w", pgx.ErrNoRows), ErrNotFound},
+		{"UniqueViolation", &pgconn.PgError{Code: uniqueViolation}, ErrConflict},
+		{"SerializationFailure", &pgconn.PgError{Code: serializationFailure}, ErrConflict},
+		{"ForeignKeyViolation", &pgconn.PgError{Code: foreignKeyViolation}, ErrConstraintViolation},
+		{"CheckViolation", &pgconn.PgError{Code: checkViolation}, ErrConstraintViolation},
+		{"NumericValueOutOfRange", &pgconn.PgError{Code: "22003"}, ErrValidation},
+		{"AdminShutdown", &pgconn.PgError{Code: adminShutdown}, ErrUnavailable},
+		{"TooManyConnections", &pgconn.PgError{Code: "53300"}, ErrUnavailable},
+		{"DeadlineExceeded", context.DeadlineExceeded, ErrUnavailable},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			err := translateError(tc.err)
+			require.ErrorIs(t, err, tc.kind)
+			require.ErrorIs(t, err, tc.err)
+
+			var dbErr *Error
+			require.ErrorAs(t, err, &dbErr)
+			require.NotEmpty(t, dbErr.Message)
+			require.NotContains(t, dbErr.Message, tc.err.Error())
+		})
+	}
+}
+
+func TestTranslateErrorInternal(t *testing.T) {
+	for _, err := range []error{
+		nil,
+		errors.New("unexpected"),
+		&pgconn.PgError{Code: "42P01", Message: `relation "invoices" does not exist`},
+	} {
+		require.Equal(t, err, translateError(err))
+	}
+}
+
+func TestTranslateErrorKeepsKind(t *testing.T) {
+	err := fmt.Errorf("tx error: %w, rollback error: %v", ErrOverpayment, errors.New("conn closed"))
+	require.Equal(t, err, translateError(err))
+	require.ErrorIs(t, err, ErrValidation)
+}
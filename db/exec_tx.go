@@ -2,22 +2,114 @@ package db
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"log/slog"
+	"math/rand"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// execTx executes the function fn within a database transaction.
-func (store *SQLStore) execTx(ctx context.Context, fn func(*Queries) error) error {
-	tx, err := store.connPool.Begin(ctx)
+// txRetryPolicy bounds the retries of transactions that fail with a
+// serialization failure or a deadlock. Before retry n the transaction waits
+// for a random time of up to baseDelay doubled n-1 times, and never more than
+// maxDelay, so that the transactions it clashed with do not clash again.
+type txRetryPolicy struct {
+	maxAttempts int
+	baseDelay   time.Duration
+	maxDelay    time.Duration
+}
+
+var defaultTxRetryPolicy = txRetryPolicy{
+	maxAttempts: 5,
+	baseDelay:   10 * time.Millisecond,
+	maxDelay:    500 * time.Millisecond,
+}
+
+func (p txRetryPolicy) delay(retry int) time.Duration {
+	ceiling := p.maxDelay
+	if retry <= 30 && p.baseDelay<<(retry-1) < ceiling {
+		ceiling = p.baseDelay << (retry - 1)
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
+
+// execTx executes the function fn within a database transaction at the
+// default isolation level. See execTxWithOptions.
+func (store *SQLStore) execTx(ctx context.Context, fn func(context.Context, *Queries) error) error {
+	return store.execTxWithOptions(ctx, pgx.TxOptions{}, fn)
+}
+
+// execTxWithOptions executes the function fn within a database transaction
+// with the given options, such as its isolation level. fn is given the
+// context of the transaction, under which its statements are traced.
+//
+// A transaction that fails with a serialization failure or a deadlock is
+// rolled back and run again from the start, as the retry policy of the store
+// allows, so fn must not keep anything from an earlier attempt. Once the
+// attempts are used up, or ctx is done while waiting to retry, the error of
+// the last attempt is returned.
+func (store *SQLStore) execTxWithOptions(ctx context.Context, opts pgx.TxOptions, fn func(context.Context, *Queries) error) (err error) {
+	ctx, span := startSpan(ctx, "transaction")
+	defer func() { endSpan(span, err) }()
+	if opts.IsoLevel != "" {
+		span.SetAttributes(attribute.String("db.transaction.isolation_level", string(opts.IsoLevel)))
+	}
+
+	for attempt := 1; ; attempt++ {
+		err = store.runTx(ctx, opts, fn)
+		if err == nil || !isRetryable(err) || attempt >= store.txRetry.maxAttempts {
+			return err
+		}
+
+		delay := store.txRetry.delay(attempt)
+		span.AddEvent("retry", trace.WithAttributes(
+			attribute.Int("attempt", attempt),
+			attribute.String("error", err.Error()),
+		))
+		slog.DebugContext(ctx, "retrying transaction",
+			slog.Int("attempt", attempt),
+			slog.Duration("delay", delay),
+			slog.String("error", err.Error()),
+		)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// runTx makes one attempt at the transaction of execTxWithOptions.
+func (store *SQLStore) runTx(ctx context.Context, opts pgx.TxOptions, fn func(context.Context, *Queries) error) error {
+	tx, err := store.connPool.BeginTx(ctx, opts)
 	if err != nil {
-		return err
+		return translateError(err)
 	}
 	q := New(tx)
-	err = fn(q)
+	err = fn(ctx, q)
 	if err != nil {
 		if rollbackErr := tx.Rollback(ctx); rollbackErr != nil {
-			return fmt.Errorf("tx error: %v, rollback error: %v", err, rollbackErr)
+			return fmt.Errorf("tx error: %w, rollback error: %v", err, rollbackErr)
 		}
 		return err
 	}
-	return tx.Commit(ctx)
+	return translateError(tx.Commit(ctx))
+}
+
+// isRetryable reports whether err is a serialization failure or a deadlock,
+// after which the transaction may succeed if it is run again.
+func isRetryable(err error) bool {
+	var pgErr *pgconn.PgError
+	if !errors.As(err, &pgErr) {
+		return false
+	}
+	return pgErr.Code == serializationFailure || pgErr.Code == deadlockDetected
 }
@@ -0,0 +1,178 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+)
+
+// newRetryTestStore returns a store on the test database that retries
+// transactions quickly.
+func newRetryTestStore(maxAttempts int) *SQLStore {
+	store := *testStore.(*SQLStore)
+	store.txRetry = txRetryPolicy{
+		maxAttempts: maxAttempts,
+		baseDelay:   time.Millisecond,
+		maxDelay:    10 * time.Millisecond,
+	}
+	return &store
+}
+
+// TestExecTxRetriesSerializationFailure makes a repeatable read transaction
+// update an invoice that another transaction updated after its snapshot was
+// taken, which fails with a serialization failure. The second attempt sees
+// the committed update and succeeds.
+func TestExecTxRetriesSerializationFailure(t *testing.T) {
+	store := newRetryTestStore(3)
+	invoice := insertRandomInvoiceRecord(t)
+	ctx := context.Background()
+
+	var attempts int
+	err := store.execTxWithOptions(ctx, pgx.TxOptions{IsoLevel: pgx.RepeatableRead}, func(ctx context.Context, q *Queries) error {
+		attempts++
+		// The snapshot is taken by the first statement, which does not lock
+		// the invoice.
+		if _, err := q.GetInvoicePaidAmount(ctx, invoice.InvoiceNumber); err != nil {
+			return err
+		}
+		if attempts == 1 {
+			_, err := store.UpdateInvoiceStatus(ctx, UpdateInvoiceStatusParams{
+				InvoiceNumber: invoice.InvoiceNumber,
+				Status:        util.OVERDUE,
+			})
+			require.NoError(t, err)
+		}
+		_, err := q.UpdateInvoiceStatus(ctx, UpdateInvoiceStatusParams{
+			InvoiceNumber: invoice.InvoiceNumber,
+			Status:        util.PAID,
+		})
+		return err
+	})
+	require.NoError(t, err)
+	require.Equal(t, 2, attempts)
+
+	updated, err := store.GetInvoiceRecordForUpdate(ctx, invoice.InvoiceNumber)
+	require.NoError(t, err)
+	require.Equal(t, util.PAID, updated.Status)
+}
+
+// TestExecTxRetriesDeadlock runs two transactions that lock the same two
+// invoices in opposite orders. PostgreSQL aborts one of them with a deadlock
+// and the retry lets both complete.
+func TestExecTxRetriesDeadlock(t *testing.T) {
+	store := newRetryTestStore(3)
+	invoice1 := insertRandomInvoiceRecord(t)
+	invoice2 := insertRandomInvoiceRecord(t)
+	ctx := context.Background()
+
+	var attempts atomic.Int32
+	var firstLocks sync.WaitGroup
+	firstLocks.Add(2)
+
+	lockBoth := func(first, second int64) error {
+		var once sync.Once
+		return store.execTx(ctx, func(ctx context.Context, q *Queries) error {
+			attempts.Add(1)
+			if _, err := q.GetInvoiceRecordForUpdate(ctx, first); err != nil {
+				return err
+			}
+			// Wait until both transactions hold their first lock, so that
+			// each then waits for the other.
+			once.Do(func() {
+				firstLocks.Done()
+				firstLocks.Wait()
+			})
+			_, err := q.GetInvoiceRecordForUpdate(ctx, second)
+			return err
+		})
+	}
+
+	errs := make(chan error, 2)
+	go func() { errs <- lockBoth(invoice1.InvoiceNumber, invoice2.InvoiceNumber) }()
+	go func() { errs <- lockBoth(invoice2.InvoiceNumber, invoice1.InvoiceNumber) }()
+
+	require.NoError(t, <-errs)
+	require.NoError(t, <-errs)
+	require.Equal(t, int32(3), attempts.Load())
+}
+
+func TestExecTxRetryLimits(t *testing.T) {
+	conflict := translateError(&pgconn.PgError{Code: serializationFailure})
+
+	testCases := []struct {
+		name     string
+		store    *SQLStore
+		ctx      func() context.Context
+		err      error
+		attempts int
+	}{
+		{
+			name:     "AttemptsUsedUp",
+			store:    newRetryTestStore(3),
+			ctx:      context.Background,
+			err:      conflict,
+			attempts: 3,
+		},
+		{
+			name:     "NotRetryable",
+			store:    newRetryTestStore(3),
+			ctx:      context.Background,
+			err:      ErrInvoiceNotPayable,
+			attempts: 1,
+		},
+		{
+			name: "ContextDone",
+			store: func() *SQLStore {
+				store := newRetryTestStore(3)
+				store.txRetry.baseDelay = time.Hour
+				store.txRetry.maxDelay = time.Hour
+				return store
+			}(),
+			ctx: func() context.Context {
+				ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+				t.Cleanup(cancel)
+				return ctx
+			},
+			err:      conflict,
+			attempts: 1,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var attempts int
+			start := time.Now()
+			err := tc.store.execTx(tc.ctx(), func(ctx context.Context, q *Queries) error {
+				attempts++
+				return tc.err
+			})
+			require.True(t, errors.Is(err, tc.err))
+			require.Equal(t, tc.attempts, attempts)
+			require.Less(t, time.Since(start), 5*time.Second)
+		})
+	}
+}
+
+func TestTxRetryPolicyDelay(t *testing.T) {
+	policy := txRetryPolicy{maxAttempts: 100, baseDelay: 10 * time.Millisecond, maxDelay: 500 * time.Millisecond}
+
+	for retry := 1; retry < 100; retry++ {
+		ceiling := policy.maxDelay
+		if retry < 7 {
+			ceiling = policy.baseDelay << (retry - 1)
+		}
+		delay := policy.delay(retry)
+		require.GreaterOrEqual(t, delay, time.Duration(0))
+		require.LessOrEqual(t, delay, ceiling)
+	}
+}
@@ -0,0 +1,30 @@
+package db
+
+import "context"
+
+// GetMigrationVersionQuery reads the table that the migrate tool keeps the
+// version of the schema in.
+const GetMigrationVersionQuery = `
+	SELECT version, dirty FROM schema_migrations
+	LIMIT 1;
+`
+
+// MigrationVersion is the number of the last migration applied to the
+// schema. Dirty means that the migration failed part way and the schema must
+// be fixed by hand.
+type MigrationVersion struct {
+	Version int64 `json:"version"`
+	Dirty   bool  `json:"dirty"`
+}
+
+func (q *Queries) GetMigrationVersion(ctx context.Context) (MigrationVersion, error) {
+	row := q.db.QueryRow(ctx, GetMigrationVersionQuery)
+	var v MigrationVersion
+	err := row.Scan(&v.Version, &v.Dirty)
+	return v, err
+}
+
+// Ping checks that a connection to the database can be acquired and used.
+func (store *SQLStore) Ping(ctx context.Context) error {
+	return translateError(store.connPool.Ping(ctx))
+}
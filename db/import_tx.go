@@ -0,0 +1,39 @@
+package db
+
+import "context"
+
+type ImportInvoiceTxParams struct {
+	Invoice    CreateInvoiceTxParams         `json:"invoice"`
+	Attachment InsertInvoiceAttachmentParams `json:"attachment"`
+}
+
+type ImportInvoiceResult struct {
+	InvoiceResult
+	Attachment InvoiceAttachment `json:"attachment"`
+}
+
+// ImportInvoiceTx creates an invoice from a received document and keeps the
+// original document as an attachment of the invoice. The service did not issue
+// a received invoice, so it is not chained.
+func (store *SQLStore) ImportInvoiceTx(ctx context.Context, arg ImportInvoiceTxParams) (ImportInvoiceResult, error) {
+	var result ImportInvoiceResult
+	err := store.execTx(
+		ctx,
+		func(ctx context.Context, q *Queries) error {
+			var err error
+			result.InvoiceResult, err = createInvoice(ctx, q, arg.Invoice)
+			if err != nil {
+				return err
+			}
+
+			attachment := arg.Attachment
+			attachment.InvoiceNumber = result.InvoiceNumber
+			result.Attachment, err = q.InsertInvoiceAttachment(ctx, attachment)
+			if err != nil {
+				return err
+			}
+			return audit(ctx, q, ActionImport, EntityInvoice, result.InvoiceNumber, nil, result.InvoiceResult)
+		},
+	)
+	return result, err
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/Rhymond/go-money"
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestImportInvoiceTx(t *testing.T) {
+	invoice := randomCreateInvoiceTxParams(util.RandomEmail())
+	invoice.BillingCurrency = pgtype.Text{String: money.EUR, Valid: true}
+	invoice.Note = pgtype.Text{String: util.RandomString(12), Valid: true}
+
+	arg := ImportInvoiceTxParams{
+		Invoice: invoice,
+		Attachment: InsertInvoiceAttachmentParams{
+			Filename:    "INV-1.xml",
+			ContentType: "application/xml",
+			Content:     []byte("<Invoice/>"),
+		},
+	}
+
+	result, err := testStore.ImportInvoiceTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, result.InvoiceNumber)
+	require.Equal(t, money.EUR, result.BillingCurrency)
+	require.Equal(t, invoice.Note.String, result.Note)
+	require.Len(t, result.LineItems, 1)
+
+	require.NotZero(t, result.Attachment.ID)
+	require.Equal(t, result.InvoiceNumber, result.Attachment.InvoiceNumber)
+	require.Equal(t, arg.Attachment.Filename, result.Attachment.Filename)
+	require.Equal(t, arg.Attachment.ContentType, result.Attachment.ContentType)
+	require.Equal(t, arg.Attachment.Content, result.Attachment.Content)
+	require.NotZero(t, result.Attachment.CreatedAt)
+}
+
+func TestImportInvoiceTxRollback(t *testing.T) {
+	customerEmail := util.RandomEmail()
+	invoice := randomCreateInvoiceTxParams(customerEmail)
+	invoice.Items[0].ProductID = pgtype.Int8{Int64: -1, Valid: true}
+
+	_, err := testStore.ImportInvoiceTx(context.Background(), ImportInvoiceTxParams{
+		Invoice: invoice,
+		Attachment: InsertInvoiceAttachmentParams{
+			Filename:    "INV-2.xml",
+			ContentType: "application/xml",
+			Content:     []byte("<Invoice/>"),
+		},
+	})
+	require.Error(t, err)
+
+	invoices, err := testStore.ListInvoices(context.Background(), ListInvoicesParams{
+		InvoiceFilter: InvoiceFilter{CustomerEmail: pgtype.Text{String: customerEmail, Valid: true}},
+		Limit:         5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, invoices)
+}
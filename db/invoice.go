@@ -2,7 +2,11 @@ package db
 
 import (
 	"context"
+	"sort"
 	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 const InsertInvoiceRecordQuery = `
@@ -10,29 +14,35 @@ const InsertInvoiceRecordQuery = `
 		customer_name, customer_email, customer_phone, customer_address,
 		sender_name, sender_email, sender_phone, sender_address,
 		issue_date, due_date, status, subtotal,
-		discount_rate, discount, total_amount, payment_info
+		discount_rate, discount, total_amount, payment_info,
+		quote_number, billing_currency, note
 	) VALUES (
-	 $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16
+	 $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17,
+	 COALESCE($18, 'USD'), COALESCE($19, 'Thank you for your patronage')
 	) RETURNING *;
 `
 
 type InsertInvoiceRecordParams struct {
-	CustomerName    string    `json:"customer_name"`
-	CustomerEmail   string    `json:"customer_email"`
-	CustomerPhone   string    `json:"customer_phone"`
-	CustomerAddress string    `json:"customer_address"`
-	SenderName      string    `json:"sender_name"`
-	SenderEmail     string    `json:"sender_email"`
-	SenderPhone     string    `json:"sender_phone"`
-	SenderAddress   string    `json:"sender_address"`
-	IssueDate       time.Time `json:"issue_date"`
-	DueDate         time.Time `json:"due_date"`
-	Status          string    `json:"status"`
-	Subtotal        int64     `json:"subtotal"`
-	DiscountRate    int64     `json:"discount_rate"`
-	Discount        int64     `json:"discount"`
-	TotalAmount     int64     `json:"total_amount"`
-	PaymentInfo     string    `json:"payment_info"`
+	CustomerName    string      `json:"customer_name"`
+	CustomerEmail   string      `json:"customer_email"`
+	CustomerPhone   string      `json:"customer_phone"`
+	CustomerAddress string      `json:"customer_address"`
+	SenderName      string      `json:"sender_name"`
+	SenderEmail     string      `json:"sender_email"`
+	SenderPhone     string      `json:"sender_phone"`
+	SenderAddress   string      `json:"sender_address"`
+	IssueDate       time.Time   `json:"issue_date"`
+	DueDate         time.Time   `json:"due_date"`
+	Status          string      `json:"status"`
+	Subtotal        int64       `json:"subtotal"`
+	DiscountRate    int64       `json:"discount_rate"`
+	Discount        int64       `json:"discount"`
+	TotalAmount     int64       `json:"total_amount"`
+	PaymentInfo     string      `json:"payment_info"`
+	QuoteNumber     pgtype.Int8 `json:"quote_number"`
+	// BillingCurrency and Note take the column defaults when they are null.
+	BillingCurrency pgtype.Text `json:"billing_currency"`
+	Note            pgtype.Text `json:"note"`
 }
 
 func (q *Queries) InsertInvoiceRecord(ctx context.Context, arg InsertInvoiceRecordParams) (Invoice, error) {
@@ -41,41 +51,280 @@ func (q *Queries) InsertInvoiceRecord(ctx context.Context, arg InsertInvoiceReco
 		arg.SenderName, arg.SenderEmail, arg.SenderPhone, arg.SenderAddress,
 		arg.IssueDate, arg.DueDate, arg.Status, arg.Subtotal,
 		arg.DiscountRate, arg.Discount, arg.TotalAmount, arg.PaymentInfo,
+		arg.QuoteNumber, arg.BillingCurrency, arg.Note,
 	)
+	return scanInvoice(row)
+}
+
+const GetInvoiceRecordForUpdateQuery = `
+	SELECT * FROM invoices
+	WHERE invoice_number = $1 LIMIT 1
+	FOR NO KEY UPDATE;
+`
+
+func (q *Queries) GetInvoiceRecordForUpdate(ctx context.Context, invoiceNumber int64) (Invoice, error) {
+	row := q.db.QueryRow(ctx, GetInvoiceRecordForUpdateQuery, invoiceNumber)
+	return scanInvoice(row)
+}
+
+const UpdateInvoiceStatusQuery = `
+	UPDATE invoices
+	SET status = $2, version = version + 1
+	WHERE invoice_number = $1
+	RETURNING *;
+`
+
+type UpdateInvoiceStatusParams struct {
+	InvoiceNumber int64  `json:"invoice_number"`
+	Status        string `json:"status"`
+}
+
+func (q *Queries) UpdateInvoiceStatus(ctx context.Context, arg UpdateInvoiceStatusParams) (Invoice, error) {
+	row := q.db.QueryRow(ctx, UpdateInvoiceStatusQuery, arg.InvoiceNumber, arg.Status)
+	return scanInvoice(row)
+}
+
+func scanInvoice(row pgx.Row) (Invoice, error) {
 	var i Invoice
-	err := row.Scan(
+	err := row.Scan(invoiceFields(&i)...)
+	return i, err
+}
+
+// invoiceFields returns the scan destinations for the columns of invoices in
+// table order.
+func invoiceFields(i *Invoice) []any {
+	return []any{
 		&i.InvoiceNumber, &i.CustomerName, &i.CustomerEmail, &i.CustomerPhone, &i.CustomerAddress,
 		&i.SenderName, &i.SenderEmail, &i.SenderPhone, &i.SenderAddress,
 		&i.IssueDate, &i.DueDate, &i.Status,
 		&i.Subtotal, &i.DiscountRate, &i.Discount, &i.TotalAmount,
 		&i.BillingCurrency, &i.PaymentInfo, &i.Note, &i.CreatedAt,
-	)
-	return i, err
+		&i.QuoteNumber, &i.Version,
+	}
 }
 
 const InsertLineItemQuery = `
 	INSERT INTO line_items (
-		invoice_number, description, quantity, unit_price, total_price
+		invoice_number, description, quantity, unit_price, total_price,
+		product_id, tax_code
 	) VALUES (
-	 $1, $2, $3, $4, $5
+	 $1, $2, $3, $4, $5, $6, $7
 	) RETURNING *;
 `
 
 type InsertLineItemParams struct {
-	InvoiceNumber int64  `json:"invoice_number"`
-	Description   string `json:"description"`
-	Quantity      int64  `json:"quantity"`
-	UnitPrice     int64  `json:"unit_price"`
-	TotalPrice    int64  `json:"total_price"`
+	InvoiceNumber int64       `json:"invoice_number"`
+	Description   string      `json:"description"`
+	Quantity      int64       `json:"quantity"`
+	UnitPrice     int64       `json:"unit_price"`
+	TotalPrice    int64       `json:"total_price"`
+	ProductID     pgtype.Int8 `json:"product_id"`
+	TaxCode       string      `json:"tax_code"`
 }
 
 func (q *Queries) InsertLineItem(ctx context.Context, arg InsertLineItemParams) (LineItem, error) {
 	row := q.db.QueryRow(ctx, InsertLineItemQuery,
 		arg.InvoiceNumber, arg.Description, arg.Quantity, arg.UnitPrice, arg.TotalPrice,
+		arg.ProductID, arg.TaxCode,
 	)
 	var l LineItem
-	err := row.Scan(
-		&l.ID, &l.InvoiceNumber, &l.Description, &l.Quantity, &l.UnitPrice, &l.TotalPrice,
-	)
+	err := row.Scan(lineItemFields(&l)...)
 	return l, err
 }
+
+// InsertLineItemsQuery inserts any number of line items in one statement. The
+// rows are inserted in the order of the arrays, so their IDs ascend in that
+// order.
+const InsertLineItemsQuery = `
+	INSERT INTO line_items (
+		invoice_number, description, quantity, unit_price, total_price,
+		product_id, tax_code
+	)
+	SELECT
+		invoice_number, description, quantity, unit_price, total_price,
+		product_id, tax_code
+	FROM unnest(
+		$1::bigint[], $2::varchar[], $3::bigint[], $4::bigint[], $5::bigint[],
+		$6::bigint[], $7::varchar[]
+	) WITH ORDINALITY AS item (
+		invoice_number, description, quantity, unit_price, total_price,
+		product_id, tax_code, position
+	)
+	ORDER BY position
+	RETURNING *;
+`
+
+// InsertLineItems inserts the line items in one round trip and returns them
+// in the order of arg.
+func (q *Queries) InsertLineItems(ctx context.Context, arg []InsertLineItemParams) ([]LineItem, error) {
+	if len(arg) == 0 {
+		return []LineItem{}, nil
+	}
+
+	invoiceNumbers := make([]int64, len(arg))
+	descriptions := make([]string, len(arg))
+	quantities := make([]int64, len(arg))
+	unitPrices := make([]int64, len(arg))
+	totalPrices := make([]int64, len(arg))
+	productIDs := make([]pgtype.Int8, len(arg))
+	taxCodes := make([]string, len(arg))
+	for i, v := range arg {
+		invoiceNumbers[i] = v.InvoiceNumber
+		descriptions[i] = v.Description
+		quantities[i] = v.Quantity
+		unitPrices[i] = v.UnitPrice
+		totalPrices[i] = v.TotalPrice
+		productIDs[i] = v.ProductID
+		taxCodes[i] = v.TaxCode
+	}
+
+	rows, err := q.db.Query(ctx, InsertLineItemsQuery,
+		invoiceNumbers, descriptions, quantities, unitPrices, totalPrices,
+		productIDs, taxCodes,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := make([]LineItem, 0, len(arg))
+	for rows.Next() {
+		var l LineItem
+		if err := rows.Scan(lineItemFields(&l)...); err != nil {
+			return nil, err
+		}
+		items = append(items, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	// RETURNING gives no guarantee of order, but the IDs do.
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].ID < items[j].ID
+	})
+	return items, nil
+}
+
+// lineItemFields returns the scan destinations for the columns of line_items
+// in table order.
+func lineItemFields(l *LineItem) []any {
+	return []any{
+		&l.ID, &l.InvoiceNumber, &l.Description, &l.Quantity, &l.UnitPrice, &l.TotalPrice,
+		&l.ProductID, &l.TaxCode,
+	}
+}
+
+// InvoiceFilter selects invoices for listings and exports. Unset fields match
+// every invoice. IssuedTo is exclusive.
+type InvoiceFilter struct {
+	CustomerEmail pgtype.Text        `json:"customer_email"`
+	Status        pgtype.Text        `json:"status"`
+	Currency      pgtype.Text        `json:"currency"`
+	IssuedFrom    pgtype.Timestamptz `json:"issued_from"`
+	IssuedTo      pgtype.Timestamptz `json:"issued_to"`
+}
+
+const invoiceFilterClause = `
+	WHERE ($1::varchar IS NULL OR i.customer_email = $1)
+		AND ($2::varchar IS NULL OR i.status = $2)
+		AND ($3::varchar IS NULL OR i.billing_currency = $3)
+		AND ($4::timestamptz IS NULL OR i.issue_date >= $4)
+		AND ($5::timestamptz IS NULL OR i.issue_date < $5)
+`
+
+func (f InvoiceFilter) args() []any {
+	return []any{f.CustomerEmail, f.Status, f.Currency, f.IssuedFrom, f.IssuedTo}
+}
+
+const ListInvoicesQuery = `
+	SELECT i.* FROM invoices i
+` + invoiceFilterClause + `
+	ORDER BY i.invoice_number
+	LIMIT $6
+	OFFSET $7;
+`
+
+type ListInvoicesParams struct {
+	InvoiceFilter
+	Limit  int32 `json:"limit"`
+	Offset int32 `json:"offset"`
+}
+
+func (q *Queries) ListInvoices(ctx context.Context, arg ListInvoicesParams) ([]Invoice, error) {
+	rows, err := q.db.Query(ctx, ListInvoicesQuery, append(arg.args(), arg.Limit, arg.Offset)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []Invoice{}
+	for rows.Next() {
+		var i Invoice
+		if err := rows.Scan(invoiceFields(&i)...); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const StreamInvoicesQuery = `
+	SELECT i.* FROM invoices i
+` + invoiceFilterClause + `
+	ORDER BY i.invoice_number;
+`
+
+// StreamInvoices calls fn for each invoice matching the filter as it is read
+// from the connection, so that exports do not hold the whole result in
+// memory. It stops at the first error returned by fn.
+func (q *Queries) StreamInvoices(ctx context.Context, arg InvoiceFilter, fn func(Invoice) error) error {
+	rows, err := q.db.Query(ctx, StreamInvoicesQuery, arg.args()...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var i Invoice
+		if err := rows.Scan(invoiceFields(&i)...); err != nil {
+			return err
+		}
+		if err := fn(i); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
+const StreamInvoiceLineItemsQuery = `
+	SELECT i.*, li.* FROM invoices i
+	JOIN line_items li ON li.invoice_number = i.invoice_number
+` + invoiceFilterClause + `
+	ORDER BY i.invoice_number, li.id;
+`
+
+// StreamInvoiceLineItems is like StreamInvoices but calls fn once for every
+// line item, together with its invoice.
+func (q *Queries) StreamInvoiceLineItems(ctx context.Context, arg InvoiceFilter, fn func(Invoice, LineItem) error) error {
+	rows, err := q.db.Query(ctx, StreamInvoiceLineItemsQuery, arg.args()...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var i Invoice
+		var l LineItem
+		if err := rows.Scan(append(invoiceFields(&i), lineItemFields(&l)...)...); err != nil {
+			return err
+		}
+		if err := fn(i, l); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
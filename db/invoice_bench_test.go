@@ -0,0 +1,137 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/kuthumipepple/numeris-book/util"
+)
+
+func randomLineItemParams(invoiceNumber int64, n int) []InsertLineItemParams {
+	items := make([]InsertLineItemParams, n)
+	for i := range items {
+		quantity := util.RandomInt(1, 100)
+		unitPrice := util.RandomInt(100, 1000)
+		items[i] = InsertLineItemParams{
+			InvoiceNumber: invoiceNumber,
+			Description:   util.RandomString(10),
+			Quantity:      quantity,
+			UnitPrice:     unitPrice,
+			TotalPrice:    quantity * unitPrice,
+			TaxCode:       "S",
+		}
+	}
+	return items
+}
+
+// insertLineItemsBatch queues one INSERT per line item in a pgx.Batch, which
+// is sent in one round trip.
+func insertLineItemsBatch(ctx context.Context, tx pgx.Tx, arg []InsertLineItemParams) ([]LineItem, error) {
+	batch := &pgx.Batch{}
+	for _, v := range arg {
+		batch.Queue(InsertLineItemQuery,
+			v.InvoiceNumber, v.Description, v.Quantity, v.UnitPrice, v.TotalPrice,
+			v.ProductID, v.TaxCode,
+		)
+	}
+	results := tx.SendBatch(ctx, batch)
+	defer results.Close()
+
+	items := make([]LineItem, len(arg))
+	for i := range items {
+		if err := results.QueryRow().Scan(lineItemFields(&items[i])...); err != nil {
+			return nil, err
+		}
+	}
+	return items, nil
+}
+
+// insertLineItemsCopy copies the line items of one invoice and selects them
+// back for their IDs, which takes two round trips.
+func insertLineItemsCopy(ctx context.Context, tx pgx.Tx, arg []InsertLineItemParams) ([]LineItem, error) {
+	_, err := tx.CopyFrom(ctx,
+		pgx.Identifier{"line_items"},
+		[]string{"invoice_number", "description", "quantity", "unit_price", "total_price", "product_id", "tax_code"},
+		pgx.CopyFromSlice(len(arg), func(i int) ([]any, error) {
+			v := arg[i]
+			return []any{v.InvoiceNumber, v.Description, v.Quantity, v.UnitPrice, v.TotalPrice, v.ProductID, v.TaxCode}, nil
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := tx.Query(ctx, "SELECT * FROM line_items WHERE invoice_number = $1 ORDER BY id", arg[0].InvoiceNumber)
+	if err != nil {
+		return nil, err
+	}
+	return pgx.CollectRows(rows, func(row pgx.CollectableRow) (LineItem, error) {
+		var l LineItem
+		err := row.Scan(lineItemFields(&l)...)
+		return l, err
+	})
+}
+
+// BenchmarkInsertLineItems compares the ways of inserting the line items of
+// an invoice. Each is run in a transaction that is rolled back, as
+// createInvoice runs in one.
+func BenchmarkInsertLineItems(b *testing.B) {
+	connPool := testStore.(*SQLStore).connPool
+
+	methods := []struct {
+		name   string
+		insert func(ctx context.Context, tx pgx.Tx, arg []InsertLineItemParams) ([]LineItem, error)
+	}{
+		{
+			name: "Loop",
+			insert: func(ctx context.Context, tx pgx.Tx, arg []InsertLineItemParams) ([]LineItem, error) {
+				q := New(tx)
+				items := make([]LineItem, len(arg))
+				for i, v := range arg {
+					var err error
+					if items[i], err = q.InsertLineItem(ctx, v); err != nil {
+						return nil, err
+					}
+				}
+				return items, nil
+			},
+		},
+		{
+			name: "Unnest",
+			insert: func(ctx context.Context, tx pgx.Tx, arg []InsertLineItemParams) ([]LineItem, error) {
+				return New(tx).InsertLineItems(ctx, arg)
+			},
+		},
+		{name: "Batch", insert: insertLineItemsBatch},
+		{name: "CopyFrom", insert: insertLineItemsCopy},
+	}
+
+	for _, size := range []int{1, 10, 100, 500} {
+		invoice := insertRandomInvoiceRecord(b)
+		arg := randomLineItemParams(invoice.InvoiceNumber, size)
+
+		for _, method := range methods {
+			b.Run(fmt.Sprintf("%s/%d", method.name, size), func(b *testing.B) {
+				ctx := context.Background()
+				for i := 0; i < b.N; i++ {
+					tx, err := connPool.Begin(ctx)
+					if err != nil {
+						b.Fatal(err)
+					}
+					items, err := method.insert(ctx, tx, arg)
+					if err != nil {
+						b.Fatal(err)
+					}
+					if len(items) != size {
+						b.Fatalf("got %d line items, want %d", len(items), size)
+					}
+					if err := tx.Rollback(ctx); err != nil {
+						b.Fatal(err)
+					}
+				}
+			})
+		}
+	}
+}
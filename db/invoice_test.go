@@ -2,15 +2,17 @@ package db
 
 import (
 	"context"
+	"errors"
 	"testing"
 	"time"
 
 	"github.com/Rhymond/go-money"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/kuthumipepple/numeris-book/util"
 	"github.com/stretchr/testify/require"
 )
 
-func insertRandomInvoiceRecord(t *testing.T) Invoice {
+func insertRandomInvoiceRecord(t testing.TB) Invoice {
 	arg := InsertInvoiceRecordParams{
 		CustomerName:    util.RandomName(),
 		CustomerEmail:   util.RandomEmail(),
@@ -30,7 +32,7 @@ func insertRandomInvoiceRecord(t *testing.T) Invoice {
 		PaymentInfo:     util.RandomString(10),
 	}
 
-	invoice, err := testStore.InsertInvoiceRecord(context.Background(), arg)
+	invoice, err := testQueries.InsertInvoiceRecord(context.Background(), arg)
 	require.NoError(t, err)
 
 	require.NotZero(t, invoice.InvoiceNumber)
@@ -74,7 +76,7 @@ func TestInsertLineItem(t *testing.T) {
 		TotalPrice:    util.RandomInt(100, 1000),
 	}
 
-	lineItem, err := testStore.InsertLineItem(context.Background(), arg)
+	lineItem, err := testQueries.InsertLineItem(context.Background(), arg)
 	require.NoError(t, err)
 
 	require.NotZero(t, lineItem.ID)
@@ -84,3 +86,117 @@ func TestInsertLineItem(t *testing.T) {
 	require.Equal(t, arg.UnitPrice, lineItem.UnitPrice)
 	require.Equal(t, arg.TotalPrice, lineItem.TotalPrice)
 }
+
+func TestInsertLineItems(t *testing.T) {
+	invoice := insertRandomInvoiceRecord(t)
+
+	product := insertRandomProduct(t)
+
+	arg := randomLineItemParams(invoice.InvoiceNumber, 50)
+	arg[3].ProductID = pgtype.Int8{Int64: product.ID, Valid: true}
+
+	lineItems, err := testQueries.InsertLineItems(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, lineItems, len(arg))
+
+	for i, lineItem := range lineItems {
+		if i > 0 {
+			require.Greater(t, lineItem.ID, lineItems[i-1].ID)
+		}
+		require.Equal(t, arg[i].InvoiceNumber, lineItem.InvoiceNumber)
+		require.Equal(t, arg[i].Description, lineItem.Description)
+		require.Equal(t, arg[i].Quantity, lineItem.Quantity)
+		require.Equal(t, arg[i].UnitPrice, lineItem.UnitPrice)
+		require.Equal(t, arg[i].TotalPrice, lineItem.TotalPrice)
+		require.Equal(t, arg[i].ProductID, lineItem.ProductID)
+		require.Equal(t, arg[i].TaxCode, lineItem.TaxCode)
+	}
+
+	lineItems, err = testQueries.InsertLineItems(context.Background(), nil)
+	require.NoError(t, err)
+	require.Empty(t, lineItems)
+}
+
+func TestUpdateInvoiceStatus(t *testing.T) {
+	invoice1 := insertRandomInvoiceRecord(t)
+
+	invoice2, err := testQueries.UpdateInvoiceStatus(context.Background(), UpdateInvoiceStatusParams{
+		InvoiceNumber: invoice1.InvoiceNumber,
+		Status:        util.PAID,
+	})
+	require.NoError(t, err)
+	require.Equal(t, invoice1.InvoiceNumber, invoice2.InvoiceNumber)
+	require.Equal(t, util.PAID, invoice2.Status)
+	require.Equal(t, invoice1.TotalAmount, invoice2.TotalAmount)
+}
+
+func TestListInvoices(t *testing.T) {
+	customerEmail := util.RandomEmail()
+	issueDate := time.Date(2024, 3, 10, 12, 0, 0, 0, time.UTC)
+
+	var invoices []Invoice
+	for i := 0; i < 3; i++ {
+		invoices = append(invoices, insertPayableInvoice(t, customerEmail, util.PENDING_PAYMENT, issueDate.AddDate(0, 0, i), 1000))
+	}
+	insertPayableInvoice(t, customerEmail, util.PAID, issueDate, 1000)
+
+	arg := ListInvoicesParams{
+		InvoiceFilter: InvoiceFilter{
+			CustomerEmail: pgtype.Text{String: customerEmail, Valid: true},
+			Status:        pgtype.Text{String: util.PENDING_PAYMENT, Valid: true},
+			IssuedTo:      pgtype.Timestamptz{Time: issueDate.AddDate(0, 0, 2), Valid: true},
+		},
+		Limit:  5,
+		Offset: 0,
+	}
+	list, err := testStore.ListInvoices(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	require.Equal(t, invoices[0].InvoiceNumber, list[0].InvoiceNumber)
+	require.Equal(t, invoices[1].InvoiceNumber, list[1].InvoiceNumber)
+
+	arg.Offset = 1
+	list, err = testStore.ListInvoices(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	require.Equal(t, invoices[1].InvoiceNumber, list[0].InvoiceNumber)
+}
+
+func TestStreamInvoices(t *testing.T) {
+	invoice := createRandomInvoiceTx(t)
+	filter := InvoiceFilter{
+		CustomerEmail: pgtype.Text{String: invoice.CustomerEmail, Valid: true},
+	}
+
+	var streamed []Invoice
+	err := testStore.StreamInvoices(context.Background(), filter, func(i Invoice) error {
+		streamed = append(streamed, i)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, streamed, 1)
+	require.Equal(t, invoice.InvoiceNumber, streamed[0].InvoiceNumber)
+
+	var lineItems []LineItem
+	err = testStore.StreamInvoiceLineItems(context.Background(), filter, func(i Invoice, l LineItem) error {
+		require.Equal(t, invoice.InvoiceNumber, i.InvoiceNumber)
+		lineItems = append(lineItems, l)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Len(t, lineItems, len(invoice.LineItems))
+	for i := range lineItems {
+		require.Equal(t, invoice.LineItems[i].ID, lineItems[i].ID)
+		require.Equal(t, invoice.LineItems[i].Description, lineItems[i].Description)
+	}
+
+	// an error from the callback stops the stream
+	errStop := errors.New("stop")
+	calls := 0
+	err = testStore.StreamInvoiceLineItems(context.Background(), filter, func(Invoice, LineItem) error {
+		calls++
+		return errStop
+	})
+	require.ErrorIs(t, err, errStop)
+	require.Equal(t, 1, calls)
+}
@@ -0,0 +1,37 @@
+package db
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPoolCollector(t *testing.T) {
+	// The pool connects lazily, so the statistics of a new pool can be read
+	// without a database.
+	pool, err := pgxpool.New(context.Background(), "postgresql://localhost:5432/numerisbookdb?pool_max_conns=7")
+	require.NoError(t, err)
+	defer pool.Close()
+
+	collector := NewPoolCollector(pool)
+	require.Equal(t, 9, testutil.CollectAndCount(collector))
+
+	problems, err := testutil.CollectAndLint(collector)
+	require.NoError(t, err)
+	require.Empty(t, problems)
+
+	expected := `
+# HELP numeris_db_pool_max_conns Maximum number of connections in the pool.
+# TYPE numeris_db_pool_max_conns gauge
+numeris_db_pool_max_conns 7
+# HELP numeris_db_pool_acquired_conns Number of connections currently in use.
+# TYPE numeris_db_pool_acquired_conns gauge
+numeris_db_pool_acquired_conns 0
+`
+	err = testutil.CollectAndCompare(collector, strings.NewReader(expected), "numeris_db_pool_max_conns", "numeris_db_pool_acquired_conns")
+	require.NoError(t, err)
+}
@@ -0,0 +1,123 @@
+package db
+
+import (
+	"embed"
+	"errors"
+	"io/fs"
+
+	"github.com/golang-migrate/migrate/v4"
+	pgxmigrate "github.com/golang-migrate/migrate/v4/database/pgx/v5"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// migrations are the schema migrations, built into the binary so that it can
+// migrate the database it runs against.
+//
+//go:embed migration/*.sql
+var migrations embed.FS
+
+// Migrator applies the embedded migrations to a database. It keeps the
+// version of the schema in the same schema_migrations table as the migrate
+// CLI, so either can be used on a database.
+//
+// Each change of version holds a PostgreSQL advisory lock, so replicas that
+// migrate on start wait for each other instead of racing.
+type Migrator struct {
+	source  source.Driver
+	migrate *migrate.Migrate
+}
+
+// MigrationStatus is the version of a database's schema and the version of
+// the last migration embedded in the binary.
+type MigrationStatus struct {
+	MigrationVersion
+	Latest int64 `json:"latest"`
+}
+
+// Pending reports whether the binary has migrations that have not been
+// applied.
+func (s MigrationStatus) Pending() bool {
+	return s.Version < s.Latest
+}
+
+// NewMigrator returns a Migrator that runs on connections from pool. It must
+// be closed, which does not close the pool.
+func NewMigrator(pool *pgxpool.Pool) (*Migrator, error) {
+	migrationFS, err := fs.Sub(migrations, "migration")
+	if err != nil {
+		return nil, err
+	}
+	src, err := iofs.New(migrationFS, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	driver, err := pgxmigrate.WithInstance(stdlib.OpenDBFromPool(pool), &pgxmigrate.Config{})
+	if err != nil {
+		src.Close()
+		return nil, translateError(err)
+	}
+
+	m, err := migrate.NewWithInstance("iofs", src, "pgx5", driver)
+	if err != nil {
+		src.Close()
+		driver.Close()
+		return nil, err
+	}
+	return &Migrator{source: src, migrate: m}, nil
+}
+
+// Up applies every migration that has not been applied.
+func (m *Migrator) Up() error {
+	return ignoreNoChange(m.migrate.Up())
+}
+
+// Down reverts the last steps migrations.
+func (m *Migrator) Down(steps int) error {
+	return ignoreNoChange(m.migrate.Steps(-steps))
+}
+
+// Force sets the version of the schema without running any migration and
+// marks it clean. It is how a schema left dirty by a failed migration is
+// recovered once it has been fixed by hand.
+func (m *Migrator) Force(version int) error {
+	return m.migrate.Force(version)
+}
+
+// Status returns the version of the schema, which is 0 before the first
+// migration, and the latest version embedded in the binary.
+func (m *Migrator) Status() (MigrationStatus, error) {
+	var status MigrationStatus
+	version, dirty, err := m.migrate.Version()
+	if err != nil && !errors.Is(err, migrate.ErrNilVersion) {
+		return status, err
+	}
+	status.Version = int64(version)
+	status.Dirty = dirty
+
+	latest, err := m.source.First()
+	for err == nil {
+		status.Latest = int64(latest)
+		latest, err = m.source.Next(latest)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		return status, err
+	}
+	return status, nil
+}
+
+// Close releases the connection of the Migrator to its pool.
+func (m *Migrator) Close() error {
+	sourceErr, dbErr := m.migrate.Close()
+	return errors.Join(sourceErr, dbErr)
+}
+
+func ignoreNoChange(err error) error {
+	if errors.Is(err, migrate.ErrNoChange) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,50 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEmbeddedMigrations(t *testing.T) {
+	names, err := fs.Glob(migrations, "migration/*.sql")
+	require.NoError(t, err)
+	require.NotEmpty(t, names)
+	require.Zero(t, len(names)%2)
+
+	// Migrations are numbered from 1 without gaps, each with an up and a
+	// down file.
+	for i := 1; i <= len(names)/2; i++ {
+		for _, direction := range []string{"up", "down"} {
+			matches, err := fs.Glob(migrations, fmt.Sprintf("migration/%06d_*.%s.sql", i, direction))
+			require.NoError(t, err)
+			require.Len(t, matches, 1, "migration %d %s", i, direction)
+		}
+	}
+}
+
+func TestMigrator(t *testing.T) {
+	migrator, err := NewMigrator(testStore.(*SQLStore).connPool)
+	require.NoError(t, err)
+	defer migrator.Close()
+
+	// The test database is migrated already, so Up has nothing to do.
+	err = migrator.Up()
+	require.NoError(t, err)
+
+	status, err := migrator.Status()
+	require.NoError(t, err)
+	require.False(t, status.Dirty)
+	require.False(t, status.Pending())
+
+	names, err := fs.Glob(migrations, "migration/*.up.sql")
+	require.NoError(t, err)
+	require.Equal(t, int64(len(names)), status.Latest)
+
+	version, err := testStore.GetMigrationVersion(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, status.MigrationVersion, version)
+}
@@ -0,0 +1,11 @@
+ALTER TABLE "invoices" DROP CONSTRAINT "invoices_quote_number_fkey";
+ALTER TABLE "quote_line_items" DROP CONSTRAINT "quote_line_items_quote_number_fkey";
+
+DROP INDEX IF EXISTS "invoices_quote_number_idx";
+DROP INDEX IF EXISTS "quotes_status_idx";
+DROP INDEX IF EXISTS "quote_line_items_quote_number_idx";
+
+ALTER TABLE "invoices" DROP COLUMN "quote_number";
+
+DROP TABLE IF EXISTS "quote_line_items";
+DROP TABLE IF EXISTS "quotes";
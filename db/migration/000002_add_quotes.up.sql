@@ -0,0 +1,42 @@
+CREATE TABLE "quotes" (
+  "quote_number" bigserial PRIMARY KEY,
+  "customer_name" varchar NOT NULL,
+  "customer_email" varchar NOT NULL,
+  "customer_phone" varchar NOT NULL,
+  "customer_address" varchar NOT NULL,
+  "sender_name" varchar NOT NULL,
+  "sender_email" varchar NOT NULL,
+  "sender_phone" varchar NOT NULL,
+  "sender_address" varchar NOT NULL,
+  "issue_date" timestamptz NOT NULL,
+  "expiry_date" timestamptz NOT NULL,
+  "status" varchar NOT NULL,
+  "subtotal" bigint NOT NULL,
+  "discount_rate" bigint NOT NULL,
+  "discount" bigint NOT NULL,
+  "total_amount" bigint NOT NULL,
+  "billing_currency" varchar NOT NULL DEFAULT 'USD',
+  "note" varchar NOT NULL DEFAULT 'Thank you for your interest',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "quote_line_items" (
+  "id" bigserial PRIMARY KEY,
+  "quote_number" bigint NOT NULL,
+  "description" varchar NOT NULL,
+  "quantity" bigint NOT NULL,
+  "unit_price" bigint NOT NULL,
+  "total_price" bigint NOT NULL
+);
+
+ALTER TABLE "invoices" ADD "quote_number" bigint;
+
+CREATE INDEX ON "quotes" ("status");
+
+CREATE INDEX ON "quote_line_items" ("quote_number");
+
+CREATE UNIQUE INDEX ON "invoices" ("quote_number");
+
+ALTER TABLE "quote_line_items" ADD FOREIGN KEY ("quote_number") REFERENCES "quotes" ("quote_number");
+
+ALTER TABLE "invoices" ADD FOREIGN KEY ("quote_number") REFERENCES "quotes" ("quote_number");
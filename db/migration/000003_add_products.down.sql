@@ -0,0 +1,16 @@
+ALTER TABLE "quote_line_items" DROP CONSTRAINT "quote_line_items_product_id_fkey";
+ALTER TABLE "line_items" DROP CONSTRAINT "line_items_product_id_fkey";
+ALTER TABLE "product_prices" DROP CONSTRAINT "product_prices_product_id_fkey";
+
+DROP INDEX IF EXISTS "quote_line_items_product_id_idx";
+DROP INDEX IF EXISTS "line_items_product_id_idx";
+DROP INDEX IF EXISTS "products_active_idx";
+
+ALTER TABLE "quote_line_items" DROP COLUMN "tax_code";
+ALTER TABLE "quote_line_items" DROP COLUMN "product_id";
+
+ALTER TABLE "line_items" DROP COLUMN "tax_code";
+ALTER TABLE "line_items" DROP COLUMN "product_id";
+
+DROP TABLE IF EXISTS "product_prices";
+DROP TABLE IF EXISTS "products";
@@ -0,0 +1,35 @@
+CREATE TABLE "products" (
+  "id" bigserial PRIMARY KEY,
+  "sku" varchar UNIQUE NOT NULL,
+  "name" varchar NOT NULL,
+  "description" varchar NOT NULL,
+  "unit" varchar NOT NULL,
+  "default_tax_code" varchar NOT NULL DEFAULT '',
+  "active" boolean NOT NULL DEFAULT true,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE TABLE "product_prices" (
+  "product_id" bigint NOT NULL,
+  "currency" varchar NOT NULL,
+  "unit_price" bigint NOT NULL,
+  PRIMARY KEY ("product_id", "currency")
+);
+
+ALTER TABLE "line_items" ADD "product_id" bigint;
+ALTER TABLE "line_items" ADD "tax_code" varchar NOT NULL DEFAULT '';
+
+ALTER TABLE "quote_line_items" ADD "product_id" bigint;
+ALTER TABLE "quote_line_items" ADD "tax_code" varchar NOT NULL DEFAULT '';
+
+CREATE INDEX ON "products" ("active");
+
+CREATE INDEX ON "line_items" ("product_id");
+
+CREATE INDEX ON "quote_line_items" ("product_id");
+
+ALTER TABLE "product_prices" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id") ON DELETE CASCADE;
+
+ALTER TABLE "line_items" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id");
+
+ALTER TABLE "quote_line_items" ADD FOREIGN KEY ("product_id") REFERENCES "products" ("id");
@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS "invoices_customer_email_issue_date_idx";
+DROP INDEX IF EXISTS "invoices_issue_date_idx";
@@ -0,0 +1,3 @@
+CREATE INDEX ON "invoices" ("issue_date");
+
+CREATE INDEX ON "invoices" ("customer_email", "issue_date");
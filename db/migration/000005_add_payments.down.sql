@@ -0,0 +1,6 @@
+ALTER TABLE "payments" DROP CONSTRAINT "payments_invoice_number_fkey";
+
+DROP INDEX IF EXISTS "payments_paid_at_idx";
+DROP INDEX IF EXISTS "payments_invoice_number_idx";
+
+DROP TABLE IF EXISTS "payments";
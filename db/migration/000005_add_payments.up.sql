@@ -0,0 +1,14 @@
+CREATE TABLE "payments" (
+  "id" bigserial PRIMARY KEY,
+  "invoice_number" bigint NOT NULL,
+  "amount" bigint NOT NULL,
+  "paid_at" timestamptz NOT NULL,
+  "reference" varchar NOT NULL DEFAULT '',
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "payments" ("invoice_number");
+
+CREATE INDEX ON "payments" ("paid_at");
+
+ALTER TABLE "payments" ADD FOREIGN KEY ("invoice_number") REFERENCES "invoices" ("invoice_number");
@@ -0,0 +1,5 @@
+ALTER TABLE "invoice_attachments" DROP CONSTRAINT "invoice_attachments_invoice_number_fkey";
+
+DROP INDEX IF EXISTS "invoice_attachments_invoice_number_idx";
+
+DROP TABLE IF EXISTS "invoice_attachments";
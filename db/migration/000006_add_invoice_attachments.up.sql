@@ -0,0 +1,12 @@
+CREATE TABLE "invoice_attachments" (
+  "id" bigserial PRIMARY KEY,
+  "invoice_number" bigint NOT NULL,
+  "filename" varchar NOT NULL,
+  "content_type" varchar NOT NULL,
+  "content" bytea NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE INDEX ON "invoice_attachments" ("invoice_number");
+
+ALTER TABLE "invoice_attachments" ADD FOREIGN KEY ("invoice_number") REFERENCES "invoices" ("invoice_number");
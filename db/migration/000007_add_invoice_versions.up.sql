@@ -0,0 +1 @@
+ALTER TABLE "invoices" ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
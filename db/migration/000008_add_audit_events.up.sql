@@ -0,0 +1,32 @@
+CREATE TABLE "audit_events" (
+  "id" bigserial PRIMARY KEY,
+  "occurred_at" timestamptz NOT NULL DEFAULT (now()),
+  "actor" varchar NOT NULL,
+  "action" varchar NOT NULL,
+  "entity_type" varchar NOT NULL,
+  "entity_id" bigint NOT NULL,
+  "before" jsonb,
+  "after" jsonb,
+  "request_id" varchar NOT NULL DEFAULT ''
+);
+
+CREATE INDEX ON "audit_events" ("entity_type", "entity_id");
+
+CREATE INDEX ON "audit_events" ("occurred_at");
+
+CREATE INDEX ON "audit_events" ("actor");
+
+-- Audit events are never changed or removed once written.
+CREATE FUNCTION "reject_audit_event_change"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'audit events cannot be changed or removed';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "audit_events_immutable"
+  BEFORE UPDATE OR DELETE ON "audit_events"
+  FOR EACH ROW EXECUTE FUNCTION "reject_audit_event_change"();
+
+CREATE TRIGGER "audit_events_not_truncated"
+  BEFORE TRUNCATE ON "audit_events"
+  FOR EACH STATEMENT EXECUTE FUNCTION "reject_audit_event_change"();
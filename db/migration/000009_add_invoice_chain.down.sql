@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS "invoice_chain";
+
+DROP FUNCTION IF EXISTS "reject_invoice_chain_change"();
@@ -0,0 +1,28 @@
+CREATE TABLE "invoice_chain" (
+  "invoice_number" bigint PRIMARY KEY,
+  "series" varchar NOT NULL,
+  "position" bigint NOT NULL,
+  "previous_hash" bytea NOT NULL,
+  "hash" bytea NOT NULL,
+  "created_at" timestamptz NOT NULL DEFAULT (now())
+);
+
+CREATE UNIQUE INDEX ON "invoice_chain" ("series", "position");
+
+ALTER TABLE "invoice_chain" ADD FOREIGN KEY ("invoice_number") REFERENCES "invoices" ("invoice_number");
+
+-- Links are never changed or removed once written, so that the chain can
+-- only be broken by disabling these triggers.
+CREATE FUNCTION "reject_invoice_chain_change"() RETURNS trigger AS $$
+BEGIN
+  RAISE EXCEPTION 'invoice chain links cannot be changed or removed';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "invoice_chain_immutable"
+  BEFORE UPDATE OR DELETE ON "invoice_chain"
+  FOR EACH ROW EXECUTE FUNCTION "reject_invoice_chain_change"();
+
+CREATE TRIGGER "invoice_chain_not_truncated"
+  BEFORE TRUNCATE ON "invoice_chain"
+  FOR EACH STATEMENT EXECUTE FUNCTION "reject_invoice_chain_change"();
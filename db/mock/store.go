@@ -13,6 +13,7 @@ import (
 	context "context"
 	reflect "reflect"
 
+	pgtype "github.com/jackc/pgx/v5/pgtype"
 	db "github.com/kuthumipepple/numeris-book/db"
 	gomock "go.uber.org/mock/gomock"
 )
@@ -41,6 +42,36 @@ func (m *MockStore) EXPECT() *MockStoreMockRecorder {
 	return m.recorder
 }
 
+// ARAging mocks base method.
+func (m *MockStore) ARAging(ctx context.Context, arg db.ARAgingParams) ([]db.ARAgingRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ARAging", ctx, arg)
+	ret0, _ := ret[0].([]db.ARAgingRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ARAging indicates an expected call of ARAging.
+func (mr *MockStoreMockRecorder) ARAging(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ARAging", reflect.TypeOf((*MockStore)(nil).ARAging), ctx, arg)
+}
+
+// ConvertQuoteTx mocks base method.
+func (m *MockStore) ConvertQuoteTx(ctx context.Context, arg db.ConvertQuoteTxParams) (db.InvoiceResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ConvertQuoteTx", ctx, arg)
+	ret0, _ := ret[0].(db.InvoiceResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ConvertQuoteTx indicates an expected call of ConvertQuoteTx.
+func (mr *MockStoreMockRecorder) ConvertQuoteTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ConvertQuoteTx", reflect.TypeOf((*MockStore)(nil).ConvertQuoteTx), ctx, arg)
+}
+
 // CreateInvoiceTx mocks base method.
 func (m *MockStore) CreateInvoiceTx(ctx context.Context, arg db.CreateInvoiceTxParams) (db.InvoiceResult, error) {
 	m.ctrl.T.Helper()
@@ -56,6 +87,80 @@ func (mr *MockStoreMockRecorder) CreateInvoiceTx(ctx, arg any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvoiceTx", reflect.TypeOf((*MockStore)(nil).CreateInvoiceTx), ctx, arg)
 }
 
+// CreateInvoicesTx mocks base method.
+func (m *MockStore) CreateInvoicesTx(ctx context.Context, args []db.CreateInvoiceTxParams) ([]db.InvoiceResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateInvoicesTx", ctx, args)
+	ret0, _ := ret[0].([]db.InvoiceResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateInvoicesTx indicates an expected call of CreateInvoicesTx.
+func (mr *MockStoreMockRecorder) CreateInvoicesTx(ctx, args any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateInvoicesTx", reflect.TypeOf((*MockStore)(nil).CreateInvoicesTx), ctx, args)
+}
+
+// CreateProductTx mocks base method.
+func (m *MockStore) CreateProductTx(ctx context.Context, arg db.CreateProductTxParams) (db.ProductResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateProductTx", ctx, arg)
+	ret0, _ := ret[0].(db.ProductResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateProductTx indicates an expected call of CreateProductTx.
+func (mr *MockStoreMockRecorder) CreateProductTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateProductTx", reflect.TypeOf((*MockStore)(nil).CreateProductTx), ctx, arg)
+}
+
+// CreateQuoteTx mocks base method.
+func (m *MockStore) CreateQuoteTx(ctx context.Context, arg db.CreateQuoteTxParams) (db.QuoteResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateQuoteTx", ctx, arg)
+	ret0, _ := ret[0].(db.QuoteResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateQuoteTx indicates an expected call of CreateQuoteTx.
+func (mr *MockStoreMockRecorder) CreateQuoteTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateQuoteTx", reflect.TypeOf((*MockStore)(nil).CreateQuoteTx), ctx, arg)
+}
+
+// DeleteProductTx mocks base method.
+func (m *MockStore) DeleteProductTx(ctx context.Context, id int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteProductTx", ctx, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteProductTx indicates an expected call of DeleteProductTx.
+func (mr *MockStoreMockRecorder) DeleteProductTx(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteProductTx", reflect.TypeOf((*MockStore)(nil).DeleteProductTx), ctx, id)
+}
+
+// GetCustomerStatement mocks base method.
+func (m *MockStore) GetCustomerStatement(ctx context.Context, arg db.CustomerStatementParams) (db.CustomerStatement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCustomerStatement", ctx, arg)
+	ret0, _ := ret[0].(db.CustomerStatement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCustomerStatement indicates an expected call of GetCustomerStatement.
+func (mr *MockStoreMockRecorder) GetCustomerStatement(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCustomerStatement", reflect.TypeOf((*MockStore)(nil).GetCustomerStatement), ctx, arg)
+}
+
 // GetInvoice mocks base method.
 func (m *MockStore) GetInvoice(ctx context.Context, id int64) (db.InvoiceResult, error) {
 	m.ctrl.T.Helper()
@@ -71,32 +176,254 @@ func (mr *MockStoreMockRecorder) GetInvoice(ctx, id any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetInvoice", reflect.TypeOf((*MockStore)(nil).GetInvoice), ctx, id)
 }
 
-// InsertInvoiceRecord mocks base method.
-func (m *MockStore) InsertInvoiceRecord(ctx context.Context, arg db.InsertInvoiceRecordParams) (db.Invoice, error) {
+// GetMigrationVersion mocks base method.
+func (m *MockStore) GetMigrationVersion(ctx context.Context) (db.MigrationVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMigrationVersion", ctx)
+	ret0, _ := ret[0].(db.MigrationVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMigrationVersion indicates an expected call of GetMigrationVersion.
+func (mr *MockStoreMockRecorder) GetMigrationVersion(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMigrationVersion", reflect.TypeOf((*MockStore)(nil).GetMigrationVersion), ctx)
+}
+
+// GetProduct mocks base method.
+func (m *MockStore) GetProduct(ctx context.Context, id int64) (db.ProductResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetProduct", ctx, id)
+	ret0, _ := ret[0].(db.ProductResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetProduct indicates an expected call of GetProduct.
+func (mr *MockStoreMockRecorder) GetProduct(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetProduct", reflect.TypeOf((*MockStore)(nil).GetProduct), ctx, id)
+}
+
+// GetQuote mocks base method.
+func (m *MockStore) GetQuote(ctx context.Context, id int64) (db.QuoteResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetQuote", ctx, id)
+	ret0, _ := ret[0].(db.QuoteResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetQuote indicates an expected call of GetQuote.
+func (mr *MockStoreMockRecorder) GetQuote(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetQuote", reflect.TypeOf((*MockStore)(nil).GetQuote), ctx, id)
+}
+
+// ImportInvoiceTx mocks base method.
+func (m *MockStore) ImportInvoiceTx(ctx context.Context, arg db.ImportInvoiceTxParams) (db.ImportInvoiceResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ImportInvoiceTx", ctx, arg)
+	ret0, _ := ret[0].(db.ImportInvoiceResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ImportInvoiceTx indicates an expected call of ImportInvoiceTx.
+func (mr *MockStoreMockRecorder) ImportInvoiceTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ImportInvoiceTx", reflect.TypeOf((*MockStore)(nil).ImportInvoiceTx), ctx, arg)
+}
+
+// ListAuditEvents mocks base method.
+func (m *MockStore) ListAuditEvents(ctx context.Context, arg db.ListAuditEventsParams) ([]db.AuditEvent, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListAuditEvents", ctx, arg)
+	ret0, _ := ret[0].([]db.AuditEvent)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListAuditEvents indicates an expected call of ListAuditEvents.
+func (mr *MockStoreMockRecorder) ListAuditEvents(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListAuditEvents", reflect.TypeOf((*MockStore)(nil).ListAuditEvents), ctx, arg)
+}
+
+// ListInvoiceChainHeads mocks base method.
+func (m *MockStore) ListInvoiceChainHeads(ctx context.Context, series pgtype.Text) ([]db.InvoiceChainLink, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInvoiceChainHeads", ctx, series)
+	ret0, _ := ret[0].([]db.InvoiceChainLink)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInvoiceChainHeads indicates an expected call of ListInvoiceChainHeads.
+func (mr *MockStoreMockRecorder) ListInvoiceChainHeads(ctx, series any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInvoiceChainHeads", reflect.TypeOf((*MockStore)(nil).ListInvoiceChainHeads), ctx, series)
+}
+
+// ListInvoices mocks base method.
+func (m *MockStore) ListInvoices(ctx context.Context, arg db.ListInvoicesParams) ([]db.Invoice, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListInvoices", ctx, arg)
+	ret0, _ := ret[0].([]db.Invoice)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListInvoices indicates an expected call of ListInvoices.
+func (mr *MockStoreMockRecorder) ListInvoices(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListInvoices", reflect.TypeOf((*MockStore)(nil).ListInvoices), ctx, arg)
+}
+
+// ListProducts mocks base method.
+func (m *MockStore) ListProducts(ctx context.Context, arg db.ListProductRecordsParams) ([]db.ProductResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ListProducts", ctx, arg)
+	ret0, _ := ret[0].([]db.ProductResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ListProducts indicates an expected call of ListProducts.
+func (mr *MockStoreMockRecorder) ListProducts(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ListProducts", reflect.TypeOf((*MockStore)(nil).ListProducts), ctx, arg)
+}
+
+// Ping mocks base method.
+func (m *MockStore) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockStoreMockRecorder) Ping(ctx any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockStore)(nil).Ping), ctx)
+}
+
+// RecordPaymentTx mocks base method.
+func (m *MockStore) RecordPaymentTx(ctx context.Context, arg db.RecordPaymentTxParams) (db.PaymentResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RecordPaymentTx", ctx, arg)
+	ret0, _ := ret[0].(db.PaymentResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RecordPaymentTx indicates an expected call of RecordPaymentTx.
+func (mr *MockStoreMockRecorder) RecordPaymentTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RecordPaymentTx", reflect.TypeOf((*MockStore)(nil).RecordPaymentTx), ctx, arg)
+}
+
+// RevenueByProduct mocks base method.
+func (m *MockStore) RevenueByProduct(ctx context.Context, arg db.RevenueByProductParams) ([]db.RevenueByProductRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevenueByProduct", ctx, arg)
+	ret0, _ := ret[0].([]db.RevenueByProductRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevenueByProduct indicates an expected call of RevenueByProduct.
+func (mr *MockStoreMockRecorder) RevenueByProduct(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevenueByProduct", reflect.TypeOf((*MockStore)(nil).RevenueByProduct), ctx, arg)
+}
+
+// RevenueSummary mocks base method.
+func (m *MockStore) RevenueSummary(ctx context.Context, arg db.RevenueSummaryParams) ([]db.RevenueSummaryRow, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RevenueSummary", ctx, arg)
+	ret0, _ := ret[0].([]db.RevenueSummaryRow)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RevenueSummary indicates an expected call of RevenueSummary.
+func (mr *MockStoreMockRecorder) RevenueSummary(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RevenueSummary", reflect.TypeOf((*MockStore)(nil).RevenueSummary), ctx, arg)
+}
+
+// StreamInvoiceLineItems mocks base method.
+func (m *MockStore) StreamInvoiceLineItems(ctx context.Context, arg db.InvoiceFilter, fn func(db.Invoice, db.LineItem) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamInvoiceLineItems", ctx, arg, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamInvoiceLineItems indicates an expected call of StreamInvoiceLineItems.
+func (mr *MockStoreMockRecorder) StreamInvoiceLineItems(ctx, arg, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamInvoiceLineItems", reflect.TypeOf((*MockStore)(nil).StreamInvoiceLineItems), ctx, arg, fn)
+}
+
+// StreamInvoices mocks base method.
+func (m *MockStore) StreamInvoices(ctx context.Context, arg db.InvoiceFilter, fn func(db.Invoice) error) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamInvoices", ctx, arg, fn)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// StreamInvoices indicates an expected call of StreamInvoices.
+func (mr *MockStoreMockRecorder) StreamInvoices(ctx, arg, fn any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamInvoices", reflect.TypeOf((*MockStore)(nil).StreamInvoices), ctx, arg, fn)
+}
+
+// UpdateProductTx mocks base method.
+func (m *MockStore) UpdateProductTx(ctx context.Context, arg db.UpdateProductTxParams) (db.ProductResult, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateProductTx", ctx, arg)
+	ret0, _ := ret[0].(db.ProductResult)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// UpdateProductTx indicates an expected call of UpdateProductTx.
+func (mr *MockStoreMockRecorder) UpdateProductTx(ctx, arg any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateProductTx", reflect.TypeOf((*MockStore)(nil).UpdateProductTx), ctx, arg)
+}
+
+// UpdateQuoteStatusTx mocks base method.
+func (m *MockStore) UpdateQuoteStatusTx(ctx context.Context, arg db.UpdateQuoteStatusParams) (db.Quote, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertInvoiceRecord", ctx, arg)
-	ret0, _ := ret[0].(db.Invoice)
+	ret := m.ctrl.Call(m, "UpdateQuoteStatusTx", ctx, arg)
+	ret0, _ := ret[0].(db.Quote)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// InsertInvoiceRecord indicates an expected call of InsertInvoiceRecord.
-func (mr *MockStoreMockRecorder) InsertInvoiceRecord(ctx, arg any) *gomock.Call {
+// UpdateQuoteStatusTx indicates an expected call of UpdateQuoteStatusTx.
+func (mr *MockStoreMockRecorder) UpdateQuoteStatusTx(ctx, arg any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertInvoiceRecord", reflect.TypeOf((*MockStore)(nil).InsertInvoiceRecord), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateQuoteStatusTx", reflect.TypeOf((*MockStore)(nil).UpdateQuoteStatusTx), ctx, arg)
 }
 
-// InsertLineItem mocks base method.
-func (m *MockStore) InsertLineItem(ctx context.Context, arg db.InsertLineItemParams) (db.LineItem, error) {
+// VerifyInvoiceChain mocks base method.
+func (m *MockStore) VerifyInvoiceChain(ctx context.Context, series pgtype.Text) (db.ChainVerification, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "InsertLineItem", ctx, arg)
-	ret0, _ := ret[0].(db.LineItem)
+	ret := m.ctrl.Call(m, "VerifyInvoiceChain", ctx, series)
+	ret0, _ := ret[0].(db.ChainVerification)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// InsertLineItem indicates an expected call of InsertLineItem.
-func (mr *MockStoreMockRecorder) InsertLineItem(ctx, arg any) *gomock.Call {
+// VerifyInvoiceChain indicates an expected call of VerifyInvoiceChain.
+func (mr *MockStoreMockRecorder) VerifyInvoiceChain(ctx, series any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertLineItem", reflect.TypeOf((*MockStore)(nil).InsertLineItem), ctx, arg)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "VerifyInvoiceChain", reflect.TypeOf((*MockStore)(nil).VerifyInvoiceChain), ctx, series)
 }
@@ -1,9 +1,50 @@
 package db
 
-import "time"
+import (
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
 
 type Invoice struct {
-	InvoiceNumber   int64     `json:"invoice_number"`
+	InvoiceNumber   int64       `json:"invoice_number"`
+	CustomerName    string      `json:"customer_name"`
+	CustomerEmail   string      `json:"customer_email"`
+	CustomerPhone   string      `json:"customer_phone"`
+	CustomerAddress string      `json:"customer_address"`
+	SenderName      string      `json:"sender_name"`
+	SenderEmail     string      `json:"sender_email"`
+	SenderPhone     string      `json:"sender_phone"`
+	SenderAddress   string      `json:"sender_address"`
+	IssueDate       time.Time   `json:"issue_date"`
+	DueDate         time.Time   `json:"due_date"`
+	Status          string      `json:"status"`
+	Subtotal        int64       `json:"subtotal"`
+	DiscountRate    int64       `json:"discount_rate"`
+	Discount        int64       `json:"discount"`
+	TotalAmount     int64       `json:"total_amount"`
+	PaymentInfo     string      `json:"payment_info"`
+	BillingCurrency string      `json:"billing_currency"`
+	Note            string      `json:"note"`
+	CreatedAt       time.Time   `json:"created_at"`
+	QuoteNumber     pgtype.Int8 `json:"quote_number"`
+	// Version starts at 1 and is incremented by every change to the invoice.
+	Version int64 `json:"version"`
+}
+
+type LineItem struct {
+	ID            int64       `json:"id"`
+	InvoiceNumber int64       `json:"invoice_number"`
+	Description   string      `json:"description"`
+	Quantity      int64       `json:"quantity"`
+	UnitPrice     int64       `json:"unit_price"`
+	TotalPrice    int64       `json:"total_price"`
+	ProductID     pgtype.Int8 `json:"product_id"`
+	TaxCode       string      `json:"tax_code"`
+}
+
+type Quote struct {
+	QuoteNumber     int64     `json:"quote_number"`
 	CustomerName    string    `json:"customer_name"`
 	CustomerEmail   string    `json:"customer_email"`
 	CustomerPhone   string    `json:"customer_phone"`
@@ -13,23 +54,59 @@ type Invoice struct {
 	SenderPhone     string    `json:"sender_phone"`
 	SenderAddress   string    `json:"sender_address"`
 	IssueDate       time.Time `json:"issue_date"`
-	DueDate         time.Time `json:"due_date"`
+	ExpiryDate      time.Time `json:"expiry_date"`
 	Status          string    `json:"status"`
 	Subtotal        int64     `json:"subtotal"`
 	DiscountRate    int64     `json:"discount_rate"`
 	Discount        int64     `json:"discount"`
 	TotalAmount     int64     `json:"total_amount"`
-	PaymentInfo     string    `json:"payment_info"`
 	BillingCurrency string    `json:"billing_currency"`
 	Note            string    `json:"note"`
 	CreatedAt       time.Time `json:"created_at"`
 }
 
-type LineItem struct {
-	ID            int64  `json:"id"`
-	InvoiceNumber int64  `json:"invoice_number"`
-	Description   string `json:"description"`
-	Quantity      int64  `json:"quantity"`
-	UnitPrice     int64  `json:"unit_price"`
-	TotalPrice    int64  `json:"total_price"`
+type QuoteLineItem struct {
+	ID          int64       `json:"id"`
+	QuoteNumber int64       `json:"quote_number"`
+	Description string      `json:"description"`
+	Quantity    int64       `json:"quantity"`
+	UnitPrice   int64       `json:"unit_price"`
+	TotalPrice  int64       `json:"total_price"`
+	ProductID   pgtype.Int8 `json:"product_id"`
+	TaxCode     string      `json:"tax_code"`
+}
+
+type Product struct {
+	ID             int64     `json:"id"`
+	SKU            string    `json:"sku"`
+	Name           string    `json:"name"`
+	Description    string    `json:"description"`
+	Unit           string    `json:"unit"`
+	DefaultTaxCode string    `json:"default_tax_code"`
+	Active         bool      `json:"active"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type ProductPrice struct {
+	ProductID int64  `json:"product_id"`
+	Currency  string `json:"currency"`
+	UnitPrice int64  `json:"unit_price"`
+}
+
+type Payment struct {
+	ID            int64     `json:"id"`
+	InvoiceNumber int64     `json:"invoice_number"`
+	Amount        int64     `json:"amount"`
+	PaidAt        time.Time `json:"paid_at"`
+	Reference     string    `json:"reference"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+type InvoiceAttachment struct {
+	ID            int64     `json:"id"`
+	InvoiceNumber int64     `json:"invoice_number"`
+	Filename      string    `json:"filename"`
+	ContentType   string    `json:"content_type"`
+	Content       []byte    `json:"-"`
+	CreatedAt     time.Time `json:"created_at"`
 }
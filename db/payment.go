@@ -0,0 +1,44 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const InsertPaymentQuery = `
+	INSERT INTO payments (
+		invoice_number, amount, paid_at, reference
+	) VALUES (
+	 $1, $2, $3, $4
+	) RETURNING *;
+`
+
+type InsertPaymentParams struct {
+	InvoiceNumber int64     `json:"invoice_number"`
+	Amount        int64     `json:"amount"`
+	PaidAt        time.Time `json:"paid_at"`
+	Reference     string    `json:"reference"`
+}
+
+func (q *Queries) InsertPayment(ctx context.Context, arg InsertPaymentParams) (Payment, error) {
+	row := q.db.QueryRow(ctx, InsertPaymentQuery,
+		arg.InvoiceNumber, arg.Amount, arg.PaidAt, arg.Reference,
+	)
+	var p Payment
+	err := row.Scan(
+		&p.ID, &p.InvoiceNumber, &p.Amount, &p.PaidAt, &p.Reference, &p.CreatedAt,
+	)
+	return p, err
+}
+
+const GetInvoicePaidAmountQuery = `
+	SELECT COALESCE(SUM(amount), 0)::bigint FROM payments
+	WHERE invoice_number = $1;
+`
+
+func (q *Queries) GetInvoicePaidAmount(ctx context.Context, invoiceNumber int64) (int64, error) {
+	row := q.db.QueryRow(ctx, GetInvoicePaidAmountQuery, invoiceNumber)
+	var amount int64
+	err := row.Scan(&amount)
+	return amount, err
+}
@@ -0,0 +1,109 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/kuthumipepple/numeris-book/util"
+)
+
+var (
+	// ErrInvoiceNotPayable is returned by RecordPaymentTx when the invoice is
+	// still a draft or has already been paid.
+	ErrInvoiceNotPayable = &Error{Kind: ErrValidation, Message: "invoice is not awaiting payment"}
+	// ErrOverpayment is returned by RecordPaymentTx when the payment is larger
+	// than the balance left on the invoice.
+	ErrOverpayment = &Error{Kind: ErrValidation, Message: "payment exceeds the balance due on the invoice"}
+	// ErrStaleInvoice is returned by RecordPaymentTx when the invoice has
+	// changed since the version that the payment was entered against.
+	ErrStaleInvoice = &Error{Kind: ErrPreconditionFailed, Message: "invoice has changed since it was read"}
+)
+
+type RecordPaymentTxParams struct {
+	InvoiceNumber int64     `json:"invoice_number"`
+	Amount        int64     `json:"amount"`
+	PaidAt        time.Time `json:"paid_at"`
+	Reference     string    `json:"reference"`
+	// Version is the version of the invoice that the payment was entered
+	// against. Zero records the payment against any version.
+	Version int64 `json:"version"`
+}
+
+type PaymentResult struct {
+	Payment
+	Invoice Invoice `json:"invoice"`
+	// BalanceDue is what is left to pay on the invoice after this payment.
+	BalanceDue int64 `json:"balance_due"`
+}
+
+// RecordPaymentTx records a payment against a pending or overdue invoice and
+// marks the invoice as paid once its total has been received. The invoice row
+// is locked so that concurrent payments cannot overpay it, and its version is
+// incremented because its balance changes.
+func (store *SQLStore) RecordPaymentTx(ctx context.Context, arg RecordPaymentTxParams) (PaymentResult, error) {
+	var result PaymentResult
+	err := store.execTx(
+		ctx,
+		func(ctx context.Context, q *Queries) error {
+
+			invoice, err := q.GetInvoiceRecordForUpdate(ctx, arg.InvoiceNumber)
+			if err != nil {
+				return err
+			}
+
+			if arg.Version != 0 && arg.Version != invoice.Version {
+				return ErrStaleInvoice
+			}
+
+			validStatuses := []string{util.PENDING_PAYMENT, util.OVERDUE}
+			if !util.Contains(validStatuses, invoice.Status) {
+				return ErrInvoiceNotPayable
+			}
+
+			paid, err := q.GetInvoicePaidAmount(ctx, invoice.InvoiceNumber)
+			if err != nil {
+				return err
+			}
+
+			balanceDue := invoice.TotalAmount - paid - arg.Amount
+			if balanceDue < 0 {
+				return ErrOverpayment
+			}
+
+			result.Payment, err = q.InsertPayment(ctx, InsertPaymentParams{
+				InvoiceNumber: invoice.InvoiceNumber,
+				Amount:        arg.Amount,
+				PaidAt:        arg.PaidAt,
+				Reference:     arg.Reference,
+			})
+			if err != nil {
+				return err
+			}
+
+			status := invoice.Status
+			if balanceDue == 0 {
+				status = util.PAID
+			}
+			before := invoice
+			invoice, err = q.UpdateInvoiceStatus(ctx, UpdateInvoiceStatusParams{
+				InvoiceNumber: invoice.InvoiceNumber,
+				Status:        status,
+			})
+			if err != nil {
+				return err
+			}
+
+			result.Invoice = invoice
+			result.BalanceDue = balanceDue
+
+			// The event has the payment along with the changes to the
+			// invoice.
+			after := struct {
+				Invoice
+				Payment Payment `json:"payment"`
+			}{invoice, result.Payment}
+			return audit(ctx, q, ActionRecordPayment, EntityInvoice, invoice.InvoiceNumber, before, after)
+		},
+	)
+	return result, err
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+)
+
+func insertPayableInvoice(t *testing.T, customerEmail, status string, issueDate time.Time, totalAmount int64) Invoice {
+	invoice, err := testQueries.InsertInvoiceRecord(context.Background(), InsertInvoiceRecordParams{
+		CustomerName:    util.RandomName(),
+		CustomerEmail:   customerEmail,
+		CustomerPhone:   util.RandomPhone(),
+		CustomerAddress: util.RandomAddress(),
+		SenderName:      util.RandomName(),
+		SenderEmail:     util.RandomEmail(),
+		SenderPhone:     util.RandomPhone(),
+		SenderAddress:   util.RandomAddress(),
+		IssueDate:       issueDate,
+		DueDate:         issueDate.AddDate(0, 0, 30),
+		Status:          status,
+		Subtotal:        totalAmount,
+		TotalAmount:     totalAmount,
+		PaymentInfo:     util.RandomString(10),
+	})
+	require.NoError(t, err)
+	return invoice
+}
+
+func TestRecordPaymentTx(t *testing.T) {
+	invoice := insertPayableInvoice(t, util.RandomEmail(), util.PENDING_PAYMENT, time.Now(), 10000)
+
+	arg := RecordPaymentTxParams{
+		InvoiceNumber: invoice.InvoiceNumber,
+		Amount:        4000,
+		PaidAt:        time.Now(),
+		Reference:     util.RandomString(8),
+	}
+	result, err := testStore.RecordPaymentTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotZero(t, result.ID)
+	require.Equal(t, invoice.InvoiceNumber, result.InvoiceNumber)
+	require.Equal(t, arg.Amount, result.Amount)
+	require.Equal(t, arg.Reference, result.Reference)
+	require.WithinDuration(t, arg.PaidAt, result.PaidAt, time.Second)
+	require.Equal(t, util.PENDING_PAYMENT, result.Invoice.Status)
+	require.Equal(t, int64(6000), result.BalanceDue)
+
+	// paying more than the balance is rejected
+	arg.Amount = 6001
+	_, err = testStore.RecordPaymentTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrOverpayment)
+
+	// paying the balance settles the invoice
+	arg.Amount = 6000
+	result, err = testStore.RecordPaymentTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, util.PAID, result.Invoice.Status)
+	require.Zero(t, result.BalanceDue)
+
+	arg.Amount = 1
+	_, err = testStore.RecordPaymentTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrInvoiceNotPayable)
+}
+
+func TestRecordPaymentTxDraft(t *testing.T) {
+	invoice := insertPayableInvoice(t, util.RandomEmail(), util.DRAFT, time.Now(), 10000)
+
+	_, err := testStore.RecordPaymentTx(context.Background(), RecordPaymentTxParams{
+		InvoiceNumber: invoice.InvoiceNumber,
+		Amount:        100,
+		PaidAt:        time.Now(),
+	})
+	require.ErrorIs(t, err, ErrInvoiceNotPayable)
+}
+
+func TestRecordPaymentTxVersion(t *testing.T) {
+	invoice := insertPayableInvoice(t, util.RandomEmail(), util.PENDING_PAYMENT, time.Now(), 10000)
+	require.Equal(t, int64(1), invoice.Version)
+
+	arg := RecordPaymentTxParams{
+		InvoiceNumber: invoice.InvoiceNumber,
+		Amount:        4000,
+		PaidAt:        time.Now(),
+		Version:       invoice.Version,
+	}
+	result, err := testStore.RecordPaymentTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(2), result.Invoice.Version)
+
+	// a payment entered against the version before the first payment is
+	// rejected and not recorded
+	_, err = testStore.RecordPaymentTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrStaleInvoice)
+	require.ErrorIs(t, err, ErrPreconditionFailed)
+
+	paid, err := testQueries.GetInvoicePaidAmount(context.Background(), invoice.InvoiceNumber)
+	require.NoError(t, err)
+	require.Equal(t, int64(4000), paid)
+
+	arg.Version = result.Invoice.Version
+	result, err = testStore.RecordPaymentTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, int64(3), result.Invoice.Version)
+}
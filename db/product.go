@@ -0,0 +1,186 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const InsertProductQuery = `
+	INSERT INTO products (
+		sku, name, description, unit, default_tax_code, active
+	) VALUES (
+	 $1, $2, $3, $4, $5, $6
+	) RETURNING *;
+`
+
+type InsertProductParams struct {
+	SKU            string `json:"sku"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	Unit           string `json:"unit"`
+	DefaultTaxCode string `json:"default_tax_code"`
+	Active         bool   `json:"active"`
+}
+
+func (q *Queries) InsertProduct(ctx context.Context, arg InsertProductParams) (Product, error) {
+	row := q.db.QueryRow(ctx, InsertProductQuery,
+		arg.SKU, arg.Name, arg.Description, arg.Unit, arg.DefaultTaxCode, arg.Active,
+	)
+	return scanProduct(row)
+}
+
+const GetProductRecordQuery = `
+	SELECT * FROM products
+	WHERE id = $1 LIMIT 1;
+`
+
+func (q *Queries) GetProductRecord(ctx context.Context, id int64) (Product, error) {
+	row := q.db.QueryRow(ctx, GetProductRecordQuery, id)
+	return scanProduct(row)
+}
+
+const ListProductRecordsQuery = `
+	SELECT * FROM products
+	WHERE $1::boolean IS NULL OR active = $1
+	ORDER BY id
+	LIMIT $2
+	OFFSET $3;
+`
+
+type ListProductRecordsParams struct {
+	Active pgtype.Bool `json:"active"`
+	Limit  int32       `json:"limit"`
+	Offset int32       `json:"offset"`
+}
+
+func (q *Queries) ListProductRecords(ctx context.Context, arg ListProductRecordsParams) ([]Product, error) {
+	rows, err := q.db.Query(ctx, ListProductRecordsQuery, arg.Active, arg.Limit, arg.Offset)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	products := []Product{}
+	for rows.Next() {
+		p, err := scanProduct(rows)
+		if err != nil {
+			return nil, err
+		}
+		products = append(products, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return products, nil
+}
+
+const UpdateProductQuery = `
+	UPDATE products
+	SET sku = $2, name = $3, description = $4, unit = $5, default_tax_code = $6, active = $7
+	WHERE id = $1
+	RETURNING *;
+`
+
+type UpdateProductParams struct {
+	ID             int64  `json:"id"`
+	SKU            string `json:"sku"`
+	Name           string `json:"name"`
+	Description    string `json:"description"`
+	Unit           string `json:"unit"`
+	DefaultTaxCode string `json:"default_tax_code"`
+	Active         bool   `json:"active"`
+}
+
+func (q *Queries) UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error) {
+	row := q.db.QueryRow(ctx, UpdateProductQuery,
+		arg.ID, arg.SKU, arg.Name, arg.Description, arg.Unit, arg.DefaultTaxCode, arg.Active,
+	)
+	return scanProduct(row)
+}
+
+const DeleteProductQuery = `
+	DELETE FROM products
+	WHERE id = $1;
+`
+
+// DeleteProduct removes a product and its prices. It returns an ErrNotFound
+// error if there is no product with the given id.
+func (q *Queries) DeleteProduct(ctx context.Context, id int64) error {
+	tag, err := q.db.Exec(ctx, DeleteProductQuery, id)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return translateError(pgx.ErrNoRows)
+	}
+	return nil
+}
+
+func scanProduct(row pgx.Row) (Product, error) {
+	var p Product
+	err := row.Scan(
+		&p.ID, &p.SKU, &p.Name, &p.Description, &p.Unit,
+		&p.DefaultTaxCode, &p.Active, &p.CreatedAt,
+	)
+	return p, err
+}
+
+const InsertProductPriceQuery = `
+	INSERT INTO product_prices (
+		product_id, currency, unit_price
+	) VALUES (
+	 $1, $2, $3
+	) RETURNING *;
+`
+
+type InsertProductPriceParams struct {
+	ProductID int64  `json:"product_id"`
+	Currency  string `json:"currency"`
+	UnitPrice int64  `json:"unit_price"`
+}
+
+func (q *Queries) InsertProductPrice(ctx context.Context, arg InsertProductPriceParams) (ProductPrice, error) {
+	row := q.db.QueryRow(ctx, InsertProductPriceQuery, arg.ProductID, arg.Currency, arg.UnitPrice)
+	var p ProductPrice
+	err := row.Scan(&p.ProductID, &p.Currency, &p.UnitPrice)
+	return p, err
+}
+
+const DeleteProductPricesQuery = `
+	DELETE FROM product_prices
+	WHERE product_id = $1;
+`
+
+func (q *Queries) DeleteProductPrices(ctx context.Context, productID int64) error {
+	_, err := q.db.Exec(ctx, DeleteProductPricesQuery, productID)
+	return err
+}
+
+const ListProductPricesQuery = `
+	SELECT * FROM product_prices
+	WHERE product_id = ANY($1::bigint[])
+	ORDER BY product_id, currency;
+`
+
+func (q *Queries) ListProductPrices(ctx context.Context, productIDs []int64) ([]ProductPrice, error) {
+	rows, err := q.db.Query(ctx, ListProductPricesQuery, productIDs)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	prices := []ProductPrice{}
+	for rows.Next() {
+		var p ProductPrice
+		if err := rows.Scan(&p.ProductID, &p.Currency, &p.UnitPrice); err != nil {
+			return nil, err
+		}
+		prices = append(prices, p)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return prices, nil
+}
@@ -0,0 +1,148 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+)
+
+func insertRandomProduct(t *testing.T) Product {
+	arg := InsertProductParams{
+		SKU:            util.RandomString(12),
+		Name:           util.RandomString(10),
+		Description:    util.RandomString(20),
+		Unit:           "hour",
+		DefaultTaxCode: "S",
+		Active:         true,
+	}
+
+	product, err := testQueries.InsertProduct(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, product.ID)
+	require.Equal(t, arg.SKU, product.SKU)
+	require.Equal(t, arg.Name, product.Name)
+	require.Equal(t, arg.Description, product.Description)
+	require.Equal(t, arg.Unit, product.Unit)
+	require.Equal(t, arg.DefaultTaxCode, product.DefaultTaxCode)
+	require.Equal(t, arg.Active, product.Active)
+	require.NotZero(t, product.CreatedAt)
+
+	return product
+}
+
+func TestInsertProduct(t *testing.T) {
+	insertRandomProduct(t)
+}
+
+func TestGetProductRecord(t *testing.T) {
+	product1 := insertRandomProduct(t)
+
+	product2, err := testQueries.GetProductRecord(context.Background(), product1.ID)
+	require.NoError(t, err)
+
+	require.Equal(t, product1.ID, product2.ID)
+	require.Equal(t, product1.SKU, product2.SKU)
+	require.Equal(t, product1.Name, product2.Name)
+	require.WithinDuration(t, product1.CreatedAt, product2.CreatedAt, time.Second)
+}
+
+func TestListProductRecords(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		insertRandomProduct(t)
+	}
+
+	arg := ListProductRecordsParams{
+		Active: pgtype.Bool{Bool: true, Valid: true},
+		Limit:  3,
+		Offset: 0,
+	}
+
+	products, err := testQueries.ListProductRecords(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, products, 3)
+
+	for _, product := range products {
+		require.True(t, product.Active)
+	}
+}
+
+func TestUpdateProduct(t *testing.T) {
+	product1 := insertRandomProduct(t)
+
+	arg := UpdateProductParams{
+		ID:             product1.ID,
+		SKU:            product1.SKU,
+		Name:           util.RandomString(10),
+		Description:    product1.Description,
+		Unit:           "day",
+		DefaultTaxCode: "Z",
+		Active:         false,
+	}
+
+	product2, err := testQueries.UpdateProduct(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, product1.ID, product2.ID)
+	require.Equal(t, arg.Name, product2.Name)
+	require.Equal(t, arg.Unit, product2.Unit)
+	require.Equal(t, arg.DefaultTaxCode, product2.DefaultTaxCode)
+	require.False(t, product2.Active)
+}
+
+func TestDeleteProduct(t *testing.T) {
+	product := insertRandomProduct(t)
+
+	_, err := testQueries.InsertProductPrice(context.Background(), InsertProductPriceParams{
+		ProductID: product.ID,
+		Currency:  "USD",
+		UnitPrice: util.RandomInt(100, 1000),
+	})
+	require.NoError(t, err)
+
+	err = testQueries.DeleteProduct(context.Background(), product.ID)
+	require.NoError(t, err)
+
+	_, err = testQueries.GetProductRecord(context.Background(), product.ID)
+	require.ErrorIs(t, err, ErrNotFound)
+
+	prices, err := testQueries.ListProductPrices(context.Background(), []int64{product.ID})
+	require.NoError(t, err)
+	require.Empty(t, prices)
+
+	err = testQueries.DeleteProduct(context.Background(), product.ID)
+	require.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestProductPrices(t *testing.T) {
+	product := insertRandomProduct(t)
+
+	eur, err := testQueries.InsertProductPrice(context.Background(), InsertProductPriceParams{
+		ProductID: product.ID,
+		Currency:  "EUR",
+		UnitPrice: util.RandomInt(100, 1000),
+	})
+	require.NoError(t, err)
+
+	usd, err := testQueries.InsertProductPrice(context.Background(), InsertProductPriceParams{
+		ProductID: product.ID,
+		Currency:  "USD",
+		UnitPrice: util.RandomInt(100, 1000),
+	})
+	require.NoError(t, err)
+
+	prices, err := testQueries.ListProductPrices(context.Background(), []int64{product.ID})
+	require.NoError(t, err)
+	require.Equal(t, []ProductPrice{eur, usd}, prices)
+
+	err = testQueries.DeleteProductPrices(context.Background(), product.ID)
+	require.NoError(t, err)
+
+	prices, err = testQueries.ListProductPrices(context.Background(), []int64{product.ID})
+	require.NoError(t, err)
+	require.Empty(t, prices)
+}
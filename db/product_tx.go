@@ -0,0 +1,190 @@
+package db
+
+import (
+	"context"
+)
+
+type ProductResult struct {
+	Product
+	Prices []ProductPrice `json:"prices"`
+}
+
+type ProductPriceParams struct {
+	Currency  string `json:"currency"`
+	UnitPrice int64  `json:"unit_price"`
+}
+
+type CreateProductTxParams struct {
+	SKU            string               `json:"sku"`
+	Name           string               `json:"name"`
+	Description    string               `json:"description"`
+	Unit           string               `json:"unit"`
+	DefaultTaxCode string               `json:"default_tax_code"`
+	Active         bool                 `json:"active"`
+	Prices         []ProductPriceParams `json:"prices"`
+}
+
+func (store *SQLStore) CreateProductTx(ctx context.Context, arg CreateProductTxParams) (ProductResult, error) {
+	var result ProductResult
+	err := store.execTx(
+		ctx,
+		func(ctx context.Context, q *Queries) error {
+
+			product, err := q.InsertProduct(ctx, InsertProductParams{
+				SKU:            arg.SKU,
+				Name:           arg.Name,
+				Description:    arg.Description,
+				Unit:           arg.Unit,
+				DefaultTaxCode: arg.DefaultTaxCode,
+				Active:         arg.Active,
+			})
+			if err != nil {
+				return err
+			}
+
+			result.Product = product
+			result.Prices, err = insertProductPrices(ctx, q, product.ID, arg.Prices)
+			if err != nil {
+				return err
+			}
+			return audit(ctx, q, ActionCreate, EntityProduct, product.ID, nil, result)
+		},
+	)
+	return result, err
+}
+
+type UpdateProductTxParams struct {
+	ID int64 `json:"id"`
+	CreateProductTxParams
+}
+
+// UpdateProductTx replaces every attribute of a product, including its full
+// set of prices.
+func (store *SQLStore) UpdateProductTx(ctx context.Context, arg UpdateProductTxParams) (ProductResult, error) {
+	var result ProductResult
+	err := store.execTx(
+		ctx,
+		func(ctx context.Context, q *Queries) error {
+
+			before, err := getProduct(ctx, q, arg.ID)
+			if err != nil {
+				return err
+			}
+
+			product, err := q.UpdateProduct(ctx, UpdateProductParams{
+				ID:             arg.ID,
+				SKU:            arg.SKU,
+				Name:           arg.Name,
+				Description:    arg.Description,
+				Unit:           arg.Unit,
+				DefaultTaxCode: arg.DefaultTaxCode,
+				Active:         arg.Active,
+			})
+			if err != nil {
+				return err
+			}
+
+			if err := q.DeleteProductPrices(ctx, product.ID); err != nil {
+				return err
+			}
+
+			result.Product = product
+			result.Prices, err = insertProductPrices(ctx, q, product.ID, arg.Prices)
+			if err != nil {
+				return err
+			}
+			return audit(ctx, q, ActionUpdate, EntityProduct, product.ID, before, result)
+		},
+	)
+	return result, err
+}
+
+// DeleteProductTx deletes a product and its prices.
+func (store *SQLStore) DeleteProductTx(ctx context.Context, id int64) error {
+	return store.execTx(
+		ctx,
+		func(ctx context.Context, q *Queries) error {
+
+			before, err := getProduct(ctx, q, id)
+			if err != nil {
+				return err
+			}
+
+			if err := q.DeleteProduct(ctx, id); err != nil {
+				return err
+			}
+			return audit(ctx, q, ActionDelete, EntityProduct, id, before, nil)
+		},
+	)
+}
+
+func insertProductPrices(ctx context.Context, q *Queries, productID int64, prices []ProductPriceParams) ([]ProductPrice, error) {
+	result := []ProductPrice{}
+	for _, price := range prices {
+		p, err := q.InsertProductPrice(ctx, InsertProductPriceParams{
+			ProductID: productID,
+			Currency:  price.Currency,
+			UnitPrice: price.UnitPrice,
+		})
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, p)
+	}
+	return result, nil
+}
+
+func (store *SQLStore) GetProduct(ctx context.Context, id int64) (ProductResult, error) {
+	return getProduct(ctx, store.Queries, id)
+}
+
+func getProduct(ctx context.Context, q *Queries, id int64) (ProductResult, error) {
+	product, err := q.GetProductRecord(ctx, id)
+	if err != nil {
+		return ProductResult{}, err
+	}
+
+	prices, err := q.ListProductPrices(ctx, []int64{id})
+	if err != nil {
+		return ProductResult{}, err
+	}
+
+	return ProductResult{Product: product, Prices: prices}, nil
+}
+
+func (store *SQLStore) ListProducts(ctx context.Context, arg ListProductRecordsParams) ([]ProductResult, error) {
+	products, err := store.ListProductRecords(ctx, arg)
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]int64, len(products))
+	results := make([]ProductResult, len(products))
+	index := make(map[int64]int, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+		results[i] = ProductResult{Product: p, Prices: []ProductPrice{}}
+		index[p.ID] = i
+	}
+
+	prices, err := store.ListProductPrices(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	for _, price := range prices {
+		i := index[price.ProductID]
+		results[i].Prices = append(results[i].Prices, price)
+	}
+
+	return results, nil
+}
+
+// Price returns the product's default unit price in the given currency.
+func (p ProductResult) Price(currency string) (int64, bool) {
+	for _, price := range p.Prices {
+		if price.Currency == currency {
+			return price.UnitPrice, true
+		}
+	}
+	return 0, false
+}
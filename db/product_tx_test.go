@@ -0,0 +1,126 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomProductTx(t *testing.T) ProductResult {
+	arg := CreateProductTxParams{
+		SKU:            util.RandomString(12),
+		Name:           util.RandomString(10),
+		Description:    util.RandomString(20),
+		Unit:           "piece",
+		DefaultTaxCode: "S",
+		Active:         true,
+		Prices: []ProductPriceParams{
+			{Currency: "EUR", UnitPrice: util.RandomInt(100, 1000)},
+			{Currency: "USD", UnitPrice: util.RandomInt(100, 1000)},
+		},
+	}
+
+	result, err := testStore.CreateProductTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, result.ID)
+	require.Equal(t, arg.SKU, result.SKU)
+	require.Equal(t, arg.Name, result.Name)
+	require.True(t, result.Active)
+
+	require.Len(t, result.Prices, len(arg.Prices))
+	for i, price := range result.Prices {
+		require.Equal(t, result.ID, price.ProductID)
+		require.Equal(t, arg.Prices[i].Currency, price.Currency)
+		require.Equal(t, arg.Prices[i].UnitPrice, price.UnitPrice)
+	}
+
+	return result
+}
+
+func TestCreateProductTx(t *testing.T) {
+	createRandomProductTx(t)
+}
+
+func TestCreateProductTxDuplicateSKU(t *testing.T) {
+	product := createRandomProductTx(t)
+
+	_, err := testStore.CreateProductTx(context.Background(), CreateProductTxParams{
+		SKU:  product.SKU,
+		Name: util.RandomString(10),
+		Unit: "piece",
+	})
+	require.ErrorIs(t, err, ErrConflict)
+}
+
+func TestGetProduct(t *testing.T) {
+	product1 := createRandomProductTx(t)
+
+	product2, err := testStore.GetProduct(context.Background(), product1.ID)
+	require.NoError(t, err)
+
+	require.Equal(t, product1.ID, product2.ID)
+	require.Equal(t, product1.SKU, product2.SKU)
+	require.Equal(t, product1.Prices, product2.Prices)
+
+	price, ok := product2.Price("USD")
+	require.True(t, ok)
+	require.Equal(t, product1.Prices[1].UnitPrice, price)
+
+	_, ok = product2.Price("JPY")
+	require.False(t, ok)
+}
+
+func TestListProducts(t *testing.T) {
+	for i := 0; i < 3; i++ {
+		createRandomProductTx(t)
+	}
+
+	results, err := testStore.ListProducts(context.Background(), ListProductRecordsParams{
+		Active: pgtype.Bool{Bool: true, Valid: true},
+		Limit:  3,
+	})
+	require.NoError(t, err)
+	require.Len(t, results, 3)
+
+	for _, result := range results {
+		for _, price := range result.Prices {
+			require.Equal(t, result.ID, price.ProductID)
+		}
+	}
+}
+
+func TestUpdateProductTx(t *testing.T) {
+	product1 := createRandomProductTx(t)
+
+	arg := UpdateProductTxParams{
+		ID: product1.ID,
+		CreateProductTxParams: CreateProductTxParams{
+			SKU:            product1.SKU,
+			Name:           util.RandomString(10),
+			Description:    product1.Description,
+			Unit:           product1.Unit,
+			DefaultTaxCode: product1.DefaultTaxCode,
+			Active:         false,
+			Prices: []ProductPriceParams{
+				{Currency: "GBP", UnitPrice: util.RandomInt(100, 1000)},
+			},
+		},
+	}
+
+	product2, err := testStore.UpdateProductTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, product1.ID, product2.ID)
+	require.Equal(t, arg.Name, product2.Name)
+	require.False(t, product2.Active)
+	require.Len(t, product2.Prices, 1)
+	require.Equal(t, "GBP", product2.Prices[0].Currency)
+
+	product3, err := testStore.GetProduct(context.Background(), product1.ID)
+	require.NoError(t, err)
+	require.Equal(t, product2.Prices, product3.Prices)
+}
@@ -2,11 +2,50 @@ package db
 
 import (
 	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
 )
 
 type Querier interface {
 	InsertInvoiceRecord(ctx context.Context, arg InsertInvoiceRecordParams) (Invoice, error)
 	InsertLineItem(ctx context.Context, arg InsertLineItemParams) (LineItem, error)
+	InsertLineItems(ctx context.Context, arg []InsertLineItemParams) ([]LineItem, error)
+	GetInvoiceRecordForUpdate(ctx context.Context, invoiceNumber int64) (Invoice, error)
+	UpdateInvoiceStatus(ctx context.Context, arg UpdateInvoiceStatusParams) (Invoice, error)
+	ListInvoices(ctx context.Context, arg ListInvoicesParams) ([]Invoice, error)
+	StreamInvoices(ctx context.Context, arg InvoiceFilter, fn func(Invoice) error) error
+	StreamInvoiceLineItems(ctx context.Context, arg InvoiceFilter, fn func(Invoice, LineItem) error) error
+	InsertQuoteRecord(ctx context.Context, arg InsertQuoteRecordParams) (Quote, error)
+	GetQuoteRecord(ctx context.Context, quoteNumber int64) (Quote, error)
+	GetQuoteRecordForUpdate(ctx context.Context, quoteNumber int64) (Quote, error)
+	UpdateQuoteStatus(ctx context.Context, arg UpdateQuoteStatusParams) (Quote, error)
+	InsertQuoteLineItem(ctx context.Context, arg InsertQuoteLineItemParams) (QuoteLineItem, error)
+	ListQuoteLineItems(ctx context.Context, quoteNumber int64) ([]QuoteLineItem, error)
+	InsertProduct(ctx context.Context, arg InsertProductParams) (Product, error)
+	GetProductRecord(ctx context.Context, id int64) (Product, error)
+	ListProductRecords(ctx context.Context, arg ListProductRecordsParams) ([]Product, error)
+	UpdateProduct(ctx context.Context, arg UpdateProductParams) (Product, error)
+	DeleteProduct(ctx context.Context, id int64) error
+	InsertProductPrice(ctx context.Context, arg InsertProductPriceParams) (ProductPrice, error)
+	DeleteProductPrices(ctx context.Context, productID int64) error
+	ListProductPrices(ctx context.Context, productIDs []int64) ([]ProductPrice, error)
+	RevenueByProduct(ctx context.Context, arg RevenueByProductParams) ([]RevenueByProductRow, error)
+	ARAging(ctx context.Context, arg ARAgingParams) ([]ARAgingRow, error)
+	RevenueSummary(ctx context.Context, arg RevenueSummaryParams) ([]RevenueSummaryRow, error)
+	InsertPayment(ctx context.Context, arg InsertPaymentParams) (Payment, error)
+	GetInvoicePaidAmount(ctx context.Context, invoiceNumber int64) (int64, error)
+	GetCustomerName(ctx context.Context, customerEmail string) (string, error)
+	GetCustomerBalance(ctx context.Context, arg GetCustomerBalanceParams) (int64, error)
+	ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]StatementEntry, error)
+	InsertInvoiceAttachment(ctx context.Context, arg InsertInvoiceAttachmentParams) (InvoiceAttachment, error)
+	GetMigrationVersion(ctx context.Context) (MigrationVersion, error)
+	InsertAuditEvent(ctx context.Context, arg InsertAuditEventParams) (AuditEvent, error)
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	LockInvoiceSeries(ctx context.Context, series string) error
+	GetInvoiceChainHead(ctx context.Context, series string) (InvoiceChainLink, error)
+	ListInvoiceChainHeads(ctx context.Context, series pgtype.Text) ([]InvoiceChainLink, error)
+	InsertInvoiceChainLink(ctx context.Context, arg InsertInvoiceChainLinkParams) (InvoiceChainLink, error)
+	StreamInvoiceChain(ctx context.Context, series pgtype.Text, fn func(InvoiceChainEntry) error) error
 }
 
 var _ Querier = (*Queries)(nil)
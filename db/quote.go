@@ -0,0 +1,162 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+const InsertQuoteRecordQuery = `
+	INSERT INTO quotes (
+		customer_name, customer_email, customer_phone, customer_address,
+		sender_name, sender_email, sender_phone, sender_address,
+		issue_date, expiry_date, status, subtotal,
+		discount_rate, discount, total_amount, billing_currency
+	) VALUES (
+	 $1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15,
+	 COALESCE($16, 'USD')
+	) RETURNING *;
+`
+
+type InsertQuoteRecordParams struct {
+	CustomerName    string      `json:"customer_name"`
+	CustomerEmail   string      `json:"customer_email"`
+	CustomerPhone   string      `json:"customer_phone"`
+	CustomerAddress string      `json:"customer_address"`
+	SenderName      string      `json:"sender_name"`
+	SenderEmail     string      `json:"sender_email"`
+	SenderPhone     string      `json:"sender_phone"`
+	SenderAddress   string      `json:"sender_address"`
+	IssueDate       time.Time   `json:"issue_date"`
+	ExpiryDate      time.Time   `json:"expiry_date"`
+	Status          string      `json:"status"`
+	Subtotal        int64       `json:"subtotal"`
+	DiscountRate    int64       `json:"discount_rate"`
+	Discount        int64       `json:"discount"`
+	TotalAmount     int64       `json:"total_amount"`
+	BillingCurrency pgtype.Text `json:"billing_currency"`
+}
+
+func (q *Queries) InsertQuoteRecord(ctx context.Context, arg InsertQuoteRecordParams) (Quote, error) {
+	row := q.db.QueryRow(ctx, InsertQuoteRecordQuery,
+		arg.CustomerName, arg.CustomerEmail, arg.CustomerPhone, arg.CustomerAddress,
+		arg.SenderName, arg.SenderEmail, arg.SenderPhone, arg.SenderAddress,
+		arg.IssueDate, arg.ExpiryDate, arg.Status, arg.Subtotal,
+		arg.DiscountRate, arg.Discount, arg.TotalAmount, arg.BillingCurrency,
+	)
+	return scanQuote(row)
+}
+
+const GetQuoteRecordQuery = `
+	SELECT * FROM quotes
+	WHERE quote_number = $1 LIMIT 1;
+`
+
+func (q *Queries) GetQuoteRecord(ctx context.Context, quoteNumber int64) (Quote, error) {
+	row := q.db.QueryRow(ctx, GetQuoteRecordQuery, quoteNumber)
+	return scanQuote(row)
+}
+
+const GetQuoteRecordForUpdateQuery = `
+	SELECT * FROM quotes
+	WHERE quote_number = $1 LIMIT 1
+	FOR NO KEY UPDATE;
+`
+
+func (q *Queries) GetQuoteRecordForUpdate(ctx context.Context, quoteNumber int64) (Quote, error) {
+	row := q.db.QueryRow(ctx, GetQuoteRecordForUpdateQuery, quoteNumber)
+	return scanQuote(row)
+}
+
+const UpdateQuoteStatusQuery = `
+	UPDATE quotes
+	SET status = $2
+	WHERE quote_number = $1
+	RETURNING *;
+`
+
+type UpdateQuoteStatusParams struct {
+	QuoteNumber int64  `json:"quote_number"`
+	Status      string `json:"status"`
+}
+
+func (q *Queries) UpdateQuoteStatus(ctx context.Context, arg UpdateQuoteStatusParams) (Quote, error) {
+	row := q.db.QueryRow(ctx, UpdateQuoteStatusQuery, arg.QuoteNumber, arg.Status)
+	return scanQuote(row)
+}
+
+func scanQuote(row pgx.Row) (Quote, error) {
+	var i Quote
+	err := row.Scan(
+		&i.QuoteNumber, &i.CustomerName, &i.CustomerEmail, &i.CustomerPhone, &i.CustomerAddress,
+		&i.SenderName, &i.SenderEmail, &i.SenderPhone, &i.SenderAddress,
+		&i.IssueDate, &i.ExpiryDate, &i.Status,
+		&i.Subtotal, &i.DiscountRate, &i.Discount, &i.TotalAmount,
+		&i.BillingCurrency, &i.Note, &i.CreatedAt,
+	)
+	return i, err
+}
+
+const InsertQuoteLineItemQuery = `
+	INSERT INTO quote_line_items (
+		quote_number, description, quantity, unit_price, total_price,
+		product_id, tax_code
+	) VALUES (
+	 $1, $2, $3, $4, $5, $6, $7
+	) RETURNING *;
+`
+
+type InsertQuoteLineItemParams struct {
+	QuoteNumber int64       `json:"quote_number"`
+	Description string      `json:"description"`
+	Quantity    int64       `json:"quantity"`
+	UnitPrice   int64       `json:"unit_price"`
+	TotalPrice  int64       `json:"total_price"`
+	ProductID   pgtype.Int8 `json:"product_id"`
+	TaxCode     string      `json:"tax_code"`
+}
+
+func (q *Queries) InsertQuoteLineItem(ctx context.Context, arg InsertQuoteLineItemParams) (QuoteLineItem, error) {
+	row := q.db.QueryRow(ctx, InsertQuoteLineItemQuery,
+		arg.QuoteNumber, arg.Description, arg.Quantity, arg.UnitPrice, arg.TotalPrice,
+		arg.ProductID, arg.TaxCode,
+	)
+	var l QuoteLineItem
+	err := row.Scan(
+		&l.ID, &l.QuoteNumber, &l.Description, &l.Quantity, &l.UnitPrice, &l.TotalPrice,
+		&l.ProductID, &l.TaxCode,
+	)
+	return l, err
+}
+
+const ListQuoteLineItemsQuery = `
+	SELECT * FROM quote_line_items
+	WHERE quote_number = $1
+	ORDER BY id;
+`
+
+func (q *Queries) ListQuoteLineItems(ctx context.Context, quoteNumber int64) ([]QuoteLineItem, error) {
+	rows, err := q.db.Query(ctx, ListQuoteLineItemsQuery, quoteNumber)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []QuoteLineItem{}
+	for rows.Next() {
+		var l QuoteLineItem
+		if err := rows.Scan(
+			&l.ID, &l.QuoteNumber, &l.Description, &l.Quantity, &l.UnitPrice, &l.TotalPrice,
+			&l.ProductID, &l.TaxCode,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, l)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
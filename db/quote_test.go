@@ -0,0 +1,117 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+)
+
+func insertRandomQuoteRecord(t *testing.T) Quote {
+	arg := InsertQuoteRecordParams{
+		CustomerName:    util.RandomName(),
+		CustomerEmail:   util.RandomEmail(),
+		CustomerPhone:   util.RandomPhone(),
+		CustomerAddress: util.RandomAddress(),
+		SenderName:      util.RandomName(),
+		SenderEmail:     util.RandomEmail(),
+		SenderPhone:     util.RandomPhone(),
+		SenderAddress:   util.RandomAddress(),
+		IssueDate:       time.Now(),
+		ExpiryDate:      time.Now().AddDate(0, 0, 14),
+		Status:          util.RandomQuoteStatus(),
+		Subtotal:        util.RandomInt(100, 10000),
+		DiscountRate:    util.RandomInt(0, 10000),
+		Discount:        util.RandomInt(0, 10000),
+		TotalAmount:     util.RandomInt(100, 10000),
+	}
+
+	quote, err := testQueries.InsertQuoteRecord(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, quote.QuoteNumber)
+
+	require.Equal(t, arg.CustomerName, quote.CustomerName)
+	require.Equal(t, arg.CustomerEmail, quote.CustomerEmail)
+	require.Equal(t, arg.CustomerPhone, quote.CustomerPhone)
+	require.Equal(t, arg.CustomerAddress, quote.CustomerAddress)
+	require.Equal(t, arg.SenderName, quote.SenderName)
+	require.Equal(t, arg.SenderEmail, quote.SenderEmail)
+	require.Equal(t, arg.SenderPhone, quote.SenderPhone)
+	require.Equal(t, arg.SenderAddress, quote.SenderAddress)
+	require.WithinDuration(t, arg.IssueDate, quote.IssueDate, time.Second)
+	require.WithinDuration(t, arg.ExpiryDate, quote.ExpiryDate, time.Second)
+	require.Equal(t, arg.Status, quote.Status)
+	require.Equal(t, arg.Subtotal, quote.Subtotal)
+	require.Equal(t, arg.DiscountRate, quote.DiscountRate)
+	require.Equal(t, arg.Discount, quote.Discount)
+	require.Equal(t, arg.TotalAmount, quote.TotalAmount)
+	require.Equal(t, money.USD, quote.BillingCurrency)
+
+	require.NotEmpty(t, quote.Note)
+	require.NotZero(t, quote.CreatedAt)
+
+	return quote
+}
+
+func TestInsertQuoteRecord(t *testing.T) {
+	insertRandomQuoteRecord(t)
+}
+
+func TestGetQuoteRecord(t *testing.T) {
+	quote1 := insertRandomQuoteRecord(t)
+
+	quote2, err := testQueries.GetQuoteRecord(context.Background(), quote1.QuoteNumber)
+	require.NoError(t, err)
+
+	require.Equal(t, quote1.QuoteNumber, quote2.QuoteNumber)
+	require.Equal(t, quote1.CustomerEmail, quote2.CustomerEmail)
+	require.Equal(t, quote1.Status, quote2.Status)
+	require.Equal(t, quote1.TotalAmount, quote2.TotalAmount)
+	require.WithinDuration(t, quote1.ExpiryDate, quote2.ExpiryDate, time.Second)
+}
+
+func TestUpdateQuoteStatus(t *testing.T) {
+	quote1 := insertRandomQuoteRecord(t)
+
+	arg := UpdateQuoteStatusParams{
+		QuoteNumber: quote1.QuoteNumber,
+		Status:      util.DECLINED,
+	}
+
+	quote2, err := testQueries.UpdateQuoteStatus(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.Equal(t, quote1.QuoteNumber, quote2.QuoteNumber)
+	require.Equal(t, util.DECLINED, quote2.Status)
+	require.Equal(t, quote1.TotalAmount, quote2.TotalAmount)
+}
+
+func TestInsertQuoteLineItem(t *testing.T) {
+	quote := insertRandomQuoteRecord(t)
+
+	arg := InsertQuoteLineItemParams{
+		QuoteNumber: quote.QuoteNumber,
+		Description: util.RandomString(10),
+		Quantity:    util.RandomInt(1, 100),
+		UnitPrice:   util.RandomInt(100, 1000),
+		TotalPrice:  util.RandomInt(100, 1000),
+	}
+
+	lineItem, err := testQueries.InsertQuoteLineItem(context.Background(), arg)
+	require.NoError(t, err)
+
+	require.NotZero(t, lineItem.ID)
+	require.Equal(t, arg.QuoteNumber, lineItem.QuoteNumber)
+	require.Equal(t, arg.Description, lineItem.Description)
+	require.Equal(t, arg.Quantity, lineItem.Quantity)
+	require.Equal(t, arg.UnitPrice, lineItem.UnitPrice)
+	require.Equal(t, arg.TotalPrice, lineItem.TotalPrice)
+
+	lineItems, err := testQueries.ListQuoteLineItems(context.Background(), quote.QuoteNumber)
+	require.NoError(t, err)
+	require.Equal(t, []QuoteLineItem{lineItem}, lineItems)
+}
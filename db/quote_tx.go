@@ -0,0 +1,229 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/util"
+)
+
+// ErrQuoteNotConvertible is returned by ConvertQuoteTx when the quote has been
+// declined, has expired or is otherwise no longer open for conversion.
+var ErrQuoteNotConvertible = &Error{Kind: ErrValidation, Message: "quote cannot be converted into an invoice"}
+
+// ErrQuoteDecided is returned by UpdateQuoteStatusTx when the quote is no
+// longer sent, because it has already been accepted, declined, expired or
+// converted.
+var ErrQuoteDecided = &Error{Kind: ErrConflict, Message: "quote is no longer sent and cannot change status"}
+
+type CreateQuoteTxParams struct {
+	CustomerName    string                      `json:"customer_name"`
+	CustomerEmail   string                      `json:"customer_email"`
+	CustomerPhone   string                      `json:"customer_phone"`
+	CustomerAddress string                      `json:"customer_address"`
+	SenderName      string                      `json:"sender_name"`
+	SenderEmail     string                      `json:"sender_email"`
+	SenderPhone     string                      `json:"sender_phone"`
+	SenderAddress   string                      `json:"sender_address"`
+	IssueDate       time.Time                   `json:"issue_date"`
+	ExpiryDate      time.Time                   `json:"expiry_date"`
+	Status          string                      `json:"status"`
+	Subtotal        int64                       `json:"subtotal"`
+	DiscountRate    int64                       `json:"discount_rate"`
+	Discount        int64                       `json:"discount"`
+	TotalAmount     int64                       `json:"total_amount"`
+	BillingCurrency pgtype.Text                 `json:"billing_currency"`
+	Items           []InsertQuoteLineItemParams `json:"line_items"`
+}
+
+type QuoteResult struct {
+	Quote
+	LineItems []QuoteLineItem `json:"line_items"`
+}
+
+func (store *SQLStore) CreateQuoteTx(ctx context.Context, arg CreateQuoteTxParams) (QuoteResult, error) {
+	var result QuoteResult
+	err := store.execTx(
+		ctx,
+		func(ctx context.Context, q *Queries) error {
+
+			quote, err := q.InsertQuoteRecord(
+				ctx,
+				InsertQuoteRecordParams{
+					CustomerName:    arg.CustomerName,
+					CustomerEmail:   arg.CustomerEmail,
+					CustomerPhone:   arg.CustomerPhone,
+					CustomerAddress: arg.CustomerAddress,
+					SenderName:      arg.SenderName,
+					SenderEmail:     arg.SenderEmail,
+					SenderPhone:     arg.SenderPhone,
+					SenderAddress:   arg.SenderAddress,
+					IssueDate:       arg.IssueDate,
+					ExpiryDate:      arg.ExpiryDate,
+					Status:          arg.Status,
+					Subtotal:        arg.Subtotal,
+					DiscountRate:    arg.DiscountRate,
+					Discount:        arg.Discount,
+					TotalAmount:     arg.TotalAmount,
+					BillingCurrency: arg.BillingCurrency,
+				},
+			)
+			if err != nil {
+				return err
+			}
+
+			result = QuoteResult{Quote: quote}
+
+			for _, item := range arg.Items {
+				item.QuoteNumber = quote.QuoteNumber
+				lineItem, err := q.InsertQuoteLineItem(ctx, item)
+				if err != nil {
+					return err
+				}
+				result.LineItems = append(result.LineItems, lineItem)
+			}
+			return audit(ctx, q, ActionCreate, EntityQuote, quote.QuoteNumber, nil, result)
+		},
+	)
+	return result, err
+}
+
+func (store *SQLStore) GetQuote(ctx context.Context, id int64) (QuoteResult, error) {
+	quote, err := store.GetQuoteRecord(ctx, id)
+	if err != nil {
+		return QuoteResult{}, err
+	}
+
+	lineItems, err := store.ListQuoteLineItems(ctx, id)
+	if err != nil {
+		return QuoteResult{}, err
+	}
+
+	return QuoteResult{Quote: quote, LineItems: lineItems}, nil
+}
+
+type ConvertQuoteTxParams struct {
+	QuoteNumber int64     `json:"quote_number"`
+	IssueDate   time.Time `json:"issue_date"`
+	DueDate     time.Time `json:"due_date"`
+	Status      string    `json:"status"`
+	PaymentInfo string    `json:"payment_info"`
+}
+
+// ConvertQuoteTx creates an invoice from a sent or accepted quote, copying its
+// parties, line items and discount, and marks the quote as accepted. The quote
+// row is locked for the duration of the transaction, and the unique index on
+// invoices.quote_number rejects a second conversion of the same quote.
+func (store *SQLStore) ConvertQuoteTx(ctx context.Context, arg ConvertQuoteTxParams) (InvoiceResult, error) {
+	var result InvoiceResult
+	err := store.execTx(
+		ctx,
+		func(ctx context.Context, q *Queries) error {
+
+			quote, err := q.GetQuoteRecordForUpdate(ctx, arg.QuoteNumber)
+			if err != nil {
+				return err
+			}
+
+			validStatuses := []string{util.SENT, util.ACCEPTED}
+			if !util.Contains(validStatuses, quote.Status) || quote.ExpiryDate.Before(arg.IssueDate) {
+				return ErrQuoteNotConvertible
+			}
+
+			quoteItems, err := q.ListQuoteLineItems(ctx, quote.QuoteNumber)
+			if err != nil {
+				return err
+			}
+
+			items := make([]InsertLineItemParams, len(quoteItems))
+			for i, v := range quoteItems {
+				items[i] = InsertLineItemParams{
+					Description: v.Description,
+					Quantity:    v.Quantity,
+					UnitPrice:   v.UnitPrice,
+					TotalPrice:  v.TotalPrice,
+					ProductID:   v.ProductID,
+					TaxCode:     v.TaxCode,
+				}
+			}
+
+			result, err = createInvoice(ctx, q, CreateInvoiceTxParams{
+				CustomerName:    quote.CustomerName,
+				CustomerEmail:   quote.CustomerEmail,
+				CustomerPhone:   quote.CustomerPhone,
+				CustomerAddress: quote.CustomerAddress,
+				SenderName:      quote.SenderName,
+				SenderEmail:     quote.SenderEmail,
+				SenderPhone:     quote.SenderPhone,
+				SenderAddress:   quote.SenderAddress,
+				IssueDate:       arg.IssueDate,
+				DueDate:         arg.DueDate,
+				Status:          arg.Status,
+				Subtotal:        quote.Subtotal,
+				DiscountRate:    quote.DiscountRate,
+				Discount:        quote.Discount,
+				TotalAmount:     quote.TotalAmount,
+				PaymentInfo:     arg.PaymentInfo,
+				QuoteNumber:     pgtype.Int8{Int64: quote.QuoteNumber, Valid: true},
+				BillingCurrency: pgtype.Text{String: quote.BillingCurrency, Valid: true},
+				Items:           items,
+			})
+			if err != nil {
+				return err
+			}
+			if err = chainInvoice(ctx, q, result); err != nil {
+				return err
+			}
+			err = audit(ctx, q, ActionCreate, EntityInvoice, result.InvoiceNumber, nil, result)
+			if err != nil {
+				return err
+			}
+
+			converted := quote
+			if quote.Status != util.ACCEPTED {
+				converted, err = q.UpdateQuoteStatus(ctx, UpdateQuoteStatusParams{
+					QuoteNumber: quote.QuoteNumber,
+					Status:      util.ACCEPTED,
+				})
+				if err != nil {
+					return err
+				}
+			}
+			// The event links the quote to the invoice made from it.
+			after := struct {
+				Quote
+				InvoiceNumber int64 `json:"invoice_number"`
+			}{converted, result.InvoiceNumber}
+			return audit(ctx, q, ActionConvert, EntityQuote, quote.QuoteNumber, quote, after)
+		},
+	)
+	return result, err
+}
+
+// UpdateQuoteStatusTx sets the status of a quote that is still sent. The
+// status is checked with the quote row locked, so that a quote converted by a
+// concurrent ConvertQuoteTx is not overwritten.
+func (store *SQLStore) UpdateQuoteStatusTx(ctx context.Context, arg UpdateQuoteStatusParams) (Quote, error) {
+	var result Quote
+	err := store.execTx(
+		ctx,
+		func(ctx context.Context, q *Queries) error {
+
+			quote, err := q.GetQuoteRecordForUpdate(ctx, arg.QuoteNumber)
+			if err != nil {
+				return err
+			}
+			if quote.Status != util.SENT {
+				return ErrQuoteDecided
+			}
+
+			result, err = q.UpdateQuoteStatus(ctx, arg)
+			if err != nil {
+				return err
+			}
+			return audit(ctx, q, ActionUpdateStatus, EntityQuote, quote.QuoteNumber, quote, result)
+		},
+	)
+	return result, err
+}
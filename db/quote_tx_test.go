@@ -0,0 +1,180 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+)
+
+func createRandomQuoteTx(t *testing.T, status string) QuoteResult {
+	n := 3
+	testItems := make([]InsertQuoteLineItemParams, n)
+	for i := 0; i < n; i++ {
+		testItems[i] = InsertQuoteLineItemParams{
+			Description: util.RandomString(10),
+			Quantity:    util.RandomInt(1, 100),
+			UnitPrice:   util.RandomInt(100, 1000),
+			TotalPrice:  util.RandomInt(100, 1000),
+		}
+	}
+	arg := CreateQuoteTxParams{
+		CustomerName:    util.RandomName(),
+		CustomerEmail:   util.RandomEmail(),
+		CustomerPhone:   util.RandomPhone(),
+		CustomerAddress: util.RandomAddress(),
+		SenderName:      util.RandomName(),
+		SenderEmail:     util.RandomEmail(),
+		SenderPhone:     util.RandomPhone(),
+		SenderAddress:   util.RandomAddress(),
+		IssueDate:       time.Now(),
+		ExpiryDate:      time.Now().AddDate(0, 0, 14),
+		Status:          status,
+		Subtotal:        util.RandomInt(100, 10000),
+		DiscountRate:    util.RandomInt(0, 10000),
+		Discount:        util.RandomInt(0, 10000),
+		TotalAmount:     util.RandomInt(100, 10000),
+		Items:           testItems,
+	}
+
+	result, err := testStore.CreateQuoteTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.NotEmpty(t, result)
+
+	quote := result.Quote
+	require.NotZero(t, quote.QuoteNumber)
+	require.Equal(t, arg.CustomerEmail, quote.CustomerEmail)
+	require.Equal(t, arg.Status, quote.Status)
+	require.Equal(t, arg.Subtotal, quote.Subtotal)
+	require.Equal(t, arg.TotalAmount, quote.TotalAmount)
+
+	require.Len(t, result.LineItems, n)
+	for i, lineItem := range result.LineItems {
+		require.NotZero(t, lineItem.ID)
+		require.Equal(t, quote.QuoteNumber, lineItem.QuoteNumber)
+		require.Equal(t, testItems[i].Description, lineItem.Description)
+		require.Equal(t, testItems[i].Quantity, lineItem.Quantity)
+		require.Equal(t, testItems[i].UnitPrice, lineItem.UnitPrice)
+		require.Equal(t, testItems[i].TotalPrice, lineItem.TotalPrice)
+	}
+
+	return result
+}
+
+func TestCreateQuoteTx(t *testing.T) {
+	createRandomQuoteTx(t, util.SENT)
+}
+
+func TestGetQuote(t *testing.T) {
+	result1 := createRandomQuoteTx(t, util.SENT)
+
+	result2, err := testStore.GetQuote(context.Background(), result1.QuoteNumber)
+	require.NoError(t, err)
+
+	require.Equal(t, result1.QuoteNumber, result2.QuoteNumber)
+	require.Equal(t, result1.CustomerEmail, result2.CustomerEmail)
+	require.Equal(t, result1.TotalAmount, result2.TotalAmount)
+	require.Equal(t, result1.LineItems, result2.LineItems)
+}
+
+func TestConvertQuoteTx(t *testing.T) {
+	quote := createRandomQuoteTx(t, util.SENT)
+
+	arg := ConvertQuoteTxParams{
+		QuoteNumber: quote.QuoteNumber,
+		IssueDate:   time.Now(),
+		DueDate:     time.Now().AddDate(0, 0, 30),
+		Status:      util.PENDING_PAYMENT,
+		PaymentInfo: util.RandomString(10),
+	}
+
+	result, err := testStore.ConvertQuoteTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	invoice := result.Invoice
+	require.NotZero(t, invoice.InvoiceNumber)
+	require.True(t, invoice.QuoteNumber.Valid)
+	require.Equal(t, quote.QuoteNumber, invoice.QuoteNumber.Int64)
+	require.Equal(t, quote.CustomerName, invoice.CustomerName)
+	require.Equal(t, quote.SenderName, invoice.SenderName)
+	require.Equal(t, arg.Status, invoice.Status)
+	require.Equal(t, arg.PaymentInfo, invoice.PaymentInfo)
+	require.Equal(t, quote.Subtotal, invoice.Subtotal)
+	require.Equal(t, quote.DiscountRate, invoice.DiscountRate)
+	require.Equal(t, quote.Discount, invoice.Discount)
+	require.Equal(t, quote.TotalAmount, invoice.TotalAmount)
+	require.Equal(t, quote.BillingCurrency, invoice.BillingCurrency)
+
+	require.Len(t, result.LineItems, len(quote.LineItems))
+	for i, lineItem := range result.LineItems {
+		require.Equal(t, invoice.InvoiceNumber, lineItem.InvoiceNumber)
+		require.Equal(t, quote.LineItems[i].Description, lineItem.Description)
+		require.Equal(t, quote.LineItems[i].Quantity, lineItem.Quantity)
+		require.Equal(t, quote.LineItems[i].UnitPrice, lineItem.UnitPrice)
+		require.Equal(t, quote.LineItems[i].TotalPrice, lineItem.TotalPrice)
+	}
+
+	updatedQuote, err := testQueries.GetQuoteRecord(context.Background(), quote.QuoteNumber)
+	require.NoError(t, err)
+	require.Equal(t, util.ACCEPTED, updatedQuote.Status)
+
+	// a quote can only be converted once
+	_, err = testStore.ConvertQuoteTx(context.Background(), arg)
+	require.Error(t, err)
+}
+
+func TestConvertQuoteTxNotConvertible(t *testing.T) {
+	declined := createRandomQuoteTx(t, util.DECLINED)
+
+	arg := ConvertQuoteTxParams{
+		QuoteNumber: declined.QuoteNumber,
+		IssueDate:   time.Now(),
+		DueDate:     time.Now().AddDate(0, 0, 30),
+		Status:      util.PENDING_PAYMENT,
+		PaymentInfo: util.RandomString(10),
+	}
+
+	_, err := testStore.ConvertQuoteTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrQuoteNotConvertible)
+
+	// an open quote cannot be converted after its expiry date
+	sent := createRandomQuoteTx(t, util.SENT)
+	arg.QuoteNumber = sent.QuoteNumber
+	arg.IssueDate = sent.ExpiryDate.AddDate(0, 0, 1)
+	arg.DueDate = arg.IssueDate.AddDate(0, 0, 30)
+
+	_, err = testStore.ConvertQuoteTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrQuoteNotConvertible)
+}
+
+func TestUpdateQuoteStatusTx(t *testing.T) {
+	quote := createRandomQuoteTx(t, util.SENT)
+
+	arg := UpdateQuoteStatusParams{QuoteNumber: quote.QuoteNumber, Status: util.DECLINED}
+	declined, err := testStore.UpdateQuoteStatusTx(context.Background(), arg)
+	require.NoError(t, err)
+	require.Equal(t, util.DECLINED, declined.Status)
+
+	// a decided quote keeps its status
+	arg.Status = util.ACCEPTED
+	_, err = testStore.UpdateQuoteStatusTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrQuoteDecided)
+	require.ErrorIs(t, err, ErrConflict)
+
+	// so does a quote that has been converted into an invoice
+	converted := createRandomQuoteTx(t, util.SENT)
+	_, err = testStore.ConvertQuoteTx(context.Background(), ConvertQuoteTxParams{
+		QuoteNumber: converted.QuoteNumber,
+		IssueDate:   time.Now(),
+		DueDate:     time.Now().AddDate(0, 0, 30),
+		Status:      util.PENDING_PAYMENT,
+		PaymentInfo: util.RandomString(10),
+	})
+	require.NoError(t, err)
+
+	arg = UpdateQuoteStatusParams{QuoteNumber: converted.QuoteNumber, Status: util.DECLINED}
+	_, err = testStore.UpdateQuoteStatusTx(context.Background(), arg)
+	require.ErrorIs(t, err, ErrQuoteDecided)
+}
@@ -0,0 +1,246 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Line totals are recorded before the invoice-level discount, so net revenue
+// allocates each invoice's discount across its lines by the discount rate.
+const RevenueByProductQuery = `
+	SELECT
+		li.product_id,
+		COALESCE(p.sku, '') AS sku,
+		COALESCE(p.name, '') AS name,
+		i.billing_currency,
+		COUNT(DISTINCT i.invoice_number) AS invoice_count,
+		SUM(li.quantity)::bigint AS quantity,
+		SUM(li.total_price)::bigint AS gross_amount,
+		ROUND(SUM(li.total_price * (10000 - i.discount_rate) / 10000.0))::bigint AS net_amount
+	FROM line_items li
+	JOIN invoices i ON i.invoice_number = li.invoice_number
+	LEFT JOIN products p ON p.id = li.product_id
+	WHERE i.status <> 'draft'
+		AND i.issue_date >= $1
+		AND i.issue_date < $2
+		AND ($3::varchar IS NULL OR i.billing_currency = $3)
+	GROUP BY li.product_id, p.sku, p.name, i.billing_currency
+	ORDER BY i.billing_currency, net_amount DESC, li.product_id;
+`
+
+type RevenueByProductParams struct {
+	From     time.Time   `json:"from"`
+	To       time.Time   `json:"to"`
+	Currency pgtype.Text `json:"currency"`
+}
+
+// RevenueByProductRow holds the revenue of a single product in one currency.
+// Line items that do not reference a product are grouped under a NULL
+// ProductID.
+type RevenueByProductRow struct {
+	ProductID       pgtype.Int8 `json:"product_id"`
+	SKU             string      `json:"sku"`
+	Name            string      `json:"name"`
+	BillingCurrency string      `json:"billing_currency"`
+	InvoiceCount    int64       `json:"invoice_count"`
+	Quantity        int64       `json:"quantity"`
+	GrossAmount     int64       `json:"gross_amount"`
+	NetAmount       int64       `json:"net_amount"`
+}
+
+func (q *Queries) RevenueByProduct(ctx context.Context, arg RevenueByProductParams) ([]RevenueByProductRow, error) {
+	rows, err := q.db.Query(ctx, RevenueByProductQuery, arg.From, arg.To, arg.Currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []RevenueByProductRow{}
+	for rows.Next() {
+		var i RevenueByProductRow
+		if err := rows.Scan(
+			&i.ProductID, &i.SKU, &i.Name, &i.BillingCurrency,
+			&i.InvoiceCount, &i.Quantity, &i.GrossAmount, &i.NetAmount,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// An invoice is outstanding from its issue until it is paid, for its total
+// less the payments made on or before the report date, so that the report can
+// be run for a past date. A paid invoice without recorded payments is taken as
+// settled. Its age is the number of days between its due date and the report
+// date, so invoices that are not yet due fall into the current bucket.
+const ARAgingQuery = `
+	SELECT
+		i.billing_currency,
+		i.customer_email,
+		MAX(i.customer_name) AS customer_name,
+		COUNT(*) AS invoice_count,
+		COALESCE(SUM(b.balance) FILTER (WHERE $1::date - i.due_date::date <= 0), 0)::bigint AS current,
+		COALESCE(SUM(b.balance) FILTER (WHERE $1::date - i.due_date::date BETWEEN 1 AND 30), 0)::bigint AS days_1_30,
+		COALESCE(SUM(b.balance) FILTER (WHERE $1::date - i.due_date::date BETWEEN 31 AND 60), 0)::bigint AS days_31_60,
+		COALESCE(SUM(b.balance) FILTER (WHERE $1::date - i.due_date::date BETWEEN 61 AND 90), 0)::bigint AS days_61_90,
+		COALESCE(SUM(b.balance) FILTER (WHERE $1::date - i.due_date::date > 90), 0)::bigint AS days_over_90,
+		SUM(b.balance)::bigint AS total
+	FROM invoices i
+	CROSS JOIN LATERAL (
+		SELECT CASE
+			WHEN i.status = 'paid' AND COUNT(p.*) = 0 THEN 0
+			ELSE i.total_amount - COALESCE(SUM(p.amount) FILTER (WHERE p.paid_at::date <= $1::date), 0)
+		END AS balance
+		FROM payments p
+		WHERE p.invoice_number = i.invoice_number
+	) b
+	WHERE i.status <> 'draft'
+		AND i.issue_date::date <= $1::date
+		AND b.balance > 0
+		AND ($2::varchar IS NULL OR i.billing_currency = $2)
+	GROUP BY i.billing_currency, i.customer_email
+	ORDER BY i.billing_currency, total DESC, i.customer_email;
+`
+
+type ARAgingParams struct {
+	AsOf     time.Time   `json:"as_of"`
+	Currency pgtype.Text `json:"currency"`
+}
+
+// ARAgingRow holds the outstanding balance of one customer in one currency,
+// split into age buckets.
+type ARAgingRow struct {
+	BillingCurrency string `json:"billing_currency"`
+	CustomerEmail   string `json:"customer_email"`
+	CustomerName    string `json:"customer_name"`
+	InvoiceCount    int64  `json:"invoice_count"`
+	Current         int64  `json:"current"`
+	Days1To30       int64  `json:"days_1_30"`
+	Days31To60      int64  `json:"days_31_60"`
+	Days61To90      int64  `json:"days_61_90"`
+	DaysOver90      int64  `json:"days_over_90"`
+	Total           int64  `json:"total"`
+}
+
+func (q *Queries) ARAging(ctx context.Context, arg ARAgingParams) ([]ARAgingRow, error) {
+	rows, err := q.db.Query(ctx, ARAgingQuery, arg.AsOf, arg.Currency)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []ARAgingRow{}
+	for rows.Next() {
+		var i ARAgingRow
+		if err := rows.Scan(
+			&i.BillingCurrency, &i.CustomerEmail, &i.CustomerName, &i.InvoiceCount,
+			&i.Current, &i.Days1To30, &i.Days31To60, &i.Days61To90, &i.DaysOver90, &i.Total,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+// Drafts have not been invoiced yet, so they are only included when they are
+// asked for through the status filter. A paid invoice counts as collected in
+// full even if its payments were not recorded, and an unpaid one for what has
+// been paid on it so far. The payments of each invoice are summed through the
+// index on payments.invoice_number, weighting the days from its issue to each
+// payment by the amount paid; a payment made before the invoice was issued
+// counts as paid on the day.
+const RevenueSummaryQuery = `
+	SELECT
+		date_trunc($1::text, i.issue_date)::date AS period_start,
+		i.billing_currency,
+		COUNT(*) AS invoice_count,
+		SUM(i.subtotal)::bigint AS invoiced,
+		SUM(i.discount)::bigint AS discounted,
+		SUM(i.total_amount)::bigint AS billed,
+		COALESCE(SUM(CASE WHEN i.status = 'paid' THEN i.total_amount ELSE COALESCE(p.paid, 0) END), 0)::bigint AS collected,
+		COALESCE(SUM(i.total_amount - COALESCE(p.paid, 0)) FILTER (WHERE i.status IN ('pending_payment', 'overdue')), 0)::bigint AS outstanding,
+		COALESCE(SUM(p.paid), 0)::bigint AS paid,
+		COALESCE(SUM(p.paid_days), 0)::bigint AS paid_days
+	FROM invoices i
+	LEFT JOIN LATERAL (
+		SELECT
+			SUM(p.amount) AS paid,
+			SUM(p.amount * GREATEST(p.paid_at::date - i.issue_date::date, 0)) AS paid_days
+		FROM payments p
+		WHERE p.invoice_number = i.invoice_number
+	) p ON true
+	WHERE i.issue_date >= $2
+		AND i.issue_date < $3
+		AND ($4::varchar IS NULL OR i.customer_email = $4)
+		AND (($5::varchar IS NULL AND i.status <> 'draft') OR i.status = $5)
+		AND ($6::varchar IS NULL OR i.billing_currency = $6)
+	GROUP BY period_start, i.billing_currency
+	ORDER BY period_start, i.billing_currency;
+`
+
+type RevenueSummaryParams struct {
+	// Interval is a date_trunc field: day, week, month or quarter.
+	Interval      string      `json:"interval"`
+	From          time.Time   `json:"from"`
+	To            time.Time   `json:"to"`
+	CustomerEmail pgtype.Text `json:"customer_email"`
+	Status        pgtype.Text `json:"status"`
+	Currency      pgtype.Text `json:"currency"`
+}
+
+// RevenueSummaryRow holds the invoicing totals of one period in one currency.
+// Billed is the invoiced amount less discounts, plus VAT, and is split between
+// Collected and Outstanding, which take partial payments into account. Paid is
+// what has been received in payments on the invoices, and PaidDays is the sum
+// of each payment's amount times the days from the issue of its invoice to the
+// payment, so that PaidDays / Paid is the average number of days to pay
+// weighted by amount.
+type RevenueSummaryRow struct {
+	PeriodStart     time.Time `json:"period_start"`
+	BillingCurrency string    `json:"billing_currency"`
+	InvoiceCount    int64     `json:"invoice_count"`
+	Invoiced        int64     `json:"invoiced"`
+	Discounted      int64     `json:"discounted"`
+	Billed          int64     `json:"billed"`
+	Collected       int64     `json:"collected"`
+	Outstanding     int64     `json:"outstanding"`
+	Paid            int64     `json:"paid"`
+	PaidDays        int64     `json:"paid_days"`
+}
+
+func (q *Queries) RevenueSummary(ctx context.Context, arg RevenueSummaryParams) ([]RevenueSummaryRow, error) {
+	rows, err := q.db.Query(ctx, RevenueSummaryQuery,
+		arg.Interval, arg.From, arg.To, arg.CustomerEmail, arg.Status, arg.Currency,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []RevenueSummaryRow{}
+	for rows.Next() {
+		var i RevenueSummaryRow
+		if err := rows.Scan(
+			&i.PeriodStart, &i.BillingCurrency, &i.InvoiceCount, &i.Invoiced,
+			&i.Discounted, &i.Billed, &i.Collected, &i.Outstanding,
+			&i.Paid, &i.PaidDays,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,293 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRevenueByProduct(t *testing.T) {
+	product := createRandomProductTx(t)
+	productID := pgtype.Int8{Int64: product.ID, Valid: true}
+	issueDate := time.Now()
+
+	arg := CreateInvoiceTxParams{
+		CustomerName:    util.RandomName(),
+		CustomerEmail:   util.RandomEmail(),
+		CustomerPhone:   util.RandomPhone(),
+		CustomerAddress: util.RandomAddress(),
+		SenderName:      util.RandomName(),
+		SenderEmail:     util.RandomEmail(),
+		SenderPhone:     util.RandomPhone(),
+		SenderAddress:   util.RandomAddress(),
+		IssueDate:       issueDate,
+		DueDate:         issueDate.AddDate(0, 0, 30),
+		Status:          util.PENDING_PAYMENT,
+		Subtotal:        3000,
+		DiscountRate:    1000,
+		Discount:        300,
+		TotalAmount:     2700,
+		PaymentInfo:     util.RandomString(10),
+		Items: []InsertLineItemParams{
+			{Description: product.Name, Quantity: 2, UnitPrice: 1000, TotalPrice: 2000, ProductID: productID},
+			{Description: product.Name, Quantity: 1, UnitPrice: 1000, TotalPrice: 1000, ProductID: productID},
+		},
+	}
+	_, err := testStore.CreateInvoiceTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	// drafts are not revenue
+	arg.Status = util.DRAFT
+	_, err = testStore.CreateInvoiceTx(context.Background(), arg)
+	require.NoError(t, err)
+
+	rows, err := testStore.RevenueByProduct(context.Background(), RevenueByProductParams{
+		From:     issueDate.Add(-time.Hour),
+		To:       issueDate.Add(time.Hour),
+		Currency: pgtype.Text{String: "USD", Valid: true},
+	})
+	require.NoError(t, err)
+
+	var found *RevenueByProductRow
+	for i := range rows {
+		require.Equal(t, "USD", rows[i].BillingCurrency)
+		if rows[i].ProductID == productID {
+			found = &rows[i]
+		}
+	}
+	require.NotNil(t, found)
+	require.Equal(t, product.SKU, found.SKU)
+	require.Equal(t, product.Name, found.Name)
+	require.Equal(t, int64(1), found.InvoiceCount)
+	require.Equal(t, int64(3), found.Quantity)
+	require.Equal(t, int64(3000), found.GrossAmount)
+	require.Equal(t, int64(2700), found.NetAmount)
+}
+
+func TestARAging(t *testing.T) {
+	asOf := time.Now()
+	customerEmail := util.RandomEmail()
+
+	createInvoice := func(status string, daysPastDue int, totalAmount int64) InvoiceResult {
+		arg := CreateInvoiceTxParams{
+			CustomerName:    "Aging Customer",
+			CustomerEmail:   customerEmail,
+			CustomerPhone:   util.RandomPhone(),
+			CustomerAddress: util.RandomAddress(),
+			SenderName:      util.RandomName(),
+			SenderEmail:     util.RandomEmail(),
+			SenderPhone:     util.RandomPhone(),
+			SenderAddress:   util.RandomAddress(),
+			IssueDate:       asOf.AddDate(0, 0, -120),
+			DueDate:         asOf.AddDate(0, 0, -daysPastDue),
+			Status:          status,
+			Subtotal:        totalAmount,
+			TotalAmount:     totalAmount,
+			PaymentInfo:     util.RandomString(10),
+			Items: []InsertLineItemParams{
+				{Description: util.RandomString(8), Quantity: 1, UnitPrice: totalAmount, TotalPrice: totalAmount},
+			},
+		}
+		result, err := testStore.CreateInvoiceTx(context.Background(), arg)
+		require.NoError(t, err)
+		return result
+	}
+
+	createInvoice(util.PENDING_PAYMENT, -5, 1000)
+	partlyPaid := createInvoice(util.OVERDUE, 10, 2000)
+	createInvoice(util.OVERDUE, 45, 3000)
+	createInvoice(util.OVERDUE, 100, 4000)
+	// paid and draft invoices are not outstanding
+	createInvoice(util.PAID, 10, 5000)
+	createInvoice(util.DRAFT, 10, 6000)
+
+	// only what is left to pay is outstanding
+	_, err := testStore.RecordPaymentTx(context.Background(), RecordPaymentTxParams{
+		InvoiceNumber: partlyPaid.InvoiceNumber,
+		Amount:        500,
+		PaidAt:        asOf.AddDate(0, 0, -1),
+	})
+	require.NoError(t, err)
+
+	rows, err := testStore.ARAging(context.Background(), ARAgingParams{AsOf: asOf})
+	require.NoError(t, err)
+
+	var found *ARAgingRow
+	for i := range rows {
+		if rows[i].CustomerEmail == customerEmail {
+			require.Nil(t, found)
+			found = &rows[i]
+		}
+	}
+	require.NotNil(t, found)
+	require.Equal(t, "USD", found.BillingCurrency)
+	require.Equal(t, "Aging Customer", found.CustomerName)
+	require.Equal(t, int64(4), found.InvoiceCount)
+	require.Equal(t, int64(1000), found.Current)
+	require.Equal(t, int64(1500), found.Days1To30)
+	require.Equal(t, int64(3000), found.Days31To60)
+	require.Equal(t, int64(0), found.Days61To90)
+	require.Equal(t, int64(4000), found.DaysOver90)
+	require.Equal(t, int64(9500), found.Total)
+}
+
+func TestARAgingPastDate(t *testing.T) {
+	now := time.Now()
+	asOf := now.AddDate(0, 0, -10)
+	customerEmail := util.RandomEmail()
+
+	createInvoice := func(issueDate, dueDate time.Time, totalAmount int64) InvoiceResult {
+		arg := randomCreateInvoiceTxParams(customerEmail)
+		arg.IssueDate = issueDate
+		arg.DueDate = dueDate
+		arg.Subtotal = totalAmount
+		arg.DiscountRate = 0
+		arg.Discount = 0
+		arg.TotalAmount = totalAmount
+		arg.Items = []InsertLineItemParams{
+			{Description: util.RandomString(8), Quantity: 1, UnitPrice: totalAmount, TotalPrice: totalAmount},
+		}
+		result, err := testStore.CreateInvoiceTx(context.Background(), arg)
+		require.NoError(t, err)
+		return result
+	}
+	pay := func(invoice InvoiceResult, amount int64, paidAt time.Time) {
+		_, err := testStore.RecordPaymentTx(context.Background(), RecordPaymentTxParams{
+			InvoiceNumber: invoice.InvoiceNumber,
+			Amount:        amount,
+			PaidAt:        paidAt,
+		})
+		require.NoError(t, err)
+	}
+
+	// paid in full after the report date, so still open on it
+	paidLater := createInvoice(now.AddDate(0, 0, -60), now.AddDate(0, 0, -30), 2000)
+	pay(paidLater, 2000, now.AddDate(0, 0, -5))
+
+	// only the payment made before the report date counts
+	partlyPaid := createInvoice(now.AddDate(0, 0, -60), now.AddDate(0, 0, -50), 3000)
+	pay(partlyPaid, 1000, now.AddDate(0, 0, -20))
+	pay(partlyPaid, 500, now.AddDate(0, 0, -2))
+
+	// issued after the report date
+	createInvoice(now.AddDate(0, 0, -5), now.AddDate(0, 0, 25), 4000)
+
+	rows, err := testStore.ARAging(context.Background(), ARAgingParams{AsOf: asOf})
+	require.NoError(t, err)
+
+	var found *ARAgingRow
+	for i := range rows {
+		if rows[i].CustomerEmail == customerEmail {
+			require.Nil(t, found)
+			found = &rows[i]
+		}
+	}
+	require.NotNil(t, found)
+	require.Equal(t, int64(2), found.InvoiceCount)
+	require.Equal(t, int64(0), found.Current)
+	require.Equal(t, int64(2000), found.Days1To30)
+	require.Equal(t, int64(2000), found.Days31To60)
+	require.Equal(t, int64(4000), found.Total)
+
+	// today both are settled or partly paid down further
+	rows, err = testStore.ARAging(context.Background(), ARAgingParams{AsOf: now})
+	require.NoError(t, err)
+	found = nil
+	for i := range rows {
+		if rows[i].CustomerEmail == customerEmail {
+			found = &rows[i]
+		}
+	}
+	require.NotNil(t, found)
+	require.Equal(t, int64(2), found.InvoiceCount)
+	require.Equal(t, int64(1500), found.Days31To60)
+	require.Equal(t, int64(4000), found.Current)
+	require.Equal(t, int64(5500), found.Total)
+}
+
+func TestRevenueSummary(t *testing.T) {
+	customerEmail := util.RandomEmail()
+
+	createInvoice := func(issueDate time.Time, status string, subtotal, discount int64) InvoiceResult {
+		arg := CreateInvoiceTxParams{
+			CustomerName:    util.RandomName(),
+			CustomerEmail:   customerEmail,
+			CustomerPhone:   util.RandomPhone(),
+			CustomerAddress: util.RandomAddress(),
+			SenderName:      util.RandomName(),
+			SenderEmail:     util.RandomEmail(),
+			SenderPhone:     util.RandomPhone(),
+			SenderAddress:   util.RandomAddress(),
+			IssueDate:       issueDate,
+			DueDate:         issueDate.AddDate(0, 0, 30),
+			Status:          status,
+			Subtotal:        subtotal,
+			Discount:        discount,
+			TotalAmount:     subtotal - discount,
+			PaymentInfo:     util.RandomString(10),
+			Items: []InsertLineItemParams{
+				{Description: util.RandomString(8), Quantity: 1, UnitPrice: subtotal, TotalPrice: subtotal},
+			},
+		}
+		result, err := testStore.CreateInvoiceTx(context.Background(), arg)
+		require.NoError(t, err)
+		return result
+	}
+
+	january := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	february := time.Date(2024, 2, 15, 12, 0, 0, 0, time.UTC)
+
+	createInvoice(january, util.PAID, 1000, 100)
+	overdue := createInvoice(january, util.OVERDUE, 2000, 0)
+	createInvoice(february, util.PENDING_PAYMENT, 4000, 400)
+	createInvoice(february, util.DRAFT, 8000, 0)
+
+	// part of the overdue invoice was paid ten days after it was issued
+	_, err := testStore.RecordPaymentTx(context.Background(), RecordPaymentTxParams{
+		InvoiceNumber: overdue.InvoiceNumber,
+		Amount:        500,
+		PaidAt:        january.AddDate(0, 0, 10),
+	})
+	require.NoError(t, err)
+
+	arg := RevenueSummaryParams{
+		Interval:      "month",
+		From:          time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		To:            time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC),
+		CustomerEmail: pgtype.Text{String: customerEmail, Valid: true},
+	}
+	rows, err := testStore.RevenueSummary(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, rows, 2)
+
+	require.Equal(t, "2024-01-01", rows[0].PeriodStart.Format(time.DateOnly))
+	require.Equal(t, "USD", rows[0].BillingCurrency)
+	require.Equal(t, int64(2), rows[0].InvoiceCount)
+	require.Equal(t, int64(3000), rows[0].Invoiced)
+	require.Equal(t, int64(100), rows[0].Discounted)
+	require.Equal(t, int64(2900), rows[0].Billed)
+	// the paid invoice counts in full and the overdue one for its payment
+	require.Equal(t, int64(900+500), rows[0].Collected)
+	require.Equal(t, int64(2000-500), rows[0].Outstanding)
+	require.Equal(t, int64(500), rows[0].Paid)
+	require.Equal(t, int64(500*10), rows[0].PaidDays)
+
+	// the draft is left out
+	require.Equal(t, "2024-02-01", rows[1].PeriodStart.Format(time.DateOnly))
+	require.Equal(t, int64(1), rows[1].InvoiceCount)
+	require.Equal(t, int64(3600), rows[1].Billed)
+	require.Equal(t, int64(3600), rows[1].Outstanding)
+	require.Zero(t, rows[1].Paid)
+
+	arg.Status = pgtype.Text{String: util.DRAFT, Valid: true}
+	rows, err = testStore.RevenueSummary(context.Background(), arg)
+	require.NoError(t, err)
+	require.Len(t, rows, 1)
+	require.Equal(t, int64(8000), rows[0].Billed)
+	require.Equal(t, int64(0), rows[0].Collected)
+	require.Equal(t, int64(0), rows[0].Outstanding)
+}
@@ -0,0 +1,111 @@
+package db
+
+import (
+	"context"
+	"time"
+)
+
+const GetCustomerNameQuery = `
+	SELECT customer_name FROM invoices
+	WHERE customer_email = $1
+	ORDER BY issue_date DESC, invoice_number DESC
+	LIMIT 1;
+`
+
+// GetCustomerName returns the name on the customer's most recent invoice.
+func (q *Queries) GetCustomerName(ctx context.Context, customerEmail string) (string, error) {
+	row := q.db.QueryRow(ctx, GetCustomerNameQuery, customerEmail)
+	var name string
+	err := row.Scan(&name)
+	return name, err
+}
+
+// Drafts have not been sent to the customer, so they are not part of what the
+// customer owes.
+const GetCustomerBalanceQuery = `
+	SELECT
+		COALESCE((
+			SELECT SUM(i.total_amount) FROM invoices i
+			WHERE i.customer_email = $1
+				AND i.billing_currency = $2
+				AND i.status <> 'draft'
+				AND i.issue_date < $3
+		), 0)::bigint
+		- COALESCE((
+			SELECT SUM(p.amount) FROM payments p
+			JOIN invoices i ON i.invoice_number = p.invoice_number
+			WHERE i.customer_email = $1
+				AND i.billing_currency = $2
+				AND p.paid_at < $3
+		), 0)::bigint;
+`
+
+type GetCustomerBalanceParams struct {
+	CustomerEmail string    `json:"customer_email"`
+	Currency      string    `json:"currency"`
+	Before        time.Time `json:"before"`
+}
+
+func (q *Queries) GetCustomerBalance(ctx context.Context, arg GetCustomerBalanceParams) (int64, error) {
+	row := q.db.QueryRow(ctx, GetCustomerBalanceQuery, arg.CustomerEmail, arg.Currency, arg.Before)
+	var balance int64
+	err := row.Scan(&balance)
+	return balance, err
+}
+
+const ListStatementEntriesQuery = `
+	SELECT 'invoice' AS kind, i.issue_date AS date, i.invoice_number, '' AS reference, i.total_amount AS amount
+	FROM invoices i
+	WHERE i.customer_email = $1
+		AND i.billing_currency = $2
+		AND i.status <> 'draft'
+		AND i.issue_date >= $3
+		AND i.issue_date < $4
+	UNION ALL
+	SELECT 'payment', p.paid_at, p.invoice_number, p.reference, -p.amount
+	FROM payments p
+	JOIN invoices i ON i.invoice_number = p.invoice_number
+	WHERE i.customer_email = $1
+		AND i.billing_currency = $2
+		AND p.paid_at >= $3
+		AND p.paid_at < $4
+	ORDER BY date, kind, invoice_number;
+`
+
+type ListStatementEntriesParams struct {
+	CustomerEmail string    `json:"customer_email"`
+	Currency      string    `json:"currency"`
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+}
+
+// StatementEntry is an invoice or a payment on a customer's account. Amount
+// is positive for invoices and negative for payments.
+type StatementEntry struct {
+	Kind          string    `json:"kind"`
+	Date          time.Time `json:"date"`
+	InvoiceNumber int64     `json:"invoice_number"`
+	Reference     string    `json:"reference"`
+	Amount        int64     `json:"amount"`
+}
+
+func (q *Queries) ListStatementEntries(ctx context.Context, arg ListStatementEntriesParams) ([]StatementEntry, error) {
+	rows, err := q.db.Query(ctx, ListStatementEntriesQuery, arg.CustomerEmail, arg.Currency, arg.From, arg.To)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []StatementEntry{}
+	for rows.Next() {
+		var i StatementEntry
+		if err := rows.Scan(&i.Kind, &i.Date, &i.InvoiceNumber, &i.Reference, &i.Amount); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
@@ -0,0 +1,87 @@
+package db
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+type CustomerStatementParams struct {
+	CustomerEmail string    `json:"customer_email"`
+	Currency      string    `json:"currency"`
+	From          time.Time `json:"from"`
+	To            time.Time `json:"to"`
+}
+
+type CustomerStatement struct {
+	CustomerEmail  string                   `json:"customer_email"`
+	CustomerName   string                   `json:"customer_name"`
+	Currency       string                   `json:"currency"`
+	From           time.Time                `json:"from"`
+	To             time.Time                `json:"to"`
+	OpeningBalance int64                    `json:"opening_balance"`
+	Entries        []CustomerStatementEntry `json:"entries"`
+	ClosingBalance int64                    `json:"closing_balance"`
+}
+
+type CustomerStatementEntry struct {
+	StatementEntry
+	// Balance is the running balance after this entry.
+	Balance int64 `json:"balance"`
+}
+
+// GetCustomerStatement lists the invoices and payments of a customer in one
+// currency between From (inclusive) and To (exclusive), with the balances
+// before, during and after the period. The queries run in a single read-only
+// repeatable read transaction, so that they all see the same snapshot and the
+// balances agree with the entries.
+func (store *SQLStore) GetCustomerStatement(ctx context.Context, arg CustomerStatementParams) (CustomerStatement, error) {
+	result := CustomerStatement{
+		CustomerEmail: arg.CustomerEmail,
+		Currency:      arg.Currency,
+		From:          arg.From,
+		To:            arg.To,
+	}
+	err := store.execTxWithOptions(
+		ctx,
+		pgx.TxOptions{IsoLevel: pgx.RepeatableRead, AccessMode: pgx.ReadOnly},
+		func(ctx context.Context, q *Queries) error {
+
+			var err error
+			result.CustomerName, err = q.GetCustomerName(ctx, arg.CustomerEmail)
+			if err != nil {
+				return err
+			}
+
+			result.OpeningBalance, err = q.GetCustomerBalance(ctx, GetCustomerBalanceParams{
+				CustomerEmail: arg.CustomerEmail,
+				Currency:      arg.Currency,
+				Before:        arg.From,
+			})
+			if err != nil {
+				return err
+			}
+
+			entries, err := q.ListStatementEntries(ctx, ListStatementEntriesParams{
+				CustomerEmail: arg.CustomerEmail,
+				Currency:      arg.Currency,
+				From:          arg.From,
+				To:            arg.To,
+			})
+			if err != nil {
+				return err
+			}
+
+			balance := result.OpeningBalance
+			result.Entries = make([]CustomerStatementEntry, len(entries))
+			for i, v := range entries {
+				balance += v.Amount
+				result.Entries[i] = CustomerStatementEntry{StatementEntry: v, Balance: balance}
+			}
+			result.ClosingBalance = balance
+			return nil
+		},
+	)
+	return result, err
+}
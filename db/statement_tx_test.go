@@ -0,0 +1,74 @@
+package db
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetCustomerStatement(t *testing.T) {
+	customerEmail := util.RandomEmail()
+	from := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	to := time.Date(2024, 7, 1, 0, 0, 0, 0, time.UTC)
+
+	// before the statement period
+	earlier := insertPayableInvoice(t, customerEmail, util.OVERDUE, from.AddDate(0, 0, -20), 3000)
+	_, err := testStore.RecordPaymentTx(context.Background(), RecordPaymentTxParams{
+		InvoiceNumber: earlier.InvoiceNumber,
+		Amount:        1000,
+		PaidAt:        from.AddDate(0, 0, -5),
+	})
+	require.NoError(t, err)
+
+	// during the statement period
+	invoice := insertPayableInvoice(t, customerEmail, util.PENDING_PAYMENT, from.AddDate(0, 0, 9), 5000)
+	_, err = testStore.RecordPaymentTx(context.Background(), RecordPaymentTxParams{
+		InvoiceNumber: earlier.InvoiceNumber,
+		Amount:        2000,
+		PaidAt:        from.AddDate(0, 0, 14),
+		Reference:     "TRX-1",
+	})
+	require.NoError(t, err)
+	insertPayableInvoice(t, customerEmail, util.DRAFT, from.AddDate(0, 0, 19), 7000)
+
+	// after the statement period
+	insertPayableInvoice(t, customerEmail, util.PENDING_PAYMENT, to.AddDate(0, 0, 1), 9000)
+
+	statement, err := testStore.GetCustomerStatement(context.Background(), CustomerStatementParams{
+		CustomerEmail: customerEmail,
+		Currency:      "USD",
+		From:          from,
+		To:            to,
+	})
+	require.NoError(t, err)
+	require.Equal(t, customerEmail, statement.CustomerEmail)
+	require.NotEmpty(t, statement.CustomerName)
+	require.Equal(t, int64(2000), statement.OpeningBalance)
+	require.Len(t, statement.Entries, 2)
+
+	require.Equal(t, "invoice", statement.Entries[0].Kind)
+	require.Equal(t, invoice.InvoiceNumber, statement.Entries[0].InvoiceNumber)
+	require.Equal(t, int64(5000), statement.Entries[0].Amount)
+	require.Equal(t, int64(7000), statement.Entries[0].Balance)
+
+	require.Equal(t, "payment", statement.Entries[1].Kind)
+	require.Equal(t, earlier.InvoiceNumber, statement.Entries[1].InvoiceNumber)
+	require.Equal(t, "TRX-1", statement.Entries[1].Reference)
+	require.Equal(t, int64(-2000), statement.Entries[1].Amount)
+	require.Equal(t, int64(5000), statement.Entries[1].Balance)
+
+	require.Equal(t, int64(5000), statement.ClosingBalance)
+}
+
+func TestGetCustomerStatementUnknownCustomer(t *testing.T) {
+	_, err := testStore.GetCustomerStatement(context.Background(), CustomerStatementParams{
+		CustomerEmail: util.RandomEmail(),
+		Currency:      "USD",
+		From:          time.Now().AddDate(0, -1, 0),
+		To:            time.Now(),
+	})
+	require.ErrorIs(t, err, ErrNotFound)
+}
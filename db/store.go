@@ -4,24 +4,55 @@ import (
 	"context"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// Store is the database as the rest of the service sees it. Every change is
+// made by one of the *Tx methods, which check it and record it in the audit
+// log, so the single statements that they are built from, such as
+// InsertPayment or UpdateProduct, are only on Queries.
 type Store interface {
-	Querier
+	ListInvoices(ctx context.Context, arg ListInvoicesParams) ([]Invoice, error)
+	StreamInvoices(ctx context.Context, arg InvoiceFilter, fn func(Invoice) error) error
+	StreamInvoiceLineItems(ctx context.Context, arg InvoiceFilter, fn func(Invoice, LineItem) error) error
+	RevenueByProduct(ctx context.Context, arg RevenueByProductParams) ([]RevenueByProductRow, error)
+	ARAging(ctx context.Context, arg ARAgingParams) ([]ARAgingRow, error)
+	RevenueSummary(ctx context.Context, arg RevenueSummaryParams) ([]RevenueSummaryRow, error)
+	GetMigrationVersion(ctx context.Context) (MigrationVersion, error)
+	ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error)
+	ListInvoiceChainHeads(ctx context.Context, series pgtype.Text) ([]InvoiceChainLink, error)
 	CreateInvoiceTx(ctx context.Context, arg CreateInvoiceTxParams) (InvoiceResult, error)
+	CreateInvoicesTx(ctx context.Context, args []CreateInvoiceTxParams) ([]InvoiceResult, error)
 	GetInvoice(ctx context.Context, id int64) (InvoiceResult, error)
+	CreateQuoteTx(ctx context.Context, arg CreateQuoteTxParams) (QuoteResult, error)
+	GetQuote(ctx context.Context, id int64) (QuoteResult, error)
+	ConvertQuoteTx(ctx context.Context, arg ConvertQuoteTxParams) (InvoiceResult, error)
+	UpdateQuoteStatusTx(ctx context.Context, arg UpdateQuoteStatusParams) (Quote, error)
+	CreateProductTx(ctx context.Context, arg CreateProductTxParams) (ProductResult, error)
+	UpdateProductTx(ctx context.Context, arg UpdateProductTxParams) (ProductResult, error)
+	DeleteProductTx(ctx context.Context, id int64) error
+	GetProduct(ctx context.Context, id int64) (ProductResult, error)
+	ListProducts(ctx context.Context, arg ListProductRecordsParams) ([]ProductResult, error)
+	RecordPaymentTx(ctx context.Context, arg RecordPaymentTxParams) (PaymentResult, error)
+	GetCustomerStatement(ctx context.Context, arg CustomerStatementParams) (CustomerStatement, error)
+	ImportInvoiceTx(ctx context.Context, arg ImportInvoiceTxParams) (ImportInvoiceResult, error)
+	VerifyInvoiceChain(ctx context.Context, series pgtype.Text) (ChainVerification, error)
+	Ping(ctx context.Context) error
 }
 
 // SQLStore provides all functions to execute SQL queries and transactions.
 type SQLStore struct {
 	connPool *pgxpool.Pool
+	txRetry  txRetryPolicy
 	*Queries
 }
 
 func NewStore(connPool *pgxpool.Pool) Store {
 	return &SQLStore{
 		connPool: connPool,
+		txRetry:  defaultTxRetryPolicy,
 		Queries:  New(connPool),
 	}
 }
@@ -43,6 +74,9 @@ type CreateInvoiceTxParams struct {
 	Discount        int64                  `json:"discount"`
 	TotalAmount     int64                  `json:"total_amount"`
 	PaymentInfo     string                 `json:"payment_info"`
+	QuoteNumber     pgtype.Int8            `json:"quote_number"`
+	BillingCurrency pgtype.Text            `json:"billing_currency"`
+	Note            pgtype.Text            `json:"note"`
 	Items           []InsertLineItemParams `json:"line_items"`
 }
 
@@ -55,47 +89,97 @@ func (store *SQLStore) CreateInvoiceTx(ctx context.Context, arg CreateInvoiceTxP
 	var result InvoiceResult
 	err := store.execTx(
 		ctx,
-		func(q *Queries) error {
-
-			invoice, err := q.InsertInvoiceRecord(
-				ctx,
-				InsertInvoiceRecordParams{
-					CustomerName:    arg.CustomerName,
-					CustomerEmail:   arg.CustomerEmail,
-					CustomerPhone:   arg.CustomerPhone,
-					CustomerAddress: arg.CustomerAddress,
-					SenderName:      arg.SenderName,
-					SenderEmail:     arg.SenderEmail,
-					SenderPhone:     arg.SenderPhone,
-					SenderAddress:   arg.SenderAddress,
-					IssueDate:       arg.IssueDate,
-					DueDate:         arg.DueDate,
-					Status:          arg.Status,
-					Subtotal:        arg.Subtotal,
-					DiscountRate:    arg.DiscountRate,
-					Discount:        arg.Discount,
-					TotalAmount:     arg.TotalAmount,
-					PaymentInfo:     arg.PaymentInfo,
-				},
-			)
+		func(ctx context.Context, q *Queries) error {
+			var err error
+			result, err = createInvoice(ctx, q, arg)
 			if err != nil {
 				return err
 			}
+			if err = chainInvoice(ctx, q, result); err != nil {
+				return err
+			}
+			return audit(ctx, q, ActionCreate, EntityInvoice, result.InvoiceNumber, nil, result)
+		},
+	)
+	return result, err
+}
 
-			result.Invoice = invoice
-
-			for _, item := range arg.Items {
-				item.InvoiceNumber = invoice.InvoiceNumber
-				lineItem, err := q.InsertLineItem(ctx, item)
+// CreateInvoicesTx creates several invoices in a single transaction. Either
+// all of them are created or, on the first error, none of them.
+func (store *SQLStore) CreateInvoicesTx(ctx context.Context, args []CreateInvoiceTxParams) ([]InvoiceResult, error) {
+	results := make([]InvoiceResult, len(args))
+	err := store.execTx(
+		ctx,
+		func(ctx context.Context, q *Queries) error {
+			for i, arg := range args {
+				var err error
+				results[i], err = createInvoice(ctx, q, arg)
+				if err != nil {
+					return err
+				}
+				if err = chainInvoice(ctx, q, results[i]); err != nil {
+					return err
+				}
+				err = audit(ctx, q, ActionCreate, EntityInvoice, results[i].InvoiceNumber, nil, results[i])
 				if err != nil {
 					return err
 				}
-				result.LineItems = append(result.LineItems, lineItem)
 			}
 			return nil
 		},
 	)
-	return result, err
+	if err != nil {
+		return nil, err
+	}
+	return results, nil
+}
+
+// createInvoice inserts an invoice and its line items using q, which is
+// expected to be bound to an open transaction. The caller audits the creation,
+// as an action of its own, and chains the invoice if it issues it.
+func createInvoice(ctx context.Context, q *Queries, arg CreateInvoiceTxParams) (InvoiceResult, error) {
+	var result InvoiceResult
+
+	invoice, err := q.InsertInvoiceRecord(
+		ctx,
+		InsertInvoiceRecordParams{
+			CustomerName:    arg.CustomerName,
+			CustomerEmail:   arg.CustomerEmail,
+			CustomerPhone:   arg.CustomerPhone,
+			CustomerAddress: arg.CustomerAddress,
+			SenderName:      arg.SenderName,
+			SenderEmail:     arg.SenderEmail,
+			SenderPhone:     arg.SenderPhone,
+			SenderAddress:   arg.SenderAddress,
+			IssueDate:       arg.IssueDate,
+			DueDate:         arg.DueDate,
+			Status:          arg.Status,
+			Subtotal:        arg.Subtotal,
+			DiscountRate:    arg.DiscountRate,
+			Discount:        arg.Discount,
+			TotalAmount:     arg.TotalAmount,
+			PaymentInfo:     arg.PaymentInfo,
+			QuoteNumber:     arg.QuoteNumber,
+			BillingCurrency: arg.BillingCurrency,
+			Note:            arg.Note,
+		},
+	)
+	if err != nil {
+		return result, err
+	}
+
+	result.Invoice = invoice
+
+	items := make([]InsertLineItemParams, len(arg.Items))
+	for i, item := range arg.Items {
+		item.InvoiceNumber = invoice.InvoiceNumber
+		items[i] = item
+	}
+	result.LineItems, err = q.InsertLineItems(ctx, items)
+	if err != nil {
+		return result, err
+	}
+	return result, nil
 }
 
 const getInvoiceQuery = `
@@ -104,9 +188,9 @@ SELECT
     i.customer_address, i.sender_name, i.sender_email, i.sender_phone,
     i.sender_address, i.issue_date, i.due_date, i.status,
     i.subtotal, i.discount_rate, i.discount, i.total_amount, i.payment_info,
-    i.billing_currency, i.note, i.created_at,
+    i.billing_currency, i.note, i.created_at, i.quote_number, i.version,
     li.id, li.invoice_number, li.description, li.quantity,
-    li.unit_price, li.total_price
+    li.unit_price, li.total_price, li.product_id, li.tax_code
 FROM
 	invoices i
 JOIN
@@ -149,12 +233,16 @@ func (store *SQLStore) GetInvoice(ctx context.Context, id int64) (InvoiceResult,
 				&result.Invoice.BillingCurrency,
 				&result.Invoice.Note,
 				&result.Invoice.CreatedAt,
+				&result.Invoice.QuoteNumber,
+				&result.Invoice.Version,
 				&lineItem.ID,
 				&lineItem.InvoiceNumber,
 				&lineItem.Description,
 				&lineItem.Quantity,
 				&lineItem.UnitPrice,
 				&lineItem.TotalPrice,
+				&lineItem.ProductID,
+				&lineItem.TaxCode,
 			)
 			if err != nil {
 				return InvoiceResult{}, err
@@ -166,12 +254,15 @@ func (store *SQLStore) GetInvoice(ctx context.Context, id int64) (InvoiceResult,
 				nil, nil, nil, nil, nil,
 				nil, nil, nil, nil, nil,
 				nil, nil, nil, nil, nil,
+				nil, nil,
 				&lineItem.ID,
 				&lineItem.InvoiceNumber,
 				&lineItem.Description,
 				&lineItem.Quantity,
 				&lineItem.UnitPrice,
 				&lineItem.TotalPrice,
+				&lineItem.ProductID,
+				&lineItem.TaxCode,
 			)
 			if err != nil {
 				return InvoiceResult{}, err
@@ -182,5 +273,8 @@ func (store *SQLStore) GetInvoice(ctx context.Context, id int64) (InvoiceResult,
 	if err = rows.Err(); err != nil {
 		return InvoiceResult{}, err
 	}
+	if !invoiceInitialized {
+		return InvoiceResult{}, translateError(pgx.ErrNoRows)
+	}
 	return result, nil
 }
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/Rhymond/go-money"
+	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/kuthumipepple/numeris-book/util"
 	"github.com/stretchr/testify/require"
 )
@@ -116,3 +117,69 @@ func TestGetInvoice(t *testing.T) {
 
 	require.Equal(t, result1.LineItems, result2.LineItems)
 }
+
+func TestGetInvoiceNotFound(t *testing.T) {
+	result, err := testStore.GetInvoice(context.Background(), -1)
+	require.ErrorIs(t, err, ErrNotFound)
+	require.Empty(t, result)
+}
+
+func randomCreateInvoiceTxParams(customerEmail string) CreateInvoiceTxParams {
+	return CreateInvoiceTxParams{
+		CustomerName:    util.RandomName(),
+		CustomerEmail:   customerEmail,
+		CustomerPhone:   util.RandomPhone(),
+		CustomerAddress: util.RandomAddress(),
+		SenderName:      util.RandomName(),
+		SenderEmail:     util.RandomEmail(),
+		SenderPhone:     util.RandomPhone(),
+		SenderAddress:   util.RandomAddress(),
+		IssueDate:       time.Now(),
+		DueDate:         time.Now().AddDate(0, 0, 30),
+		Status:          util.PENDING_PAYMENT,
+		Subtotal:        1000,
+		TotalAmount:     1000,
+		PaymentInfo:     util.RandomString(10),
+		Items: []InsertLineItemParams{
+			{Description: util.RandomString(10), Quantity: 1, UnitPrice: 1000, TotalPrice: 1000},
+		},
+	}
+}
+
+func TestCreateInvoicesTx(t *testing.T) {
+	customerEmail := util.RandomEmail()
+	args := []CreateInvoiceTxParams{
+		randomCreateInvoiceTxParams(customerEmail),
+		randomCreateInvoiceTxParams(customerEmail),
+	}
+
+	results, err := testStore.CreateInvoicesTx(context.Background(), args)
+	require.NoError(t, err)
+	require.Len(t, results, 2)
+	for i, result := range results {
+		require.NotZero(t, result.InvoiceNumber)
+		require.Equal(t, args[i].CustomerName, result.CustomerName)
+		require.Len(t, result.LineItems, 1)
+		require.Equal(t, result.InvoiceNumber, result.LineItems[0].InvoiceNumber)
+	}
+}
+
+func TestCreateInvoicesTxRollback(t *testing.T) {
+	customerEmail := util.RandomEmail()
+	bad := randomCreateInvoiceTxParams(customerEmail)
+	bad.Items[0].ProductID = pgtype.Int8{Int64: -1, Valid: true}
+
+	_, err := testStore.CreateInvoicesTx(context.Background(), []CreateInvoiceTxParams{
+		randomCreateInvoiceTxParams(customerEmail),
+		bad,
+	})
+	require.Error(t, err)
+
+	// the first invoice was rolled back with the second
+	invoices, err := testStore.ListInvoices(context.Background(), ListInvoicesParams{
+		InvoiceFilter: InvoiceFilter{CustomerEmail: pgtype.Text{String: customerEmail, Valid: true}},
+		Limit:         5,
+	})
+	require.NoError(t, err)
+	require.Empty(t, invoices)
+}
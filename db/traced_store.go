@@ -0,0 +1,200 @@
+package db
+
+import (
+	"context"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// tracedStore is a Store that makes a span for each of its methods. The
+// transactions and statements of a method are traced under its span.
+type tracedStore struct {
+	store Store
+}
+
+// NewTracedStore returns a Store that traces the methods of store.
+func NewTracedStore(store Store) Store {
+	return tracedStore{store: store}
+}
+
+func (s tracedStore) ListInvoices(ctx context.Context, arg ListInvoicesParams) ([]Invoice, error) {
+	ctx, span := startSpan(ctx, "Store.ListInvoices")
+	result, err := s.store.ListInvoices(ctx, arg)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) StreamInvoices(ctx context.Context, arg InvoiceFilter, fn func(Invoice) error) error {
+	ctx, span := startSpan(ctx, "Store.StreamInvoices")
+	err := s.store.StreamInvoices(ctx, arg, fn)
+	endSpan(span, err)
+	return err
+}
+
+func (s tracedStore) StreamInvoiceLineItems(ctx context.Context, arg InvoiceFilter, fn func(Invoice, LineItem) error) error {
+	ctx, span := startSpan(ctx, "Store.StreamInvoiceLineItems")
+	err := s.store.StreamInvoiceLineItems(ctx, arg, fn)
+	endSpan(span, err)
+	return err
+}
+
+func (s tracedStore) RevenueByProduct(ctx context.Context, arg RevenueByProductParams) ([]RevenueByProductRow, error) {
+	ctx, span := startSpan(ctx, "Store.RevenueByProduct")
+	result, err := s.store.RevenueByProduct(ctx, arg)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) ARAging(ctx context.Context, arg ARAgingParams) ([]ARAgingRow, error) {
+	ctx, span := startSpan(ctx, "Store.ARAging")
+	result, err := s.store.ARAging(ctx, arg)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) RevenueSummary(ctx context.Context, arg RevenueSummaryParams) ([]RevenueSummaryRow, error) {
+	ctx, span := startSpan(ctx, "Store.RevenueSummary")
+	result, err := s.store.RevenueSummary(ctx, arg)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) ListAuditEvents(ctx context.Context, arg ListAuditEventsParams) ([]AuditEvent, error) {
+	ctx, span := startSpan(ctx, "Store.ListAuditEvents")
+	result, err := s.store.ListAuditEvents(ctx, arg)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) ListInvoiceChainHeads(ctx context.Context, series pgtype.Text) ([]InvoiceChainLink, error) {
+	ctx, span := startSpan(ctx, "Store.ListInvoiceChainHeads")
+	result, err := s.store.ListInvoiceChainHeads(ctx, series)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) CreateInvoiceTx(ctx context.Context, arg CreateInvoiceTxParams) (InvoiceResult, error) {
+	ctx, span := startSpan(ctx, "Store.CreateInvoiceTx")
+	result, err := s.store.CreateInvoiceTx(ctx, arg)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) CreateInvoicesTx(ctx context.Context, args []CreateInvoiceTxParams) ([]InvoiceResult, error) {
+	ctx, span := startSpan(ctx, "Store.CreateInvoicesTx")
+	result, err := s.store.CreateInvoicesTx(ctx, args)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) GetInvoice(ctx context.Context, id int64) (InvoiceResult, error) {
+	ctx, span := startSpan(ctx, "Store.GetInvoice")
+	result, err := s.store.GetInvoice(ctx, id)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) CreateQuoteTx(ctx context.Context, arg CreateQuoteTxParams) (QuoteResult, error) {
+	ctx, span := startSpan(ctx, "Store.CreateQuoteTx")
+	result, err := s.store.CreateQuoteTx(ctx, arg)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) GetQuote(ctx context.Context, id int64) (QuoteResult, error) {
+	ctx, span := startSpan(ctx, "Store.GetQuote")
+	result, err := s.store.GetQuote(ctx, id)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) ConvertQuoteTx(ctx context.Context, arg ConvertQuoteTxParams) (InvoiceResult, error) {
+	ctx, span := startSpan(ctx, "Store.ConvertQuoteTx")
+	result, err := s.store.ConvertQuoteTx(ctx, arg)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) UpdateQuoteStatusTx(ctx context.Context, arg UpdateQuoteStatusParams) (Quote, error) {
+	ctx, span := startSpan(ctx, "Store.UpdateQuoteStatusTx")
+	result, err := s.store.UpdateQuoteStatusTx(ctx, arg)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) CreateProductTx(ctx context.Context, arg CreateProductTxParams) (ProductResult, error) {
+	ctx, span := startSpan(ctx, "Store.CreateProductTx")
+	result, err := s.store.CreateProductTx(ctx, arg)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) UpdateProductTx(ctx context.Context, arg UpdateProductTxParams) (ProductResult, error) {
+	ctx, span := startSpan(ctx, "Store.UpdateProductTx")
+	result, err := s.store.UpdateProductTx(ctx, arg)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) DeleteProductTx(ctx context.Context, id int64) error {
+	ctx, span := startSpan(ctx, "Store.DeleteProductTx")
+	err := s.store.DeleteProductTx(ctx, id)
+	endSpan(span, err)
+	return err
+}
+
+func (s tracedStore) GetProduct(ctx context.Context, id int64) (ProductResult, error) {
+	ctx, span := startSpan(ctx, "Store.GetProduct")
+	result, err := s.store.GetProduct(ctx, id)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) ListProducts(ctx context.Context, arg ListProductRecordsParams) ([]ProductResult, error) {
+	ctx, span := startSpan(ctx, "Store.ListProducts")
+	result, err := s.store.ListProducts(ctx, arg)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) RecordPaymentTx(ctx context.Context, arg RecordPaymentTxParams) (PaymentResult, error) {
+	ctx, span := startSpan(ctx, "Store.RecordPaymentTx")
+	result, err := s.store.RecordPaymentTx(ctx, arg)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) GetCustomerStatement(ctx context.Context, arg CustomerStatementParams) (CustomerStatement, error) {
+	ctx, span := startSpan(ctx, "Store.GetCustomerStatement")
+	result, err := s.store.GetCustomerStatement(ctx, arg)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) ImportInvoiceTx(ctx context.Context, arg ImportInvoiceTxParams) (ImportInvoiceResult, error) {
+	ctx, span := startSpan(ctx, "Store.ImportInvoiceTx")
+	result, err := s.store.ImportInvoiceTx(ctx, arg)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) VerifyInvoiceChain(ctx context.Context, series pgtype.Text) (ChainVerification, error) {
+	ctx, span := startSpan(ctx, "Store.VerifyInvoiceChain")
+	result, err := s.store.VerifyInvoiceChain(ctx, series)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) GetMigrationVersion(ctx context.Context) (MigrationVersion, error) {
+	ctx, span := startSpan(ctx, "Store.GetMigrationVersion")
+	result, err := s.store.GetMigrationVersion(ctx)
+	endSpan(span, err)
+	return result, err
+}
+
+func (s tracedStore) Ping(ctx context.Context) error {
+	ctx, span := startSpan(ctx, "Store.Ping")
+	err := s.store.Ping(ctx)
+	endSpan(span, err)
+	return err
+}
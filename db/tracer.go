@@ -0,0 +1,62 @@
+package db
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// QueryTracer logs the queries run on a connection with their duration.
+// Queries that take at least the slow query threshold are logged as warnings,
+// others at the debug level. Arguments are not logged, as they hold customer
+// details.
+type QueryTracer struct {
+	logger    *slog.Logger
+	threshold time.Duration
+}
+
+// NewQueryTracer returns a QueryTracer that logs to logger. With a zero
+// threshold, every query is logged as slow.
+func NewQueryTracer(logger *slog.Logger, threshold time.Duration) *QueryTracer {
+	return &QueryTracer{logger: logger, threshold: threshold}
+}
+
+type queryStartKey struct{}
+
+type queryStart struct {
+	sql  string
+	time time.Time
+}
+
+func (t *QueryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	return context.WithValue(ctx, queryStartKey{}, queryStart{sql: data.SQL, time: time.Now()})
+}
+
+func (t *QueryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	start, ok := ctx.Value(queryStartKey{}).(queryStart)
+	if !ok {
+		return
+	}
+	duration := time.Since(start.time)
+
+	level, msg := slog.LevelDebug, "query"
+	if duration >= t.threshold {
+		level, msg = slog.LevelWarn, "slow query"
+	}
+	if !t.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("sql", strings.Join(strings.Fields(start.sql), " ")),
+		slog.Duration("duration", duration),
+		slog.Int64("rows", data.CommandTag.RowsAffected()),
+	}
+	if data.Err != nil {
+		attrs = append(attrs, slog.String("error", data.Err.Error()))
+	}
+	t.logger.LogAttrs(ctx, level, msg, attrs...)
+}
@@ -0,0 +1,90 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/kuthumipepple/numeris-book/util"
+	"github.com/stretchr/testify/require"
+)
+
+func TestQueryTracer(t *testing.T) {
+	testCases := []struct {
+		name  string
+		level string
+		delay time.Duration
+		err   error
+		check func(t *testing.T, records []map[string]any)
+	}{
+		{
+			name:  "Slow",
+			level: "info",
+			delay: 20 * time.Millisecond,
+			check: func(t *testing.T, records []map[string]any) {
+				require.Len(t, records, 1)
+				require.Equal(t, "slow query", records[0]["msg"])
+				require.Equal(t, "WARN", records[0]["level"])
+				require.Equal(t, "SELECT * FROM invoices WHERE invoice_number = $1", records[0]["sql"])
+				require.GreaterOrEqual(t, records[0]["duration"], float64(20*time.Millisecond))
+				require.EqualValues(t, 1, records[0]["rows"])
+				require.Equal(t, "req-1", records[0]["request_id"])
+				require.NotContains(t, records[0], "error")
+			},
+		},
+		{
+			name:  "FastNotLogged",
+			level: "info",
+			check: func(t *testing.T, records []map[string]any) {
+				require.Empty(t, records)
+			},
+		},
+		{
+			name:  "FastAtDebugLevel",
+			level: "debug",
+			err:   errors.New("canceling statement due to user request"),
+			check: func(t *testing.T, records []map[string]any) {
+				require.Len(t, records, 1)
+				require.Equal(t, "query", records[0]["msg"])
+				require.Equal(t, "DEBUG", records[0]["level"])
+				require.Equal(t, "canceling statement due to user request", records[0]["error"])
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			var logs bytes.Buffer
+			logger, err := util.NewLogger(&logs, tc.level)
+			require.NoError(t, err)
+			tracer := NewQueryTracer(logger, 10*time.Millisecond)
+
+			ctx := util.WithRequestID(context.Background(), "req-1")
+			ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{
+				SQL:  "\n\tSELECT * FROM invoices\n\tWHERE invoice_number = $1\n",
+				Args: []any{int64(7)},
+			})
+			time.Sleep(tc.delay)
+			tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{
+				CommandTag: pgconn.NewCommandTag("SELECT 1"),
+				Err:        tc.err,
+			})
+
+			var records []map[string]any
+			decoder := json.NewDecoder(&logs)
+			for decoder.More() {
+				var record map[string]any
+				require.NoError(t, decoder.Decode(&record))
+				records = append(records, record)
+			}
+			tc.check(t, records)
+		})
+	}
+}
@@ -0,0 +1,64 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// tracer makes the spans of the store, its transactions and its statements
+// with the global tracer provider.
+var tracer = otel.Tracer("github.com/kuthumipepple/numeris-book/db")
+
+// startSpan starts a span for an operation of the store.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindInternal),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL))
+}
+
+// endSpan ends span, marking it as failed if err is not nil. A record that is
+// not found is an answer rather than a failure, so it is only noted.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		if !errors.Is(err, ErrNotFound) {
+			span.SetStatus(codes.Error, err.Error())
+		}
+	}
+	span.End()
+}
+
+// SpanTracer makes a span for each statement run on a connection. Like
+// QueryTracer, it leaves the arguments out.
+type SpanTracer struct{}
+
+func NewSpanTracer() *SpanTracer {
+	return &SpanTracer{}
+}
+
+func (t *SpanTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	sql := strings.Join(strings.Fields(data.SQL), " ")
+	operation, _, _ := strings.Cut(sql, " ")
+	operation = strings.ToUpper(operation)
+
+	ctx, _ = tracer.Start(ctx, operation, trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemPostgreSQL,
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(sql),
+		))
+	return ctx
+}
+
+func (t *SpanTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	span.SetAttributes(attribute.Int64("db.rows_affected", data.CommandTag.RowsAffected()))
+	endSpan(span, data.Err)
+}
@@ -0,0 +1,97 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// stubStore runs a statement through a SpanTracer in GetInvoice, as the
+// connections of an SQLStore do.
+type stubStore struct {
+	Store
+	err error
+}
+
+func (s stubStore) GetInvoice(ctx context.Context, id int64) (InvoiceResult, error) {
+	tracer := NewSpanTracer()
+	ctx = tracer.TraceQueryStart(ctx, nil, pgx.TraceQueryStartData{
+		SQL:  "\n\tselect * from invoices\n\twhere invoice_number = $1\n",
+		Args: []any{id},
+	})
+	tracer.TraceQueryEnd(ctx, nil, pgx.TraceQueryEndData{
+		CommandTag: pgconn.NewCommandTag("SELECT 1"),
+		Err:        s.err,
+	})
+	return InvoiceResult{}, s.err
+}
+
+func TestTracedStore(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	testCases := []struct {
+		name   string
+		err    error
+		status codes.Code
+	}{
+		{
+			name:   "OK",
+			status: codes.Unset,
+		},
+		{
+			name:   "NotFound",
+			err:    translateError(pgx.ErrNoRows),
+			status: codes.Unset,
+		},
+		{
+			name:   "Failed",
+			err:    errors.New("connection reset by peer"),
+			status: codes.Error,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			ended := len(recorder.Ended())
+			store := NewTracedStore(stubStore{err: tc.err})
+
+			_, err := store.GetInvoice(context.Background(), 7)
+			require.Equal(t, tc.err, err)
+
+			spans := recorder.Ended()[ended:]
+			require.Len(t, spans, 2)
+			statement, method := spans[0], spans[1]
+
+			require.Equal(t, "Store.GetInvoice", method.Name())
+			require.Equal(t, trace.SpanKindInternal, method.SpanKind())
+			require.False(t, method.Parent().IsValid())
+			require.Equal(t, tc.status, method.Status().Code)
+
+			require.Equal(t, "SELECT", statement.Name())
+			require.Equal(t, trace.SpanKindClient, statement.SpanKind())
+			require.Equal(t, method.SpanContext().SpanID(), statement.Parent().SpanID())
+			require.Equal(t, method.SpanContext().TraceID(), statement.SpanContext().TraceID())
+			require.Equal(t, tc.status, statement.Status().Code)
+			require.Contains(t, statement.Attributes(), attribute.String("db.system", "postgresql"))
+			require.Contains(t, statement.Attributes(), attribute.String("db.query.text", "select * from invoices where invoice_number = $1"))
+			require.Contains(t, statement.Attributes(), attribute.Int64("db.rows_affected", 1))
+			if tc.err != nil {
+				require.Len(t, method.Events(), 1)
+				require.Equal(t, "exception", method.Events()[0].Name)
+			}
+		})
+	}
+}
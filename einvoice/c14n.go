@@ -0,0 +1,277 @@
+package einvoice
+
+import (
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+)
+
+const xmlNamespace = "http://www.w3.org/XML/1998/namespace"
+
+// c14nElement is what the selectors of canonicalize are given about an
+// element: its name and the names of its attributes, with the namespace
+// prefixes resolved.
+type c14nElement struct {
+	Name  xml.Name
+	Attrs []xml.Attr
+}
+
+func (e c14nElement) attr(local string) string {
+	for _, a := range e.Attrs {
+		if a.Name.Space == "" && a.Name.Local == local {
+			return a.Value
+		}
+	}
+	return ""
+}
+
+// c14nFrame is an open element of the document being canonicalized.
+type c14nFrame struct {
+	start xml.StartElement
+	// scope maps the prefixes in scope to their namespaces.
+	scope map[string]string
+	// rendered maps the prefixes declared in the output by the element or
+	// its ancestors to their namespaces.
+	rendered map[string]string
+	output   bool
+	omitted  bool
+}
+
+// canonicalize returns the Exclusive XML Canonicalization 1.0, without
+// comments, of the first element of data that sel matches, or of the whole
+// document if sel is nil. Elements that omit matches are left out with their
+// content, which is how the enveloped signature transform removes the
+// signature from the document it signs.
+//
+// The InclusiveNamespaces prefix list is not supported, and neither are
+// DTDs, whose defaults would change the canonical form.
+func canonicalize(data []byte, sel, omit func(c14nElement) bool) ([]byte, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	var out bytes.Buffer
+	var stack []*c14nFrame
+	found, done := false, false
+
+	for !done {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var parent *c14nFrame
+		if len(stack) > 0 {
+			parent = stack[len(stack)-1]
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			f := &c14nFrame{start: t.Copy(), scope: map[string]string{"xml": xmlNamespace}}
+			if parent != nil {
+				f.scope = parent.scope
+				f.rendered = parent.rendered
+				f.output = parent.output
+				f.omitted = parent.omitted
+			}
+			if declaresNamespaces(t) {
+				f.scope = cloneNamespaces(f.scope)
+				for _, a := range t.Attr {
+					if prefix, ok := namespaceDeclaration(a); ok {
+						f.scope[prefix] = a.Value
+					}
+				}
+			}
+			stack = append(stack, f)
+			if f.omitted {
+				continue
+			}
+
+			e := resolveElement(t, f.scope)
+			if omit != nil && omit(e) {
+				f.omitted = true
+				continue
+			}
+			if !f.output && (sel == nil || !found && sel(e)) {
+				f.output, found = true, true
+				f.rendered = map[string]string{}
+			}
+			if f.output {
+				f.rendered = writeC14NStart(&out, t, f.scope, f.rendered)
+			}
+
+		case xml.EndElement:
+			if parent == nil {
+				return nil, errors.New("unexpected end element")
+			}
+			stack = stack[:len(stack)-1]
+			if parent.output && !parent.omitted {
+				out.WriteString("</" + qualifiedName(parent.start.Name) + ">")
+				// the selected element ends the output
+				if sel != nil && (len(stack) == 0 || !stack[len(stack)-1].output) {
+					done = true
+				}
+			}
+
+		case xml.CharData:
+			if parent != nil && parent.output && !parent.omitted {
+				writeC14NText(&out, string(t))
+			}
+
+		case xml.ProcInst:
+			if t.Target == "xml" {
+				continue
+			}
+			pi := "<?" + t.Target
+			if len(t.Inst) > 0 {
+				pi += " " + string(t.Inst)
+			}
+			pi += "?>"
+			switch {
+			case parent != nil && parent.output && !parent.omitted:
+				out.WriteString(pi)
+			case parent == nil && sel == nil && found:
+				out.WriteString("\n" + pi)
+			case parent == nil && sel == nil:
+				out.WriteString(pi + "\n")
+			}
+		}
+	}
+
+	if !found {
+		return nil, errors.New("element to canonicalize not found")
+	}
+	return out.Bytes(), nil
+}
+
+func declaresNamespaces(t xml.StartElement) bool {
+	for _, a := range t.Attr {
+		if _, ok := namespaceDeclaration(a); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// namespaceDeclaration returns the prefix that an xmlns attribute declares,
+// which is empty for the default namespace.
+func namespaceDeclaration(a xml.Attr) (string, bool) {
+	switch {
+	case a.Name.Space == "xmlns":
+		return a.Name.Local, true
+	case a.Name.Space == "" && a.Name.Local == "xmlns":
+		return "", true
+	}
+	return "", false
+}
+
+func cloneNamespaces(m map[string]string) map[string]string {
+	clone := make(map[string]string, len(m)+1)
+	for k, v := range m {
+		clone[k] = v
+	}
+	return clone
+}
+
+func resolveElement(t xml.StartElement, scope map[string]string) c14nElement {
+	e := c14nElement{Name: xml.Name{Space: scope[t.Name.Space], Local: t.Name.Local}}
+	for _, a := range t.Attr {
+		if _, ok := namespaceDeclaration(a); ok {
+			continue
+		}
+		name := a.Name
+		if name.Space != "" {
+			name.Space = scope[name.Space]
+		}
+		e.Attrs = append(e.Attrs, xml.Attr{Name: name, Value: a.Value})
+	}
+	return e
+}
+
+func qualifiedName(n xml.Name) string {
+	if n.Space == "" {
+		return n.Local
+	}
+	return n.Space + ":" + n.Local
+}
+
+// writeC14NStart writes a start tag with the namespace declarations that the
+// element visibly uses and that no output ancestor has declared, and its
+// attributes in canonical order. It returns the declarations in force for
+// the children.
+func writeC14NStart(out *bytes.Buffer, t xml.StartElement, scope, rendered map[string]string) map[string]string {
+	type attr struct {
+		space, local, name, value string
+	}
+	utilized := map[string]bool{t.Name.Space: true}
+	var attrs []attr
+	for _, a := range t.Attr {
+		if _, ok := namespaceDeclaration(a); ok {
+			continue
+		}
+		space := ""
+		if a.Name.Space != "" {
+			utilized[a.Name.Space] = true
+			space = scope[a.Name.Space]
+		}
+		attrs = append(attrs, attr{space, a.Name.Local, qualifiedName(a.Name), a.Value})
+	}
+	delete(utilized, "xml")
+
+	prefixes := make([]string, 0, len(utilized))
+	for p := range utilized {
+		prefixes = append(prefixes, p)
+	}
+	sort.Strings(prefixes)
+
+	out.WriteString("<" + qualifiedName(t.Name))
+	copied := false
+	for _, p := range prefixes {
+		uri := scope[p]
+		// an empty default namespace is only declared to undo one
+		if previous, ok := rendered[p]; previous == uri && (ok || p == "") {
+			continue
+		}
+		if !copied {
+			rendered, copied = cloneNamespaces(rendered), true
+		}
+		rendered[p] = uri
+		if p == "" {
+			out.WriteString(` xmlns="`)
+		} else {
+			out.WriteString(` xmlns:` + p + `="`)
+		}
+		writeC14NAttrValue(out, uri)
+		out.WriteString(`"`)
+	}
+
+	sort.Slice(attrs, func(i, j int) bool {
+		if attrs[i].space != attrs[j].space {
+			return attrs[i].space < attrs[j].space
+		}
+		return attrs[i].local < attrs[j].local
+	})
+	for _, a := range attrs {
+		out.WriteString(" " + a.name + `="`)
+		writeC14NAttrValue(out, a.value)
+		out.WriteString(`"`)
+	}
+	out.WriteString(">")
+	return rendered
+}
+
+var (
+	c14nTextEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;", "\r", "&#xD;")
+	c14nAttrEscaper = strings.NewReplacer("&", "&amp;", "<", "&lt;", `"`, "&quot;", "\t", "&#x9;", "\n", "&#xA;", "\r", "&#xD;")
+)
+
+func writeC14NText(out *bytes.Buffer, s string) {
+	c14nTextEscaper.WriteString(out, s)
+}
+
+func writeC14NAttrValue(out *bytes.Buffer, s string) {
+	c14nAttrEscaper.WriteString(out, s)
+}
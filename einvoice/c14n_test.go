@@ -0,0 +1,63 @@
+package einvoice
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCanonicalize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		input    string
+		sel      func(c14nElement) bool
+		omit     func(c14nElement) bool
+		expected string
+	}{
+		{
+			name:     "Document",
+			input:    "<?xml version=\"1.0\"?>\n<?pi data?>\n<a  b='1' a=\"x &amp; &lt;y&gt;&#9;\"><!-- c --><e/>t\r\n<![CDATA[<x>]]></a>\n",
+			expected: "<?pi data?>\n<a a=\"x &amp; &lt;y>&#x9;\" b=\"1\"><e></e>t\n&lt;x&gt;</a>",
+		},
+		{
+			name:     "UnusedAndRedundantNamespaces",
+			input:    `<p:a xmlns:p="urn:p" xmlns:q="urn:q"><p:b xmlns:p="urn:p"><q:c/></p:b></p:a>`,
+			expected: `<p:a xmlns:p="urn:p"><p:b><q:c xmlns:q="urn:q"></q:c></p:b></p:a>`,
+		},
+		{
+			name:     "AttributeOrder",
+			input:    `<a xmlns="urn:d" xmlns:z="urn:a" xmlns:y="urn:b" y:k="1" z:k="2" k="3" c="4"/>`,
+			expected: `<a xmlns="urn:d" xmlns:y="urn:b" xmlns:z="urn:a" c="4" k="3" z:k="2" y:k="1"></a>`,
+		},
+		{
+			name:     "DefaultNamespaceUndone",
+			input:    `<a xmlns="urn:d"><b xmlns=""><c/></b></a>`,
+			expected: `<a xmlns="urn:d"><b xmlns=""><c></c></b></a>`,
+		},
+		{
+			name:     "SelectedElement",
+			input:    `<a xmlns="urn:d" xmlns:p="urn:p"><p:b Id="x"><c p:k="1"/></p:b><p:b Id="y"/></a>`,
+			sel:      hasID("x"),
+			expected: `<p:b xmlns:p="urn:p" Id="x"><c xmlns="urn:d" p:k="1"></c></p:b>`,
+		},
+		{
+			name:     "OmittedElement",
+			input:    `<a xmlns:ds="` + dsigNamespace + `"><b/><ds:Signature><ds:SignedInfo/></ds:Signature></a>`,
+			omit:     isXMLSignature,
+			expected: `<a><b></b></a>`,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := canonicalize([]byte(tc.input), tc.sel, tc.omit)
+			require.NoError(t, err)
+			require.Equal(t, tc.expected, string(got))
+		})
+	}
+
+	_, err := canonicalize([]byte(`<a/>`), hasID("missing"), nil)
+	require.Error(t, err)
+}
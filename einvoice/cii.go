@@ -0,0 +1,289 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+const (
+	ciiInvoiceNamespace      = "urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"
+	ciiQualifiedNamespace    = "urn:un:unece:uncefact:data:standard:QualifiedDataType:100"
+	ciiAggregateNamespace    = "urn:un:unece:uncefact:data:standard:ReusableAggregateBusinessInformationEntity:100"
+	ciiUnqualifiedNamespace  = "urn:un:unece:uncefact:data:standard:UnqualifiedDataType:100"
+	en16931GuidelineID       = "urn:cen.eu:en16931:2017"
+	ciiDateFormat            = "102"
+	ciiVATRegistrationScheme = "VA"
+)
+
+// The element order of these types follows the UN/CEFACT CII D16B schema, as
+// required by Factur-X and ZUGFeRD.
+
+type ciiInvoice struct {
+	XMLName     xml.Name       `xml:"rsm:CrossIndustryInvoice"`
+	XmlnsRsm    string         `xml:"xmlns:rsm,attr"`
+	XmlnsQdt    string         `xml:"xmlns:qdt,attr"`
+	XmlnsRam    string         `xml:"xmlns:ram,attr"`
+	XmlnsUdt    string         `xml:"xmlns:udt,attr"`
+	Context     ciiContext     `xml:"rsm:ExchangedDocumentContext"`
+	Document    ciiDocument    `xml:"rsm:ExchangedDocument"`
+	Transaction ciiTransaction `xml:"rsm:SupplyChainTradeTransaction"`
+}
+
+type ciiContext struct {
+	GuidelineID string `xml:"ram:GuidelineSpecifiedDocumentContextParameter>ram:ID"`
+}
+
+type ciiDocument struct {
+	ID            string      `xml:"ram:ID"`
+	TypeCode      string      `xml:"ram:TypeCode"`
+	IssueDateTime ciiDateTime `xml:"ram:IssueDateTime"`
+	IncludedNote  *ciiNote    `xml:"ram:IncludedNote"`
+}
+
+type ciiNote struct {
+	Content string `xml:"ram:Content"`
+}
+
+type ciiDateTime struct {
+	DateTimeString ciiDateTimeString `xml:"udt:DateTimeString"`
+}
+
+type ciiDateTimeString struct {
+	Format string `xml:"format,attr"`
+	Value  string `xml:",chardata"`
+}
+
+type ciiTransaction struct {
+	LineItems  []ciiLineItem       `xml:"ram:IncludedSupplyChainTradeLineItem"`
+	Agreement  ciiHeaderAgreement  `xml:"ram:ApplicableHeaderTradeAgreement"`
+	Delivery   struct{}            `xml:"ram:ApplicableHeaderTradeDelivery"`
+	Settlement ciiHeaderSettlement `xml:"ram:ApplicableHeaderTradeSettlement"`
+}
+
+type ciiLineItem struct {
+	LineID     string            `xml:"ram:AssociatedDocumentLineDocument>ram:LineID"`
+	Product    ciiProduct        `xml:"ram:SpecifiedTradeProduct"`
+	NetPrice   string            `xml:"ram:SpecifiedLineTradeAgreement>ram:NetPriceProductTradePrice>ram:ChargeAmount"`
+	Quantity   ciiQuantity       `xml:"ram:SpecifiedLineTradeDelivery>ram:BilledQuantity"`
+	Settlement ciiLineSettlement `xml:"ram:SpecifiedLineTradeSettlement"`
+}
+
+type ciiProduct struct {
+	SellerAssignedID string `xml:"ram:SellerAssignedID,omitempty"`
+	Name             string `xml:"ram:Name"`
+}
+
+type ciiQuantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ciiLineSettlement struct {
+	Tax             ciiTradeTax `xml:"ram:ApplicableTradeTax"`
+	LineTotalAmount string      `xml:"ram:SpecifiedTradeSettlementLineMonetarySummation>ram:LineTotalAmount"`
+}
+
+type ciiTradeTax struct {
+	CalculatedAmount    string `xml:"ram:CalculatedAmount,omitempty"`
+	TypeCode            string `xml:"ram:TypeCode"`
+	ExemptionReason     string `xml:"ram:ExemptionReason,omitempty"`
+	BasisAmount         string `xml:"ram:BasisAmount,omitempty"`
+	CategoryCode        string `xml:"ram:CategoryCode"`
+	ExemptionReasonCode string `xml:"ram:ExemptionReasonCode,omitempty"`
+	RatePercent         string `xml:"ram:RateApplicablePercent,omitempty"`
+}
+
+type ciiHeaderAgreement struct {
+	BuyerReference string        `xml:"ram:BuyerReference"`
+	Seller         ciiTradeParty `xml:"ram:SellerTradeParty"`
+	Buyer          ciiTradeParty `xml:"ram:BuyerTradeParty"`
+}
+
+type ciiTradeParty struct {
+	Name            string              `xml:"ram:Name"`
+	Contact         *ciiContact         `xml:"ram:DefinedTradeContact"`
+	Address         ciiAddress          `xml:"ram:PostalTradeAddress"`
+	URI             ciiIdentifier       `xml:"ram:URIUniversalCommunication>ram:URIID"`
+	TaxRegistration *ciiTaxRegistration `xml:"ram:SpecifiedTaxRegistration"`
+}
+
+type ciiContact struct {
+	Telephone string `xml:"ram:TelephoneUniversalCommunication>ram:CompleteNumber,omitempty"`
+	Email     string `xml:"ram:EmailURIUniversalCommunication>ram:URIID,omitempty"`
+}
+
+type ciiAddress struct {
+	LineOne   string `xml:"ram:LineOne,omitempty"`
+	CountryID string `xml:"ram:CountryID"`
+}
+
+type ciiIdentifier struct {
+	SchemeID string `xml:"schemeID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ciiTaxRegistration struct {
+	ID ciiIdentifier `xml:"ram:ID"`
+}
+
+type ciiHeaderSettlement struct {
+	PaymentReference    string               `xml:"ram:PaymentReference"`
+	InvoiceCurrencyCode string               `xml:"ram:InvoiceCurrencyCode"`
+	PaymentMeansCode    string               `xml:"ram:SpecifiedTradeSettlementPaymentMeans>ram:TypeCode"`
+	Taxes               []ciiTradeTax        `xml:"ram:ApplicableTradeTax"`
+	Allowances          []ciiAllowance       `xml:"ram:SpecifiedTradeAllowanceCharge"`
+	PaymentTerms        *ciiPaymentTerms     `xml:"ram:SpecifiedTradePaymentTerms"`
+	Summation           ciiMonetarySummation `xml:"ram:SpecifiedTradeSettlementHeaderMonetarySummation"`
+}
+
+type ciiAllowance struct {
+	ChargeIndicator bool        `xml:"ram:ChargeIndicator>udt:Indicator"`
+	ActualAmount    string      `xml:"ram:ActualAmount"`
+	Reason          string      `xml:"ram:Reason"`
+	Tax             ciiTradeTax `xml:"ram:CategoryTradeTax"`
+}
+
+type ciiPaymentTerms struct {
+	Description string       `xml:"ram:Description,omitempty"`
+	DueDate     *ciiDateTime `xml:"ram:DueDateDateTime"`
+}
+
+type ciiMonetarySummation struct {
+	LineTotalAmount      string    `xml:"ram:LineTotalAmount"`
+	AllowanceTotalAmount string    `xml:"ram:AllowanceTotalAmount,omitempty"`
+	TaxBasisTotalAmount  string    `xml:"ram:TaxBasisTotalAmount"`
+	TaxTotalAmount       ciiAmount `xml:"ram:TaxTotalAmount"`
+	GrandTotalAmount     string    `xml:"ram:GrandTotalAmount"`
+	DuePayableAmount     string    `xml:"ram:DuePayableAmount"`
+}
+
+type ciiAmount struct {
+	CurrencyID string `xml:"currencyID,attr"`
+	Value      string `xml:",chardata"`
+}
+
+// CII renders the document as a UN/CEFACT Cross Industry Invoice following
+// the EN 16931 profile of Factur-X and ZUGFeRD. It does not validate the
+// document; call Validate first.
+func (d Document) CII() ([]byte, error) {
+	amount := func(v int64) string {
+		return formatAmount(v, d.Currency)
+	}
+
+	invoice := ciiInvoice{
+		XmlnsRsm: ciiInvoiceNamespace,
+		XmlnsQdt: ciiQualifiedNamespace,
+		XmlnsRam: ciiAggregateNamespace,
+		XmlnsUdt: ciiUnqualifiedNamespace,
+		Context:  ciiContext{GuidelineID: en16931GuidelineID},
+		Document: ciiDocument{
+			ID:            d.Number,
+			TypeCode:      commercialInvoiceCode,
+			IssueDateTime: newCIIDateTime(d.IssueDate),
+		},
+		Transaction: ciiTransaction{
+			Agreement: ciiHeaderAgreement{
+				BuyerReference: d.BuyerReference,
+				Seller:         newCIITradeParty(d.Seller),
+				Buyer:          newCIITradeParty(d.Buyer),
+			},
+			Settlement: ciiHeaderSettlement{
+				PaymentReference:    d.Number,
+				InvoiceCurrencyCode: d.Currency,
+				PaymentMeansCode:    unspecifiedPaymentCode,
+				Summation: ciiMonetarySummation{
+					LineTotalAmount:     amount(d.LineTotal),
+					TaxBasisTotalAmount: amount(d.TaxExclusiveTotal),
+					TaxTotalAmount:      ciiAmount{CurrencyID: d.Currency, Value: amount(d.TaxTotal)},
+					GrandTotalAmount:    amount(d.TaxInclusiveTotal),
+					DuePayableAmount:    amount(d.PayableAmount),
+				},
+			},
+		},
+	}
+	if d.Note != "" {
+		invoice.Document.IncludedNote = &ciiNote{Content: d.Note}
+	}
+	if d.AllowanceTotal != 0 {
+		invoice.Transaction.Settlement.Summation.AllowanceTotalAmount = amount(d.AllowanceTotal)
+	}
+	if d.PaymentTerms != "" || !d.DueDate.IsZero() {
+		terms := &ciiPaymentTerms{Description: d.PaymentTerms}
+		if !d.DueDate.IsZero() {
+			dueDate := newCIIDateTime(d.DueDate)
+			terms.DueDate = &dueDate
+		}
+		invoice.Transaction.Settlement.PaymentTerms = terms
+	}
+
+	for _, v := range d.Lines {
+		invoice.Transaction.LineItems = append(invoice.Transaction.LineItems, ciiLineItem{
+			LineID:   v.ID,
+			Product:  ciiProduct{SellerAssignedID: v.SellerID, Name: v.Name},
+			NetPrice: amount(v.UnitPrice),
+			Quantity: ciiQuantity{UnitCode: unitCode, Value: strconv.FormatInt(v.Quantity, 10)},
+			Settlement: ciiLineSettlement{
+				Tax:             newCIITradeTax(v.Tax),
+				LineTotalAmount: amount(v.NetAmount),
+			},
+		})
+	}
+
+	for _, v := range d.TaxSubtotals {
+		tax := newCIITradeTax(v.Tax)
+		tax.CalculatedAmount = amount(v.TaxAmount)
+		tax.BasisAmount = amount(v.TaxableAmount)
+		if v.Tax.Code == NotSubjectVAT {
+			tax.ExemptionReason = "Not subject to VAT"
+			tax.ExemptionReasonCode = "VATEX-EU-O"
+		}
+		invoice.Transaction.Settlement.Taxes = append(invoice.Transaction.Settlement.Taxes, tax)
+	}
+
+	for _, v := range d.Allowances {
+		invoice.Transaction.Settlement.Allowances = append(invoice.Transaction.Settlement.Allowances, ciiAllowance{
+			ChargeIndicator: false,
+			ActualAmount:    amount(v.Amount),
+			Reason:          "Discount",
+			Tax:             newCIITradeTax(v.Tax),
+		})
+	}
+
+	data, err := xml.MarshalIndent(invoice, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func newCIITradeParty(p Party) ciiTradeParty {
+	party := ciiTradeParty{
+		Name:    p.Name,
+		Address: ciiAddress{LineOne: p.Address, CountryID: p.CountryCode},
+		URI:     ciiIdentifier{SchemeID: emailSchemeID, Value: p.Email},
+	}
+	if p.Phone != "" || p.Email != "" {
+		party.Contact = &ciiContact{Telephone: p.Phone, Email: p.Email}
+	}
+	if p.VATID != "" {
+		party.TaxRegistration = &ciiTaxRegistration{
+			ID: ciiIdentifier{SchemeID: ciiVATRegistrationScheme, Value: p.VATID},
+		}
+	}
+	return party
+}
+
+func newCIITradeTax(tax TaxCategory) ciiTradeTax {
+	return ciiTradeTax{
+		TypeCode:     "VAT",
+		CategoryCode: tax.Code,
+		RatePercent:  tax.Percent,
+	}
+}
+
+func newCIIDateTime(t time.Time) ciiDateTime {
+	return ciiDateTime{
+		DateTimeString: ciiDateTimeString{Format: ciiDateFormat, Value: t.Format("20060102")},
+	}
+}
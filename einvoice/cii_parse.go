@@ -0,0 +1,215 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// The types below read a received Cross Industry Invoice. Like the UBL input
+// types, they match elements by local name only.
+
+type ciiInvoiceIn struct {
+	XMLName     xml.Name
+	ID          string              `xml:"ExchangedDocument>ID"`
+	TypeCode    string              `xml:"ExchangedDocument>TypeCode"`
+	IssueDate   ciiDateTimeStringIn `xml:"ExchangedDocument>IssueDateTime>DateTimeString"`
+	Notes       []string            `xml:"ExchangedDocument>IncludedNote>Content"`
+	Transaction ciiTransactionIn    `xml:"SupplyChainTradeTransaction"`
+}
+
+type ciiDateTimeStringIn struct {
+	Format string `xml:"format,attr"`
+	Value  string `xml:",chardata"`
+}
+
+type ciiTransactionIn struct {
+	LineItems      []ciiLineItemIn       `xml:"IncludedSupplyChainTradeLineItem"`
+	BuyerReference string                `xml:"ApplicableHeaderTradeAgreement>BuyerReference"`
+	Seller         ciiTradePartyIn       `xml:"ApplicableHeaderTradeAgreement>SellerTradeParty"`
+	Buyer          ciiTradePartyIn       `xml:"ApplicableHeaderTradeAgreement>BuyerTradeParty"`
+	Settlement     ciiHeaderSettlementIn `xml:"ApplicableHeaderTradeSettlement"`
+}
+
+type ciiLineItemIn struct {
+	LineID        string           `xml:"AssociatedDocumentLineDocument>LineID"`
+	SellerID      string           `xml:"SpecifiedTradeProduct>SellerAssignedID"`
+	Name          string           `xml:"SpecifiedTradeProduct>Name"`
+	NetPrice      string           `xml:"SpecifiedLineTradeAgreement>NetPriceProductTradePrice>ChargeAmount"`
+	BasisQuantity string           `xml:"SpecifiedLineTradeAgreement>NetPriceProductTradePrice>BasisQuantity"`
+	Quantity      string           `xml:"SpecifiedLineTradeDelivery>BilledQuantity"`
+	Tax           ciiTradeTaxIn    `xml:"SpecifiedLineTradeSettlement>ApplicableTradeTax"`
+	Allowances    []ciiAllowanceIn `xml:"SpecifiedLineTradeSettlement>SpecifiedTradeAllowanceCharge"`
+	LineTotal     string           `xml:"SpecifiedLineTradeSettlement>SpecifiedTradeSettlementLineMonetarySummation>LineTotalAmount"`
+}
+
+type ciiTradeTaxIn struct {
+	CalculatedAmount string `xml:"CalculatedAmount"`
+	BasisAmount      string `xml:"BasisAmount"`
+	CategoryCode     string `xml:"CategoryCode"`
+	RatePercent      string `xml:"RateApplicablePercent"`
+}
+
+type ciiTradePartyIn struct {
+	Name      string          `xml:"Name"`
+	Telephone string          `xml:"DefinedTradeContact>TelephoneUniversalCommunication>CompleteNumber"`
+	Email     string          `xml:"DefinedTradeContact>EmailURIUniversalCommunication>URIID"`
+	Postcode  string          `xml:"PostalTradeAddress>PostcodeCode"`
+	LineOne   string          `xml:"PostalTradeAddress>LineOne"`
+	LineTwo   string          `xml:"PostalTradeAddress>LineTwo"`
+	LineThree string          `xml:"PostalTradeAddress>LineThree"`
+	CityName  string          `xml:"PostalTradeAddress>CityName"`
+	CountryID string          `xml:"PostalTradeAddress>CountryID"`
+	URI       ciiIdentifier   `xml:"URIUniversalCommunication>URIID"`
+	TaxIDs    []ciiIdentifier `xml:"SpecifiedTaxRegistration>ID"`
+}
+
+type ciiHeaderSettlementIn struct {
+	InvoiceCurrencyCode string           `xml:"InvoiceCurrencyCode"`
+	Taxes               []ciiTradeTaxIn  `xml:"ApplicableTradeTax"`
+	Allowances          []ciiAllowanceIn `xml:"SpecifiedTradeAllowanceCharge"`
+	PaymentTerms        []struct {
+		Description string              `xml:"Description"`
+		DueDate     ciiDateTimeStringIn `xml:"DueDateDateTime>DateTimeString"`
+	} `xml:"SpecifiedTradePaymentTerms"`
+	Summation struct {
+		LineTotalAmount      string      `xml:"LineTotalAmount"`
+		ChargeTotalAmount    string      `xml:"ChargeTotalAmount"`
+		AllowanceTotalAmount string      `xml:"AllowanceTotalAmount"`
+		TaxBasisTotalAmount  string      `xml:"TaxBasisTotalAmount"`
+		TaxTotalAmounts      []ciiAmount `xml:"TaxTotalAmount"`
+		RoundingAmount       string      `xml:"RoundingAmount"`
+		GrandTotalAmount     string      `xml:"GrandTotalAmount"`
+		TotalPrepaidAmount   string      `xml:"TotalPrepaidAmount"`
+		DuePayableAmount     string      `xml:"DuePayableAmount"`
+	} `xml:"SpecifiedTradeSettlementHeaderMonetarySummation"`
+}
+
+type ciiAllowanceIn struct {
+	ChargeIndicator bool          `xml:"ChargeIndicator>Indicator"`
+	ActualAmount    string        `xml:"ActualAmount"`
+	Tax             ciiTradeTaxIn `xml:"CategoryTradeTax"`
+}
+
+// ParseCII reads a UN/CEFACT Cross Industry Invoice, such as the XML of a
+// Factur-X or ZUGFeRD invoice, into a Document with the totals stated in the
+// invoice. Call Validate to check them. Credit notes, charges and prepaid
+// amounts are not supported.
+func ParseCII(data []byte) (Document, error) {
+	var in ciiInvoiceIn
+	if err := xml.Unmarshal(data, &in); err != nil {
+		return Document{}, err
+	}
+	settlement := in.Transaction.Settlement
+	p := &documentParser{currency: strings.TrimSpace(settlement.InvoiceCurrencyCode)}
+	if in.XMLName.Space != ciiInvoiceNamespace || in.XMLName.Local != "CrossIndustryInvoice" {
+		p.fail("not a Cross Industry Invoice")
+	}
+	if code := strings.TrimSpace(in.TypeCode); code != commercialInvoiceCode {
+		p.fail("invoice type code %q is not supported", code)
+	}
+	amount := func(s string) int64 {
+		return p.amount(s, "")
+	}
+	date := func(v ciiDateTimeStringIn) time.Time {
+		if v.Format != "" && v.Format != ciiDateFormat {
+			p.fail("date format %q is not supported", v.Format)
+		}
+		return p.date(v.Value, "20060102")
+	}
+
+	d := Document{
+		Number:         strings.TrimSpace(in.ID),
+		IssueDate:      date(in.IssueDate),
+		Currency:       p.currency,
+		Note:           joinNonEmpty("\n", in.Notes...),
+		BuyerReference: strings.TrimSpace(in.Transaction.BuyerReference),
+		Seller:         in.Transaction.Seller.party(),
+		Buyer:          in.Transaction.Buyer.party(),
+	}
+
+	var terms []string
+	for _, v := range settlement.PaymentTerms {
+		terms = append(terms, v.Description)
+		if d.DueDate.IsZero() {
+			d.DueDate = date(v.DueDate)
+		}
+	}
+	d.PaymentTerms = joinNonEmpty("\n", terms...)
+
+	for _, v := range in.Transaction.LineItems {
+		if len(v.Allowances) > 0 {
+			p.fail("invoice line allowances and charges are not supported")
+		}
+		p.baseQuantity(v.BasisQuantity)
+		d.Lines = append(d.Lines, Line{
+			ID:        strings.TrimSpace(v.LineID),
+			Name:      strings.TrimSpace(v.Name),
+			Quantity:  p.quantity(v.Quantity),
+			UnitPrice: amount(v.NetPrice),
+			NetAmount: amount(v.LineTotal),
+			SellerID:  strings.TrimSpace(v.SellerID),
+			Tax:       p.tax(v.Tax.CategoryCode, v.Tax.RatePercent),
+		})
+	}
+
+	for _, v := range settlement.Allowances {
+		if v.ChargeIndicator {
+			p.unsupported(amount(v.ActualAmount), "document level charges")
+			continue
+		}
+		d.Allowances = append(d.Allowances, Allowance{
+			Amount: amount(v.ActualAmount),
+			Tax:    p.tax(v.Tax.CategoryCode, v.Tax.RatePercent),
+		})
+	}
+
+	for _, v := range settlement.Taxes {
+		d.TaxSubtotals = append(d.TaxSubtotals, TaxSubtotal{
+			TaxableAmount: amount(v.BasisAmount),
+			TaxAmount:     amount(v.CalculatedAmount),
+			Tax:           p.tax(v.CategoryCode, v.RatePercent),
+		})
+	}
+
+	summation := settlement.Summation
+	d.LineTotal = amount(summation.LineTotalAmount)
+	d.AllowanceTotal = amount(summation.AllowanceTotalAmount)
+	d.TaxExclusiveTotal = amount(summation.TaxBasisTotalAmount)
+	d.TaxInclusiveTotal = amount(summation.GrandTotalAmount)
+	d.PayableAmount = amount(summation.DuePayableAmount)
+	// The VAT total is stated once in the invoice currency and, when it
+	// differs, once more in the tax accounting currency.
+	for _, v := range summation.TaxTotalAmounts {
+		if v.CurrencyID == "" || v.CurrencyID == d.Currency {
+			d.TaxTotal = p.amount(v.Value, v.CurrencyID)
+		}
+	}
+	p.unsupported(amount(summation.ChargeTotalAmount), "document level charges")
+	p.unsupported(amount(summation.TotalPrepaidAmount), "prepaid amounts")
+	p.unsupported(amount(summation.RoundingAmount), "rounding amounts")
+
+	if p.err != nil {
+		return Document{}, p.err
+	}
+	return d, nil
+}
+
+func (in ciiTradePartyIn) party() Party {
+	p := Party{
+		Name:        strings.TrimSpace(in.Name),
+		Email:       strings.TrimSpace(in.Email),
+		Phone:       strings.TrimSpace(in.Telephone),
+		Address:     joinNonEmpty(", ", in.LineOne, in.LineTwo, in.LineThree, in.CityName, in.Postcode),
+		CountryCode: strings.TrimSpace(in.CountryID),
+	}
+	if in.URI.SchemeID == emailSchemeID {
+		p.Email = strings.TrimSpace(in.URI.Value)
+	}
+	for _, v := range in.TaxIDs {
+		if v.SchemeID == ciiVATRegistrationScheme {
+			p.VATID = strings.TrimSpace(v.Value)
+		}
+	}
+	return p
+}
@@ -0,0 +1,52 @@
+package einvoice
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCII(t *testing.T) {
+	testCases := []struct {
+		name     string
+		golden   string
+		document func(t *testing.T) Document
+	}{
+		{
+			name:   "StandardAndZeroRated",
+			golden: "cii_standard.xml",
+			document: func(t *testing.T) Document {
+				d, err := NewDocument(testInvoice(), testOptions())
+				require.NoError(t, err)
+				return d
+			},
+		},
+		{
+			name:     "NotSubjectToVAT",
+			golden:   "cii_not_subject.xml",
+			document: newNotSubjectDocument,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			d := tc.document(t)
+			require.NoError(t, d.Validate())
+
+			data, err := d.CII()
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", tc.golden)
+			if *update {
+				require.NoError(t, os.WriteFile(golden, data, 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(want), string(data))
+		})
+	}
+}
@@ -0,0 +1,261 @@
+package einvoice
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"fmt"
+	"math/big"
+	"sort"
+)
+
+var (
+	oidData                 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 1}
+	oidSignedData           = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 7, 2}
+	oidContentType          = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 3}
+	oidMessageDigest        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 4}
+	oidSigningCertificateV2 = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 9, 16, 2, 47}
+	oidSHA256               = asn1.ObjectIdentifier{2, 16, 840, 1, 101, 3, 4, 2, 1}
+	oidRSAEncryption        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 1}
+	oidSHA256WithRSA        = asn1.ObjectIdentifier{1, 2, 840, 113549, 1, 1, 11}
+	oidECDSAWithSHA256      = asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}
+)
+
+// cmsContentInfo holds its content with the explicit tag [0], which
+// encoding/asn1 does not apply to raw values, so it is part of Content.
+type cmsContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+	Content     asn1.RawValue
+}
+
+type cmsSignedData struct {
+	Version          int
+	DigestAlgorithms []pkix.AlgorithmIdentifier `asn1:"set"`
+	EncapContentInfo cmsEncapsulatedContentInfo
+	Certificates     asn1.RawValue
+	SignerInfos      []cmsSignerInfo `asn1:"set"`
+}
+
+// cmsEncapsulatedContentInfo has no content, as the signatures here are
+// detached.
+type cmsEncapsulatedContentInfo struct {
+	ContentType asn1.ObjectIdentifier
+}
+
+type cmsSignerInfo struct {
+	Version            int
+	SID                cmsIssuerAndSerialNumber
+	DigestAlgorithm    pkix.AlgorithmIdentifier
+	SignedAttrs        asn1.RawValue
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          []byte
+}
+
+type cmsIssuerAndSerialNumber struct {
+	Issuer       asn1.RawValue
+	SerialNumber *big.Int
+}
+
+type cmsAttribute struct {
+	Type   asn1.ObjectIdentifier
+	Values asn1.RawValue
+}
+
+// essCertIDv2 identifies the signing certificate by its SHA-256 hash, the
+// default algorithm, which is therefore left out.
+type essCertIDv2 struct {
+	CertHash []byte
+}
+
+type signingCertificateV2 struct {
+	Certs []essCertIDv2
+}
+
+// signCMS returns a detached CAdES signature, as the CMS SignedData of RFC
+// 5652, of a document with the given SHA-256 digest. The signed attributes
+// are those of the CAdES baseline B level without a signing time, which
+// PAdES takes from the signature dictionary instead.
+func (s *Signer) signCMS(digest []byte) ([]byte, error) {
+	signatureAlgorithm, err := cmsSignatureAlgorithm(s.Certificate)
+	if err != nil {
+		return nil, err
+	}
+
+	attrs, err := marshalCMSAttributes(
+		attribute(oidContentType, oidData),
+		attribute(oidMessageDigest, digest),
+		attribute(oidSigningCertificateV2, signingCertificateV2{
+			Certs: []essCertIDv2{{CertHash: sha256Sum(s.Certificate.Raw)}},
+		}),
+	)
+	if err != nil {
+		return nil, err
+	}
+	// the signature covers the attributes with the tag of a SET, not the
+	// implicit tag they are stored with
+	signature, err := s.sign(sha256Sum(signedAttrsSet(attrs)))
+	if err != nil {
+		return nil, err
+	}
+
+	var certs []byte
+	for _, cert := range s.certificates() {
+		certs = append(certs, cert.Raw...)
+	}
+	signedData, err := asn1.Marshal(cmsSignedData{
+		Version:          1,
+		DigestAlgorithms: []pkix.AlgorithmIdentifier{{Algorithm: oidSHA256}},
+		EncapContentInfo: cmsEncapsulatedContentInfo{ContentType: oidData},
+		Certificates:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: certs},
+		SignerInfos: []cmsSignerInfo{{
+			Version: 1,
+			SID: cmsIssuerAndSerialNumber{
+				Issuer:       asn1.RawValue{FullBytes: s.Certificate.RawIssuer},
+				SerialNumber: s.Certificate.SerialNumber,
+			},
+			DigestAlgorithm:    pkix.AlgorithmIdentifier{Algorithm: oidSHA256},
+			SignedAttrs:        asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: attrs},
+			SignatureAlgorithm: signatureAlgorithm,
+			Signature:          signature,
+		}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return asn1.Marshal(cmsContentInfo{
+		ContentType: oidSignedData,
+		Content:     asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, IsCompound: true, Bytes: signedData},
+	})
+}
+
+func cmsSignatureAlgorithm(cert *x509.Certificate) (pkix.AlgorithmIdentifier, error) {
+	switch cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return pkix.AlgorithmIdentifier{Algorithm: oidSHA256WithRSA, Parameters: asn1.NullRawValue}, nil
+	case *ecdsa.PublicKey:
+		return pkix.AlgorithmIdentifier{Algorithm: oidECDSAWithSHA256}, nil
+	}
+	return pkix.AlgorithmIdentifier{}, fmt.Errorf("unsupported public key %T", cert.PublicKey)
+}
+
+type cmsAttributeValue struct {
+	oid   asn1.ObjectIdentifier
+	value any
+}
+
+func attribute(oid asn1.ObjectIdentifier, value any) cmsAttributeValue {
+	return cmsAttributeValue{oid, value}
+}
+
+// marshalCMSAttributes encodes attributes with a single value each, in the
+// order of their encodings, as DER requires for the members of a SET.
+func marshalCMSAttributes(values ...cmsAttributeValue) ([]byte, error) {
+	encoded := make([][]byte, len(values))
+	for i, v := range values {
+		value, err := asn1.Marshal(v.value)
+		if err != nil {
+			return nil, err
+		}
+		encoded[i], err = asn1.Marshal(cmsAttribute{
+			Type:   v.oid,
+			Values: asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: value},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Slice(encoded, func(i, j int) bool { return bytes.Compare(encoded[i], encoded[j]) < 0 })
+	return bytes.Join(encoded, nil), nil
+}
+
+// signedAttrsSet encodes the content of the signed attributes as a SET.
+func signedAttrsSet(attrs []byte) []byte {
+	set, _ := asn1.Marshal(asn1.RawValue{Class: asn1.ClassUniversal, Tag: asn1.TagSet, IsCompound: true, Bytes: attrs})
+	return set
+}
+
+// verifyCMS checks a detached CMS signature of a document with the given
+// SHA-256 digest and returns the signer's certificate. Only signatures with
+// signed attributes, as CAdES requires, are accepted.
+func verifyCMS(data, digest []byte) (*x509.Certificate, error) {
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, fmt.Sprintf(format, args...))
+	}
+
+	var info cmsContentInfo
+	if _, err := asn1.Unmarshal(data, &info); err != nil || !info.ContentType.Equal(oidSignedData) {
+		return nil, invalid("not a CMS signature")
+	}
+	var signedData cmsSignedData
+	if _, err := asn1.Unmarshal(info.Content.Bytes, &signedData); err != nil {
+		return nil, invalid("malformed CMS signature: %v", err)
+	}
+	if len(signedData.SignerInfos) != 1 {
+		return nil, invalid("CMS signature has %d signers", len(signedData.SignerInfos))
+	}
+	signer := signedData.SignerInfos[0]
+	if signedData.Certificates.Class != asn1.ClassContextSpecific || signedData.Certificates.Tag != 0 {
+		return nil, invalid("CMS signature has no certificates")
+	}
+	certs, err := x509.ParseCertificates(signedData.Certificates.Bytes)
+	if err != nil {
+		return nil, invalid("%v", err)
+	}
+	var cert *x509.Certificate
+	for _, c := range certs {
+		if bytes.Equal(c.RawIssuer, signer.SID.Issuer.FullBytes) && c.SerialNumber.Cmp(signer.SID.SerialNumber) == 0 {
+			cert = c
+			break
+		}
+	}
+	if cert == nil {
+		return nil, invalid("signing certificate not found")
+	}
+
+	if !signer.DigestAlgorithm.Algorithm.Equal(oidSHA256) {
+		return nil, invalid("unsupported digest algorithm %v", signer.DigestAlgorithm.Algorithm)
+	}
+	if signer.SignedAttrs.Class != asn1.ClassContextSpecific || signer.SignedAttrs.Tag != 0 {
+		return nil, invalid("CMS signature has no signed attributes")
+	}
+	var attrs []cmsAttribute
+	if _, err := asn1.UnmarshalWithParams(signedAttrsSet(signer.SignedAttrs.Bytes), &attrs, "set"); err != nil {
+		return nil, invalid("malformed signed attributes: %v", err)
+	}
+	var messageDigest []byte
+	var signingCert signingCertificateV2
+	for _, attr := range attrs {
+		switch {
+		case attr.Type.Equal(oidMessageDigest):
+			_, err = asn1.Unmarshal(attr.Values.Bytes, &messageDigest)
+		case attr.Type.Equal(oidSigningCertificateV2):
+			_, err = asn1.Unmarshal(attr.Values.Bytes, &signingCert)
+		}
+		if err != nil {
+			return nil, invalid("malformed signed attribute %v: %v", attr.Type, err)
+		}
+	}
+	if !bytes.Equal(messageDigest, digest) {
+		return nil, invalid("document does not match the signed digest")
+	}
+	if len(signingCert.Certs) > 0 && !bytes.Equal(signingCert.Certs[0].CertHash, sha256Sum(cert.Raw)) {
+		return nil, invalid("signing certificate does not match the signed certificate hash")
+	}
+
+	var algorithm x509.SignatureAlgorithm
+	switch alg := signer.SignatureAlgorithm.Algorithm; {
+	case alg.Equal(oidSHA256WithRSA) || alg.Equal(oidRSAEncryption):
+		algorithm = x509.SHA256WithRSA
+	case alg.Equal(oidECDSAWithSHA256):
+		algorithm = x509.ECDSAWithSHA256
+	default:
+		return nil, invalid("unsupported signature algorithm %v", alg)
+	}
+	if err := cert.CheckSignature(algorithm, signedAttrsSet(signer.SignedAttrs.Bytes), signer.Signature); err != nil {
+		return nil, invalid("%v", err)
+	}
+	return cert, nil
+}
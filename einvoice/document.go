@@ -0,0 +1,284 @@
+// Package einvoice renders invoices as structured e-invoices following the
+// European standard EN 16931.
+package einvoice
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/kuthumipepple/numeris-book/db"
+)
+
+// VAT category codes (UNCL5305) supported for line items.
+const (
+	StandardRate  = "S"
+	ZeroRated     = "Z"
+	NotSubjectVAT = "O"
+)
+
+// Options holds the details that e-invoices need but that are not stored with
+// an invoice.
+type Options struct {
+	SellerCountryCode string
+	SellerVATID       string
+	BuyerCountryCode  string
+	// BuyerReference is used by the buyer to route the invoice internally.
+	// It defaults to the invoice number.
+	BuyerReference string
+	// DefaultTaxCode applies to line items without a tax code.
+	DefaultTaxCode string
+}
+
+type Party struct {
+	Name        string
+	Email       string
+	Phone       string
+	Address     string
+	CountryCode string
+	VATID       string
+}
+
+// TaxCategory is a VAT category with its rate in percent. The rate is empty
+// for items that are not subject to VAT.
+type TaxCategory struct {
+	Code    string
+	Percent string
+}
+
+type Line struct {
+	ID        string
+	Name      string
+	Quantity  int64
+	UnitPrice int64
+	NetAmount int64
+	SellerID  string
+	Tax       TaxCategory
+}
+
+// Allowance is the share of the invoice discount that applies to the lines
+// of one tax category.
+type Allowance struct {
+	Amount int64
+	Tax    TaxCategory
+}
+
+type TaxSubtotal struct {
+	TaxableAmount int64
+	TaxAmount     int64
+	Tax           TaxCategory
+}
+
+// Document is an invoice in the shape of the EN 16931 semantic model, with
+// all amounts in minor units of Currency.
+type Document struct {
+	Number         string
+	IssueDate      time.Time
+	DueDate        time.Time
+	Currency       string
+	Note           string
+	BuyerReference string
+	PaymentTerms   string
+	Seller         Party
+	Buyer          Party
+	Lines          []Line
+	Allowances     []Allowance
+	TaxSubtotals   []TaxSubtotal
+
+	LineTotal         int64
+	AllowanceTotal    int64
+	TaxExclusiveTotal int64
+	TaxTotal          int64
+	TaxInclusiveTotal int64
+	PayableAmount     int64
+}
+
+// NewDocument builds the e-invoice model of an invoice. Line items are priced
+// net of VAT and the VAT of each tax category, as worked out by TaxTotal, is
+// included in the stored total amount, so the payable amount agrees with it.
+// The tax code of a line item is written as a category optionally followed by
+// a rate, e.g. "S:7.5".
+//
+// The invoice discount is split across the tax categories in proportion to
+// their line totals.
+func NewDocument(result db.InvoiceResult, opts Options) (Document, error) {
+	number := strconv.FormatInt(result.InvoiceNumber, 10)
+	buyerReference := opts.BuyerReference
+	if buyerReference == "" {
+		buyerReference = number
+	}
+
+	d := Document{
+		Number:         number,
+		IssueDate:      result.IssueDate,
+		DueDate:        result.DueDate,
+		Currency:       result.BillingCurrency,
+		Note:           result.Note,
+		BuyerReference: buyerReference,
+		PaymentTerms:   result.PaymentInfo,
+		Seller: Party{
+			Name:        result.SenderName,
+			Email:       result.SenderEmail,
+			Phone:       result.SenderPhone,
+			Address:     result.SenderAddress,
+			CountryCode: opts.SellerCountryCode,
+			VATID:       opts.SellerVATID,
+		},
+		Buyer: Party{
+			Name:        result.CustomerName,
+			Email:       result.CustomerEmail,
+			Phone:       result.CustomerPhone,
+			Address:     result.CustomerAddress,
+			CountryCode: opts.BuyerCountryCode,
+		},
+	}
+
+	var bases taxBases
+	for i, v := range result.LineItems {
+		code := v.TaxCode
+		if code == "" {
+			code = opts.DefaultTaxCode
+		}
+		tax, err := ParseTaxCode(code)
+		if err != nil {
+			return Document{}, fmt.Errorf("line item %d: %w", v.ID, err)
+		}
+
+		line := Line{
+			ID:        strconv.Itoa(i + 1),
+			Name:      v.Description,
+			Quantity:  v.Quantity,
+			UnitPrice: v.UnitPrice,
+			NetAmount: v.TotalPrice,
+			Tax:       tax,
+		}
+		if v.ProductID.Valid {
+			line.SellerID = strconv.FormatInt(v.ProductID.Int64, 10)
+		}
+		d.Lines = append(d.Lines, line)
+		bases.add(tax, v.TotalPrice)
+	}
+
+	d.LineTotal = bases.total
+	d.Allowances, d.TaxSubtotals = bases.split(result.Discount)
+	for _, v := range d.Allowances {
+		d.AllowanceTotal += v.Amount
+	}
+	for _, v := range d.TaxSubtotals {
+		d.TaxTotal += v.TaxAmount
+	}
+
+	d.TaxExclusiveTotal = d.LineTotal - d.AllowanceTotal
+	d.TaxInclusiveTotal = d.TaxExclusiveTotal + d.TaxTotal
+	d.PayableAmount = d.TaxInclusiveTotal
+	return d, nil
+}
+
+// TaxTotal works out the VAT of an invoice's line items after the invoice
+// discount, by the same rules as NewDocument. Line items without a tax code
+// fall under defaultTaxCode.
+func TaxTotal(items []db.InsertLineItemParams, discount int64, defaultTaxCode string) (int64, error) {
+	var bases taxBases
+	for i, v := range items {
+		code := v.TaxCode
+		if code == "" {
+			code = defaultTaxCode
+		}
+		tax, err := ParseTaxCode(code)
+		if err != nil {
+			return 0, fmt.Errorf("line item %d: %w", i+1, err)
+		}
+		bases.add(tax, v.TotalPrice)
+	}
+
+	_, subtotals := bases.split(discount)
+	var total int64
+	for _, v := range subtotals {
+		total += v.TaxAmount
+	}
+	return total, nil
+}
+
+// taxBases holds the net line totals per tax category, in order of first use.
+type taxBases struct {
+	categories []TaxCategory
+	amounts    map[TaxCategory]int64
+	total      int64
+}
+
+func (b *taxBases) add(tax TaxCategory, amount int64) {
+	if b.amounts == nil {
+		b.amounts = map[TaxCategory]int64{}
+	}
+	if _, ok := b.amounts[tax]; !ok {
+		b.categories = append(b.categories, tax)
+	}
+	b.amounts[tax] += amount
+	b.total += amount
+}
+
+// split splits discount across the tax categories in proportion to their line
+// totals and works out the VAT of each. The discount of the last category
+// absorbs the rounding so that the allowances add up to the discount.
+func (b *taxBases) split(discount int64) ([]Allowance, []TaxSubtotal) {
+	var allowances []Allowance
+	var subtotals []TaxSubtotal
+
+	remaining := discount
+	for i, tax := range b.categories {
+		share := remaining
+		if i < len(b.categories)-1 && b.total != 0 {
+			share = int64(math.Round(float64(discount) * float64(b.amounts[tax]) / float64(b.total)))
+		}
+		remaining -= share
+
+		if share != 0 {
+			allowances = append(allowances, Allowance{Amount: share, Tax: tax})
+		}
+
+		taxable := b.amounts[tax] - share
+		subtotal := TaxSubtotal{TaxableAmount: taxable, Tax: tax}
+		if tax.Percent != "" {
+			rate, _ := strconv.ParseFloat(tax.Percent, 64)
+			subtotal.TaxAmount = int64(math.Round(float64(taxable) * rate / 100))
+		}
+		subtotals = append(subtotals, subtotal)
+	}
+	return allowances, subtotals
+}
+
+// ParseTaxCode parses a tax code such as "S:19", "Z" or "O".
+func ParseTaxCode(code string) (TaxCategory, error) {
+	category, percent, _ := strings.Cut(strings.TrimSpace(code), ":")
+	tax := TaxCategory{Code: strings.ToUpper(strings.TrimSpace(category))}
+
+	if percent = strings.TrimSpace(percent); percent != "" {
+		rate, err := strconv.ParseFloat(percent, 64)
+		if err != nil || rate < 0 {
+			return TaxCategory{}, fmt.Errorf("invalid VAT rate in tax code %q", code)
+		}
+		tax.Percent = strconv.FormatFloat(rate, 'f', -1, 64)
+	} else if tax.Code == ZeroRated {
+		tax.Percent = "0"
+	}
+	return tax, nil
+}
+
+// TaxCode returns the tax code of the category in the form that line items
+// store, e.g. "S:7.5".
+func (t TaxCategory) TaxCode() string {
+	if t.Percent == "" || t.Code == ZeroRated {
+		return t.Code
+	}
+	return t.Code + ":" + t.Percent
+}
+
+// formatAmount renders an amount in minor units as a decimal number, e.g.
+// 123456 USD as "1234.56".
+func formatAmount(amount int64, currency string) string {
+	m := money.New(amount, currency)
+	return strconv.FormatFloat(m.AsMajorUnits(), 'f', m.Currency().Fraction, 64)
+}
@@ -0,0 +1,146 @@
+package einvoice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+	"github.com/kuthumipepple/numeris-book/db"
+	"github.com/stretchr/testify/require"
+)
+
+func testInvoice() db.InvoiceResult {
+	issueDate := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	return db.InvoiceResult{
+		Invoice: db.Invoice{
+			InvoiceNumber:   1042,
+			CustomerName:    "Acme Public Works",
+			CustomerEmail:   "ap@acme.example",
+			CustomerPhone:   "+2348000000001",
+			CustomerAddress: "12 Marina Road, Lagos",
+			SenderName:      "Numeris Studio",
+			SenderEmail:     "billing@numeris.example",
+			SenderPhone:     "+2348000000002",
+			SenderAddress:   "3 Allen Avenue, Ikeja",
+			IssueDate:       issueDate,
+			DueDate:         issueDate.AddDate(0, 0, 30),
+			Status:          "pending_payment",
+			Subtotal:        30000,
+			DiscountRate:    1000,
+			Discount:        3000,
+			TotalAmount:     28350,
+			PaymentInfo:     "Bank transfer to account 0123456789",
+			BillingCurrency: "USD",
+			Note:            "Thank you for your patronage",
+		},
+		LineItems: []db.LineItem{
+			{ID: 1, InvoiceNumber: 1042, Description: "Design work", Quantity: 2, UnitPrice: 10000, TotalPrice: 20000, ProductID: pgtype.Int8{Int64: 7, Valid: true}, TaxCode: "S:7.5"},
+			{ID: 2, InvoiceNumber: 1042, Description: "Printed report", Quantity: 1, UnitPrice: 10000, TotalPrice: 10000, TaxCode: "Z"},
+		},
+	}
+}
+
+func testOptions() Options {
+	return Options{
+		SellerCountryCode: "NG",
+		SellerVATID:       "NG12345678",
+		BuyerCountryCode:  "NG",
+		DefaultTaxCode:    "O",
+	}
+}
+
+func TestNewDocument(t *testing.T) {
+	d, err := NewDocument(testInvoice(), testOptions())
+	require.NoError(t, err)
+
+	require.Equal(t, "1042", d.Number)
+	require.Equal(t, "1042", d.BuyerReference)
+	require.Equal(t, "NG12345678", d.Seller.VATID)
+	require.Len(t, d.Lines, 2)
+	require.Equal(t, "7", d.Lines[0].SellerID)
+	require.Equal(t, TaxCategory{Code: StandardRate, Percent: "7.5"}, d.Lines[0].Tax)
+	require.Equal(t, TaxCategory{Code: ZeroRated, Percent: "0"}, d.Lines[1].Tax)
+
+	// the discount is split by category in proportion to the line totals
+	require.Equal(t, []Allowance{
+		{Amount: 2000, Tax: d.Lines[0].Tax},
+		{Amount: 1000, Tax: d.Lines[1].Tax},
+	}, d.Allowances)
+	require.Equal(t, []TaxSubtotal{
+		{TaxableAmount: 18000, TaxAmount: 1350, Tax: d.Lines[0].Tax},
+		{TaxableAmount: 9000, TaxAmount: 0, Tax: d.Lines[1].Tax},
+	}, d.TaxSubtotals)
+
+	require.Equal(t, int64(30000), d.LineTotal)
+	require.Equal(t, int64(3000), d.AllowanceTotal)
+	require.Equal(t, int64(27000), d.TaxExclusiveTotal)
+	require.Equal(t, int64(1350), d.TaxTotal)
+	require.Equal(t, int64(28350), d.TaxInclusiveTotal)
+	require.Equal(t, int64(28350), d.PayableAmount)
+}
+
+func TestTaxTotal(t *testing.T) {
+	invoice := testInvoice()
+	items := make([]db.InsertLineItemParams, len(invoice.LineItems))
+	for i, v := range invoice.LineItems {
+		items[i] = db.InsertLineItemParams{Quantity: v.Quantity, UnitPrice: v.UnitPrice, TotalPrice: v.TotalPrice, TaxCode: v.TaxCode}
+	}
+
+	tax, err := TaxTotal(items, invoice.Discount, testOptions().DefaultTaxCode)
+	require.NoError(t, err)
+	require.Equal(t, int64(1350), tax)
+
+	// the total stored with the invoice is what its e-invoice says is payable
+	d, err := NewDocument(invoice, testOptions())
+	require.NoError(t, err)
+	require.Equal(t, invoice.Subtotal-invoice.Discount+tax, invoice.TotalAmount)
+	require.Equal(t, invoice.TotalAmount, d.PayableAmount)
+
+	items[0].TaxCode = "S:abc"
+	_, err = TaxTotal(items, invoice.Discount, testOptions().DefaultTaxCode)
+	require.EqualError(t, err, `line item 1: invalid VAT rate in tax code "S:abc"`)
+}
+
+func TestNewDocumentDiscountRounding(t *testing.T) {
+	invoice := testInvoice()
+	invoice.LineItems = []db.LineItem{
+		{Description: "A", Quantity: 1, UnitPrice: 100, TotalPrice: 100, TaxCode: "S:10"},
+		{Description: "B", Quantity: 1, UnitPrice: 100, TotalPrice: 100, TaxCode: "S:20"},
+		{Description: "C", Quantity: 1, UnitPrice: 100, TotalPrice: 100, TaxCode: "Z"},
+	}
+	invoice.Discount = 100
+
+	d, err := NewDocument(invoice, testOptions())
+	require.NoError(t, err)
+	require.Len(t, d.Allowances, 3)
+	require.Equal(t, int64(33), d.Allowances[0].Amount)
+	require.Equal(t, int64(33), d.Allowances[1].Amount)
+	require.Equal(t, int64(34), d.Allowances[2].Amount)
+	require.Equal(t, int64(100), d.AllowanceTotal)
+}
+
+func TestNewDocumentDefaultTaxCode(t *testing.T) {
+	invoice := testInvoice()
+	for i := range invoice.LineItems {
+		invoice.LineItems[i].TaxCode = ""
+	}
+	opts := testOptions()
+	opts.BuyerReference = "PO-77"
+
+	d, err := NewDocument(invoice, opts)
+	require.NoError(t, err)
+	require.Equal(t, "PO-77", d.BuyerReference)
+	require.Equal(t, []TaxSubtotal{
+		{TaxableAmount: 27000, Tax: TaxCategory{Code: NotSubjectVAT}},
+	}, d.TaxSubtotals)
+	require.Equal(t, int64(0), d.TaxTotal)
+	require.Equal(t, int64(27000), d.PayableAmount)
+}
+
+func TestNewDocumentInvalidTaxCode(t *testing.T) {
+	invoice := testInvoice()
+	invoice.LineItems[0].TaxCode = "S:abc"
+
+	_, err := NewDocument(invoice, testOptions())
+	require.EqualError(t, err, `line item 1: invalid VAT rate in tax code "S:abc"`)
+}
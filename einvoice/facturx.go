@@ -0,0 +1,122 @@
+package einvoice
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"time"
+)
+
+const (
+	// FacturXFilename is the name Factur-X and ZUGFeRD readers look for.
+	FacturXFilename     = "factur-x.xml"
+	facturXNamespace    = "urn:factur-x:pdfa:CrossIndustryDocument:invoice:1p0#"
+	facturXConformance  = "EN 16931"
+	facturXRelationship = "Alternative"
+	pdfProducer         = "numeris-book"
+)
+
+// FacturX renders the document as a Factur-X (ZUGFeRD 2) invoice of the EN
+// 16931 profile: a PDF/A-3 file showing the invoice and carrying its Cross
+// Industry Invoice XML as an attachment. It does not validate the document;
+// call Validate first.
+func (d Document) FacturX() ([]byte, error) {
+	cii, err := d.CII()
+	if err != nil {
+		return nil, err
+	}
+
+	var pdf bytes.Buffer
+	if err := writeInvoicePDF(&pdf, d); err != nil {
+		return nil, err
+	}
+
+	title := "Invoice " + d.Number
+	return convertToPDFA3(pdf.Bytes(), pdfaParams{
+		Title:    title,
+		Author:   d.Seller.Name,
+		Producer: pdfProducer,
+		Date:     d.IssueDate,
+		Metadata: facturXMetadata(title, d.Seller.Name, d.IssueDate),
+		Attachment: pdfaAttachment{
+			Name:         FacturXFilename,
+			Description:  "Factur-X invoice",
+			MimeType:     "text/xml",
+			Relationship: facturXRelationship,
+			Content:      cii,
+		},
+	})
+}
+
+// facturXMetadata returns the XMP packet of a Factur-X invoice. Besides the
+// PDF/A identification and the document information, which must match the
+// information dictionary, it declares the Factur-X properties together with
+// the PDF/A extension schema that describes them.
+func facturXMetadata(title, author string, date time.Time) []byte {
+	escape := func(s string) string {
+		var b bytes.Buffer
+		xml.EscapeText(&b, []byte(s))
+		return b.String()
+	}
+	property := func(name, description string) string {
+		return fmt.Sprintf(`
+              <rdf:li rdf:parseType="Resource">
+                <pdfaProperty:name>%s</pdfaProperty:name>
+                <pdfaProperty:valueType>Text</pdfaProperty:valueType>
+                <pdfaProperty:category>external</pdfaProperty:category>
+                <pdfaProperty:description>%s</pdfaProperty:description>
+              </rdf:li>`, name, description)
+	}
+	timestamp := date.UTC().Format("2006-01-02T15:04:05Z")
+
+	return []byte(`<?xpacket begin="` + "\uFEFF" + `" id="W5M0MpCehiHzreSzNTczkc9d"?>
+<x:xmpmeta xmlns:x="adobe:ns:meta/">
+  <rdf:RDF xmlns:rdf="http://www.w3.org/1999/02/22-rdf-syntax-ns#">
+    <rdf:Description rdf:about="" xmlns:pdfaid="http://www.aiim.org/pdfa/ns/id/">
+      <pdfaid:part>3</pdfaid:part>
+      <pdfaid:conformance>B</pdfaid:conformance>
+    </rdf:Description>
+    <rdf:Description rdf:about="" xmlns:dc="http://purl.org/dc/elements/1.1/">
+      <dc:format>application/pdf</dc:format>
+      <dc:title><rdf:Alt><rdf:li xml:lang="x-default">` + escape(title) + `</rdf:li></rdf:Alt></dc:title>
+      <dc:creator><rdf:Seq><rdf:li>` + escape(author) + `</rdf:li></rdf:Seq></dc:creator>
+    </rdf:Description>
+    <rdf:Description rdf:about="" xmlns:pdf="http://ns.adobe.com/pdf/1.3/">
+      <pdf:Producer>` + pdfProducer + `</pdf:Producer>
+    </rdf:Description>
+    <rdf:Description rdf:about="" xmlns:xmp="http://ns.adobe.com/xap/1.0/">
+      <xmp:CreateDate>` + timestamp + `</xmp:CreateDate>
+      <xmp:ModifyDate>` + timestamp + `</xmp:ModifyDate>
+    </rdf:Description>
+    <rdf:Description rdf:about="" xmlns:fx="` + facturXNamespace + `">
+      <fx:DocumentType>INVOICE</fx:DocumentType>
+      <fx:DocumentFileName>` + FacturXFilename + `</fx:DocumentFileName>
+      <fx:Version>1.0</fx:Version>
+      <fx:ConformanceLevel>` + facturXConformance + `</fx:ConformanceLevel>
+    </rdf:Description>
+    <rdf:Description rdf:about=""
+        xmlns:pdfaExtension="http://www.aiim.org/pdfa/ns/extension/"
+        xmlns:pdfaSchema="http://www.aiim.org/pdfa/ns/schema#"
+        xmlns:pdfaProperty="http://www.aiim.org/pdfa/ns/property#">
+      <pdfaExtension:schemas>
+        <rdf:Bag>
+          <rdf:li rdf:parseType="Resource">
+            <pdfaSchema:schema>Factur-X PDFA Extension Schema</pdfaSchema:schema>
+            <pdfaSchema:namespaceURI>` + facturXNamespace + `</pdfaSchema:namespaceURI>
+            <pdfaSchema:prefix>fx</pdfaSchema:prefix>
+            <pdfaSchema:property>
+              <rdf:Seq>` +
+		property("DocumentFileName", "The name of the embedded XML document") +
+		property("DocumentType", "The type of the hybrid document in capital letters, e.g. INVOICE or ORDER") +
+		property("Version", "The actual version of the standard applying to the embedded XML document") +
+		property("ConformanceLevel", "The conformance level of the embedded XML document") + `
+              </rdf:Seq>
+            </pdfaSchema:property>
+          </rdf:li>
+        </rdf:Bag>
+      </pdfaExtension:schemas>
+    </rdf:Description>
+  </rdf:RDF>
+</x:xmpmeta>
+<?xpacket end="w"?>`)
+}
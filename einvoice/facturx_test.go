@@ -0,0 +1,68 @@
+package einvoice
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFacturX(t *testing.T) {
+	d, err := NewDocument(testInvoice(), testOptions())
+	require.NoError(t, err)
+
+	data, err := d.FacturX()
+	require.NoError(t, err)
+	require.True(t, bytes.HasPrefix(data, []byte(pdfaHeader)))
+
+	// every cross reference section points at the objects it lists
+	sections := regexp.MustCompile(`(?m)^startxref\n(\d+)\n%%EOF`).FindAllSubmatch(data, -1)
+	require.Len(t, sections, 2)
+	for _, section := range sections {
+		offset, err := strconv.Atoi(string(section[1]))
+		require.NoError(t, err)
+		offsets, trailer, err := parseXRef(data[offset:])
+		require.NoError(t, err)
+		require.NotEmpty(t, trailer)
+		for n, offset := range offsets {
+			require.True(t, bytes.HasPrefix(data[offset:], []byte(fmt.Sprintf("%d 0 obj", n))), "object %d", n)
+		}
+	}
+
+	// the last trailer identifies the file and points at the updated catalog
+	offset, _ := strconv.Atoi(string(sections[1][1]))
+	offsets, trailer, err := parseXRef(data[offset:])
+	require.NoError(t, err)
+	require.Contains(t, trailer, "/ID [")
+	catalog, err := objectDictionary(data, findRef(rootPattern, trailer), offsets[findRef(rootPattern, trailer)])
+	require.NoError(t, err)
+	require.Contains(t, catalog, "/Type /Catalog")
+	require.Contains(t, catalog, "/OutputIntents [")
+	require.Contains(t, catalog, "/AF [")
+	require.Contains(t, catalog, "/Names << /EmbeddedFiles << /Names [(factur-x.xml)")
+
+	cii, err := d.CII()
+	require.NoError(t, err)
+	require.Contains(t, string(data), "/Subtype /text#2Fxml")
+	require.Contains(t, string(data), "/AFRelationship /Alternative")
+	require.True(t, bytes.Contains(data, cii))
+	require.Contains(t, string(data), "<pdfaid:part>3</pdfaid:part>")
+	require.Contains(t, string(data), "<fx:ConformanceLevel>EN 16931</fx:ConformanceLevel>")
+	require.Contains(t, string(data), "/CreationDate (D:20240301000000Z)")
+}
+
+func TestSRGBProfile(t *testing.T) {
+	profile := srgbProfile()
+	require.Equal(t, len(profile), int(profile[0])<<24|int(profile[1])<<16|int(profile[2])<<8|int(profile[3]))
+	require.Equal(t, "mntrRGB XYZ ", string(profile[12:24]))
+	require.Equal(t, "acsp", string(profile[36:40]))
+}
+
+func TestPDFString(t *testing.T) {
+	require.Equal(t, `(Invoice \(draft\))`, pdfString("Invoice (draft)"))
+	require.Equal(t, "<FEFF00C9006C00E9>", pdfString("Élé"))
+	require.Equal(t, "/text#2Fxml", pdfName("text/xml"))
+}
@@ -0,0 +1,78 @@
+package einvoice
+
+import (
+	"bytes"
+	"encoding/binary"
+	"math"
+)
+
+// srgbProfile builds an ICC version 2 display profile for sRGB, using the
+// sRGB primaries adapted to D50 and a 2.2 gamma curve. PDF/A needs an output
+// intent for the device colours that fpdf writes, and a generated profile
+// saves shipping a binary ICC file.
+func srgbProfile() []byte {
+	xyz := func(x, y, z float64) []byte {
+		b := []byte("XYZ \x00\x00\x00\x00")
+		for _, v := range []float64{x, y, z} {
+			b = binary.BigEndian.AppendUint32(b, uint32(int32(math.Round(v*65536))))
+		}
+		return b
+	}
+	text := func(s string) []byte {
+		return append([]byte("text\x00\x00\x00\x00"+s), 0)
+	}
+	desc := func(s string) []byte {
+		b := []byte("desc\x00\x00\x00\x00")
+		b = binary.BigEndian.AppendUint32(b, uint32(len(s)+1))
+		b = append(b, s...)
+		b = append(b, 0)
+		// empty Unicode and ScriptCode descriptions
+		return append(b, make([]byte, 4+4+2+1+67)...)
+	}
+	// a single gamma value as u8Fixed8Number
+	curve := append([]byte("curv\x00\x00\x00\x00\x00\x00\x00\x01"), 0x02, 0x33)
+
+	tags := []struct {
+		signature string
+		data      []byte
+	}{
+		{"desc", desc("sRGB")},
+		{"cprt", text("No copyright, use freely")},
+		{"wtpt", xyz(0.9642, 1.0, 0.8249)},
+		{"rXYZ", xyz(0.4361, 0.2225, 0.0139)},
+		{"gXYZ", xyz(0.3851, 0.7169, 0.0971)},
+		{"bXYZ", xyz(0.1431, 0.0606, 0.7141)},
+		{"rTRC", curve},
+		{"gTRC", curve},
+		{"bTRC", curve},
+	}
+
+	var table, data bytes.Buffer
+	offset := 128 + 4 + 12*len(tags)
+	binary.Write(&table, binary.BigEndian, uint32(len(tags)))
+	for _, tag := range tags {
+		table.WriteString(tag.signature)
+		binary.Write(&table, binary.BigEndian, uint32(offset+data.Len()))
+		binary.Write(&table, binary.BigEndian, uint32(len(tag.data)))
+		data.Write(tag.data)
+		// tag data starts on a 4 byte boundary
+		for data.Len()%4 != 0 {
+			data.WriteByte(0)
+		}
+	}
+
+	size := 128 + table.Len() + data.Len()
+	header := make([]byte, 128)
+	binary.BigEndian.PutUint32(header[0:], uint32(size))
+	binary.BigEndian.PutUint32(header[8:], 0x02100000)
+	copy(header[12:], "mntrRGB XYZ ")
+	// creation date: 2024-01-01 00:00:00
+	binary.BigEndian.PutUint16(header[24:], 2024)
+	binary.BigEndian.PutUint16(header[26:], 1)
+	binary.BigEndian.PutUint16(header[28:], 1)
+	copy(header[36:], "acsp")
+	copy(header[68:], xyz(0.9642, 1.0, 0.8249)[8:])
+
+	profile := append(header, table.Bytes()...)
+	return append(profile, data.Bytes()...)
+}
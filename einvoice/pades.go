@@ -0,0 +1,289 @@
+package einvoice
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	prevPattern        = regexp.MustCompile(`/Prev (\d+)`)
+	pagesPattern       = regexp.MustCompile(`/Pages (\d+) 0 R`)
+	firstKidPattern    = regexp.MustCompile(`/Kids \[\s*(\d+) 0 R`)
+	idPattern          = regexp.MustCompile(`/ID\s*\[[^\]]*\]`)
+	lengthPattern      = regexp.MustCompile(`/Length (\d+)`)
+	byteRangePattern   = regexp.MustCompile(`/ByteRange\s*\[\s*(\d+)\s+(\d+)\s+(\d+)\s+(\d+)\s*\]`)
+	signingTimePattern = regexp.MustCompile(`/M\s*\(D:(\d{14})(Z|[+-]\d{2}'\d{2}'?)?`)
+	facturXSpecPattern = regexp.MustCompile(`(?s)/F \(` + regexp.QuoteMeta(FacturXFilename) + `\).*?/EF\s*<<\s*/F (\d+) 0 R`)
+)
+
+// signatureFieldName is the name of the form field that holds the signature.
+const signatureFieldName = "Signature1"
+
+// SignPDF adds a PAdES baseline B signature to a PDF, such as a Factur-X
+// invoice, made at the given time. The signature is added in an incremental
+// update, as an invisible signature field whose value holds a detached CAdES
+// signature of the whole file but the signature itself, so that the file
+// remains a valid PDF/A-3 document.
+//
+// The document must not have an interactive form yet.
+func (s *Signer) SignPDF(data []byte, at time.Time) ([]byte, error) {
+	offsets, trailer, xrefOffset, err := pdfObjects(data)
+	if err != nil {
+		return nil, err
+	}
+	size, root := findRef(sizePattern, trailer), findRef(rootPattern, trailer)
+	if size <= 0 || root <= 0 || offsets[root] == 0 {
+		return nil, errMalformedPDF
+	}
+	catalog, err := objectDictionary(data, root, offsets[root])
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(catalog, "/AcroForm") {
+		return nil, errors.New("PDF already has a form")
+	}
+	pagesObj := findRef(pagesPattern, catalog)
+	pages, err := objectDictionary(data, pagesObj, offsets[pagesObj])
+	if err != nil {
+		return nil, err
+	}
+	pageObj := findRef(firstKidPattern, pages)
+	page, err := objectDictionary(data, pageObj, offsets[pageObj])
+	if err != nil {
+		return nil, err
+	}
+	if strings.Contains(page, "/Annots") {
+		return nil, errors.New("PDF page already has annotations")
+	}
+
+	// the signature is embedded as a hexadecimal string of a fixed size, so
+	// that the byte range can be written before it is known
+	contentsSize := 8192
+	for _, cert := range s.certificates() {
+		contentsSize += len(cert.Raw)
+	}
+
+	var out bytes.Buffer
+	out.Write(data)
+	if !bytes.HasSuffix(data, []byte("\n")) {
+		out.WriteString("\n")
+	}
+
+	updated := map[int]int{}
+	sigObj, fieldObj := size, size+1
+
+	updated[sigObj] = out.Len()
+	fmt.Fprintf(&out, "%d 0 obj\n<< /Type /Sig /Filter /Adobe.PPKLite /SubFilter /ETSI.CAdES.detached /M %s /ByteRange ",
+		sigObj, pdfDate(at))
+	byteRangeOffset := out.Len()
+	fmt.Fprintf(&out, "[%010d %010d %010d %010d] /Contents ", 0, 0, 0, 0)
+	contentsStart := out.Len()
+	out.WriteString("<" + strings.Repeat("0", 2*contentsSize) + ">")
+	contentsEnd := out.Len()
+	out.WriteString(" >>\nendobj\n")
+
+	object := func(n int, dict string) {
+		updated[n] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\nendobj\n", n, dict)
+	}
+	object(fieldObj, fmt.Sprintf("<< /Type /Annot /Subtype /Widget /FT /Sig /T %s /V %d 0 R /F 132 /Rect [0 0 0 0] /P %d 0 R >>",
+		pdfString(signatureFieldName), sigObj, pageObj))
+	object(pageObj, fmt.Sprintf("%s\n/Annots [%d 0 R]\n>>", strings.TrimSpace(strings.TrimSuffix(page, ">>")), fieldObj))
+	object(root, fmt.Sprintf("%s\n/AcroForm << /Fields [%d 0 R] /SigFlags 3 >>\n>>", strings.TrimSpace(strings.TrimSuffix(catalog, ">>")), fieldObj))
+
+	updateXRefOffset := out.Len()
+	numbers := make([]int, 0, len(updated))
+	for n := range updated {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	out.WriteString("xref\n")
+	for _, n := range numbers {
+		fmt.Fprintf(&out, "%d 1\n%010d 00000 n \n", n, updated[n])
+	}
+	out.WriteString("trailer\n<< /Size " + strconv.Itoa(fieldObj+1) + " /Root " + strconv.Itoa(root) + " 0 R")
+	if info := findRef(infoPattern, trailer); info > 0 {
+		fmt.Fprintf(&out, " /Info %d 0 R", info)
+	}
+	if id := idPattern.FindString(trailer); id != "" {
+		out.WriteString(" " + id)
+	}
+	fmt.Fprintf(&out, " /Prev %d >>\nstartxref\n%d\n%%%%EOF\n", xrefOffset, updateXRefOffset)
+
+	signed := out.Bytes()
+	byteRange := fmt.Sprintf("[%010d %010d %010d %010d]", 0, contentsStart, contentsEnd, len(signed)-contentsEnd)
+	copy(signed[byteRangeOffset:], byteRange)
+
+	cms, err := s.signCMS(sha256Sum(signed[:contentsStart], signed[contentsEnd:]))
+	if err != nil {
+		return nil, err
+	}
+	if len(cms) > contentsSize {
+		return nil, fmt.Errorf("signature of %d bytes does not fit in %d bytes", len(cms), contentsSize)
+	}
+	hex.Encode(signed[contentsStart+1:], cms)
+	return signed, nil
+}
+
+// VerifyPDF checks the last signature of a PDF signed by SignPDF, which must
+// cover the whole file, and reads the Factur-X invoice it carries.
+func VerifyPDF(data []byte) (SignedDocument, error) {
+	signed := SignedDocument{Format: FormatPAdES}
+
+	offsets, _, _, err := pdfObjects(data)
+	if err != nil {
+		return signed, err
+	}
+	cii, err := facturXAttachment(data, offsets)
+	if err != nil {
+		return signed, err
+	}
+	signed.Document, err = ParseCII(cii)
+	if err != nil {
+		return signed, err
+	}
+
+	matches := byteRangePattern.FindAllSubmatchIndex(data, -1)
+	if matches == nil {
+		return signed, ErrNoSignature
+	}
+	match := matches[len(matches)-1]
+	var byteRange [4]int
+	for i := range byteRange {
+		byteRange[i], _ = strconv.Atoi(string(data[match[2+2*i]:match[3+2*i]]))
+	}
+	contentsStart, contentsEnd := byteRange[1], byteRange[2]
+	if byteRange[0] != 0 || contentsStart >= contentsEnd || contentsEnd+byteRange[3] != len(data) {
+		return signed, fmt.Errorf("%w: signature does not cover the whole document", ErrInvalidSignature)
+	}
+	if data[contentsStart] != '<' || data[contentsEnd-1] != '>' {
+		return signed, fmt.Errorf("%w: malformed signature contents", ErrInvalidSignature)
+	}
+	cms, err := hex.DecodeString(string(data[contentsStart+1 : contentsEnd-1]))
+	if err != nil {
+		return signed, fmt.Errorf("%w: malformed signature contents", ErrInvalidSignature)
+	}
+
+	// the signing time is in the signature dictionary, which starts after
+	// the last object header before the byte range
+	dictStart := bytes.LastIndex(data[:match[0]], []byte(" obj"))
+	if m := signingTimePattern.FindSubmatch(data[dictStart+1 : contentsStart]); m != nil {
+		signed.SigningTime = parsePDFDate(string(m[1]), string(m[2]))
+	}
+
+	signed.Certificate, err = verifyCMS(cms, sha256Sum(data[:contentsStart], data[contentsEnd:]))
+	if err != nil {
+		return signed, err
+	}
+	if err := checkSigningTime(signed.Certificate, signed.SigningTime); err != nil {
+		return signed, err
+	}
+	return signed, nil
+}
+
+// pdfObjects reads the cross reference tables of a PDF, following the chain
+// of incremental updates, and returns the offsets of the objects in their
+// latest revision, the last trailer and the offset of the last cross
+// reference table. Cross reference streams are not supported.
+func pdfObjects(data []byte) (map[int]int, string, int, error) {
+	match := startXRefPattern.FindSubmatch(data)
+	if match == nil {
+		return nil, "", 0, errMalformedPDF
+	}
+	xrefOffset, _ := strconv.Atoi(string(match[1]))
+
+	offsets := map[int]int{}
+	var last string
+	seen := map[int]bool{}
+	for offset := xrefOffset; offset > 0; {
+		if offset >= len(data) || seen[offset] {
+			return nil, "", 0, errMalformedPDF
+		}
+		seen[offset] = true
+		section, trailer, err := parseXRef(data[offset:])
+		if err != nil {
+			return nil, "", 0, err
+		}
+		for n, v := range section {
+			if _, ok := offsets[n]; !ok {
+				offsets[n] = v
+			}
+		}
+		if last == "" {
+			last = trailer
+		}
+		offset = findRef(prevPattern, trailer)
+	}
+	return offsets, last, xrefOffset, nil
+}
+
+// facturXAttachment returns the content of the Factur-X XML embedded in a
+// PDF.
+func facturXAttachment(data []byte, offsets map[int]int) ([]byte, error) {
+	match := facturXSpecPattern.FindSubmatch(data)
+	if match == nil {
+		return nil, fmt.Errorf("%w: PDF has no %s attachment", ErrInvalidDocument, FacturXFilename)
+	}
+	n, _ := strconv.Atoi(string(match[1]))
+	offset, ok := offsets[n]
+	prefix := fmt.Sprintf("%d 0 obj", n)
+	if !ok || offset >= len(data) || !bytes.HasPrefix(data[offset:], []byte(prefix)) {
+		return nil, errMalformedPDF
+	}
+
+	body := data[offset+len(prefix):]
+	start := bytes.Index(body, []byte("stream"))
+	if start < 0 {
+		return nil, errMalformedPDF
+	}
+	dict := string(body[:start])
+	length := findRef(lengthPattern, dict)
+	start += len("stream")
+	if bytes.HasPrefix(body[start:], []byte("\r\n")) {
+		start += 2
+	} else if bytes.HasPrefix(body[start:], []byte("\n")) {
+		start++
+	}
+	if length <= 0 || start+length > len(body) {
+		return nil, errMalformedPDF
+	}
+	stream := body[start : start+length]
+
+	if strings.Contains(dict, "/FlateDecode") {
+		r, err := zlib.NewReader(bytes.NewReader(stream))
+		if err != nil {
+			return nil, errMalformedPDF
+		}
+		defer r.Close()
+		return io.ReadAll(io.LimitReader(r, 10<<20))
+	}
+	return stream, nil
+}
+
+// parsePDFDate parses the digits and the time zone of a PDF date such as
+// D:20240301120000+01'00'. A date without a time zone is taken as UTC.
+func parsePDFDate(digits, zone string) time.Time {
+	t, err := time.Parse("20060102150405", digits)
+	if err != nil {
+		return time.Time{}
+	}
+	if len(zone) >= 6 {
+		hours, _ := strconv.Atoi(zone[1:3])
+		minutes, _ := strconv.Atoi(zone[4:6])
+		offset := time.Duration(hours)*time.Hour + time.Duration(minutes)*time.Minute
+		if zone[0] == '+' {
+			offset = -offset
+		}
+		t = t.Add(offset)
+	}
+	return t.UTC()
+}
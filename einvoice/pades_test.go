@@ -0,0 +1,163 @@
+package einvoice
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignPDF(t *testing.T) {
+	d, err := NewDocument(testInvoice(), testOptions())
+	require.NoError(t, err)
+	data, err := d.FacturX()
+	require.NoError(t, err)
+
+	for _, keyType := range []string{"rsa", "ecdsa"} {
+		t.Run(keyType, func(t *testing.T) {
+			signer := newTestSigner(t, keyType)
+			signed, err := signer.SignPDF(data, testSigningTime)
+			require.NoError(t, err)
+			require.True(t, bytes.HasPrefix(signed, data))
+
+			// every cross reference section points at the objects it lists
+			sections := regexp.MustCompile(`(?m)^startxref\n(\d+)\n%%EOF`).FindAllSubmatch(signed, -1)
+			require.Len(t, sections, 3)
+			for _, section := range sections {
+				offset, err := strconv.Atoi(string(section[1]))
+				require.NoError(t, err)
+				offsets, _, err := parseXRef(signed[offset:])
+				require.NoError(t, err)
+				for n, offset := range offsets {
+					require.True(t, bytes.HasPrefix(signed[offset:], []byte(fmt.Sprintf("%d 0 obj", n))), "object %d", n)
+				}
+			}
+
+			offsets, trailer, _, err := pdfObjects(signed)
+			require.NoError(t, err)
+			require.Contains(t, trailer, "/ID [")
+			catalog, err := objectDictionary(signed, findRef(rootPattern, trailer), offsets[findRef(rootPattern, trailer)])
+			require.NoError(t, err)
+			require.Contains(t, catalog, "/AcroForm << /Fields [")
+			require.Contains(t, catalog, "/AF [")
+			require.Contains(t, string(signed), "/SubFilter /ETSI.CAdES.detached /M (D:20240301093000Z)")
+
+			verified, err := VerifyPDF(signed)
+			require.NoError(t, err)
+			require.Equal(t, FormatPAdES, verified.Format)
+			require.True(t, verified.Certificate.Equal(signer.Certificate))
+			require.True(t, verified.SignedBy(signer))
+			require.Equal(t, testSigningTime, verified.SigningTime)
+			require.Equal(t, d, verified.Document)
+		})
+	}
+}
+
+func TestVerifyPDF(t *testing.T) {
+	d, err := NewDocument(testInvoice(), testOptions())
+	require.NoError(t, err)
+	data, err := d.FacturX()
+	require.NoError(t, err)
+	signer := newTestSigner(t, "ecdsa")
+	signed, err := signer.SignPDF(data, testSigningTime)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name        string
+		document    func(t *testing.T) []byte
+		checkResult func(t *testing.T, result SignedDocument, err error)
+	}{
+		{
+			name: "Unsigned",
+			document: func(t *testing.T) []byte {
+				return data
+			},
+			checkResult: func(t *testing.T, result SignedDocument, err error) {
+				require.ErrorIs(t, err, ErrNoSignature)
+				require.Equal(t, d, result.Document)
+			},
+		},
+		{
+			name: "ChangedAfterSigning",
+			document: func(t *testing.T) []byte {
+				changed := bytes.Clone(signed)
+				i := bytes.Index(changed, []byte("/Title (Invoice 1042)"))
+				require.Positive(t, i)
+				copy(changed[i:], "/Title (Invoice 1043)")
+				return changed
+			},
+			checkResult: func(t *testing.T, result SignedDocument, err error) {
+				require.ErrorIs(t, err, ErrInvalidSignature)
+				require.ErrorContains(t, err, "does not match the signed digest")
+				require.Equal(t, d, result.Document)
+			},
+		},
+		{
+			name: "UpdatedAfterSigning",
+			document: func(t *testing.T) []byte {
+				_, _, xrefOffset, err := pdfObjects(signed)
+				require.NoError(t, err)
+				update := fmt.Sprintf("xref\ntrailer\n<< /Size 1 /Prev %d >>\nstartxref\n%d\n%%%%EOF\n", xrefOffset, len(signed))
+				return append(bytes.Clone(signed), update...)
+			},
+			checkResult: func(t *testing.T, result SignedDocument, err error) {
+				require.ErrorIs(t, err, ErrInvalidSignature)
+				require.ErrorContains(t, err, "does not cover the whole document")
+			},
+		},
+		{
+			name: "SignedOutsideCertificateValidity",
+			document: func(t *testing.T) []byte {
+				signed, err := signer.SignPDF(data, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+				require.NoError(t, err)
+				return signed
+			},
+			checkResult: func(t *testing.T, result SignedDocument, err error) {
+				require.ErrorIs(t, err, ErrInvalidSignature)
+				require.ErrorContains(t, err, "not valid at the signing time")
+			},
+		},
+		{
+			name: "NotFacturX",
+			document: func(t *testing.T) []byte {
+				return bytes.ReplaceAll(signed, []byte("(factur-x.xml)"), []byte("(factur-y.xml)"))
+			},
+			checkResult: func(t *testing.T, result SignedDocument, err error) {
+				require.ErrorIs(t, err, ErrInvalidDocument)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := Verify(tc.document(t))
+			tc.checkResult(t, result, err)
+		})
+	}
+}
+
+func TestSignPDFAlreadySigned(t *testing.T) {
+	d, err := NewDocument(testInvoice(), testOptions())
+	require.NoError(t, err)
+	data, err := d.FacturX()
+	require.NoError(t, err)
+	signer := newTestSigner(t, "ecdsa")
+	signed, err := signer.SignPDF(data, testSigningTime)
+	require.NoError(t, err)
+
+	_, err = signer.SignPDF(signed, testSigningTime)
+	require.ErrorContains(t, err, "already has a form")
+}
+
+func TestParsePDFDate(t *testing.T) {
+	require.Equal(t, testSigningTime, parsePDFDate("20240301093000", "Z"))
+	require.Equal(t, testSigningTime, parsePDFDate("20240301093000", ""))
+	require.Equal(t, testSigningTime, parsePDFDate("20240301103000", "+01'00'"))
+	require.Equal(t, testSigningTime, parsePDFDate("20240301043000", "-05'00"))
+}
@@ -0,0 +1,134 @@
+package einvoice
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Rhymond/go-money"
+)
+
+// ErrInvalidDocument is returned when a received e-invoice is well-formed XML
+// but cannot be read into a Document, e.g. because an amount is not a number
+// or the invoice uses features that invoices here cannot represent.
+var ErrInvalidDocument = errors.New("invalid e-invoice")
+
+// documentParser collects the first error met while reading the values of a
+// received e-invoice, so that the mapping code can read every value before
+// checking for errors once.
+type documentParser struct {
+	currency string
+	err      error
+}
+
+func (p *documentParser) fail(format string, args ...any) {
+	if p.err == nil {
+		p.err = fmt.Errorf("%w: %s", ErrInvalidDocument, fmt.Sprintf(format, args...))
+	}
+}
+
+// amount parses a decimal amount in the document currency into minor units.
+// An empty amount is zero. currencyID is the currency the amount is stated in,
+// if any.
+func (p *documentParser) amount(s, currencyID string) int64 {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0
+	}
+	if currencyID != "" && currencyID != p.currency {
+		p.fail("amount %s is in %s, not the invoice currency %s", s, currencyID, p.currency)
+		return 0
+	}
+	currency := money.GetCurrency(p.currency)
+	if currency == nil {
+		p.fail("unknown currency code %q", p.currency)
+		return 0
+	}
+
+	whole, fraction, _ := strings.Cut(strings.TrimPrefix(s, "-"), ".")
+	fraction = strings.TrimRight(fraction, "0")
+	if len(fraction) > currency.Fraction {
+		p.fail("amount %s has more decimals than %s allows", s, p.currency)
+		return 0
+	}
+	digits := whole + fraction + strings.Repeat("0", currency.Fraction-len(fraction))
+	v, err := strconv.ParseUint(digits, 10, 63)
+	if err != nil || whole == "" {
+		p.fail("invalid amount %q", s)
+		return 0
+	}
+	if strings.HasPrefix(s, "-") {
+		return -int64(v)
+	}
+	return int64(v)
+}
+
+// quantity parses an invoiced quantity, which must be a whole number.
+func (p *documentParser) quantity(s string) int64 {
+	s = strings.TrimSpace(s)
+	whole, fraction, _ := strings.Cut(s, ".")
+	if strings.TrimRight(fraction, "0") != "" {
+		p.fail("fractional quantity %s is not supported", s)
+		return 0
+	}
+	v, err := strconv.ParseInt(whole, 10, 64)
+	if err != nil {
+		p.fail("invalid quantity %q", s)
+		return 0
+	}
+	return v
+}
+
+// baseQuantity checks that a price applies to a single unit, as line item
+// prices here always do.
+func (p *documentParser) baseQuantity(s string) {
+	if s = strings.TrimSpace(s); s != "" && p.quantity(s) != 1 {
+		p.fail("price base quantity %s is not supported", s)
+	}
+}
+
+func (p *documentParser) date(s, layout string) time.Time {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return time.Time{}
+	}
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		p.fail("invalid date %q", s)
+	}
+	return t
+}
+
+// tax reads a VAT category. Unknown category codes are kept so that Validate
+// reports them.
+func (p *documentParser) tax(code, percent string) TaxCategory {
+	code = strings.TrimSpace(code)
+	if percent = strings.TrimSpace(percent); percent != "" {
+		code += ":" + percent
+	}
+	tax, err := ParseTaxCode(code)
+	if err != nil {
+		p.fail("%v", err)
+	}
+	return tax
+}
+
+// unsupported reports a non-zero amount for a feature that invoices here do
+// not have, such as charges or prepaid amounts.
+func (p *documentParser) unsupported(amount int64, feature string) {
+	if amount != 0 {
+		p.fail("%s are not supported", feature)
+	}
+}
+
+func joinNonEmpty(sep string, values ...string) string {
+	var parts []string
+	for _, v := range values {
+		if v = strings.TrimSpace(v); v != "" {
+			parts = append(parts, v)
+		}
+	}
+	return strings.Join(parts, sep)
+}
@@ -0,0 +1,238 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRoundTrip(t *testing.T) {
+	testCases := []struct {
+		name     string
+		golden   string
+		parse    func([]byte) (Document, error)
+		document func(t *testing.T) Document
+	}{
+		{
+			name:   "UBLStandardAndZeroRated",
+			golden: "ubl_standard.xml",
+			parse:  ParseUBL,
+			document: func(t *testing.T) Document {
+				d, err := NewDocument(testInvoice(), testOptions())
+				require.NoError(t, err)
+				return d
+			},
+		},
+		{
+			name:     "UBLNotSubjectToVAT",
+			golden:   "ubl_not_subject.xml",
+			parse:    ParseUBL,
+			document: newNotSubjectDocument,
+		},
+		{
+			name:   "CIIStandardAndZeroRated",
+			golden: "cii_standard.xml",
+			parse:  ParseCII,
+			document: func(t *testing.T) Document {
+				d, err := NewDocument(testInvoice(), testOptions())
+				require.NoError(t, err)
+				return d
+			},
+		},
+		{
+			name:     "CIINotSubjectToVAT",
+			golden:   "cii_not_subject.xml",
+			parse:    ParseCII,
+			document: newNotSubjectDocument,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			data, err := os.ReadFile(filepath.Join("testdata", tc.golden))
+			require.NoError(t, err)
+
+			d, err := tc.parse(data)
+			require.NoError(t, err)
+			require.NoError(t, d.Validate())
+			require.Equal(t, tc.document(t), d)
+		})
+	}
+}
+
+func TestParseUBL(t *testing.T) {
+	golden, err := os.ReadFile(filepath.Join("testdata", "ubl_standard.xml"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		old, new   string
+		checkError func(t *testing.T, err error)
+		check      func(t *testing.T, d Document)
+	}{
+		{
+			name: "PostalAddressParts",
+			old:  `<cbc:StreetName>12 Marina Road, Lagos</cbc:StreetName>`,
+			new:  `<cbc:StreetName>12 Marina Road</cbc:StreetName><cbc:CityName>Lagos</cbc:CityName><cbc:PostalZone>101241</cbc:PostalZone>`,
+			check: func(t *testing.T, d Document) {
+				require.Equal(t, "12 Marina Road, Lagos, 101241", d.Buyer.Address)
+			},
+		},
+		{
+			name: "MisstatedTotal",
+			old:  `<cbc:TaxExclusiveAmount currencyID="USD">270.00</cbc:TaxExclusiveAmount>`,
+			new:  `<cbc:TaxExclusiveAmount currencyID="USD">270.01</cbc:TaxExclusiveAmount>`,
+			check: func(t *testing.T, d Document) {
+				require.Equal(t, int64(27001), d.TaxExclusiveTotal)
+
+				var validationErr *ValidationError
+				require.ErrorAs(t, d.Validate(), &validationErr)
+				require.Equal(t, "BR-CO-13", validationErr.Violations[0].Rule)
+			},
+		},
+		{
+			name:       "Malformed",
+			old:        `</Invoice>`,
+			new:        ``,
+			checkError: isSyntaxError,
+		},
+		{
+			name:       "CreditNote",
+			old:        `<cbc:InvoiceTypeCode>380</cbc:InvoiceTypeCode>`,
+			new:        `<cbc:InvoiceTypeCode>381</cbc:InvoiceTypeCode>`,
+			checkError: isInvalidDocument,
+		},
+		{
+			name:       "FractionalQuantity",
+			old:        `<cbc:InvoicedQuantity unitCode="C62">2</cbc:InvoicedQuantity>`,
+			new:        `<cbc:InvoicedQuantity unitCode="C62">2.5</cbc:InvoicedQuantity>`,
+			checkError: isInvalidDocument,
+		},
+		{
+			name:       "TooManyDecimals",
+			old:        `<cbc:PriceAmount currencyID="USD">100.00</cbc:PriceAmount>`,
+			new:        `<cbc:PriceAmount currencyID="USD">100.001</cbc:PriceAmount>`,
+			checkError: isInvalidDocument,
+		},
+		{
+			name:       "OtherCurrency",
+			old:        `<cbc:PayableAmount currencyID="USD">`,
+			new:        `<cbc:PayableAmount currencyID="EUR">`,
+			checkError: isInvalidDocument,
+		},
+		{
+			name:       "PrepaidAmount",
+			old:        `<cbc:PayableAmount`,
+			new:        `<cbc:PrepaidAmount currencyID="USD">10.00</cbc:PrepaidAmount><cbc:PayableAmount`,
+			checkError: isInvalidDocument,
+		},
+		{
+			name:       "Charge",
+			old:        `<cbc:ChargeIndicator>false</cbc:ChargeIndicator>`,
+			new:        `<cbc:ChargeIndicator>true</cbc:ChargeIndicator>`,
+			checkError: isInvalidDocument,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			data := strings.Replace(string(golden), tc.old, tc.new, 1)
+			require.NotEqual(t, string(golden), data)
+
+			d, err := ParseUBL([]byte(data))
+			if tc.checkError != nil {
+				tc.checkError(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tc.check(t, d)
+		})
+	}
+}
+
+func TestParseCII(t *testing.T) {
+	golden, err := os.ReadFile(filepath.Join("testdata", "cii_standard.xml"))
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name       string
+		old, new   string
+		checkError func(t *testing.T, err error)
+		check      func(t *testing.T, d Document)
+	}{
+		{
+			name: "MisstatedVAT",
+			old:  `<ram:CalculatedAmount>13.50</ram:CalculatedAmount>`,
+			new:  `<ram:CalculatedAmount>15.00</ram:CalculatedAmount>`,
+			check: func(t *testing.T, d Document) {
+				var validationErr *ValidationError
+				require.ErrorAs(t, d.Validate(), &validationErr)
+				rules := make([]string, len(validationErr.Violations))
+				for i, v := range validationErr.Violations {
+					rules[i] = v.Rule
+				}
+				require.ElementsMatch(t, []string{"BR-CO-14", "BR-S-09"}, rules)
+			},
+		},
+		{
+			name:       "NotAnInvoice",
+			old:        `rsm:CrossIndustryInvoice xmlns:rsm="urn:un:unece:uncefact:data:standard:CrossIndustryInvoice:100"`,
+			new:        `rsm:CrossIndustryInvoice xmlns:rsm="urn:example"`,
+			checkError: isInvalidDocument,
+		},
+		{
+			name:       "DateFormat",
+			old:        `<udt:DateTimeString format="102">20240301</udt:DateTimeString>`,
+			new:        `<udt:DateTimeString format="203">202403011200</udt:DateTimeString>`,
+			checkError: isInvalidDocument,
+		},
+		{
+			name:       "BasisQuantity",
+			old:        `<ram:ChargeAmount>100.00</ram:ChargeAmount>`,
+			new:        `<ram:ChargeAmount>100.00</ram:ChargeAmount><ram:BasisQuantity unitCode="C62">10</ram:BasisQuantity>`,
+			checkError: isInvalidDocument,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			data := strings.Replace(string(golden), tc.old, tc.new, 1)
+			require.NotEqual(t, string(golden), data)
+
+			d, err := ParseCII([]byte(data))
+			if tc.checkError != nil {
+				tc.checkError(t, err)
+				return
+			}
+			require.NoError(t, err)
+			tc.check(t, d)
+		})
+	}
+}
+
+func isSyntaxError(t *testing.T, err error) {
+	var syntaxErr *xml.SyntaxError
+	require.ErrorAs(t, err, &syntaxErr)
+}
+
+func isInvalidDocument(t *testing.T, err error) {
+	require.ErrorIs(t, err, ErrInvalidDocument)
+}
+
+func TestTaxCode(t *testing.T) {
+	for _, code := range []string{"S:7.5", "Z", "O"} {
+		tax, err := ParseTaxCode(code)
+		require.NoError(t, err)
+		require.Equal(t, code, tax.TaxCode())
+	}
+}
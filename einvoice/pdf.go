@@ -0,0 +1,114 @@
+package einvoice
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/Rhymond/go-money"
+	"github.com/go-pdf/fpdf"
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// PDF/A requires every font to be embedded, so the human-readable invoice is
+// set in the Go fonts rather than the PDF core fonts.
+const pdfFont = "Go"
+
+// writeInvoicePDF renders the human-readable invoice. It shows the same
+// figures as the structured invoice, including the VAT breakdown.
+func writeInvoicePDF(w io.Writer, d Document) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.AddUTF8FontFromBytes(pdfFont, "", goregular.TTF)
+	pdf.AddUTF8FontFromBytes(pdfFont, "B", gobold.TTF)
+	amount := func(v int64) string {
+		return money.New(v, d.Currency).Display()
+	}
+
+	pdf.AddPage()
+	pdf.SetFont(pdfFont, "B", 16)
+	pdf.CellFormat(0, 10, "Invoice "+d.Number, "", 1, "L", false, 0, "")
+
+	pdf.SetFont(pdfFont, "", 10)
+	pdf.CellFormat(0, 6, "Issue date: "+d.IssueDate.Format(time.DateOnly), "", 1, "L", false, 0, "")
+	if !d.DueDate.IsZero() {
+		pdf.CellFormat(0, 6, "Due date: "+d.DueDate.Format(time.DateOnly), "", 1, "L", false, 0, "")
+	}
+	pdf.CellFormat(0, 6, "Buyer reference: "+d.BuyerReference, "", 1, "L", false, 0, "")
+	pdf.Ln(4)
+
+	top := pdf.GetY()
+	party := func(x float64, title string, p Party) {
+		pdf.SetXY(x, top)
+		pdf.SetFont(pdfFont, "B", 10)
+		pdf.CellFormat(90, 6, title, "", 2, "L", false, 0, "")
+		pdf.SetFont(pdfFont, "", 10)
+		for _, v := range []string{p.Name, p.Address, p.CountryCode, p.Email, p.Phone} {
+			if v != "" {
+				pdf.MultiCell(90, 5, v, "", "L", false)
+				pdf.SetX(x)
+			}
+		}
+		if p.VATID != "" {
+			pdf.CellFormat(90, 5, "VAT ID: "+p.VATID, "", 2, "L", false, 0, "")
+		}
+	}
+	party(10, "From", d.Seller)
+	sellerBottom := pdf.GetY()
+	party(110, "Bill to", d.Buyer)
+	pdf.SetXY(10, max(sellerBottom, pdf.GetY()))
+	pdf.Ln(6)
+
+	widths := []float64{10, 80, 20, 30, 20, 30}
+	row := func(cells ...string) {
+		for i, v := range cells {
+			align := "R"
+			if i == 1 {
+				align = "L"
+			}
+			pdf.CellFormat(widths[i], 7, v, "B", 0, align, false, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	pdf.SetFont(pdfFont, "B", 10)
+	row("#", "Description", "Quantity", "Unit price", "VAT", "Amount")
+	pdf.SetFont(pdfFont, "", 10)
+	for _, v := range d.Lines {
+		row(v.ID, v.Name, strconv.FormatInt(v.Quantity, 10), amount(v.UnitPrice), taxLabel(v.Tax), amount(v.NetAmount))
+	}
+	pdf.Ln(4)
+
+	total := func(label, value string) {
+		pdf.CellFormat(140, 6, label, "", 0, "R", false, 0, "")
+		pdf.CellFormat(50, 6, value, "", 1, "R", false, 0, "")
+	}
+	total("Line total", amount(d.LineTotal))
+	for _, v := range d.Allowances {
+		total("Discount ("+taxLabel(v.Tax)+")", "-"+amount(v.Amount))
+	}
+	total("Total without VAT", amount(d.TaxExclusiveTotal))
+	for _, v := range d.TaxSubtotals {
+		total("VAT "+taxLabel(v.Tax)+" on "+amount(v.TaxableAmount), amount(v.TaxAmount))
+	}
+	pdf.SetFont(pdfFont, "B", 10)
+	total("Amount due", amount(d.PayableAmount))
+	pdf.SetFont(pdfFont, "", 10)
+	pdf.Ln(6)
+
+	if d.PaymentTerms != "" {
+		pdf.MultiCell(0, 5, "Payment: "+d.PaymentTerms, "", "L", false)
+	}
+	if d.Note != "" {
+		pdf.MultiCell(0, 5, d.Note, "", "L", false)
+	}
+
+	return pdf.Output(w)
+}
+
+func taxLabel(tax TaxCategory) string {
+	if tax.Code == NotSubjectVAT {
+		return "n/a"
+	}
+	return tax.Percent + "%"
+}
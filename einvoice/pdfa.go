@@ -0,0 +1,271 @@
+package einvoice
+
+import (
+	"bytes"
+	"crypto/md5"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf16"
+)
+
+// pdfaAttachment is a file embedded in a PDF/A-3 document and associated
+// with it through the catalog's AF array.
+type pdfaAttachment struct {
+	Name         string
+	Description  string
+	MimeType     string
+	Relationship string
+	Content      []byte
+}
+
+type pdfaParams struct {
+	Title      string
+	Author     string
+	Producer   string
+	Date       time.Time
+	Metadata   []byte
+	Attachment pdfaAttachment
+}
+
+var (
+	errMalformedPDF = errors.New("malformed PDF")
+
+	startXRefPattern = regexp.MustCompile(`startxref\s+(\d+)\s+%%EOF\s*$`)
+	sizePattern      = regexp.MustCompile(`/Size (\d+)`)
+	rootPattern      = regexp.MustCompile(`/Root (\d+) 0 R`)
+	infoPattern      = regexp.MustCompile(`/Info (\d+) 0 R`)
+
+	emptyNamesPattern = regexp.MustCompile(`/Names <<\s*/EmbeddedFiles << /Names \[\s*\] >>\s*>>`)
+)
+
+// pdfaHeader marks the file as binary with a comment of four bytes above 127,
+// as PDF/A requires.
+const pdfaHeader = "%PDF-1.7\n%\xe2\xe3\xcf\xd3\n"
+
+// convertToPDFA3 turns a PDF written by fpdf into a PDF/A-3b file. fpdf
+// cannot write the output intent, XMP metadata and associated files that
+// PDF/A-3 needs, so they are added in an incremental update that replaces the
+// document catalog and information dictionary. The original header is
+// replaced too, which shifts every object and is corrected in the cross
+// reference table.
+//
+// The document itself must only use embedded fonts.
+func convertToPDFA3(data []byte, params pdfaParams) ([]byte, error) {
+	headerEnd := bytes.IndexByte(data, '\n')
+	if !bytes.HasPrefix(data, []byte("%PDF-1.")) || headerEnd < 0 {
+		return nil, errMalformedPDF
+	}
+	shift := len(pdfaHeader) - (headerEnd + 1)
+
+	match := startXRefPattern.FindSubmatch(data)
+	if match == nil {
+		return nil, errMalformedPDF
+	}
+	xrefOffset, _ := strconv.Atoi(string(match[1]))
+	if xrefOffset <= headerEnd || xrefOffset >= len(data) {
+		return nil, errMalformedPDF
+	}
+
+	offsets, trailer, err := parseXRef(data[xrefOffset:])
+	if err != nil {
+		return nil, err
+	}
+	size, root, info := findRef(sizePattern, trailer), findRef(rootPattern, trailer), findRef(infoPattern, trailer)
+	if size <= 0 || root <= 0 || info <= 0 || offsets[root] == 0 {
+		return nil, errMalformedPDF
+	}
+	catalog, err := objectDictionary(data, root, offsets[root])
+	if err != nil {
+		return nil, err
+	}
+	// fpdf always writes a name dictionary, which the update replaces to list
+	// the attachment
+	catalog = emptyNamesPattern.ReplaceAllString(catalog, "")
+	if strings.Contains(catalog, "/Names") {
+		return nil, errors.New("PDF already has named objects")
+	}
+
+	var out bytes.Buffer
+	out.WriteString(pdfaHeader)
+	out.Write(data[headerEnd+1 : xrefOffset])
+
+	// the original cross reference table, shifted past the new header
+	newXRefOffset := out.Len()
+	fmt.Fprintf(&out, "xref\n0 %d\n0000000000 65535 f \n", size)
+	for n := 1; n < size; n++ {
+		if offset, ok := offsets[n]; ok {
+			fmt.Fprintf(&out, "%010d 00000 n \n", offset+shift)
+		} else {
+			out.WriteString("0000000000 65535 f \n")
+		}
+	}
+	fmt.Fprintf(&out, "trailer\n%s\nstartxref\n%d\n%%%%EOF\n", trailer, newXRefOffset)
+
+	// the incremental update
+	updated := map[int]int{}
+	object := func(n int, dict string, stream []byte) {
+		updated[n] = out.Len()
+		fmt.Fprintf(&out, "%d 0 obj\n%s\n", n, dict)
+		if stream != nil {
+			out.WriteString("stream\n")
+			out.Write(stream)
+			out.WriteString("\nendstream\n")
+		}
+		out.WriteString("endobj\n")
+	}
+
+	attachment := params.Attachment
+	fileObj, specObj, profileObj, intentObj, metadataObj := size, size+1, size+2, size+3, size+4
+	date := pdfDate(params.Date)
+
+	object(fileObj, fmt.Sprintf("<< /Type /EmbeddedFile /Subtype %s /Params << /Size %d /ModDate %s >> /Length %d >>",
+		pdfName(attachment.MimeType), len(attachment.Content), date, len(attachment.Content)), attachment.Content)
+	object(specObj, fmt.Sprintf("<< /Type /Filespec /F %s /UF %s /Desc %s /AFRelationship /%s /EF << /F %d 0 R /UF %d 0 R >> >>",
+		pdfString(attachment.Name), pdfString(attachment.Name), pdfString(attachment.Description),
+		attachment.Relationship, fileObj, fileObj), nil)
+
+	profile := srgbProfile()
+	object(profileObj, fmt.Sprintf("<< /N 3 /Length %d >>", len(profile)), profile)
+	object(intentObj, fmt.Sprintf("<< /Type /OutputIntent /S /GTS_PDFA1 /OutputConditionIdentifier (sRGB) /Info (sRGB IEC61966-2.1) /DestOutputProfile %d 0 R >>",
+		profileObj), nil)
+	object(metadataObj, fmt.Sprintf("<< /Type /Metadata /Subtype /XML /Length %d >>", len(params.Metadata)), params.Metadata)
+
+	object(info, fmt.Sprintf("<< /Title %s /Author %s /Producer %s /CreationDate %s /ModDate %s >>",
+		pdfString(params.Title), pdfString(params.Author), pdfString(params.Producer), date, date), nil)
+	object(root, fmt.Sprintf("%s\n/Metadata %d 0 R\n/OutputIntents [%d 0 R]\n/AF [%d 0 R]\n/Names << /EmbeddedFiles << /Names [%s %d 0 R] >> >>\n>>",
+		strings.TrimSpace(strings.TrimSuffix(catalog, ">>")), metadataObj, intentObj, specObj, pdfString(attachment.Name), specObj), nil)
+
+	updateXRefOffset := out.Len()
+	numbers := make([]int, 0, len(updated))
+	for n := range updated {
+		numbers = append(numbers, n)
+	}
+	sort.Ints(numbers)
+	out.WriteString("xref\n")
+	for _, n := range numbers {
+		fmt.Fprintf(&out, "%d 1\n%010d 00000 n \n", n, updated[n])
+	}
+
+	id := md5.Sum(append([]byte(params.Title), attachment.Content...))
+	fmt.Fprintf(&out, "trailer\n<< /Size %d /Root %d 0 R /Info %d 0 R /Prev %d /ID [<%x> <%x>] >>\nstartxref\n%d\n%%%%EOF\n",
+		metadataObj+1, root, info, newXRefOffset, id, id, updateXRefOffset)
+
+	return out.Bytes(), nil
+}
+
+// parseXRef reads a cross reference table and the trailer dictionary that
+// follows it. It returns the offsets of the objects in use by object number.
+func parseXRef(data []byte) (map[int]int, string, error) {
+	lines := strings.Split(string(data), "\n")
+	if len(lines) < 2 || strings.TrimSpace(lines[0]) != "xref" {
+		return nil, "", errMalformedPDF
+	}
+
+	offsets := map[int]int{}
+	i := 1
+	for i < len(lines) && !strings.HasPrefix(lines[i], "trailer") {
+		var start, count int
+		if _, err := fmt.Sscanf(lines[i], "%d %d", &start, &count); err != nil {
+			return nil, "", errMalformedPDF
+		}
+		i++
+		for n := start; n < start+count; n++ {
+			if i >= len(lines) {
+				return nil, "", errMalformedPDF
+			}
+			fields := strings.Fields(lines[i])
+			i++
+			if len(fields) != 3 {
+				return nil, "", errMalformedPDF
+			}
+			if fields[2] == "n" {
+				offset, err := strconv.Atoi(fields[0])
+				if err != nil {
+					return nil, "", errMalformedPDF
+				}
+				offsets[n] = offset
+			}
+		}
+	}
+	if i >= len(lines) {
+		return nil, "", errMalformedPDF
+	}
+
+	rest := strings.Join(lines[i:], "\n")
+	// the trailer of an updated revision is followed by the update
+	if n := strings.Index(rest, "startxref"); n >= 0 {
+		rest = rest[:n]
+	}
+	start, end := strings.Index(rest, "<<"), strings.LastIndex(rest, ">>")
+	if start < 0 || end < start {
+		return nil, "", errMalformedPDF
+	}
+	return offsets, rest[start : end+2], nil
+}
+
+// objectDictionary returns the dictionary of an object without a stream.
+func objectDictionary(data []byte, n, offset int) (string, error) {
+	prefix := fmt.Sprintf("%d 0 obj", n)
+	if offset >= len(data) || !bytes.HasPrefix(data[offset:], []byte(prefix)) {
+		return "", errMalformedPDF
+	}
+	body := data[offset+len(prefix):]
+	end := bytes.Index(body, []byte("endobj"))
+	if end < 0 {
+		return "", errMalformedPDF
+	}
+	dict := strings.TrimSpace(string(body[:end]))
+	if !strings.HasPrefix(dict, "<<") || !strings.HasSuffix(dict, ">>") {
+		return "", errMalformedPDF
+	}
+	return dict, nil
+}
+
+func findRef(pattern *regexp.Regexp, s string) int {
+	match := pattern.FindStringSubmatch(s)
+	if match == nil {
+		return 0
+	}
+	n, _ := strconv.Atoi(match[1])
+	return n
+}
+
+// pdfString encodes a PDF text string, as UTF-16 when it is not plain ASCII.
+func pdfString(s string) string {
+	for _, r := range s {
+		if r > 126 || r < 32 {
+			var b strings.Builder
+			b.WriteString("<FEFF")
+			for _, v := range utf16.Encode([]rune(s)) {
+				fmt.Fprintf(&b, "%04X", v)
+			}
+			b.WriteString(">")
+			return b.String()
+		}
+	}
+	return "(" + strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(s) + ")"
+}
+
+// pdfName encodes a PDF name, escaping the characters that names cannot
+// contain, e.g. "text/xml" as /text#2Fxml.
+func pdfName(s string) string {
+	var b strings.Builder
+	b.WriteString("/")
+	for _, c := range []byte(s) {
+		if c < '!' || c > '~' || strings.IndexByte("#/()<>[]{}%", c) >= 0 {
+			fmt.Fprintf(&b, "#%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+func pdfDate(t time.Time) string {
+	return "(D:" + t.UTC().Format("20060102150405") + "Z)"
+}
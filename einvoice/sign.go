@@ -0,0 +1,198 @@
+package einvoice
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"time"
+)
+
+// Formats of signed documents.
+const (
+	FormatPAdES = "pades"
+	FormatXAdES = "xades"
+)
+
+var (
+	// ErrNoSignature is returned when a document to verify is not signed.
+	ErrNoSignature = errors.New("document is not signed")
+	// ErrInvalidSignature is returned when the signature of a document does
+	// not verify, e.g. because the document was changed after it was signed.
+	ErrInvalidSignature = errors.New("invalid signature")
+)
+
+// Signer signs rendered documents with a certificate and its private key.
+type Signer struct {
+	Key         crypto.Signer
+	Certificate *x509.Certificate
+	// Chain holds the intermediate certificates, which are embedded in the
+	// signatures so that a verifier can build the path to a trusted root.
+	Chain []*x509.Certificate
+}
+
+// NewSigner reads a signer from PEM data: the certificates, the signer's own
+// first, and its RSA or ECDSA P-256 private key in PKCS #8, PKCS #1 or SEC 1
+// form.
+func NewSigner(certPEM, keyPEM []byte) (*Signer, error) {
+	var certs []*x509.Certificate
+	for block, rest := pem.Decode(certPEM); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	if len(certs) == 0 {
+		return nil, errors.New("no certificate found")
+	}
+
+	block, _ := pem.Decode(keyPEM)
+	if block == nil {
+		return nil, errors.New("no PEM block found in key")
+	}
+	var key any
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(block.Bytes)
+	default:
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+	case *ecdsa.PrivateKey:
+		if k.Curve != elliptic.P256() {
+			return nil, errors.New("only ECDSA keys on P-256 are supported")
+		}
+	default:
+		return nil, fmt.Errorf("key is a %T, not an RSA or ECDSA key", key)
+	}
+	signer := key.(crypto.Signer)
+	if !publicKeysEqual(signer.Public(), certs[0].PublicKey) {
+		return nil, errors.New("key does not match the certificate")
+	}
+
+	return &Signer{Key: signer, Certificate: certs[0], Chain: certs[1:]}, nil
+}
+
+// LoadSigner reads a signer from a certificate file and a key file in PEM
+// form, as NewSigner describes.
+func LoadSigner(certFile, keyFile string) (*Signer, error) {
+	certPEM, err := os.ReadFile(certFile)
+	if err != nil {
+		return nil, err
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, err
+	}
+	return NewSigner(certPEM, keyPEM)
+}
+
+// sign signs the SHA-256 digest of a document. ECDSA signatures are ASN.1
+// encoded, as CMS has them.
+func (s *Signer) sign(digest []byte) ([]byte, error) {
+	return s.Key.Sign(rand.Reader, digest, crypto.SHA256)
+}
+
+func (s *Signer) certificates() []*x509.Certificate {
+	return append([]*x509.Certificate{s.Certificate}, s.Chain...)
+}
+
+// SignedDocument is a verified document together with its signature.
+type SignedDocument struct {
+	Format      string
+	Certificate *x509.Certificate
+	SigningTime time.Time
+	// Document is the invoice that the signature covers.
+	Document Document
+}
+
+// SignedBy reports whether the document was signed with the key of s. The
+// keys are compared rather than the certificates, so that documents signed
+// before a certificate was renewed are recognized.
+func (d SignedDocument) SignedBy(s *Signer) bool {
+	return d.Certificate != nil && publicKeysEqual(d.Certificate.PublicKey, s.Certificate.PublicKey)
+}
+
+func publicKeysEqual(a, b crypto.PublicKey) bool {
+	key, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && key.Equal(b)
+}
+
+// Verify checks the signature of a document signed by SignPDF or SignXML,
+// telling them apart by their content. The document is read before the
+// signature is checked, so that when the error is ErrNoSignature or
+// ErrInvalidSignature the returned SignedDocument still holds the invoice.
+func Verify(data []byte) (SignedDocument, error) {
+	if bytes.HasPrefix(data, []byte("%PDF-")) {
+		return VerifyPDF(data)
+	}
+	return VerifyXML(data)
+}
+
+// checkSigningTime checks that the certificate was valid when the document
+// was signed.
+func checkSigningTime(cert *x509.Certificate, at time.Time) error {
+	if at.Before(cert.NotBefore) || at.After(cert.NotAfter) {
+		return fmt.Errorf("%w: certificate was not valid at the signing time", ErrInvalidSignature)
+	}
+	return nil
+}
+
+// Differences returns the parts of the invoice in which d and other differ,
+// e.g. to check a received document against the invoice it claims to be.
+func (d Document) Differences(other Document) []string {
+	var diffs []string
+	check := func(part string, equal bool) {
+		if !equal {
+			diffs = append(diffs, part)
+		}
+	}
+	check("number", d.Number == other.Number)
+	check("issue date", formatDate(d.IssueDate) == formatDate(other.IssueDate))
+	check("due date", formatDate(d.DueDate) == formatDate(other.DueDate))
+	check("currency", d.Currency == other.Currency)
+	check("note", d.Note == other.Note)
+	check("buyer reference", d.BuyerReference == other.BuyerReference)
+	check("payment terms", d.PaymentTerms == other.PaymentTerms)
+	check("seller", d.Seller == other.Seller)
+	check("buyer", d.Buyer == other.Buyer)
+	check("lines", slices.Equal(d.Lines, other.Lines))
+	check("allowances", slices.Equal(d.Allowances, other.Allowances))
+	check("tax subtotals", slices.Equal(d.TaxSubtotals, other.TaxSubtotals))
+	check("totals", d.LineTotal == other.LineTotal &&
+		d.AllowanceTotal == other.AllowanceTotal &&
+		d.TaxExclusiveTotal == other.TaxExclusiveTotal &&
+		d.TaxTotal == other.TaxTotal &&
+		d.TaxInclusiveTotal == other.TaxInclusiveTotal &&
+		d.PayableAmount == other.PayableAmount)
+	return diffs
+}
+
+func sha256Sum(data ...[]byte) []byte {
+	h := sha256.New()
+	for _, v := range data {
+		h.Write(v)
+	}
+	return h.Sum(nil)
+}
@@ -0,0 +1,171 @@
+package einvoice
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testSigningTime is within the validity of the certificates of test signers.
+var testSigningTime = time.Date(2024, 3, 1, 9, 30, 0, 0, time.UTC)
+
+func newTestKey(t *testing.T, keyType string) crypto.Signer {
+	var key crypto.Signer
+	var err error
+	switch keyType {
+	case "rsa":
+		key, err = rsa.GenerateKey(rand.Reader, 2048)
+	case "ecdsa":
+		key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	case "ed25519":
+		_, key, err = ed25519.GenerateKey(rand.Reader)
+	}
+	require.NoError(t, err)
+	return key
+}
+
+// newTestCertificate returns a self-signed certificate for key, valid during
+// 2024.
+func newTestCertificate(t *testing.T, key crypto.Signer) *x509.Certificate {
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "Numeris Studio", Organization: []string{"Numeris Studio"}},
+		NotBefore:    time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+		NotAfter:     time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageContentCommitment,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, key.Public(), key)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert
+}
+
+func newTestSigner(t *testing.T, keyType string) *Signer {
+	key := newTestKey(t, keyType)
+	return &Signer{Key: key, Certificate: newTestCertificate(t, key)}
+}
+
+func TestNewSigner(t *testing.T) {
+	encodeCert := func(cert *x509.Certificate) []byte {
+		return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+	}
+	pkcs8 := func(key crypto.Signer) []byte {
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		require.NoError(t, err)
+		return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})
+	}
+
+	rsaKey := newTestKey(t, "rsa")
+	rsaCert := newTestCertificate(t, rsaKey)
+	ecKey := newTestKey(t, "ecdsa")
+	ecCert := newTestCertificate(t, ecKey)
+	ecDER, err := x509.MarshalECPrivateKey(ecKey.(*ecdsa.PrivateKey))
+	require.NoError(t, err)
+	edKey := newTestKey(t, "ed25519")
+
+	testCases := []struct {
+		name            string
+		certPEM, keyPEM []byte
+		checkSigner     func(t *testing.T, signer *Signer, err error)
+	}{
+		{
+			name:    "RSAPKCS1WithChain",
+			certPEM: append(encodeCert(rsaCert), encodeCert(ecCert)...),
+			keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(rsaKey.(*rsa.PrivateKey))}),
+			checkSigner: func(t *testing.T, signer *Signer, err error) {
+				require.NoError(t, err)
+				require.True(t, signer.Certificate.Equal(rsaCert))
+				require.Len(t, signer.Chain, 1)
+				require.True(t, signer.Chain[0].Equal(ecCert))
+			},
+		},
+		{
+			name:    "ECDSASEC1",
+			certPEM: encodeCert(ecCert),
+			keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: ecDER}),
+			checkSigner: func(t *testing.T, signer *Signer, err error) {
+				require.NoError(t, err)
+				require.True(t, signer.Certificate.Equal(ecCert))
+				require.Empty(t, signer.Chain)
+			},
+		},
+		{
+			name:    "ECDSAPKCS8",
+			certPEM: encodeCert(ecCert),
+			keyPEM:  pkcs8(ecKey),
+			checkSigner: func(t *testing.T, signer *Signer, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:    "KeyDoesNotMatch",
+			certPEM: encodeCert(rsaCert),
+			keyPEM:  pkcs8(ecKey),
+			checkSigner: func(t *testing.T, signer *Signer, err error) {
+				require.ErrorContains(t, err, "does not match")
+			},
+		},
+		{
+			name:    "UnsupportedKey",
+			certPEM: encodeCert(rsaCert),
+			keyPEM:  pkcs8(edKey),
+			checkSigner: func(t *testing.T, signer *Signer, err error) {
+				require.ErrorContains(t, err, "not an RSA or ECDSA key")
+			},
+		},
+		{
+			name:    "NoCertificate",
+			certPEM: pkcs8(rsaKey),
+			keyPEM:  pkcs8(rsaKey),
+			checkSigner: func(t *testing.T, signer *Signer, err error) {
+				require.ErrorContains(t, err, "no certificate")
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			signer, err := NewSigner(tc.certPEM, tc.keyPEM)
+			tc.checkSigner(t, signer, err)
+		})
+	}
+}
+
+func TestSignedBy(t *testing.T) {
+	signer := newTestSigner(t, "ecdsa")
+	renewed := &Signer{Key: signer.Key, Certificate: newTestCertificate(t, signer.Key)}
+
+	signed := SignedDocument{Certificate: signer.Certificate}
+	require.True(t, signed.SignedBy(signer))
+	require.True(t, signed.SignedBy(renewed))
+	require.False(t, signed.SignedBy(newTestSigner(t, "ecdsa")))
+	require.False(t, SignedDocument{}.SignedBy(signer))
+}
+
+func TestDocumentDifferences(t *testing.T) {
+	d, err := NewDocument(testInvoice(), testOptions())
+	require.NoError(t, err)
+	require.Empty(t, d.Differences(d))
+
+	changed := testInvoice()
+	changed.LineItems[1].UnitPrice = 5000
+	changed.LineItems[1].TotalPrice = 5000
+	changed.DueDate = changed.DueDate.AddDate(0, 0, 1)
+	other, err := NewDocument(changed, testOptions())
+	require.NoError(t, err)
+	require.Equal(t, []string{"due date", "lines", "allowances", "tax subtotals", "totals"}, d.Differences(other))
+}
@@ -0,0 +1,294 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"strconv"
+	"time"
+)
+
+const (
+	ublInvoiceNamespace    = "urn:oasis:names:specification:ubl:schema:xsd:Invoice-2"
+	ublAggregateNamespace  = "urn:oasis:names:specification:ubl:schema:xsd:CommonAggregateComponents-2"
+	ublBasicNamespace      = "urn:oasis:names:specification:ubl:schema:xsd:CommonBasicComponents-2"
+	peppolCustomizationID  = "urn:cen.eu:en16931:2017#compliant#urn:fdc:peppol.eu:2017:poacc:billing:3.0"
+	peppolProfileID        = "urn:fdc:peppol.eu:2017:poacc:billing:01:1.0"
+	commercialInvoiceCode  = "380"
+	unspecifiedPaymentCode = "1"
+	// C62 is the UN/ECE recommendation 20 code for "one", used for items
+	// counted in units.
+	unitCode = "C62"
+	// Electronic addresses are email addresses (EAS code EM).
+	emailSchemeID = "EM"
+)
+
+// The element order of these types follows the UBL 2.1 Invoice schema, which
+// requires child elements to appear in sequence.
+
+type ublInvoice struct {
+	XMLName              xml.Name         `xml:"Invoice"`
+	Xmlns                string           `xml:"xmlns,attr"`
+	XmlnsCac             string           `xml:"xmlns:cac,attr"`
+	XmlnsCbc             string           `xml:"xmlns:cbc,attr"`
+	CustomizationID      string           `xml:"cbc:CustomizationID"`
+	ProfileID            string           `xml:"cbc:ProfileID"`
+	ID                   string           `xml:"cbc:ID"`
+	IssueDate            string           `xml:"cbc:IssueDate"`
+	DueDate              string           `xml:"cbc:DueDate,omitempty"`
+	InvoiceTypeCode      string           `xml:"cbc:InvoiceTypeCode"`
+	Note                 string           `xml:"cbc:Note,omitempty"`
+	DocumentCurrencyCode string           `xml:"cbc:DocumentCurrencyCode"`
+	BuyerReference       string           `xml:"cbc:BuyerReference"`
+	Supplier             ublPartyWrapper  `xml:"cac:AccountingSupplierParty"`
+	Customer             ublPartyWrapper  `xml:"cac:AccountingCustomerParty"`
+	PaymentMeans         ublPaymentMeans  `xml:"cac:PaymentMeans"`
+	PaymentTerms         *ublPaymentTerms `xml:"cac:PaymentTerms"`
+	AllowanceCharges     []ublAllowance   `xml:"cac:AllowanceCharge"`
+	TaxTotal             ublTaxTotal      `xml:"cac:TaxTotal"`
+	LegalMonetaryTotal   ublMonetaryTotal `xml:"cac:LegalMonetaryTotal"`
+	InvoiceLines         []ublInvoiceLine `xml:"cac:InvoiceLine"`
+}
+
+type ublPartyWrapper struct {
+	Party ublParty `xml:"cac:Party"`
+}
+
+type ublParty struct {
+	EndpointID     ublIdentifier      `xml:"cbc:EndpointID"`
+	PostalAddress  ublAddress         `xml:"cac:PostalAddress"`
+	PartyTaxScheme *ublPartyTaxScheme `xml:"cac:PartyTaxScheme"`
+	LegalEntity    ublLegalEntity     `xml:"cac:PartyLegalEntity"`
+	Contact        *ublContact        `xml:"cac:Contact"`
+}
+
+type ublIdentifier struct {
+	SchemeID string `xml:"schemeID,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ublAddress struct {
+	StreetName string     `xml:"cbc:StreetName,omitempty"`
+	Country    ublCountry `xml:"cac:Country"`
+}
+
+type ublCountry struct {
+	IdentificationCode string `xml:"cbc:IdentificationCode"`
+}
+
+type ublPartyTaxScheme struct {
+	CompanyID string       `xml:"cbc:CompanyID"`
+	TaxScheme ublTaxScheme `xml:"cac:TaxScheme"`
+}
+
+type ublTaxScheme struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublLegalEntity struct {
+	RegistrationName string `xml:"cbc:RegistrationName"`
+}
+
+type ublContact struct {
+	Telephone      string `xml:"cbc:Telephone,omitempty"`
+	ElectronicMail string `xml:"cbc:ElectronicMail,omitempty"`
+}
+
+type ublPaymentMeans struct {
+	PaymentMeansCode string `xml:"cbc:PaymentMeansCode"`
+	PaymentID        string `xml:"cbc:PaymentID"`
+}
+
+type ublPaymentTerms struct {
+	Note string `xml:"cbc:Note"`
+}
+
+type ublAllowance struct {
+	ChargeIndicator       bool           `xml:"cbc:ChargeIndicator"`
+	AllowanceChargeReason string         `xml:"cbc:AllowanceChargeReason"`
+	Amount                ublAmount      `xml:"cbc:Amount"`
+	TaxCategory           ublTaxCategory `xml:"cac:TaxCategory"`
+}
+
+type ublAmount struct {
+	CurrencyID string `xml:"currencyID,attr"`
+	Value      string `xml:",chardata"`
+}
+
+type ublQuantity struct {
+	UnitCode string `xml:"unitCode,attr"`
+	Value    string `xml:",chardata"`
+}
+
+type ublTaxCategory struct {
+	ID                     string       `xml:"cbc:ID"`
+	Percent                string       `xml:"cbc:Percent,omitempty"`
+	TaxExemptionReasonCode string       `xml:"cbc:TaxExemptionReasonCode,omitempty"`
+	TaxExemptionReason     string       `xml:"cbc:TaxExemptionReason,omitempty"`
+	TaxScheme              ublTaxScheme `xml:"cac:TaxScheme"`
+}
+
+type ublTaxTotal struct {
+	TaxAmount    ublAmount        `xml:"cbc:TaxAmount"`
+	TaxSubtotals []ublTaxSubtotal `xml:"cac:TaxSubtotal"`
+}
+
+type ublTaxSubtotal struct {
+	TaxableAmount ublAmount      `xml:"cbc:TaxableAmount"`
+	TaxAmount     ublAmount      `xml:"cbc:TaxAmount"`
+	TaxCategory   ublTaxCategory `xml:"cac:TaxCategory"`
+}
+
+type ublMonetaryTotal struct {
+	LineExtensionAmount  ublAmount  `xml:"cbc:LineExtensionAmount"`
+	TaxExclusiveAmount   ublAmount  `xml:"cbc:TaxExclusiveAmount"`
+	TaxInclusiveAmount   ublAmount  `xml:"cbc:TaxInclusiveAmount"`
+	AllowanceTotalAmount *ublAmount `xml:"cbc:AllowanceTotalAmount"`
+	PayableAmount        ublAmount  `xml:"cbc:PayableAmount"`
+}
+
+type ublInvoiceLine struct {
+	ID                  string      `xml:"cbc:ID"`
+	InvoicedQuantity    ublQuantity `xml:"cbc:InvoicedQuantity"`
+	LineExtensionAmount ublAmount   `xml:"cbc:LineExtensionAmount"`
+	Item                ublItem     `xml:"cac:Item"`
+	Price               ublPrice    `xml:"cac:Price"`
+}
+
+type ublItem struct {
+	Name                      string         `xml:"cbc:Name"`
+	SellersItemIdentification *ublItemID     `xml:"cac:SellersItemIdentification"`
+	ClassifiedTaxCategory     ublTaxCategory `xml:"cac:ClassifiedTaxCategory"`
+}
+
+type ublItemID struct {
+	ID string `xml:"cbc:ID"`
+}
+
+type ublPrice struct {
+	PriceAmount ublAmount `xml:"cbc:PriceAmount"`
+}
+
+// UBL renders the document as a UBL 2.1 Invoice following PEPPOL BIS Billing
+// 3.0. It does not validate the document; call Validate first.
+func (d Document) UBL() ([]byte, error) {
+	amount := func(v int64) ublAmount {
+		return ublAmount{CurrencyID: d.Currency, Value: formatAmount(v, d.Currency)}
+	}
+
+	invoice := ublInvoice{
+		Xmlns:                ublInvoiceNamespace,
+		XmlnsCac:             ublAggregateNamespace,
+		XmlnsCbc:             ublBasicNamespace,
+		CustomizationID:      peppolCustomizationID,
+		ProfileID:            peppolProfileID,
+		ID:                   d.Number,
+		IssueDate:            formatDate(d.IssueDate),
+		DueDate:              formatDate(d.DueDate),
+		InvoiceTypeCode:      commercialInvoiceCode,
+		Note:                 d.Note,
+		DocumentCurrencyCode: d.Currency,
+		BuyerReference:       d.BuyerReference,
+		Supplier:             ublPartyWrapper{Party: newUBLParty(d.Seller)},
+		Customer:             ublPartyWrapper{Party: newUBLParty(d.Buyer)},
+		PaymentMeans: ublPaymentMeans{
+			PaymentMeansCode: unspecifiedPaymentCode,
+			PaymentID:        d.Number,
+		},
+		TaxTotal: ublTaxTotal{TaxAmount: amount(d.TaxTotal)},
+		LegalMonetaryTotal: ublMonetaryTotal{
+			LineExtensionAmount: amount(d.LineTotal),
+			TaxExclusiveAmount:  amount(d.TaxExclusiveTotal),
+			TaxInclusiveAmount:  amount(d.TaxInclusiveTotal),
+			PayableAmount:       amount(d.PayableAmount),
+		},
+	}
+	if d.PaymentTerms != "" {
+		invoice.PaymentTerms = &ublPaymentTerms{Note: d.PaymentTerms}
+	}
+	if d.AllowanceTotal != 0 {
+		total := amount(d.AllowanceTotal)
+		invoice.LegalMonetaryTotal.AllowanceTotalAmount = &total
+	}
+
+	for _, v := range d.Allowances {
+		invoice.AllowanceCharges = append(invoice.AllowanceCharges, ublAllowance{
+			ChargeIndicator:       false,
+			AllowanceChargeReason: "Discount",
+			Amount:                amount(v.Amount),
+			TaxCategory:           newUBLTaxCategory(v.Tax, false),
+		})
+	}
+
+	for _, v := range d.TaxSubtotals {
+		invoice.TaxTotal.TaxSubtotals = append(invoice.TaxTotal.TaxSubtotals, ublTaxSubtotal{
+			TaxableAmount: amount(v.TaxableAmount),
+			TaxAmount:     amount(v.TaxAmount),
+			TaxCategory:   newUBLTaxCategory(v.Tax, true),
+		})
+	}
+
+	for _, v := range d.Lines {
+		line := ublInvoiceLine{
+			ID:                  v.ID,
+			InvoicedQuantity:    ublQuantity{UnitCode: unitCode, Value: strconv.FormatInt(v.Quantity, 10)},
+			LineExtensionAmount: amount(v.NetAmount),
+			Item: ublItem{
+				Name:                  v.Name,
+				ClassifiedTaxCategory: newUBLTaxCategory(v.Tax, false),
+			},
+			Price: ublPrice{PriceAmount: amount(v.UnitPrice)},
+		}
+		if v.SellerID != "" {
+			line.Item.SellersItemIdentification = &ublItemID{ID: v.SellerID}
+		}
+		invoice.InvoiceLines = append(invoice.InvoiceLines, line)
+	}
+
+	data, err := xml.MarshalIndent(invoice, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), data...), nil
+}
+
+func newUBLParty(p Party) ublParty {
+	party := ublParty{
+		EndpointID: ublIdentifier{SchemeID: emailSchemeID, Value: p.Email},
+		PostalAddress: ublAddress{
+			StreetName: p.Address,
+			Country:    ublCountry{IdentificationCode: p.CountryCode},
+		},
+		LegalEntity: ublLegalEntity{RegistrationName: p.Name},
+	}
+	if p.VATID != "" {
+		party.PartyTaxScheme = &ublPartyTaxScheme{
+			CompanyID: p.VATID,
+			TaxScheme: ublTaxScheme{ID: "VAT"},
+		}
+	}
+	if p.Phone != "" || p.Email != "" {
+		party.Contact = &ublContact{Telephone: p.Phone, ElectronicMail: p.Email}
+	}
+	return party
+}
+
+// newUBLTaxCategory returns the UBL tax category of a VAT category. Exemption
+// reasons are only allowed in the VAT breakdown.
+func newUBLTaxCategory(tax TaxCategory, breakdown bool) ublTaxCategory {
+	category := ublTaxCategory{
+		ID:        tax.Code,
+		Percent:   tax.Percent,
+		TaxScheme: ublTaxScheme{ID: "VAT"},
+	}
+	if breakdown && tax.Code == NotSubjectVAT {
+		category.TaxExemptionReasonCode = "VATEX-EU-O"
+		category.TaxExemptionReason = "Not subject to VAT"
+	}
+	return category
+}
+
+func formatDate(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
@@ -0,0 +1,201 @@
+package einvoice
+
+import (
+	"encoding/xml"
+	"strings"
+	"time"
+)
+
+// The types below read a received UBL invoice. They match elements by local
+// name only, so they accept any namespace prefixes.
+
+type ublInvoiceIn struct {
+	XMLName              xml.Name
+	ID                   string             `xml:"ID"`
+	IssueDate            string             `xml:"IssueDate"`
+	DueDate              string             `xml:"DueDate"`
+	InvoiceTypeCode      string             `xml:"InvoiceTypeCode"`
+	Notes                []string           `xml:"Note"`
+	DocumentCurrencyCode string             `xml:"DocumentCurrencyCode"`
+	BuyerReference       string             `xml:"BuyerReference"`
+	Supplier             ublPartyIn         `xml:"AccountingSupplierParty>Party"`
+	Customer             ublPartyIn         `xml:"AccountingCustomerParty>Party"`
+	PaymentTerms         []string           `xml:"PaymentTerms>Note"`
+	AllowanceCharges     []ublAllowanceIn   `xml:"AllowanceCharge"`
+	TaxTotals            []ublTaxTotalIn    `xml:"TaxTotal"`
+	LegalMonetaryTotal   ublMonetaryTotalIn `xml:"LegalMonetaryTotal"`
+	InvoiceLines         []ublInvoiceLineIn `xml:"InvoiceLine"`
+}
+
+type ublPartyIn struct {
+	EndpointID     ublIdentifier `xml:"EndpointID"`
+	Name           string        `xml:"PartyName>Name"`
+	StreetName     string        `xml:"PostalAddress>StreetName"`
+	AdditionalName string        `xml:"PostalAddress>AdditionalStreetName"`
+	CityName       string        `xml:"PostalAddress>CityName"`
+	PostalZone     string        `xml:"PostalAddress>PostalZone"`
+	CountryCode    string        `xml:"PostalAddress>Country>IdentificationCode"`
+	TaxSchemes     []struct {
+		CompanyID string `xml:"CompanyID"`
+		TaxScheme string `xml:"TaxScheme>ID"`
+	} `xml:"PartyTaxScheme"`
+	RegistrationName string `xml:"PartyLegalEntity>RegistrationName"`
+	Telephone        string `xml:"Contact>Telephone"`
+	ElectronicMail   string `xml:"Contact>ElectronicMail"`
+}
+
+type ublAllowanceIn struct {
+	ChargeIndicator bool             `xml:"ChargeIndicator"`
+	Amount          ublAmount        `xml:"Amount"`
+	TaxCategory     ublTaxCategoryIn `xml:"TaxCategory"`
+}
+
+type ublTaxCategoryIn struct {
+	ID      string `xml:"ID"`
+	Percent string `xml:"Percent"`
+}
+
+type ublTaxTotalIn struct {
+	TaxAmount    ublAmount `xml:"TaxAmount"`
+	TaxSubtotals []struct {
+		TaxableAmount ublAmount        `xml:"TaxableAmount"`
+		TaxAmount     ublAmount        `xml:"TaxAmount"`
+		TaxCategory   ublTaxCategoryIn `xml:"TaxCategory"`
+	} `xml:"TaxSubtotal"`
+}
+
+type ublMonetaryTotalIn struct {
+	LineExtensionAmount   ublAmount `xml:"LineExtensionAmount"`
+	TaxExclusiveAmount    ublAmount `xml:"TaxExclusiveAmount"`
+	TaxInclusiveAmount    ublAmount `xml:"TaxInclusiveAmount"`
+	AllowanceTotalAmount  ublAmount `xml:"AllowanceTotalAmount"`
+	ChargeTotalAmount     ublAmount `xml:"ChargeTotalAmount"`
+	PrepaidAmount         ublAmount `xml:"PrepaidAmount"`
+	PayableRoundingAmount ublAmount `xml:"PayableRoundingAmount"`
+	PayableAmount         ublAmount `xml:"PayableAmount"`
+}
+
+type ublInvoiceLineIn struct {
+	ID                  string           `xml:"ID"`
+	InvoicedQuantity    string           `xml:"InvoicedQuantity"`
+	LineExtensionAmount ublAmount        `xml:"LineExtensionAmount"`
+	AllowanceCharges    []ublAllowanceIn `xml:"AllowanceCharge"`
+	Name                string           `xml:"Item>Name"`
+	SellerID            string           `xml:"Item>SellersItemIdentification>ID"`
+	TaxCategory         ublTaxCategoryIn `xml:"Item>ClassifiedTaxCategory"`
+	PriceAmount         ublAmount        `xml:"Price>PriceAmount"`
+	BaseQuantity        string           `xml:"Price>BaseQuantity"`
+}
+
+// ParseUBL reads a UBL 2.1 invoice, such as a PEPPOL BIS Billing 3.0 invoice,
+// into a Document with the totals stated in the invoice. Call Validate to
+// check them. Credit notes, charges and prepaid amounts are not supported.
+func ParseUBL(data []byte) (Document, error) {
+	var in ublInvoiceIn
+	if err := xml.Unmarshal(data, &in); err != nil {
+		return Document{}, err
+	}
+	p := &documentParser{currency: strings.TrimSpace(in.DocumentCurrencyCode)}
+	if in.XMLName.Space != ublInvoiceNamespace || in.XMLName.Local != "Invoice" {
+		p.fail("not a UBL invoice")
+	}
+	if code := strings.TrimSpace(in.InvoiceTypeCode); code != commercialInvoiceCode {
+		p.fail("invoice type code %q is not supported", code)
+	}
+	amount := func(a ublAmount) int64 {
+		return p.amount(a.Value, a.CurrencyID)
+	}
+
+	d := Document{
+		Number:         strings.TrimSpace(in.ID),
+		IssueDate:      p.date(in.IssueDate, time.DateOnly),
+		DueDate:        p.date(in.DueDate, time.DateOnly),
+		Currency:       p.currency,
+		Note:           joinNonEmpty("\n", in.Notes...),
+		BuyerReference: strings.TrimSpace(in.BuyerReference),
+		PaymentTerms:   joinNonEmpty("\n", in.PaymentTerms...),
+		Seller:         in.Supplier.party(),
+		Buyer:          in.Customer.party(),
+	}
+
+	for _, v := range in.InvoiceLines {
+		if len(v.AllowanceCharges) > 0 {
+			p.fail("invoice line allowances and charges are not supported")
+		}
+		p.baseQuantity(v.BaseQuantity)
+		d.Lines = append(d.Lines, Line{
+			ID:        strings.TrimSpace(v.ID),
+			Name:      strings.TrimSpace(v.Name),
+			Quantity:  p.quantity(v.InvoicedQuantity),
+			UnitPrice: amount(v.PriceAmount),
+			NetAmount: amount(v.LineExtensionAmount),
+			SellerID:  strings.TrimSpace(v.SellerID),
+			Tax:       p.tax(v.TaxCategory.ID, v.TaxCategory.Percent),
+		})
+	}
+
+	for _, v := range in.AllowanceCharges {
+		if v.ChargeIndicator {
+			p.unsupported(amount(v.Amount), "document level charges")
+			continue
+		}
+		d.Allowances = append(d.Allowances, Allowance{
+			Amount: amount(v.Amount),
+			Tax:    p.tax(v.TaxCategory.ID, v.TaxCategory.Percent),
+		})
+	}
+
+	// A second tax total without subtotals may state the VAT in the tax
+	// accounting currency, which is not supported.
+	for _, total := range in.TaxTotals {
+		if total.TaxAmount.CurrencyID != "" && total.TaxAmount.CurrencyID != d.Currency {
+			p.fail("VAT accounting currency %s is not supported", total.TaxAmount.CurrencyID)
+			continue
+		}
+		d.TaxTotal += amount(total.TaxAmount)
+		for _, v := range total.TaxSubtotals {
+			d.TaxSubtotals = append(d.TaxSubtotals, TaxSubtotal{
+				TaxableAmount: amount(v.TaxableAmount),
+				TaxAmount:     amount(v.TaxAmount),
+				Tax:           p.tax(v.TaxCategory.ID, v.TaxCategory.Percent),
+			})
+		}
+	}
+
+	totals := in.LegalMonetaryTotal
+	d.LineTotal = amount(totals.LineExtensionAmount)
+	d.AllowanceTotal = amount(totals.AllowanceTotalAmount)
+	d.TaxExclusiveTotal = amount(totals.TaxExclusiveAmount)
+	d.TaxInclusiveTotal = amount(totals.TaxInclusiveAmount)
+	d.PayableAmount = amount(totals.PayableAmount)
+	p.unsupported(amount(totals.ChargeTotalAmount), "document level charges")
+	p.unsupported(amount(totals.PrepaidAmount), "prepaid amounts")
+	p.unsupported(amount(totals.PayableRoundingAmount), "rounding amounts")
+
+	if p.err != nil {
+		return Document{}, p.err
+	}
+	return d, nil
+}
+
+func (in ublPartyIn) party() Party {
+	p := Party{
+		Name:        strings.TrimSpace(in.RegistrationName),
+		Email:       strings.TrimSpace(in.ElectronicMail),
+		Phone:       strings.TrimSpace(in.Telephone),
+		Address:     joinNonEmpty(", ", in.StreetName, in.AdditionalName, in.CityName, in.PostalZone),
+		CountryCode: strings.TrimSpace(in.CountryCode),
+	}
+	if p.Name == "" {
+		p.Name = strings.TrimSpace(in.Name)
+	}
+	if in.EndpointID.SchemeID == emailSchemeID {
+		p.Email = strings.TrimSpace(in.EndpointID.Value)
+	}
+	for _, v := range in.TaxSchemes {
+		if strings.TrimSpace(v.TaxScheme) == "VAT" {
+			p.VATID = strings.TrimSpace(v.CompanyID)
+		}
+	}
+	return p
+}
@@ -0,0 +1,70 @@
+package einvoice
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var update = flag.Bool("update", false, "update golden files")
+
+func TestUBL(t *testing.T) {
+	testCases := []struct {
+		name     string
+		golden   string
+		document func(t *testing.T) Document
+	}{
+		{
+			name:   "StandardAndZeroRated",
+			golden: "ubl_standard.xml",
+			document: func(t *testing.T) Document {
+				d, err := NewDocument(testInvoice(), testOptions())
+				require.NoError(t, err)
+				return d
+			},
+		},
+		{
+			name:     "NotSubjectToVAT",
+			golden:   "ubl_not_subject.xml",
+			document: newNotSubjectDocument,
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			d := tc.document(t)
+			require.NoError(t, d.Validate())
+
+			data, err := d.UBL()
+			require.NoError(t, err)
+
+			golden := filepath.Join("testdata", tc.golden)
+			if *update {
+				require.NoError(t, os.WriteFile(golden, data, 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			require.Equal(t, string(want), string(data))
+		})
+	}
+}
+
+func newNotSubjectDocument(t *testing.T) Document {
+	invoice := testInvoice()
+	invoice.PaymentInfo = ""
+	for i := range invoice.LineItems {
+		invoice.LineItems[i].TaxCode = ""
+	}
+	opts := testOptions()
+	opts.SellerVATID = ""
+	opts.BuyerReference = "PO-77"
+
+	d, err := NewDocument(invoice, opts)
+	require.NoError(t, err)
+	return d
+}
@@ -0,0 +1,175 @@
+package einvoice
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/Rhymond/go-money"
+)
+
+// Violation is a failed business rule, identified by its EN 16931 or PEPPOL
+// rule ID.
+type Violation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
+type ValidationError struct {
+	Violations []Violation
+}
+
+func (e *ValidationError) Error() string {
+	messages := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		messages[i] = fmt.Sprintf("[%s] %s", v.Rule, v.Message)
+	}
+	return "invalid e-invoice: " + strings.Join(messages, "; ")
+}
+
+// Validate checks the document against the business rules of EN 16931 and
+// PEPPOL BIS Billing 3.0 that can be broken by invoice data or configuration.
+// It returns a *ValidationError listing every violation.
+func (d Document) Validate() error {
+	var violations []Violation
+	check := func(ok bool, rule, format string, args ...any) {
+		if !ok {
+			violations = append(violations, Violation{Rule: rule, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+
+	check(d.Number != "", "BR-02", "an invoice shall have an invoice number")
+	check(!d.IssueDate.IsZero(), "BR-03", "an invoice shall have an issue date")
+	check(d.Currency != "", "BR-05", "an invoice shall have a currency code")
+	check(d.Currency == "" || money.GetCurrency(d.Currency) != nil, "BR-CL-04", "unknown currency code %q", d.Currency)
+	check(d.Seller.Name != "", "BR-06", "an invoice shall contain the seller name")
+	check(d.Buyer.Name != "", "BR-07", "an invoice shall contain the buyer name")
+	check(d.Seller.CountryCode != "", "BR-09", "the seller postal address shall contain a country code")
+	check(d.Buyer.CountryCode != "", "BR-11", "the buyer postal address shall contain a country code")
+	check(d.BuyerReference != "", "PEPPOL-EN16931-R003", "a buyer reference or purchase order reference shall be provided")
+	check(d.Buyer.Email != "", "PEPPOL-EN16931-R010", "the buyer electronic address shall be provided")
+	check(d.Seller.Email != "", "PEPPOL-EN16931-R020", "the seller electronic address shall be provided")
+	check(len(d.Lines) > 0, "BR-16", "an invoice shall have at least one invoice line")
+	check(d.PayableAmount <= 0 || !d.DueDate.IsZero() || d.PaymentTerms != "",
+		"BR-CO-25", "a positive amount due requires a payment due date or payment terms")
+
+	var lineTotal int64
+	categories := map[string]bool{}
+	for _, line := range d.Lines {
+		check(line.ID != "", "BR-21", "each invoice line shall have an identifier")
+		check(line.Quantity != 0, "BR-22", "invoice line %s shall have an invoiced quantity", line.ID)
+		check(line.Name != "", "BR-25", "invoice line %s shall contain the item name", line.ID)
+		check(line.UnitPrice >= 0, "BR-27", "the item net price of invoice line %s shall not be negative", line.ID)
+		check(line.NetAmount == line.Quantity*line.UnitPrice, "PEPPOL-EN16931-R120",
+			"the net amount of invoice line %s shall equal the quantity times the item net price", line.ID)
+		lineTotal += line.NetAmount
+		categories[line.Tax.Code] = true
+
+		switch line.Tax.Code {
+		case StandardRate:
+			rate, _ := strconv.ParseFloat(line.Tax.Percent, 64)
+			check(rate > 0, "BR-S-05", "the VAT rate of standard rated invoice line %s shall be greater than zero", line.ID)
+		case ZeroRated:
+			check(line.Tax.Percent == "0", "BR-Z-05", "the VAT rate of zero rated invoice line %s shall be 0", line.ID)
+		case NotSubjectVAT:
+			check(line.Tax.Percent == "", "BR-O-05", "invoice line %s is not subject to VAT and shall not contain a VAT rate", line.ID)
+		default:
+			check(false, "BR-CL-18", "invoice line %s has unsupported VAT category code %q", line.ID, line.Tax.Code)
+		}
+	}
+
+	if categories[StandardRate] {
+		check(d.Seller.VATID != "", "BR-S-02", "an invoice with standard rated lines shall contain the seller VAT identifier")
+	}
+	if categories[ZeroRated] {
+		check(d.Seller.VATID != "", "BR-Z-02", "an invoice with zero rated lines shall contain the seller VAT identifier")
+	}
+	if categories[NotSubjectVAT] {
+		check(d.Seller.VATID == "", "BR-O-02", "an invoice with lines not subject to VAT shall not contain the seller VAT identifier")
+		check(len(categories) == 1, "BR-O-11", "an invoice with lines not subject to VAT shall not contain other VAT categories")
+	}
+
+	var allowanceTotal, taxTotal int64
+	for _, v := range d.Allowances {
+		allowanceTotal += v.Amount
+	}
+	for _, v := range d.TaxSubtotals {
+		taxTotal += v.TaxAmount
+	}
+
+	check(lineTotal == d.LineTotal, "BR-CO-10", "the sum of invoice line net amounts shall equal the line total")
+	check(allowanceTotal == d.AllowanceTotal, "BR-CO-11", "the sum of document level allowances shall equal the allowance total")
+	check(taxTotal == d.TaxTotal, "BR-CO-14", "the VAT total shall equal the sum of the VAT category tax amounts")
+	check(d.TaxExclusiveTotal == d.LineTotal-d.AllowanceTotal, "BR-CO-13",
+		"the total without VAT shall equal the line total minus allowances")
+	check(d.TaxInclusiveTotal == d.TaxExclusiveTotal+d.TaxTotal, "BR-CO-15",
+		"the total with VAT shall equal the total without VAT plus the VAT total")
+	check(d.PayableAmount == d.TaxInclusiveTotal, "BR-CO-16",
+		"the amount due shall equal the total with VAT")
+
+	// the VAT breakdown recomputed from the lines and allowances
+	var breakdown []TaxCategory
+	taxable := map[TaxCategory]int64{}
+	add := func(tax TaxCategory, amount int64) {
+		if _, ok := taxable[tax]; !ok {
+			breakdown = append(breakdown, tax)
+		}
+		taxable[tax] += amount
+	}
+	for _, line := range d.Lines {
+		add(line.Tax, line.NetAmount)
+	}
+	for _, v := range d.Allowances {
+		add(v.Tax, -v.Amount)
+	}
+
+	subtotals := map[TaxCategory]TaxSubtotal{}
+	for _, v := range d.TaxSubtotals {
+		_, ok := taxable[v.Tax]
+		check(ok, breakdownRule(v.Tax, "08"), "the VAT breakdown for %s has no invoice lines or allowances", categoryName(v.Tax))
+		subtotals[v.Tax] = v
+	}
+	for _, tax := range breakdown {
+		subtotal, ok := subtotals[tax]
+		check(ok && subtotal.TaxableAmount == taxable[tax], breakdownRule(tax, "08"),
+			"the VAT breakdown for %s shall have a taxable amount of the line net amounts minus allowances", categoryName(tax))
+		if !ok {
+			continue
+		}
+		// one minor unit of difference is allowed for rounding
+		rate, _ := strconv.ParseFloat(tax.Percent, 64)
+		want := int64(math.Round(float64(subtotal.TaxableAmount) * rate / 100))
+		check(abs(subtotal.TaxAmount-want) <= 1, breakdownRule(tax, "09"),
+			"the VAT amount for %s shall equal the taxable amount times the VAT rate", categoryName(tax))
+	}
+
+	if len(violations) > 0 {
+		return &ValidationError{Violations: violations}
+	}
+	return nil
+}
+
+// breakdownRule returns the ID of a VAT breakdown rule of a category, e.g.
+// BR-S-08. The rules of unsupported categories are reported as BR-CL-18.
+func breakdownRule(tax TaxCategory, n string) string {
+	switch tax.Code {
+	case StandardRate, ZeroRated, NotSubjectVAT:
+		return "BR-" + tax.Code + "-" + n
+	}
+	return "BR-CL-18"
+}
+
+func categoryName(tax TaxCategory) string {
+	if tax.Percent == "" {
+		return "VAT category " + tax.Code
+	}
+	return fmt.Sprintf("VAT category %s at %s%%", tax.Code, tax.Percent)
+}
+
+func abs(v int64) int64 {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,146 @@
+package einvoice
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidate(t *testing.T) {
+	testCases := []struct {
+		name   string
+		modify func(d *Document)
+		rules  []string
+	}{
+		{
+			name:   "Valid",
+			modify: func(d *Document) {},
+		},
+		{
+			name: "MissingParties",
+			modify: func(d *Document) {
+				d.Seller.Name = ""
+				d.Seller.Email = ""
+				d.Buyer.CountryCode = ""
+			},
+			rules: []string{"BR-06", "BR-11", "PEPPOL-EN16931-R020"},
+		},
+		{
+			name: "NoLines",
+			modify: func(d *Document) {
+				d.Lines = nil
+				d.Allowances = nil
+				d.TaxSubtotals = nil
+				d.LineTotal, d.AllowanceTotal, d.TaxExclusiveTotal = 0, 0, 0
+				d.TaxTotal, d.TaxInclusiveTotal, d.PayableAmount = 0, 0, 0
+			},
+			rules: []string{"BR-16"},
+		},
+		{
+			name: "MissingDates",
+			modify: func(d *Document) {
+				d.IssueDate = time.Time{}
+				d.DueDate = time.Time{}
+				d.PaymentTerms = ""
+			},
+			rules: []string{"BR-03", "BR-CO-25"},
+		},
+		{
+			name: "UnknownCurrency",
+			modify: func(d *Document) {
+				d.Currency = "XYZ"
+			},
+			rules: []string{"BR-CL-04"},
+		},
+		{
+			name: "StandardRatedWithoutVATID",
+			modify: func(d *Document) {
+				d.Seller.VATID = ""
+			},
+			rules: []string{"BR-S-02", "BR-Z-02"},
+		},
+		{
+			name: "StandardRatedWithoutRate",
+			modify: func(d *Document) {
+				d.Lines[0].Tax.Percent = ""
+			},
+			// the line no longer matches the allowance and breakdown at 7.5%
+			rules: []string{"BR-S-05", "BR-S-08", "BR-S-08"},
+		},
+		{
+			name: "NotSubjectMixedWithOtherCategories",
+			modify: func(d *Document) {
+				d.Lines[1].Tax = TaxCategory{Code: NotSubjectVAT}
+			},
+			rules: []string{"BR-O-02", "BR-O-11", "BR-O-08", "BR-Z-08"},
+		},
+		{
+			name: "UnsupportedCategory",
+			modify: func(d *Document) {
+				d.Lines[1].Tax = TaxCategory{Code: "E"}
+			},
+			rules: []string{"BR-CL-18", "BR-CL-18", "BR-Z-08"},
+		},
+		{
+			name: "InconsistentTotals",
+			modify: func(d *Document) {
+				d.LineTotal++
+				d.TaxExclusiveTotal++
+				d.TaxInclusiveTotal += 2
+			},
+			rules: []string{"BR-CO-10", "BR-CO-15", "BR-CO-16"},
+		},
+		{
+			name: "LineNetAmount",
+			modify: func(d *Document) {
+				d.Lines[1].Quantity = 2
+			},
+			rules: []string{"PEPPOL-EN16931-R120"},
+		},
+		{
+			name: "VATBreakdown",
+			modify: func(d *Document) {
+				d.TaxSubtotals[0].TaxableAmount++
+				d.TaxSubtotals[1].TaxAmount = 5
+				d.TaxTotal += 5
+				d.TaxInclusiveTotal += 5
+				d.PayableAmount += 5
+			},
+			rules: []string{"BR-S-08", "BR-Z-09"},
+		},
+		{
+			name: "VATRounding",
+			modify: func(d *Document) {
+				d.TaxSubtotals[0].TaxAmount++
+				d.TaxTotal++
+				d.TaxInclusiveTotal++
+				d.PayableAmount++
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			d, err := NewDocument(testInvoice(), testOptions())
+			require.NoError(t, err)
+			tc.modify(&d)
+
+			err = d.Validate()
+			if len(tc.rules) == 0 {
+				require.NoError(t, err)
+				return
+			}
+
+			var validationErr *ValidationError
+			require.ErrorAs(t, err, &validationErr)
+			rules := make([]string, len(validationErr.Violations))
+			for i, v := range validationErr.Violations {
+				rules[i] = v.Rule
+			}
+			require.ElementsMatch(t, tc.rules, rules)
+		})
+	}
+}
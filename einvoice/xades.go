@@ -0,0 +1,417 @@
+package einvoice
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+	"time"
+)
+
+const (
+	dsigNamespace  = "http://www.w3.org/2000/09/xmldsig#"
+	xadesNamespace = "http://uri.etsi.org/01903/v1.3.2#"
+
+	ublExtensionNamespace          = "urn:oasis:names:specification:ubl:schema:xsd:CommonExtensionComponents-2"
+	ublSignatureNamespace          = "urn:oasis:names:specification:ubl:schema:xsd:CommonSignatureComponents-2"
+	ublSignatureAggregateNamespace = "urn:oasis:names:specification:ubl:schema:xsd:SignatureAggregateComponents-2"
+
+	algorithmExcC14N              = "http://www.w3.org/2001/10/xml-exc-c14n#"
+	algorithmEnvelopedSignature   = dsigNamespace + "enveloped-signature"
+	algorithmSHA256               = "http://www.w3.org/2001/04/xmlenc#sha256"
+	algorithmRSASHA256            = "http://www.w3.org/2001/04/xmldsig-more#rsa-sha256"
+	algorithmECDSASHA256          = "http://www.w3.org/2001/04/xmldsig-more#ecdsa-sha256"
+	referenceTypeSignedProperties = "http://uri.etsi.org/01903#SignedProperties"
+
+	xadesSignatureID        = "xades-signature"
+	xadesSignedPropertiesID = "xades-signed-properties"
+)
+
+// SignXML adds a XAdES baseline B enveloped signature, made at the given
+// time, to a UBL or Cross Industry invoice. In a UBL invoice the signature
+// goes in the UBL extensions, as the UBL 2.1 signature extension has it;
+// elsewhere it is the last child of the root element. The signature covers
+// the whole document, canonicalized with Exclusive XML Canonicalization.
+func (s *Signer) SignXML(data []byte, at time.Time) ([]byte, error) {
+	signatureMethod, err := xmlSignatureMethod(s.Certificate)
+	if err != nil {
+		return nil, err
+	}
+	offset, wrapStart, wrapEnd, err := signaturePlacement(data)
+	if err != nil {
+		return nil, err
+	}
+	enveloped := func(signature string) []byte {
+		var b bytes.Buffer
+		b.Write(data[:offset])
+		b.WriteString(wrapStart + signature + wrapEnd)
+		b.Write(data[offset:])
+		return b.Bytes()
+	}
+
+	// the enveloped signature transform leaves the signature out, so the
+	// document is digested with an empty one in its place
+	document, err := canonicalize(enveloped(`<ds:Signature xmlns:ds="`+dsigNamespace+`"/>`), nil, isXMLSignature)
+	if err != nil {
+		return nil, err
+	}
+
+	var certs strings.Builder
+	for _, cert := range s.certificates() {
+		certs.WriteString("<ds:X509Certificate>" + base64.StdEncoding.EncodeToString(cert.Raw) + "</ds:X509Certificate>")
+	}
+	object := `<ds:Object>` +
+		`<xades:QualifyingProperties xmlns:xades="` + xadesNamespace + `" Target="#` + xadesSignatureID + `">` +
+		`<xades:SignedProperties Id="` + xadesSignedPropertiesID + `">` +
+		`<xades:SignedSignatureProperties>` +
+		`<xades:SigningTime>` + at.UTC().Format(time.RFC3339) + `</xades:SigningTime>` +
+		`<xades:SigningCertificateV2><xades:Cert><xades:CertDigest>` +
+		`<ds:DigestMethod Algorithm="` + algorithmSHA256 + `"/>` +
+		`<ds:DigestValue>` + base64.StdEncoding.EncodeToString(sha256Sum(s.Certificate.Raw)) + `</ds:DigestValue>` +
+		`</xades:CertDigest></xades:Cert></xades:SigningCertificateV2>` +
+		`</xades:SignedSignatureProperties>` +
+		`</xades:SignedProperties>` +
+		`</xades:QualifyingProperties>` +
+		`</ds:Object>`
+	signedProperties, err := canonicalize([]byte(xmlSignatureElement(object)), hasID(xadesSignedPropertiesID), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	signedInfo := `<ds:SignedInfo>` +
+		`<ds:CanonicalizationMethod Algorithm="` + algorithmExcC14N + `"/>` +
+		`<ds:SignatureMethod Algorithm="` + signatureMethod + `"/>` +
+		`<ds:Reference URI="">` +
+		`<ds:Transforms>` +
+		`<ds:Transform Algorithm="` + algorithmEnvelopedSignature + `"/>` +
+		`<ds:Transform Algorithm="` + algorithmExcC14N + `"/>` +
+		`</ds:Transforms>` +
+		`<ds:DigestMethod Algorithm="` + algorithmSHA256 + `"/>` +
+		`<ds:DigestValue>` + base64.StdEncoding.EncodeToString(sha256Sum(document)) + `</ds:DigestValue>` +
+		`</ds:Reference>` +
+		`<ds:Reference Type="` + referenceTypeSignedProperties + `" URI="#` + xadesSignedPropertiesID + `">` +
+		`<ds:Transforms><ds:Transform Algorithm="` + algorithmExcC14N + `"/></ds:Transforms>` +
+		`<ds:DigestMethod Algorithm="` + algorithmSHA256 + `"/>` +
+		`<ds:DigestValue>` + base64.StdEncoding.EncodeToString(sha256Sum(signedProperties)) + `</ds:DigestValue>` +
+		`</ds:Reference>` +
+		`</ds:SignedInfo>`
+	canonicalSignedInfo, err := canonicalize([]byte(xmlSignatureElement(signedInfo)), isXMLElement(dsigNamespace, "SignedInfo"), nil)
+	if err != nil {
+		return nil, err
+	}
+	signatureValue, err := s.sign(sha256Sum(canonicalSignedInfo))
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.Certificate.PublicKey.(*ecdsa.PublicKey); ok {
+		// XML signatures hold the two integers of an ECDSA signature side by
+		// side rather than ASN.1 encoded
+		if signatureValue, err = ecdsaRawSignature(signatureValue); err != nil {
+			return nil, err
+		}
+	}
+
+	return enveloped(xmlSignatureElement(signedInfo +
+		`<ds:SignatureValue>` + base64.StdEncoding.EncodeToString(signatureValue) + `</ds:SignatureValue>` +
+		`<ds:KeyInfo><ds:X509Data>` + certs.String() + `</ds:X509Data></ds:KeyInfo>` +
+		object)), nil
+}
+
+func xmlSignatureElement(content string) string {
+	return `<ds:Signature xmlns:ds="` + dsigNamespace + `" Id="` + xadesSignatureID + `">` + content + `</ds:Signature>`
+}
+
+func xmlSignatureMethod(cert *x509.Certificate) (string, error) {
+	switch cert.PublicKey.(type) {
+	case *rsa.PublicKey:
+		return algorithmRSASHA256, nil
+	case *ecdsa.PublicKey:
+		return algorithmECDSASHA256, nil
+	}
+	return "", fmt.Errorf("unsupported public key %T", cert.PublicKey)
+}
+
+// signaturePlacement returns the offset in an XML invoice at which the
+// signature goes and the elements that wrap it there.
+func signaturePlacement(data []byte) (offset int, wrapStart, wrapEnd string, err error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	depth, ubl := 0, false
+	for {
+		before := int(d.InputOffset())
+		tok, err := d.Token()
+		if err == io.EOF {
+			return 0, "", "", errors.New("XML document has no root element")
+		}
+		if err != nil {
+			return 0, "", "", err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name {
+			case xml.Name{Space: dsigNamespace, Local: "Signature"}:
+				return 0, "", "", errors.New("XML document is already signed")
+			case xml.Name{Space: ublExtensionNamespace, Local: "UBLExtensions"}:
+				return 0, "", "", errors.New("UBL invoice already has extensions")
+			}
+			depth++
+			if depth == 1 && t.Name.Space == ublInvoiceNamespace {
+				ubl, offset = true, int(d.InputOffset())
+			}
+		case xml.EndElement:
+			depth--
+			if depth > 0 {
+				continue
+			}
+			if !ubl {
+				return before, "", "", nil
+			}
+			return offset,
+				`<ext:UBLExtensions xmlns:ext="` + ublExtensionNamespace + `"><ext:UBLExtension><ext:ExtensionContent>` +
+					`<sig:UBLDocumentSignatures xmlns:sig="` + ublSignatureNamespace + `">` +
+					`<sac:SignatureInformation xmlns:sac="` + ublSignatureAggregateNamespace + `">`,
+				`</sac:SignatureInformation></sig:UBLDocumentSignatures>` +
+					`</ext:ExtensionContent></ext:UBLExtension></ext:UBLExtensions>`,
+				nil
+		}
+	}
+}
+
+func isXMLElement(space, local string) func(c14nElement) bool {
+	return func(e c14nElement) bool {
+		return e.Name.Space == space && e.Name.Local == local
+	}
+}
+
+var isXMLSignature = isXMLElement(dsigNamespace, "Signature")
+
+func hasID(id string) func(c14nElement) bool {
+	return func(e c14nElement) bool {
+		return e.attr("Id") == id
+	}
+}
+
+type xmlAlgorithm struct {
+	Algorithm string `xml:"Algorithm,attr"`
+}
+
+type xmlReference struct {
+	URI          string         `xml:"URI,attr"`
+	Type         string         `xml:"Type,attr"`
+	Transforms   []xmlAlgorithm `xml:"Transforms>Transform"`
+	DigestMethod xmlAlgorithm   `xml:"DigestMethod"`
+	DigestValue  string         `xml:"DigestValue"`
+}
+
+type xmlCertDigest struct {
+	DigestMethod xmlAlgorithm `xml:"DigestMethod"`
+	DigestValue  string       `xml:"DigestValue"`
+}
+
+// xmlSignatureIn reads an XML signature. Like the invoice types, it matches
+// elements by local name only.
+type xmlSignatureIn struct {
+	SignedInfo struct {
+		CanonicalizationMethod xmlAlgorithm   `xml:"CanonicalizationMethod"`
+		SignatureMethod        xmlAlgorithm   `xml:"SignatureMethod"`
+		References             []xmlReference `xml:"Reference"`
+	} `xml:"SignedInfo"`
+	SignatureValue   string   `xml:"SignatureValue"`
+	Certificates     []string `xml:"KeyInfo>X509Data>X509Certificate"`
+	SignedProperties struct {
+		ID          string          `xml:"Id,attr"`
+		SigningTime string          `xml:"SignedSignatureProperties>SigningTime"`
+		CertDigests []xmlCertDigest `xml:"SignedSignatureProperties>SigningCertificateV2>Cert>CertDigest"`
+	} `xml:"Object>QualifyingProperties>SignedProperties"`
+}
+
+// VerifyXML checks the signature of a UBL or Cross Industry invoice signed
+// by SignXML, which must cover the whole document, and reads the invoice.
+func VerifyXML(data []byte) (SignedDocument, error) {
+	signed := SignedDocument{Format: FormatXAdES}
+	invalid := func(format string, args ...any) error {
+		return fmt.Errorf("%w: %s", ErrInvalidSignature, fmt.Sprintf(format, args...))
+	}
+
+	root, signature, err := readXMLSignature(data)
+	if err != nil {
+		return signed, err
+	}
+	switch root {
+	case xml.Name{Space: ublInvoiceNamespace, Local: "Invoice"}:
+		signed.Document, err = ParseUBL(data)
+	case xml.Name{Space: ciiInvoiceNamespace, Local: "CrossIndustryInvoice"}:
+		signed.Document, err = ParseCII(data)
+	default:
+		err = fmt.Errorf("%w: not a UBL or Cross Industry invoice", ErrInvalidDocument)
+	}
+	if err != nil {
+		return signed, err
+	}
+	if signature == nil {
+		return signed, ErrNoSignature
+	}
+
+	if len(signature.Certificates) == 0 {
+		return signed, invalid("signature has no certificate")
+	}
+	cert, err := x509.ParseCertificate(decodeBase64(signature.Certificates[0]))
+	if err != nil {
+		return signed, invalid("%v", err)
+	}
+	signed.Certificate = cert
+
+	info := signature.SignedInfo
+	if info.CanonicalizationMethod.Algorithm != algorithmExcC14N {
+		return signed, invalid("unsupported canonicalization %s", info.CanonicalizationMethod.Algorithm)
+	}
+	coversDocument := false
+	for _, ref := range info.References {
+		if ref.DigestMethod.Algorithm != algorithmSHA256 {
+			return signed, invalid("unsupported digest algorithm %s", ref.DigestMethod.Algorithm)
+		}
+		var transforms []string
+		for _, t := range ref.Transforms {
+			transforms = append(transforms, t.Algorithm)
+		}
+
+		var content []byte
+		switch {
+		case ref.URI == "" && strings.Join(transforms, " ") == algorithmEnvelopedSignature+" "+algorithmExcC14N:
+			content, err = canonicalize(data, nil, isXMLSignature)
+			coversDocument = true
+		case strings.HasPrefix(ref.URI, "#") && strings.Join(transforms, " ") == algorithmExcC14N:
+			content, err = canonicalize(data, hasID(strings.TrimPrefix(ref.URI, "#")), nil)
+		default:
+			return signed, invalid("unsupported reference %q", ref.URI)
+		}
+		if err != nil {
+			return signed, invalid("reference %q: %v", ref.URI, err)
+		}
+		if !bytes.Equal(sha256Sum(content), decodeBase64(ref.DigestValue)) {
+			return signed, invalid("digest of reference %q does not match", ref.URI)
+		}
+	}
+	if !coversDocument {
+		return signed, invalid("signature does not cover the whole document")
+	}
+
+	properties := signature.SignedProperties
+	propertiesSigned := false
+	for _, ref := range info.References {
+		if properties.ID != "" && ref.URI == "#"+properties.ID {
+			propertiesSigned = true
+		}
+	}
+	if !propertiesSigned {
+		return signed, invalid("signed properties are not signed")
+	}
+	if len(properties.CertDigests) > 0 {
+		digest := properties.CertDigests[0]
+		if digest.DigestMethod.Algorithm != algorithmSHA256 || !bytes.Equal(decodeBase64(digest.DigestValue), sha256Sum(cert.Raw)) {
+			return signed, invalid("signing certificate does not match the signed certificate digest")
+		}
+	}
+	signed.SigningTime, err = time.Parse(time.RFC3339, strings.TrimSpace(properties.SigningTime))
+	if err != nil {
+		return signed, invalid("invalid signing time %q", properties.SigningTime)
+	}
+
+	canonicalSignedInfo, err := canonicalize(data, isXMLElement(dsigNamespace, "SignedInfo"), nil)
+	if err != nil {
+		return signed, invalid("%v", err)
+	}
+	signatureValue := decodeBase64(signature.SignatureValue)
+	var algorithm x509.SignatureAlgorithm
+	switch info.SignatureMethod.Algorithm {
+	case algorithmRSASHA256:
+		algorithm = x509.SHA256WithRSA
+	case algorithmECDSASHA256:
+		algorithm = x509.ECDSAWithSHA256
+		if signatureValue, err = ecdsaASN1Signature(signatureValue); err != nil {
+			return signed, invalid("%v", err)
+		}
+	default:
+		return signed, invalid("unsupported signature method %s", info.SignatureMethod.Algorithm)
+	}
+	if err := cert.CheckSignature(algorithm, canonicalSignedInfo, signatureValue); err != nil {
+		return signed, invalid("%v", err)
+	}
+	if err := checkSigningTime(cert, signed.SigningTime); err != nil {
+		return signed, err
+	}
+	return signed, nil
+}
+
+// readXMLSignature returns the name of the root element of an XML document
+// and its first XML signature, if it has one.
+func readXMLSignature(data []byte) (xml.Name, *xmlSignatureIn, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	var root xml.Name
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return root, nil, nil
+		}
+		if err != nil {
+			return root, nil, err
+		}
+		t, ok := tok.(xml.StartElement)
+		if !ok {
+			continue
+		}
+		if root.Local == "" {
+			root = t.Name
+		}
+		if t.Name.Space == dsigNamespace && t.Name.Local == "Signature" {
+			var signature xmlSignatureIn
+			if err := d.DecodeElement(&signature, &t); err != nil {
+				return root, nil, err
+			}
+			return root, &signature, nil
+		}
+	}
+}
+
+// decodeBase64 decodes a base64 value of an XML signature, which may be
+// broken into lines. Invalid values decode to nothing, which then fails to
+// match.
+func decodeBase64(s string) []byte {
+	v, _ := base64.StdEncoding.DecodeString(strings.Join(strings.Fields(s), ""))
+	return v
+}
+
+type ecdsaSignature struct {
+	R, S *big.Int
+}
+
+// ecdsaRawSignature converts an ASN.1 ECDSA P-256 signature to the form of
+// XML signatures.
+func ecdsaRawSignature(der []byte) ([]byte, error) {
+	var sig ecdsaSignature
+	if _, err := asn1.Unmarshal(der, &sig); err != nil {
+		return nil, err
+	}
+	raw := make([]byte, 64)
+	sig.R.FillBytes(raw[:32])
+	sig.S.FillBytes(raw[32:])
+	return raw, nil
+}
+
+func ecdsaASN1Signature(raw []byte) ([]byte, error) {
+	if len(raw) != 64 {
+		return nil, errors.New("ECDSA signature has the wrong length")
+	}
+	return asn1.Marshal(ecdsaSignature{
+		R: new(big.Int).SetBytes(raw[:32]),
+		S: new(big.Int).SetBytes(raw[32:]),
+	})
+}
@@ -0,0 +1,161 @@
+package einvoice
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignXML(t *testing.T) {
+	d, err := NewDocument(testInvoice(), testOptions())
+	require.NoError(t, err)
+	ubl, err := d.UBL()
+	require.NoError(t, err)
+	cii, err := d.CII()
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name     string
+		keyType  string
+		document []byte
+		check    func(t *testing.T, signed []byte)
+	}{
+		{
+			name:     "UBLWithRSA",
+			keyType:  "rsa",
+			document: ubl,
+			check: func(t *testing.T, signed []byte) {
+				// the extensions come first in a UBL invoice
+				start := bytes.Index(signed, []byte("<Invoice "))
+				end := bytes.IndexByte(signed[start:], '>')
+				require.True(t, bytes.HasPrefix(signed[start+end+1:], []byte("<ext:UBLExtensions ")))
+				require.Contains(t, string(signed), "<sac:SignatureInformation")
+			},
+		},
+		{
+			name:     "UBLWithECDSA",
+			keyType:  "ecdsa",
+			document: ubl,
+			check: func(t *testing.T, signed []byte) {
+				require.Contains(t, string(signed), algorithmECDSASHA256)
+			},
+		},
+		{
+			name:     "CII",
+			keyType:  "ecdsa",
+			document: cii,
+			check: func(t *testing.T, signed []byte) {
+				require.True(t, bytes.HasSuffix(bytes.TrimSpace(signed), []byte("</ds:Signature></rsm:CrossIndustryInvoice>")))
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			signer := newTestSigner(t, tc.keyType)
+			signed, err := signer.SignXML(tc.document, testSigningTime)
+			require.NoError(t, err)
+			tc.check(t, signed)
+
+			verified, err := VerifyXML(signed)
+			require.NoError(t, err)
+			require.Equal(t, FormatXAdES, verified.Format)
+			require.True(t, verified.SignedBy(signer))
+			require.Equal(t, testSigningTime, verified.SigningTime)
+			require.Equal(t, d, verified.Document)
+
+			_, err = signer.SignXML(signed, testSigningTime)
+			require.ErrorContains(t, err, "already")
+		})
+	}
+}
+
+func TestVerifyXML(t *testing.T) {
+	d, err := NewDocument(testInvoice(), testOptions())
+	require.NoError(t, err)
+	ubl, err := d.UBL()
+	require.NoError(t, err)
+	signer := newTestSigner(t, "rsa")
+	signed, err := signer.SignXML(ubl, testSigningTime)
+	require.NoError(t, err)
+
+	testCases := []struct {
+		name        string
+		old, new    string
+		document    []byte
+		checkResult func(t *testing.T, result SignedDocument, err error)
+	}{
+		{
+			name:     "Unsigned",
+			document: ubl,
+			checkResult: func(t *testing.T, result SignedDocument, err error) {
+				require.ErrorIs(t, err, ErrNoSignature)
+				require.Equal(t, d, result.Document)
+			},
+		},
+		{
+			name:     "Reindented",
+			document: signed,
+			old:      "\n  <cbc:ID>",
+			new:      "\n\t<cbc:ID>",
+			checkResult: func(t *testing.T, result SignedDocument, err error) {
+				require.ErrorIs(t, err, ErrInvalidSignature)
+			},
+		},
+		{
+			name:     "ChangedNote",
+			document: signed,
+			old:      "<cbc:Note>Thank you for your patronage</cbc:Note>",
+			new:      "<cbc:Note>Pay to account 9876543210</cbc:Note>",
+			checkResult: func(t *testing.T, result SignedDocument, err error) {
+				require.ErrorIs(t, err, ErrInvalidSignature)
+				require.ErrorContains(t, err, `digest of reference "" does not match`)
+				require.Equal(t, "Pay to account 9876543210", result.Document.Note)
+			},
+		},
+		{
+			name:     "ChangedSigningTime",
+			document: signed,
+			old:      "<xades:SigningTime>2024-03-01T09:30:00Z",
+			new:      "<xades:SigningTime>2024-02-01T09:30:00Z",
+			checkResult: func(t *testing.T, result SignedDocument, err error) {
+				require.ErrorIs(t, err, ErrInvalidSignature)
+				require.ErrorContains(t, err, `digest of reference "#xades-signed-properties" does not match`)
+			},
+		},
+		{
+			name:     "EquivalentSerialization",
+			document: signed,
+			old:      `<cbc:EndpointID schemeID="EM">`,
+			new:      `<cbc:EndpointID  schemeID='EM' >`,
+			checkResult: func(t *testing.T, result SignedDocument, err error) {
+				require.NoError(t, err)
+			},
+		},
+		{
+			name:     "NotAnInvoice",
+			document: []byte(`<Order xmlns="urn:example"/>`),
+			checkResult: func(t *testing.T, result SignedDocument, err error) {
+				require.ErrorIs(t, err, ErrInvalidDocument)
+			},
+		},
+	}
+
+	for i := range testCases {
+		tc := testCases[i]
+
+		t.Run(tc.name, func(t *testing.T) {
+			document := tc.document
+			if tc.old != "" {
+				require.Contains(t, string(document), tc.old)
+				document = []byte(strings.Replace(string(document), tc.old, tc.new, 1))
+			}
+			result, err := Verify(document)
+			tc.checkResult(t, result, err)
+		})
+	}
+}
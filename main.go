@@ -1,30 +1,7 @@
 package main
 
-import (
-	"context"
-	"log"
-
-	"github.com/jackc/pgx/v5/pgxpool"
-	"github.com/kuthumipepple/numeris-book/api"
-	"github.com/kuthumipepple/numeris-book/db"
-	"github.com/kuthumipepple/numeris-book/util"
-)
+import "github.com/kuthumipepple/numeris-book/cmd"
 
 func main() {
-	config, err := util.LoadConfig(".")
-	if err != nil {
-		log.Fatal("cannot load config:", err)
-	}
-
-	connPool, err := pgxpool.New(context.Background(), config.DBSource)
-	if err != nil {
-		log.Fatal("cannot connect to db:", err)
-	}
-
-	store := db.NewStore(connPool)
-	apiServer := api.NewServer(store)
-	err = apiServer.Start(config.HTTPServerAddress)
-	if err != nil {
-		log.Fatal("cannot start server")
-	}
+	cmd.Execute()
 }
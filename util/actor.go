@@ -0,0 +1,22 @@
+package util
+
+import "context"
+
+// SystemActor is the actor of changes made without one in their context, such
+// as those of background jobs.
+const SystemActor = "system"
+
+// WithActor returns a copy of ctx that carries who is making the changes
+// done with it, which the audit log records.
+func WithActor(ctx context.Context, actor string) context.Context {
+	return context.WithValue(ctx, actorKey, actor)
+}
+
+// Actor returns who is making the changes done with ctx, or SystemActor if
+// ctx does not say.
+func Actor(ctx context.Context) string {
+	if actor, ok := ctx.Value(actorKey).(string); ok && actor != "" {
+		return actor
+	}
+	return SystemActor
+}
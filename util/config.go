@@ -1,10 +1,51 @@
 package util
 
-import "github.com/spf13/viper"
+import (
+	"time"
+
+	"github.com/spf13/viper"
+)
 
 type Config struct {
 	DBSource          string `mapstructure:"DB_SOURCE"`
 	HTTPServerAddress string `mapstructure:"HTTP_SERVER_ADDRESS"`
+	SellerCountryCode string `mapstructure:"SELLER_COUNTRY_CODE"`
+	SellerVATID       string `mapstructure:"SELLER_VAT_ID"`
+	DefaultTaxCode    string `mapstructure:"DEFAULT_TAX_CODE"`
+	// DefaultCurrency is the ISO 4217 code of the currency that invoices
+	// and quotes created through the API are billed in, and so the catalog
+	// price that their line items default to.
+	DefaultCurrency string `mapstructure:"DEFAULT_CURRENCY"`
+	// LogLevel is debug, info, warn or error.
+	LogLevel string `mapstructure:"LOG_LEVEL"`
+	// SlowQueryThreshold is how long a query may take before it is logged as
+	// slow. Faster queries are only logged at the debug level.
+	SlowQueryThreshold time.Duration `mapstructure:"SLOW_QUERY_THRESHOLD"`
+	// OTLPEndpoint is the URL of the OpenTelemetry collector that traces are
+	// sent to over OTLP/HTTP. Tracing is off if it is empty.
+	OTLPEndpoint string `mapstructure:"OTLP_ENDPOINT"`
+	// HTTPReadTimeout, HTTPWriteTimeout and HTTPIdleTimeout bound the time
+	// to read a request, to write its response and to keep an idle
+	// connection open. Zero means no limit.
+	HTTPReadTimeout  time.Duration `mapstructure:"HTTP_READ_TIMEOUT"`
+	HTTPWriteTimeout time.Duration `mapstructure:"HTTP_WRITE_TIMEOUT"`
+	HTTPIdleTimeout  time.Duration `mapstructure:"HTTP_IDLE_TIMEOUT"`
+	// ShutdownTimeout is how long requests in flight are given to finish
+	// when the server is asked to stop.
+	ShutdownTimeout time.Duration `mapstructure:"SHUTDOWN_TIMEOUT"`
+	// MigrateOnStart makes the server apply pending migrations before it
+	// starts serving.
+	MigrateOnStart bool `mapstructure:"MIGRATE_ON_START"`
+	// ChainSigningKeyFile is the path of a PEM file with the PKCS #8 Ed25519
+	// private key that signs exports of the invoice hash chain. Exports are
+	// unavailable if it is empty.
+	ChainSigningKeyFile string `mapstructure:"CHAIN_SIGNING_KEY_FILE"`
+	// SigningCertificateFile and SigningKeyFile are the paths of PEM files
+	// with the certificate, followed by its chain, and the RSA or ECDSA
+	// private key that invoice PDFs and XML e-invoices are signed with.
+	// Documents are not signed if they are empty.
+	SigningCertificateFile string `mapstructure:"SIGNING_CERTIFICATE_FILE"`
+	SigningKeyFile         string `mapstructure:"SIGNING_KEY_FILE"`
 }
 
 func LoadConfig(path string) (config Config, err error) {
@@ -56,3 +56,9 @@ func RandomStatus() string {
 	status := []string{DRAFT, PENDING_PAYMENT, PAID, OVERDUE}
 	return status[rand.Intn(len(status))]
 }
+
+// RandomQuoteStatus generates a random quote status
+func RandomQuoteStatus() string {
+	status := []string{SENT, ACCEPTED, DECLINED, EXPIRED}
+	return status[rand.Intn(len(status))]
+}
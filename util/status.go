@@ -8,6 +8,14 @@ const (
 	PAID            = "paid"
 )
 
+// all valid quote statuses
+const (
+	SENT     = "sent"
+	ACCEPTED = "accepted"
+	DECLINED = "declined"
+	EXPIRED  = "expired"
+)
+
 // Contains checks if a slice of strings contains a specific string element.
 func Contains(slice []string, item string) bool {
 	for _, v := range slice {